@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Access logging writes one JSON line per request to stdout or a file.
+// Search terms and request bodies may be sensitive, so query values are
+// redacted by default and bodies are not logged at all; parameters and
+// body fields in access_log.allow are kept verbatim. Log files rotate by
+// size, and successful requests can be sampled.
+
+// accessLogModes are the values of access_log.query and access_log.bodies:
+// log as sent, mask values outside access_log.allow, or leave out
+var accessLogModes = []string{"full", "redact", "omit"}
+
+// redacted replaces logged values that may be sensitive
+const redacted = "REDACTED"
+
+// defaultAccessLogAllow are parameters and body fields safe to log as sent
+var defaultAccessLogAllow = []string{"page", "limit", "sort", "category", "format", "pin", "fields", "servers"}
+
+// AccessLogEntry is one line of the access log
+type AccessLogEntry struct {
+	Time       time.Time   `json:"time"`
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Query      interface{} `json:"query,omitempty"`
+	Status     int         `json:"status"`
+	Bytes      int64       `json:"bytes"`
+	DurationMS float64     `json:"duration_ms"`
+	Client     string      `json:"client"`
+	User       string      `json:"user,omitempty"`
+	UserAgent  string      `json:"user_agent,omitempty"`
+	// Body is the request body, as JSON when it parses; BodyBytes is its
+	// size whether or not it is logged
+	Body      interface{} `json:"body,omitempty"`
+	BodyBytes int64       `json:"body_bytes,omitempty"`
+}
+
+// validateAccessLog checks the access_log settings
+func validateAccessLog(c *Config) error {
+	if !slices.Contains(accessLogModes, c.AccessLogQuery) || !slices.Contains(accessLogModes, c.AccessLogBodies) {
+		return fmt.Errorf("access_log.query and access_log.bodies must be full, redact or omit")
+	}
+	if c.AccessLogSample < 0 || c.AccessLogSample > 1 {
+		return fmt.Errorf("access_log.sample must be between 0 and 1")
+	}
+	if c.AccessLogMaxSize < 0 || c.AccessLogMaxFiles < 0 || c.AccessLogMaxBody < 0 {
+		return fmt.Errorf("access_log.max_size, access_log.max_files and access_log.max_body must not be negative")
+	}
+	return nil
+}
+
+// rotatingFile is an append-only log file that is renamed to FILE.1,
+// FILE.2 and so on once it grows past maxSize, keeping maxFiles old files
+type rotatingFile struct {
+	sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	return rf, rf.open()
+}
+
+func (rf *rotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file, rf.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts the old files up by one, dropping the oldest, and starts
+// a new file. It is called with rf locked.
+func (rf *rotatingFile) rotate() error {
+	rf.file.Close()
+	os.Remove(fmt.Sprintf("%s.%d", rf.path, rf.maxFiles))
+	for i := rf.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	if rf.maxFiles > 0 {
+		os.Rename(rf.path, rf.path+".1")
+	} else {
+		os.Remove(rf.path)
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.Lock()
+	defer rf.Unlock()
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// accessLog is where access log lines go; nil while access logging is off
+var accessLog struct {
+	sync.Mutex
+	out io.Writer
+}
+
+// startAccessLog opens the access log when one is configured
+func startAccessLog() {
+	switch cfg.AccessLog {
+	case "":
+		return
+	case "stdout":
+		accessLog.out = os.Stdout
+	default:
+		file, err := openRotatingFile(cfg.AccessLog, cfg.AccessLogMaxSize, cfg.AccessLogMaxFiles)
+		if err != nil {
+			log.Fatalf("❌ Cannot open access log: %v", err)
+		}
+		accessLog.out = file
+	}
+	log.Printf("📜 Access log to %s (query %s, bodies %s, sampling %g)", cfg.AccessLog, cfg.AccessLogQuery, cfg.AccessLogBodies, cfg.AccessLogSample)
+}
+
+// redactQuery renders a query string under a mode: every parameter outside
+// the allowed ones keeps its name but not its values when redacting
+func redactQuery(query url.Values, mode string, allow []string) interface{} {
+	if len(query) == 0 || mode == "omit" {
+		return nil
+	}
+	if mode == "full" {
+		return query.Encode()
+	}
+	masked := make(url.Values, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			if !slices.Contains(allow, key) {
+				value = redacted
+			}
+			masked.Add(key, value)
+		}
+	}
+	return masked.Encode()
+}
+
+// redactJSON masks the strings and numbers of a decoded JSON value, except
+// under the allowed object keys
+func redactJSON(value interface{}, allow []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if slices.Contains(allow, key) {
+				masked[key] = item
+			} else {
+				masked[key] = redactJSON(item, allow)
+			}
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = redactJSON(item, allow)
+		}
+		return masked
+	case string, float64:
+		return redacted
+	}
+	return value
+}
+
+// redactBody renders a captured request body under a mode. Bodies that are
+// not JSON are only logged in full mode; truncated ones never parse.
+func redactBody(body []byte, mode string, allow []string) interface{} {
+	if len(body) == 0 || mode == "omit" {
+		return nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		if mode == "full" {
+			return string(body)
+		}
+		return nil
+	}
+	if mode == "full" {
+		return decoded
+	}
+	return redactJSON(decoded, allow)
+}
+
+// bodyRecorder keeps the first max bytes of a request body as the handler
+// reads it, and counts the rest
+type bodyRecorder struct {
+	io.ReadCloser
+	max   int64
+	kept  bytes.Buffer
+	total int64
+}
+
+func (br *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := br.ReadCloser.Read(p)
+	if room := br.max - int64(br.kept.Len()); room > 0 {
+		br.kept.Write(p[:min(int64(n), room)])
+	}
+	br.total += int64(n)
+	return n, err
+}
+
+// statusRecorder remembers the status and size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(p []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	n, err := sr.ResponseWriter.Write(p)
+	sr.bytes += int64(n)
+	return n, err
+}
+
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// accessLogMiddleware logs every request once it is answered. Requests
+// answered with an error are always logged; the others are sampled at
+// access_log.sample. The Authorization header is never logged.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accessLog.out == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		var body *bodyRecorder
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &bodyRecorder{ReadCloser: r.Body, max: cfg.AccessLogMaxBody}
+			if cfg.AccessLogBodies == "omit" {
+				body.max = 0
+			}
+			r.Body = body
+		}
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if recorder.status < 400 && cfg.AccessLogSample < 1 && rand.Float64() >= cfg.AccessLogSample {
+			return
+		}
+
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		entry := AccessLogEntry{
+			Time:       start.UTC(),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      redactQuery(r.URL.Query(), cfg.AccessLogQuery, cfg.AccessLogAllow),
+			Status:     recorder.status,
+			Bytes:      recorder.bytes,
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			Client:     client,
+			UserAgent:  r.UserAgent(),
+		}
+		entry.User, _ = apiKeyUser(r)
+		if body != nil {
+			entry.BodyBytes = body.total
+			if body.total <= body.max {
+				entry.Body = redactBody(body.kept.Bytes(), cfg.AccessLogBodies, cfg.AccessLogAllow)
+			}
+		}
+		var line bytes.Buffer
+		encoder := json.NewEncoder(&line)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(entry); err != nil {
+			return
+		}
+		accessLog.Lock()
+		defer accessLog.Unlock()
+		if _, err := accessLog.out.Write(line.Bytes()); err != nil {
+			log.Printf("❌ Failed to write the access log: %v", err)
+		}
+	})
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Advisory is a security notice affecting one or more catalog servers
+type Advisory struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Summary     string    `json:"summary"`
+	Severity    string    `json:"severity"`
+	Servers     []string  `json:"servers"`
+	URL         string    `json:"url,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+var advisorySeverities = map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+
+var (
+	advisoriesMu sync.RWMutex
+	advisories   []Advisory
+)
+
+func loadAdvisories() {
+	var stored []Advisory
+	if err := readJSONFile(dataPath("advisories.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load advisories: %v", err)
+		return
+	}
+	for _, advisory := range stored {
+		for i, serverID := range advisory.Servers {
+			advisory.Servers[i] = canonicalID(serverID)
+		}
+	}
+	advisoriesMu.Lock()
+	advisories = stored
+	advisoriesMu.Unlock()
+}
+
+// advisoryTopics are the webhook topics an advisory is published on:
+// "advisory.<category>.<server>" and "advisory.vendor.<vendor>.<server>"
+// for every affected server
+func advisoryTopics(advisory Advisory) []string {
+	seen := make(map[string]bool)
+	var topics []string
+	for _, serverID := range advisory.Servers {
+		config, _ := getEntry(serverID)
+		for _, topic := range []string{
+			"advisory." + getString(config, "category", "other") + "." + serverID,
+			"advisory.vendor." + entryVendor(config) + "." + serverID,
+		} {
+			if !seen[topic] {
+				seen[topic] = true
+				topics = append(topics, topic)
+			}
+		}
+	}
+	return topics
+}
+
+// topicMatches matches dot-separated topics against a pattern where "*"
+// matches one segment, or every remaining segment when it comes last
+func topicMatches(pattern, topic string) bool {
+	patternParts := strings.Split(pattern, ".")
+	topicParts := strings.Split(topic, ".")
+	for i, part := range patternParts {
+		if part == "*" && i == len(patternParts)-1 {
+			return len(topicParts) > i
+		}
+		if i >= len(topicParts) || (part != "*" && part != topicParts[i]) {
+			return false
+		}
+	}
+	return len(patternParts) == len(topicParts)
+}
+
+// notifyWebhooks delivers an advisory to every subscription whose pattern
+// matches one of its topics. Subscriptions are "PATTERN=URL".
+func notifyWebhooks(advisory Advisory) {
+	topics := advisoryTopics(advisory)
+	client := outboundClient(10 * time.Second)
+	for _, spec := range cfg.Webhooks {
+		eq := strings.Index(spec, "=")
+		if eq <= 0 {
+			continue
+		}
+		pattern, target := spec[:eq], spec[eq+1:]
+		var matched []string
+		for _, topic := range topics {
+			if topicMatches(pattern, topic) {
+				matched = append(matched, topic)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"event":    "advisory.published",
+			"topics":   matched,
+			"advisory": advisory,
+		})
+		go func(target string) {
+			resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Printf("⚠️  Webhook %s failed: %v", target, err)
+				recordComponent("webhooks", err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				log.Printf("⚠️  Webhook %s returned HTTP %d", target, resp.StatusCode)
+				recordComponent("webhooks", fmt.Errorf("HTTP %d from %s", resp.StatusCode, target))
+				return
+			}
+			recordComponent("webhooks", nil)
+		}(target)
+	}
+}
+
+// filterAdvisories keeps advisories affecting a server that matches the
+// category and vendor filters, newest first
+func filterAdvisories(categories []string, vendor string) []Advisory {
+	advisoriesMu.RLock()
+	defer advisoriesMu.RUnlock()
+
+	var result []Advisory
+	for _, advisory := range advisories {
+		for _, serverID := range advisory.Servers {
+			config, _ := getEntry(serverID)
+			if inCategories(categories, config) &&
+				(vendor == "" || entryVendor(config) == vendor) {
+				result = append(result, advisory)
+				break
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PublishedAt.After(result[j].PublishedAt)
+	})
+	return result
+}
+
+// atomFeed and atomEntry are the subset of RFC 4287 the advisory feed uses
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary"`
+	Link       *atomLink      `xml:"link,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// advisoriesHandler lists advisories (GET, JSON) or publishes one (POST, admin)
+func advisoriesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		result := filterAdvisories(requestedCategories(w, r), r.URL.Query().Get("vendor"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"advisories": result,
+			"total":      len(result),
+		})
+	case "POST":
+		if !requireAdmin(w, r) {
+			return
+		}
+		var advisory Advisory
+		if err := decodeJSONBody(w, r, &advisory); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		if advisory.Title == "" || len(advisory.Servers) == 0 {
+			writeError(w, http.StatusBadRequest, "Fields 'title' and 'servers' are required")
+			return
+		}
+		if !advisorySeverities[advisory.Severity] {
+			writeError(w, http.StatusBadRequest, "Field 'severity' must be low, medium, high or critical")
+			return
+		}
+		if err := validateServerIDs(advisory.Servers); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		for _, serverID := range advisory.Servers {
+			if _, exists := getEntry(serverID); !exists {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Server '%s' not found", serverID))
+				return
+			}
+		}
+		advisory.ID = newID()
+		advisory.PublishedAt = time.Now().UTC()
+
+		advisoriesMu.Lock()
+		advisories = append(advisories, advisory)
+		err := writeJSONFile(dataPath("advisories.json"), advisories)
+		advisoriesMu.Unlock()
+		if err != nil {
+			log.Printf("❌ Failed to save advisories: %v", err)
+		}
+
+		notifyWebhooks(advisory)
+		notify(Notification{
+			Event:   "advisory.published",
+			Title:   fmt.Sprintf("%s advisory: %s", strings.ToUpper(advisory.Severity[:1])+advisory.Severity[1:], advisory.Title),
+			Message: advisory.Summary,
+			Servers: advisory.Servers,
+			Data:    advisory,
+		})
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(advisory)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// advisoryFeedHandler serves advisories as an Atom feed, optionally
+// limited to a category and/or vendor
+func advisoryFeedHandler(w http.ResponseWriter, r *http.Request) {
+	category := r.URL.Query().Get("category")
+	vendor := r.URL.Query().Get("vendor")
+	result := filterAdvisories(requestedCategories(w, r), vendor)
+
+	title := "MCP Server Catalog advisories"
+	if category != "" {
+		title += " · " + category
+	}
+	if vendor != "" {
+		title += " · " + vendor
+	}
+	feed := atomFeed{
+		ID:      "urn:mcp-catalog:advisories:" + category + ":" + vendor,
+		Title:   title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: r.URL.RequestURI(), Rel: "self"},
+	}
+	if len(result) > 0 {
+		feed.Updated = result[0].PublishedAt.Format(time.RFC3339)
+	}
+	for _, advisory := range result {
+		entry := atomEntry{
+			ID:      "urn:mcp-catalog:advisory:" + advisory.ID,
+			Title:   fmt.Sprintf("[%s] %s", strings.ToUpper(advisory.Severity), advisory.Title),
+			Updated: advisory.PublishedAt.Format(time.RFC3339),
+			Summary: advisory.Summary,
+		}
+		if advisory.URL != "" {
+			entry.Link = &atomLink{Href: advisory.URL}
+		}
+		for _, topic := range advisoryTopics(advisory) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: topic})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	encoder.Encode(feed)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiVersionHeader negotiates the API version on unversioned clients and
+// reports the version that served a response
+const apiVersionHeader = "API-Version"
+
+// apiVersions are the served API versions, newest last. Every version is
+// served by the v1 handlers; apiVersionMiddleware translates the
+// differences:
+//
+//   - v2 errors are {"error": {"status": N, "message": "..."}} objects
+//     rather than bare strings
+var apiVersions = []int{1, 2}
+
+const latestAPIVersion = 2
+
+// apiDateLayout is the layout of the api.v1_deprecation and api.v1_sunset
+// settings
+const apiDateLayout = "2006-01-02"
+
+// validateAPIPolicy checks the v1 deprecation and sunset dates
+func validateAPIPolicy(c *Config) error {
+	var deprecation, sunset time.Time
+	var err error
+	if c.APIV1Deprecation != "" {
+		if deprecation, err = time.Parse(apiDateLayout, c.APIV1Deprecation); err != nil {
+			return fmt.Errorf("api.v1_deprecation must be a YYYY-MM-DD date")
+		}
+	}
+	if c.APIV1Sunset != "" {
+		if sunset, err = time.Parse(apiDateLayout, c.APIV1Sunset); err != nil {
+			return fmt.Errorf("api.v1_sunset must be a YYYY-MM-DD date")
+		}
+		if deprecation.IsZero() || !sunset.After(deprecation) {
+			return fmt.Errorf("api.v1_sunset needs an earlier api.v1_deprecation")
+		}
+	}
+	return nil
+}
+
+// requestAPIVersion picks the version of a request: the /api/vN path
+// prefix, else the API-Version header, else 1. It returns the path on the
+// v1 core.
+func requestAPIVersion(r *http.Request) (version int, corePath string, err error) {
+	rest, versioned := strings.CutPrefix(r.URL.Path, "/api/v")
+	if !versioned {
+		return 1, r.URL.Path, nil
+	}
+	number, tail, _ := strings.Cut(rest, "/")
+	version, err = strconv.Atoi(number)
+	if err != nil || !isAPIVersion(version) {
+		return 0, "", fmt.Errorf("API version '%s' does not exist; this server speaks v1 and v2", number)
+	}
+	corePath = "/api/v1/" + tail
+	if version == 1 {
+		if requested := r.Header.Get(apiVersionHeader); requested != "" {
+			version, err = strconv.Atoi(strings.TrimPrefix(strings.ToLower(requested), "v"))
+			if err != nil || !isAPIVersion(version) {
+				return 0, "", fmt.Errorf("Header '%s' must be 1 or 2", apiVersionHeader)
+			}
+		}
+	}
+	return version, corePath, nil
+}
+
+func isAPIVersion(version int) bool {
+	return version >= apiVersions[0] && version <= latestAPIVersion
+}
+
+// apiVersionMiddleware serves /api/v2 from the v1 handlers, announces the
+// deprecation of v1 with Deprecation, Sunset and Link headers (RFC 9745,
+// RFC 8594) and answers 410 once v1 is past its sunset date
+func apiVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		version, corePath, err := requestAPIVersion(r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		w.Header().Set(apiVersionHeader, strconv.Itoa(version))
+		w.Header().Add("Vary", apiVersionHeader)
+
+		if version == 1 && cfg.APIV1Deprecation != "" {
+			successor := "/api/v2" + strings.TrimPrefix(corePath, "/api/v1")
+			deprecation, _ := time.Parse(apiDateLayout, cfg.APIV1Deprecation)
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecation.Unix()))
+			w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			if cfg.APIMigrationURL != "" {
+				w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", cfg.APIMigrationURL))
+			}
+			if cfg.APIV1Sunset != "" {
+				sunset, _ := time.Parse(apiDateLayout, cfg.APIV1Sunset)
+				w.Header().Set("Sunset", sunset.Format(http.TimeFormat))
+				if !time.Now().Before(sunset) {
+					w.Header().Set("Content-Type", "application/json")
+					writeError(w, http.StatusGone, fmt.Sprintf("API v1 was retired on %s; use %s", cfg.APIV1Sunset, successor))
+					return
+				}
+			}
+		}
+
+		if version == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		core := r.Clone(r.Context())
+		core.URL.Path, core.URL.RawPath = corePath, ""
+		// Handlers link within the version through the header
+		core.Header.Set(apiVersionHeader, strconv.Itoa(version))
+		shim := &versionedWriter{ResponseWriter: w, version: version}
+		next.ServeHTTP(shim, core)
+		shim.finish()
+	})
+}
+
+// versionedWriter translates a v1 response into a newer version: it
+// rewrites redirects to the version's paths and buffers JSON errors to
+// re-encode them
+type versionedWriter struct {
+	http.ResponseWriter
+	version     int
+	wroteHeader bool
+	status      int
+	errorBody   *bytes.Buffer
+}
+
+func (w *versionedWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if location := w.Header().Get("Location"); strings.HasPrefix(location, "/api/v1/") {
+		w.Header().Set("Location", fmt.Sprintf("/api/v%d/%s", w.version, strings.TrimPrefix(location, "/api/v1/")))
+	}
+	if status >= 400 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.status, w.errorBody = status, &bytes.Buffer{}
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *versionedWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.errorBody != nil {
+		return w.errorBody.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *versionedWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok && w.errorBody == nil {
+		flusher.Flush()
+	}
+}
+
+func (w *versionedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes a buffered error in the v2 shape. Other fields of the v1
+// error body are kept alongside.
+func (w *versionedWriter) finish() {
+	if w.errorBody == nil {
+		return
+	}
+	body := w.errorBody.Bytes()
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err == nil {
+		if message, ok := doc["error"].(string); ok {
+			doc["error"] = map[string]interface{}{"status": w.status, "message": message}
+			body, _ = json.Marshal(doc)
+			body = append(body, '\n')
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Catalog artifacts are published to an S3-compatible bucket, AWS S3 or
+// GCS with HMAC keys among others, whenever a reload or sync records a new
+// snapshot. Each catalog hash gets its own keys:
+//
+//	PREFIX/revisions/HASH/catalog.json
+//	PREFIX/revisions/HASH/catalog-bundle.tar.gz
+//	PREFIX/revisions/HASH/openapi.json
+//	PREFIX/latest.json
+//
+// latest.json is written last and names the keys of the newest revision.
+
+// ArtifactsStatus is the state of artifact publishing, kept in
+// data/artifacts.json and reported by /readyz?verbose=true
+type ArtifactsStatus struct {
+	// Target is the endpoint, bucket and prefix published to; changing
+	// them publishes again
+	Target      string            `json:"target,omitempty"`
+	Hash        string            `json:"hash,omitempty"`
+	PublishedAt *time.Time        `json:"published_at,omitempty"`
+	Keys        map[string]string `json:"keys,omitempty"`
+	LastError   string            `json:"last_error,omitempty"`
+}
+
+// artifactsRetry is how long a failed publish waits before trying again
+const artifactsRetry = time.Minute
+
+// artifactsTimeout bounds the upload of one artifact
+const artifactsTimeout = 2 * time.Minute
+
+var (
+	artifactsMu     sync.Mutex
+	artifactsStatus ArtifactsStatus
+	// artifactsPending wakes the publisher; a full channel means a publish
+	// is already due, so changes in between coalesce
+	artifactsPending = make(chan struct{}, 1)
+)
+
+// validateArtifacts checks the bucket settings when publishing is on
+func validateArtifacts(c *Config) error {
+	if c.ArtifactsBucket == "" {
+		return nil
+	}
+	if endpoint, err := url.Parse(c.ArtifactsEndpoint); err != nil || endpoint.Scheme != "https" && endpoint.Scheme != "http" || endpoint.Host == "" {
+		return fmt.Errorf("artifacts.endpoint must be an http(s) URL such as https://s3.amazonaws.com")
+	}
+	if c.ArtifactsAccessKey == "" || c.ArtifactsSecretKey == "" {
+		return fmt.Errorf("artifacts.bucket needs artifacts.access_key and artifacts.secret_key")
+	}
+	return nil
+}
+
+// startArtifacts runs the publisher when a bucket is configured
+func startArtifacts() {
+	if cfg.ArtifactsBucket == "" {
+		return
+	}
+	var stored ArtifactsStatus
+	if err := readJSONFile(dataPath("artifacts.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load artifact publishing state: %v", err)
+	}
+	artifactsMu.Lock()
+	artifactsStatus = stored
+	artifactsMu.Unlock()
+
+	go func() {
+		for range artifactsPending {
+			if err := publishArtifacts(context.Background()); err != nil {
+				log.Printf("❌ Publishing catalog artifacts failed, retrying in %s: %v", artifactsRetry, err)
+				time.AfterFunc(artifactsRetry, scheduleArtifacts)
+			}
+		}
+	}()
+	log.Printf("☁️  Publishing catalog artifacts to %s/%s", cfg.ArtifactsEndpoint, cfg.ArtifactsBucket)
+}
+
+// scheduleArtifacts asks the publisher to publish the served catalog
+func scheduleArtifacts() {
+	if cfg.ArtifactsBucket == "" {
+		return
+	}
+	select {
+	case artifactsPending <- struct{}{}:
+	default:
+	}
+}
+
+// publishArtifacts uploads the artifacts of the served catalog unless its
+// hash is already the latest. The hash and the files are built from one
+// read of the catalog, so a revision's keys hold the catalog they name.
+func publishArtifacts(ctx context.Context) error {
+	target := cfg.ArtifactsEndpoint + "/" + cfg.ArtifactsBucket + "/" + artifactKey("")
+	catalogMu.Lock()
+	hash, err := catalogHash(servers)
+	if err != nil {
+		catalogMu.Unlock()
+		return err
+	}
+	artifactsMu.Lock()
+	published := artifactsStatus.Hash == hash && artifactsStatus.Target == target
+	artifactsMu.Unlock()
+	if published {
+		catalogMu.Unlock()
+		return nil
+	}
+	files, err := artifactFiles()
+	serverCount := len(servers)
+	catalogMu.Unlock()
+
+	var keys map[string]string
+	if err == nil {
+		keys, err = uploadArtifacts(ctx, hash, serverCount, files)
+	}
+
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	if err != nil {
+		artifactsStatus.LastError = err.Error()
+	} else {
+		now := time.Now().UTC()
+		artifactsStatus = ArtifactsStatus{Target: target, Hash: hash, PublishedAt: &now, Keys: keys}
+		log.Printf("☁️  Published catalog artifacts of %s", hash[:12])
+	}
+	if saveErr := writeJSONFile(dataPath("artifacts.json"), artifactsStatus); saveErr != nil {
+		log.Printf("❌ Failed to save artifact publishing state: %v", saveErr)
+	}
+	return err
+}
+
+// artifact is one published file
+type artifact struct {
+	data        []byte
+	contentType string
+}
+
+// artifactFiles builds the served catalog, the offline bundle and, when
+// configured, the captured OpenAPI spec. catalogMu must be held.
+func artifactFiles() (map[string]artifact, error) {
+	files := make(map[string]artifact)
+	catalog, err := json.MarshalIndent(map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"servers":        servers,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files["catalog.json"] = artifact{data: append(catalog, '\n'), contentType: "application/json"}
+
+	var bundle bytes.Buffer
+	if err := writeBundle(&bundle); err != nil {
+		return nil, err
+	}
+	files["catalog-bundle.tar.gz"] = artifact{data: bundle.Bytes(), contentType: "application/gzip"}
+
+	if cfg.ArtifactsOpenAPI != "" {
+		spec, err := ioutil.ReadFile(cfg.ArtifactsOpenAPI)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read OpenAPI spec: %v", err)
+		}
+		files["openapi.json"] = artifact{data: spec, contentType: "application/json"}
+	}
+	return files, nil
+}
+
+// artifactKey prefixes an object key with artifacts.prefix
+func artifactKey(name string) string {
+	if prefix := strings.Trim(cfg.ArtifactsPrefix, "/"); prefix != "" {
+		return prefix + "/" + name
+	}
+	return name
+}
+
+// uploadArtifacts writes the revision's files, then moves latest.json to
+// them. Revisioned keys never change content, so caches may keep them.
+func uploadArtifacts(ctx context.Context, hash string, serverCount int, files map[string]artifact) (map[string]string, error) {
+	latest := map[string]interface{}{
+		"hash":         hash,
+		"published_at": time.Now().UTC(),
+		"server_count": serverCount,
+	}
+	keys := make(map[string]string, len(files))
+	for name, file := range files {
+		key := artifactKey("revisions/" + hash[:12] + "/" + name)
+		if err := putObject(ctx, key, file.data, file.contentType, cfg.CacheImmutable); err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		keys[name] = key
+	}
+	latest["artifacts"] = keys
+	pointer, err := json.MarshalIndent(latest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return keys, putObject(ctx, artifactKey("latest.json"), append(pointer, '\n'), "application/json", "no-cache")
+}
+
+// putObject uploads an object with a path-style PUT signed with AWS
+// Signature Version 4
+func putObject(ctx context.Context, key string, data []byte, contentType, cacheControl string) error {
+	segments := strings.Split(cfg.ArtifactsBucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	target := strings.TrimSuffix(cfg.ArtifactsEndpoint, "/") + "/" + strings.Join(segments, "/")
+	req, err := http.NewRequestWithContext(ctx, "PUT", target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", cacheControl)
+	signRequest(req, sha256Hex(data), time.Now().UTC())
+
+	resp, err := outboundClient(artifactsTimeout).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signRequest adds the SigV4 headers for the artifacts credentials. Every
+// header set on the request is signed.
+func signRequest(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	scope := day + "/" + cfg.ArtifactsRegion + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + cfg.ArtifactsSecretKey)
+	for _, part := range []string{day, cfg.ArtifactsRegion, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", cfg.ArtifactsAccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// artifactsReport returns the publishing state for /readyz
+func artifactsReport() ArtifactsStatus {
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	return artifactsStatus
+}
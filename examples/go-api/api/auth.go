@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// requireAdmin checks the bearer token against the configured admin token and
+// writes the error response itself when the caller is not an admin.
+// Admin endpoints are disabled entirely when no token is configured.
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token := cfg.AdminToken
+	if token == "" {
+		writeError(w, http.StatusForbidden, "Admin API is disabled")
+		return false
+	}
+
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "Invalid admin token")
+		return false
+	}
+	return true
+}
+
+// apiKeyUser resolves the user behind the request's API key. Keys are
+// configured as "KEY=USER" pairs.
+func apiKeyUser(r *http.Request) (string, bool) {
+	provided := r.Header.Get("X-API-Key")
+	if provided == "" {
+		provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if provided == "" {
+		return "", false
+	}
+	for _, spec := range cfg.APIKeys {
+		eq := strings.LastIndex(spec, "=")
+		if eq <= 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(spec[:eq])) == 1 {
+			return spec[eq+1:], true
+		}
+	}
+	return "", false
+}
+
+// isAdmin reports whether a request carries the admin token, without
+// answering it
+func isAdmin(r *http.Request) bool {
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(cfg.AdminToken)) == 1
+}
+
+// requireUser writes a 401 and reports false unless the request carries a
+// valid API key
+func requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	user, ok := apiKeyUser(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "A valid API key is required")
+		return "", false
+	}
+	return user, true
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// awesomeBullet matches a list item of the form
+// "- [owner/repo](https://github.com/owner/repo) 🐍 ☁️ - Description"
+var awesomeBullet = regexp.MustCompile(`^\s*[-*]\s+\[([^\]]+)\]\((https?://[^)\s]+)\)\s*(.*)$`)
+
+// htmlTag matches inline HTML such as the anchors lists put in headings
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// awesomeLanguages maps the language legend of awesome lists to the package
+// registry a draft most likely publishes to
+var awesomeLanguages = map[string]string{
+	"🐍": "pypi",
+	"📇": "npm",
+}
+
+// awesomeCategories maps words of section headings to catalog categories
+var awesomeCategories = []struct {
+	keyword  string
+	category string
+}{
+	{"database", "database"},
+	{"version control", "version-control"},
+	{"file system", "files"},
+	{"filesystem", "files"},
+	{"search", "search"},
+	{"browser", "web"},
+	{"cloud", "cloud-storage"},
+	{"knowledge", "knowledge-management"},
+	{"memory", "memory"},
+	{"developer", "development"},
+	{"coding", "development"},
+	{"communication", "communication"},
+	{"security", "security"},
+	{"monitoring", "monitoring"},
+	{"data", "data"},
+}
+
+// headingCategory derives a category from a markdown section heading
+func headingCategory(heading string) string {
+	heading = strings.ToLower(heading)
+	for _, mapping := range awesomeCategories {
+		if strings.Contains(heading, mapping.keyword) {
+			return mapping.category
+		}
+	}
+	// Fall back to a slug of the heading without emoji or anchors
+	var slug strings.Builder
+	for _, word := range tokenize(heading) {
+		if slug.Len() > 0 {
+			slug.WriteByte('-')
+		}
+		slug.WriteString(word)
+	}
+	if slug.Len() == 0 {
+		return "other"
+	}
+	return slug.String()
+}
+
+// draftServerID turns a repository name into a catalog ID, dropping the
+// "mcp"/"server" affixes almost every project carries
+func draftServerID(repo string) string {
+	words := tokenize(repo)
+	var kept []string
+	for _, word := range words {
+		if word != "mcp" && word != "server" && word != "servers" {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+	return strings.Join(kept, "-")
+}
+
+// DraftEntry is an entry inferred from an awesome list bullet
+type DraftEntry struct {
+	ServerID string
+	Entry    map[string]interface{}
+	Notes    []string
+}
+
+// parseAwesomeList extracts draft entries from an awesome list. Section
+// headings become categories and legend emoji become hints.
+func parseAwesomeList(markdown string) []DraftEntry {
+	var drafts []DraftEntry
+	heading := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(line, "#") {
+			heading = strings.TrimSpace(htmlTag.ReplaceAllString(strings.TrimLeft(line, "#"), ""))
+			continue
+		}
+		match := awesomeBullet.FindStringSubmatch(line)
+		if match == nil || heading == "" {
+			continue
+		}
+		title, link, rest := match[1], match[2], match[3]
+		repo := normalizeRepoURL(link)
+		parts := strings.Split(repo, "/")
+		if len(parts) != 3 || parts[2] == "" {
+			continue
+		}
+		// The title names monorepo subprojects the repository URL loses
+		owner, name := parts[1], title[strings.LastIndex(title, "/")+1:]
+
+		// Everything before " - " is the legend, after it the description
+		legend, description := rest, ""
+		if dash := strings.Index(rest, " - "); dash >= 0 {
+			legend, description = rest[:dash], strings.TrimSpace(rest[dash+3:])
+		} else if strings.HasPrefix(strings.TrimSpace(rest), "-") {
+			legend, description = "", strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), "-"))
+		}
+
+		category := headingCategory(heading)
+		categories := []interface{}{category}
+		entry := map[string]interface{}{
+			"name":        name,
+			"description": description,
+			"category":    category,
+			"vendor":      vendorSlug(owner),
+			"homepage":    link,
+			"repository":  map[string]interface{}{"url": "https://" + repo, "source": "github"},
+		}
+		notes := []string{fmt.Sprintf("Imported from section '%s'", heading)}
+		if strings.Contains(legend, "🎖") {
+			categories = append(categories, "official")
+		}
+		for emoji, registry := range awesomeLanguages {
+			if strings.Contains(legend, emoji) {
+				notes = append(notes, fmt.Sprintf("Likely published to %s; confirm the package name", registry))
+			}
+		}
+		if strings.Contains(legend, "☁") {
+			entry["transports"] = []interface{}{"streamable-http"}
+			notes = append(notes, "Marked as a cloud service; add its 'url'")
+		} else if strings.Contains(legend, "🏠") {
+			entry["transports"] = []interface{}{"stdio"}
+		}
+		entry["categories"] = categories
+		if description == "" {
+			notes = append(notes, "No description in the list")
+		}
+		drafts = append(drafts, DraftEntry{ServerID: namespacedID(vendorSlug(owner), draftServerID(name)), Entry: entry, Notes: notes})
+	}
+	return drafts
+}
+
+// readAwesomeSource reads a list from a local file or an http(s) URL
+func readAwesomeSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return ioutil.ReadFile(source)
+	}
+	client := outboundClient(30 * time.Second)
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, source)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// importAwesomeCommand files every unknown server of an awesome MCP servers
+// list into the submission queue:
+//
+//	import-awesome -from README.md|URL [-dry-run]
+func importAwesomeCommand(args []string) error {
+	flags := flag.NewFlagSet("import-awesome", flag.ContinueOnError)
+	from := flags.String("from", "https://raw.githubusercontent.com/punkpeye/awesome-mcp-servers/main/README.md", "markdown file or URL of the list")
+	dryRun := flags.Bool("dry-run", false, "only report what would be queued")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := readAwesomeSource(*from)
+	if err != nil {
+		return err
+	}
+	drafts := parseAwesomeList(string(data))
+
+	known := make(map[string]bool)
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		if repo, ok := config["repository"].(map[string]interface{}); ok {
+			known[normalizeRepoURL(getString(repo, "url", ""))] = true
+		}
+	}
+
+	queued, skipped := 0, 0
+	for _, draft := range drafts {
+		repo, _ := draft.Entry["repository"].(map[string]interface{})
+		if checkNewServerID(draft.ServerID, draft.Entry) != nil || known[normalizeRepoURL(getString(repo, "url", ""))] {
+			skipped++
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("would queue %s (%s)\n", draft.ServerID, getString(draft.Entry, "category", ""))
+			queued++
+			continue
+		}
+		if _, added := queueSubmission(draft.ServerID, draft.Entry, "awesome-list:"+*from, draft.Notes); added {
+			queued++
+		} else {
+			skipped++
+		}
+	}
+
+	if !*dryRun {
+		submissionsMu.Lock()
+		err := saveSubmissions()
+		submissionsMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Parsed %d servers: %d queued for review, %d already known or pending\n", len(drafts), queued, skipped)
+	return nil
+}
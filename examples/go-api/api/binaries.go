@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultBinaryDir is where prebuilt server binaries are installed unless
+// the request sets binary_dir
+const defaultBinaryDir = "/usr/local/lib/mcp/bin"
+
+// BinaryInstall is a prebuilt binary a generated config launches, with
+// the downloads its install script chooses from
+type BinaryInstall struct {
+	ServerID string        `json:"server_id"`
+	Path     string        `json:"path"`
+	Version  string        `json:"version"`
+	Assets   []BinaryAsset `json:"assets"`
+}
+
+// installBinary points a server config launched from a binary
+// distribution at the installed binary. Assets are limited to os when the
+// request names one.
+func installBinary(serverID string, distribution Distribution, mcpConfig map[string]interface{}, binaryDir, os string) *BinaryInstall {
+	install := &BinaryInstall{ServerID: serverID, Path: path.Join(binaryDir, distribution.Binary), Version: distribution.Version}
+	for _, asset := range distribution.Assets {
+		if platformOS, _, _ := strings.Cut(asset.Platform, "-"); os == "" || platformOS == os {
+			install.Assets = append(install.Assets, asset)
+		}
+	}
+	mcpConfig["command"] = install.Path
+	return install
+}
+
+// binaryInstallFunctions are the sh helpers of binary install steps:
+// naming the platform as the catalog does, and downloading a binary that
+// is only moved into place when its SHA-256 matches
+const binaryInstallFunctions = `mcp_platform() {
+  case "$(uname -s)" in
+    Linux) os=linux ;;
+    Darwin) os=macos ;;
+    MINGW*|MSYS*|CYGWIN*) os=windows ;;
+    *) os=unknown ;;
+  esac
+  case "$(uname -m)" in
+    x86_64|amd64) arch=amd64 ;;
+    arm64|aarch64) arch=arm64 ;;
+    *) arch=unknown ;;
+  esac
+  echo "$os-$arch"
+}
+mcp_sha256() {
+  if command -v sha256sum >/dev/null 2>&1; then
+    sha256sum "$1" | cut -d ' ' -f 1
+  else
+    shasum -a 256 "$1" | cut -d ' ' -f 1
+  fi
+}
+mcp_install_binary() {
+  mkdir -p "$(dirname "$3")"
+  curl -fsSL -o "$3.download" "$1"
+  actual="$(mcp_sha256 "$3.download")"
+  if [ "$actual" != "$2" ]; then
+    rm -f "$3.download"
+    echo "Checksum mismatch for $1: expected $2, got $actual; refusing to install" >&2
+    exit 1
+  fi
+  chmod +x "$3.download"
+  mv "$3.download" "$3"
+}
+platform="$(mcp_platform)"
+`
+
+// binaryInstallSteps renders sh that downloads, verifies and places every
+// binary, failing on a platform without a download
+func binaryInstallSteps(installs []*BinaryInstall) string {
+	if len(installs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(binaryInstallFunctions)
+	for _, install := range installs {
+		b.WriteString("case \"$platform\" in\n")
+		for _, asset := range install.Assets {
+			fmt.Fprintf(&b, "  %s) mcp_install_binary %s %s %s ;;\n", asset.Platform, shellQuote(asset.URL), strings.ToLower(asset.SHA256), shellQuote(install.Path))
+		}
+		fmt.Fprintf(&b, "  *) echo %s >&2; exit 1 ;;\n", shellQuote(fmt.Sprintf("No %s %s binary for ", install.ServerID, install.Version))+"\"$platform\"")
+		b.WriteString("esac\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calls to GitHub, package registries, upstream catalogs and other external
+// services go through a circuit breaker per dependency. After
+// breakerThreshold failures in a row the breaker opens and calls fail at
+// once instead of waiting for a timeout; after breakerCooldown one call is
+// let through to probe the dependency, closing the breaker when it
+// succeeds. /readyz reports every breaker.
+
+// breakerThreshold is how many failures in a row open a breaker
+const breakerThreshold = 3
+
+// breakerCooldown is how long an open breaker fails calls before probing
+const breakerCooldown = time.Minute
+
+// errCircuitOpen is returned for calls to a dependency whose breaker is open
+var errCircuitOpen = errors.New("circuit open")
+
+// dependencyHosts name the dependencies behind well-known hosts; other
+// hosts are their own dependency
+var dependencyHosts = map[string]string{
+	"github.com":                "github",
+	"api.github.com":            "github",
+	"raw.githubusercontent.com": "github",
+	"registry.npmjs.org":        "npm",
+	"pypi.org":                  "pypi",
+	"hub.docker.com":            "docker",
+}
+
+// BreakerStatus is a dependency's breaker as /readyz reports it. State is
+// closed, open or half_open once an open breaker may probe again.
+type BreakerStatus struct {
+	State       string     `json:"state"`
+	Failures    int        `json:"failures"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+type circuitBreaker struct {
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	lastError   string
+	lastSuccess time.Time
+}
+
+var breakers = struct {
+	sync.Mutex
+	byDependency map[string]*circuitBreaker
+}{byDependency: make(map[string]*circuitBreaker)}
+
+// dependencyName names the dependency a request goes to
+func dependencyName(req *http.Request) string {
+	host := strings.ToLower(req.URL.Hostname())
+	if name, ok := dependencyHosts[host]; ok {
+		return name
+	}
+	return host
+}
+
+// allowDependency reports whether a call may go out: the breaker is closed,
+// or open past its cooldown with no probe in flight
+func allowDependency(name string, now time.Time) bool {
+	breakers.Lock()
+	defer breakers.Unlock()
+	breaker := breakers.byDependency[name]
+	if breaker == nil || breaker.failures < breakerThreshold {
+		return true
+	}
+	if breaker.probing || now.Sub(breaker.openedAt) < breakerCooldown {
+		return false
+	}
+	breaker.probing = true
+	return true
+}
+
+// recordDependency counts a call's outcome. A failure that reaches the
+// threshold, or a failed probe, opens the breaker again.
+func recordDependency(name string, err error, now time.Time) {
+	breakers.Lock()
+	defer breakers.Unlock()
+	breaker := breakers.byDependency[name]
+	if breaker == nil {
+		breaker = &circuitBreaker{}
+		breakers.byDependency[name] = breaker
+	}
+	breaker.probing = false
+	if err == nil {
+		breaker.failures, breaker.lastSuccess = 0, now
+		return
+	}
+	breaker.failures++
+	breaker.lastError = err.Error()
+	if breaker.failures >= breakerThreshold {
+		breaker.openedAt = now
+	}
+}
+
+// callDependency sends a request through its dependency's breaker.
+// Transport errors, 429 and 5xx answers count as failures; other answers
+// are returned as usual for the caller to judge.
+func callDependency(client *http.Client, req *http.Request) (*http.Response, error) {
+	name := dependencyName(req)
+	if !allowDependency(name, time.Now()) {
+		return nil, fmt.Errorf("%s: %w", name, errCircuitOpen)
+	}
+	resp, err := client.Do(req)
+	switch {
+	case err != nil:
+		recordDependency(name, err, time.Now())
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		recordDependency(name, fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL.Host), time.Now())
+	default:
+		recordDependency(name, nil, time.Now())
+	}
+	return resp, err
+}
+
+// breakerReport returns every dependency's breaker by name
+func breakerReport(now time.Time) map[string]BreakerStatus {
+	breakers.Lock()
+	defer breakers.Unlock()
+	report := make(map[string]BreakerStatus, len(breakers.byDependency))
+	for name, breaker := range breakers.byDependency {
+		status := BreakerStatus{State: "closed", Failures: breaker.failures, LastError: breaker.lastError}
+		if breaker.failures >= breakerThreshold {
+			openedAt := breaker.openedAt.UTC()
+			status.State, status.OpenedAt = "open", &openedAt
+			if now.Sub(breaker.openedAt) >= breakerCooldown {
+				status.State = "half_open"
+			}
+		}
+		if !breaker.lastSuccess.IsZero() {
+			lastSuccess := breaker.lastSuccess.UTC()
+			status.LastSuccess = &lastSuccess
+		}
+		report[name] = status
+	}
+	return report
+}
+
+// openDependencies lists the dependencies whose breaker is not closed
+func openDependencies(report map[string]BreakerStatus) []string {
+	var open []string
+	for name, status := range report {
+		if status.State != "closed" {
+			open = append(open, name)
+		}
+	}
+	sort.Strings(open)
+	return open
+}
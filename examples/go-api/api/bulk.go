@@ -0,0 +1,224 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxProfilesPerRequest caps how many profiles one bulk request may contain
+const maxProfilesPerRequest = 100
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// BulkProfile is the server selection and parameters of one user profile
+type BulkProfile struct {
+	Servers    []string                     `json:"servers"`
+	Parameters map[string]map[string]string `json:"parameters,omitempty"`
+}
+
+// BulkConfigRequest is the body of POST /api/v1/servers/generate-config/bulk.
+// Shared values apply to every profile; "{profile}" in a shared value is
+// replaced with the profile name.
+type BulkConfigRequest struct {
+	Profiles map[string]BulkProfile `json:"profiles"`
+	Shared   map[string]string      `json:"shared"`
+	Format   string                 `json:"format"`
+	// AllowExperimental lets experimental servers into every profile
+	AllowExperimental bool `json:"allow_experimental"`
+}
+
+func parseBulkConfigRequest(body io.Reader) (BulkConfigRequest, error) {
+	var req BulkConfigRequest
+	if err := decodeStrict(body, &req); err != nil {
+		return req, err
+	}
+	if len(req.Profiles) == 0 {
+		return req, badRequest("Missing 'profiles' in request body")
+	}
+	if len(req.Profiles) > maxProfilesPerRequest {
+		return req, badRequest("At most %d profiles may be requested at once", maxProfilesPerRequest)
+	}
+	for name, profile := range req.Profiles {
+		if !profileNamePattern.MatchString(name) {
+			return req, badRequest("Invalid profile name '%s'", name)
+		}
+		if err := validateServerIDs(profile.Servers); err != nil {
+			return req, badRequest("Profile '%s': %v", name, err)
+		}
+		profile.Parameters = canonicalKeys(profile.Parameters)
+		req.Profiles[name] = profile
+	}
+	if req.Format == "" {
+		req.Format = "claude_desktop"
+	}
+	return req, nil
+}
+
+// profileResult is the config of one profile and how it was assembled
+type profileResult struct {
+	Config map[string]interface{}
+	// Included are the servers written to the config, Unknown those not
+	// in the catalog
+	Included []string
+	Unknown  []string
+	// Notes explain the added dependencies; Warnings name the servers
+	// that could not be launched
+	Notes    []string
+	Warnings []string
+}
+
+// profileConfig builds the config of one profile, with the servers its
+// servers require, resolving each declared environment variable from the
+// profile's parameters, then the shared values, and finally leaving a
+// ${VAR} placeholder. Servers launch as generate-config launches them for
+// the client.
+func profileConfig(r *http.Request, name string, profile BulkProfile, shared map[string]string, client ClientProfile) profileResult {
+	mcpServers := make(map[string]interface{})
+	var result profileResult
+	bridges := 0
+
+	selected, notes := withRequiredDependencies(profile.Servers)
+	result.Notes = notes
+	for _, serverID := range selected {
+		config, exists := tenantEntry(r, serverID)
+		if !exists {
+			result.Unknown = append(result.Unknown, serverID)
+			continue
+		}
+
+		_, _, mcpConfig, bridge, err := launchDistribution(serverID, config, nil, "", defaultConfigOptions, client, bridges)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+			continue
+		}
+		result.Included = append(result.Included, serverID)
+		if bridge != nil {
+			bridges++
+		}
+		if _, local := mcpConfig["command"]; !local {
+			mcpServers[serverID] = mcpConfig
+			continue
+		}
+		env := make(map[string]string)
+		for _, question := range envQuestions(config) {
+			if value, ok := profile.Parameters[serverID][question.Key]; ok {
+				env[question.Key] = value
+			} else if value, ok := shared[question.Key]; ok {
+				env[question.Key] = strings.ReplaceAll(value, "{profile}", name)
+			} else if question.Required {
+				env[question.Key] = "${" + question.Key + "}"
+			}
+		}
+		if len(env) > 0 {
+			mcpConfig["env"] = env
+		}
+		mcpServers[serverID] = mcpConfig
+	}
+
+	result.Config = client.Shape.writeConfig(mcpServers)
+	return result
+}
+
+// bulkConfigHandler generates one config per profile, returned as a JSON
+// document keyed by profile or, with ?output=zip, as a zip of files
+func bulkConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	output := r.URL.Query().Get("output")
+	if output != "" && output != "json" && output != "zip" {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusBadRequest, "Query parameter 'output' must be json or zip")
+		return
+	}
+
+	limitBody(w, r)
+	req, err := parseBulkConfigRequest(r.Body)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeRequestError(w, err)
+		return
+	}
+
+	names := make([]string, 0, len(req.Profiles))
+	for name := range req.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if tenant, policy, ok := requestPolicy(r); ok {
+		violations := make(map[string][]PolicyViolation)
+		for _, name := range names {
+			if found := evaluatePolicy(r, policy, req.Profiles[name].Servers); len(found) > 0 {
+				violations[name] = found
+			}
+		}
+		if len(violations) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			writePolicyViolations(w, tenant, violations)
+			return
+		}
+	}
+
+	for _, name := range names {
+		check := GenerateConfigRequest{Servers: req.Profiles[name].Servers, AllowExperimental: req.AllowExperimental}
+		if err := checkExperimental(check); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Profile '%s': %v", name, err))
+			return
+		}
+	}
+
+	client := clientProfile(req.Format)
+	if output == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="mcp-configs.zip"`)
+
+		archive := zip.NewWriter(w)
+		for _, name := range names {
+			config := profileConfig(r, name, req.Profiles[name], req.Shared, client).Config
+			file, err := archive.Create(name + ".json")
+			if err != nil {
+				return
+			}
+			encoder := json.NewEncoder(file)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(config)
+		}
+		archive.Close()
+		return
+	}
+
+	profiles := make(map[string]interface{})
+	for _, name := range names {
+		built := profileConfig(r, name, req.Profiles[name], req.Shared, client)
+		result := map[string]interface{}{
+			"config":           built.Config,
+			"servers_included": built.Included,
+		}
+		if len(built.Unknown) > 0 {
+			result["unknown_servers"] = built.Unknown
+		}
+		if len(built.Notes) > 0 {
+			result["dependency_notes"] = built.Notes
+		}
+		if len(built.Warnings) > 0 {
+			result["warnings"] = built.Warnings
+		}
+		profiles[name] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"format":   req.Format,
+		"profiles": profiles,
+	})
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bundleFiles holds the members of the bundle the catalog was loaded
+// from, keyed by their path inside the archive
+var bundleFiles map[string][]byte
+
+// readSourceFile returns the contents of a catalog data file. When serving
+// from a bundle only the named bundle member is considered; otherwise the
+// first readable path wins.
+func readSourceFile(bundleName string, paths []string) ([]byte, string, bool) {
+	if bundleFiles != nil {
+		data, ok := bundleFiles[bundleName]
+		return data, cfg.BundlePath + ":" + bundleName, ok
+	}
+	for _, p := range paths {
+		if data, err := ioutil.ReadFile(p); err == nil {
+			return data, p, true
+		}
+	}
+	return nil, "", false
+}
+
+// loadBundle reads a gzipped tarball produced by the bundle command
+func loadBundle(bundlePath string) (map[string][]byte, error) {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	reader := tar.NewReader(gz)
+
+	files := make(map[string][]byte)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(header.Name)] = data
+	}
+	if _, ok := files["catalog.json"]; !ok {
+		return nil, fmt.Errorf("%s is not a catalog bundle: missing catalog.json", bundlePath)
+	}
+	log.Printf("📦 Serving from bundle %s (%d files)", bundlePath, len(files))
+	return files, nil
+}
+
+// assetFiles returns the icons and READMEs of the catalog, from the active
+// bundle or the configured assets directory, then from remote enrichment
+func assetFiles() map[string][]byte {
+	assets := make(map[string][]byte)
+	defer func() {
+		for name, data := range enrichedAssets() {
+			if _, local := assets[name]; !local {
+				assets[name] = data
+			}
+		}
+	}()
+	if bundleFiles != nil {
+		for name, data := range bundleFiles {
+			if strings.HasPrefix(name, "icons/") || strings.HasPrefix(name, "readmes/") {
+				assets[name] = data
+			}
+		}
+		return assets
+	}
+
+	for _, dir := range []string{"icons", "readmes"} {
+		matches, _ := filepath.Glob(filepath.Join(cfg.AssetsDir, dir, "*"))
+		for _, match := range matches {
+			serverID := strings.TrimSuffix(filepath.Base(match), filepath.Ext(match))
+			if _, exists := getEntry(serverID); !exists {
+				continue
+			}
+			if data, err := ioutil.ReadFile(match); err == nil {
+				assets[dir+"/"+filepath.Base(match)] = data
+			}
+		}
+	}
+	return assets
+}
+
+// bundleContents assembles every file that goes into an offline bundle
+func bundleContents() (map[string][]byte, error) {
+	_, createdAt := revisionInfo()
+	files := make(map[string][]byte)
+	marshal := func(name string, v interface{}) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		files[name] = append(data, '\n')
+		return nil
+	}
+
+	doc := map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"servers":        servers,
+	}
+	if err := marshal("catalog.json", doc); err != nil {
+		return nil, err
+	}
+	categoryMetaMu.RLock()
+	err := marshal("categories.json", categoryMeta)
+	categoryMetaMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	vendorsMu.RLock()
+	err = marshal("vendors.json", vendors)
+	vendorsMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := marshal("synonyms.json", synonyms); err != nil {
+		return nil, err
+	}
+
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		if tools := entryTools(config); len(tools) > 0 {
+			if err := marshal("schemas/"+serverID+".json", tools); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, data := range assetFiles() {
+		files[name] = data
+	}
+
+	checksums := make(map[string]string, len(files))
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		checksums[name] = hex.EncodeToString(sum[:])
+	}
+	manifest := map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"created_at":     createdAt.Format(time.RFC3339),
+		"server_count":   len(servers),
+		"files":          checksums,
+	}
+	if err := marshal("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// writeBundle streams the bundle as a gzipped tarball
+func writeBundle(w io.Writer) error {
+	files, err := bundleContents()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	_, modified := revisionInfo()
+	for _, name := range names {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(files[name])),
+			ModTime: modified,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := archive.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// cacheBundle writes the bundle to path through a temporary file, and
+// also to extra when given, then drops bundles of older revisions
+func cacheBundle(path string, extra io.Writer) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, "bundle-*.tmp")
+	if err != nil {
+		return err
+	}
+	var out io.Writer = tmp
+	if extra != nil {
+		out = io.MultiWriter(tmp, extra)
+	}
+	if err := writeBundle(out); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(dir, "bundle-*.tar.gz"))
+	for _, old := range stale {
+		if old != path {
+			os.Remove(old)
+		}
+	}
+	return nil
+}
+
+// exportBundleHandler serves the offline bundle. The bundle is byte-stable
+// per catalog revision, so it is cached on disk and served with Range and
+// If-Range support for resumable downloads. A plain download of a revision
+// that is not cached yet streams with chunked encoding while the cache is
+// written, instead of buffering the archive in memory. Keyed by the
+// current ?revision, the download is immutable.
+func exportBundleHandler(w http.ResponseWriter, r *http.Request) {
+	if !revisionKeyed(w, r) {
+		return
+	}
+	// Revisions restart at 1 with the process, so the timestamp keeps
+	// bundles cached by an earlier run from being mistaken for this one
+	rev, modified := revisionInfo()
+	version := fmt.Sprintf("%d-%d", rev, modified.UnixNano())
+	etag := `"bundle-` + version + `"`
+	cached := dataPath(filepath.Join("exports", "bundle-"+version+".tar.gz"))
+
+	filename := fmt.Sprintf("mcp-catalog-%s.tar.gz", modified.Format("20060102"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	file, err := os.Open(cached)
+	if os.IsNotExist(err) && r.Header.Get("Range") == "" && r.Header.Get("If-None-Match") == "" {
+		if err := cacheBundle(cached, w); err != nil {
+			// Headers are already sent; the client sees a truncated download
+			log.Printf("❌ Failed to stream bundle: %v", err)
+		}
+		return
+	}
+	if os.IsNotExist(err) {
+		if err = cacheBundle(cached, nil); err == nil {
+			file, err = os.Open(cached)
+		}
+	}
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusInternalServerError, "Failed to build bundle")
+		log.Printf("❌ Failed to build bundle: %v", err)
+		return
+	}
+	defer file.Close()
+	http.ServeContent(w, r, "", modified, file)
+}
+
+// assetHandler serves icons and READMEs, e.g. /api/v1/assets/icons/github.png
+func assetHandler(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean(r.PathValue("path"))
+	data, ok := assetFiles()[name]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Asset '%s' not found", name))
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+// bundleCommand writes an offline bundle of the catalog:
+//
+//	bundle [-o catalog-bundle.tar.gz]
+func bundleCommand(args []string) error {
+	flags := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	output := flags.String("o", "catalog-bundle.tar.gz", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeBundle(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s with %d servers\n", *output, len(servers))
+	return nil
+}
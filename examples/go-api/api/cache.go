@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultCacheRoutes assign endpoint classes by path prefix. GET requests
+// outside every prefix are listings.
+var defaultCacheRoutes = []string{
+	"/health=private",
+	"/readyz=private",
+	"/api/v1/servers/generate-config=private",
+	"/api/v1/generated-configs/=private",
+	"/api/v1/wizard/=private",
+	"/api/v1/config=private",
+	"/api/v1/features=private",
+	"/api/v1/admin/=private",
+	"/api/v1/me/=private",
+	"/api/v1/debug/=private",
+	"/api/v1/revision=revalidate",
+	"/api/v1/export=revalidate",
+	"/api/v1/schema=static",
+	"/api/v1/assets/=static",
+}
+
+// cacheClasses are the endpoint classes routes may be assigned to
+var cacheClasses = []string{"listing", "static", "immutable", "revalidate", "private"}
+
+// cacheControl returns the Cache-Control value of an endpoint class
+func cacheControl(class string) string {
+	switch class {
+	case "static":
+		return cfg.CacheStatic
+	case "immutable":
+		return cfg.CacheImmutable
+	case "revalidate":
+		return "no-cache"
+	case "private":
+		return "no-store"
+	}
+	return cfg.CacheListing
+}
+
+// cacheRoute assigns an endpoint class to paths under a prefix
+type cacheRoute struct {
+	prefix string
+	class  string
+}
+
+// parseCacheRoutes parses "PREFIX=CLASS" assignments
+func parseCacheRoutes(specs []string) ([]cacheRoute, error) {
+	var routes []cacheRoute
+	for _, spec := range specs {
+		eq := strings.Index(spec, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid cache route '%s', want PREFIX=CLASS", spec)
+		}
+		class := strings.TrimSpace(spec[eq+1:])
+		known := false
+		for _, name := range cacheClasses {
+			known = known || name == class
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid cache route '%s': class must be one of %s", spec, strings.Join(cacheClasses, ", "))
+		}
+		routes = append(routes, cacheRoute{prefix: strings.TrimSpace(spec[:eq]), class: class})
+	}
+	return routes, nil
+}
+
+// cacheClassFor resolves the endpoint class of a path; the longest
+// matching route wins
+func cacheClassFor(path string) string {
+	class := "listing"
+	routes, _ := parseCacheRoutes(cfg.CacheRoutes)
+	matched := ""
+	for _, route := range routes {
+		if strings.HasPrefix(path, route.prefix) && len(route.prefix) > len(matched) {
+			matched = route.prefix
+			class = route.class
+		}
+	}
+	return class
+}
+
+// hasCredentials reports whether a request carries an admin token or API
+// key, either of which may select a tenant's view of the catalog
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != ""
+}
+
+// cacheMiddleware sets a Cache-Control header by endpoint class before the
+// handler runs, so handlers that know better can replace it. Writes are
+// never cacheable, and neither are authenticated reads: s-maxage would
+// otherwise let a shared cache serve one user's response to another.
+func cacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method != "GET" && r.Method != "HEAD":
+			w.Header().Set("Cache-Control", "no-store")
+		case hasCredentials(r):
+			w.Header().Set("Cache-Control", "private, no-cache")
+		default:
+			class := cacheClassFor(r.URL.Path)
+			w.Header().Set("Cache-Control", cacheControl(class))
+			if class == "listing" {
+				// Listings switch to JSON:API on the Accept header, are
+				// personalized by the client context header and show
+				// the tenant of an API key its own overlays
+				w.Header().Add("Vary", "Accept")
+				w.Header().Add("Vary", clientContextHeader)
+				w.Header().Add("Vary", "X-API-Key")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// revisionKeyed handles ?revision=N on exports. A URL keyed by the current
+// revision never changes content, so it is served as immutable; a stale
+// revision is gone because only the current catalog can be exported.
+// It reports false after writing an error response.
+func revisionKeyed(w http.ResponseWriter, r *http.Request) bool {
+	raw := r.URL.Query().Get("revision")
+	if raw == "" {
+		return true
+	}
+	requested, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusBadRequest, "Query parameter 'revision' must be a revision number")
+		return false
+	}
+	if current, _ := revisionInfo(); requested != current {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Revision %d is not the current revision %d", requested, current))
+		return false
+	}
+	if !hasCredentials(r) {
+		w.Header().Set("Cache-Control", cacheControl("immutable"))
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Synced catalogs are evaluated before they are served. A candidate that
+// fails a check is stored as a held snapshot instead of being activated;
+// an admin promotes it with POST /api/v1/snapshots/{hash}/promote, or a
+// later sync that passes supersedes it.
+
+// defaultCanaryQueries are searches whose results must survive a sync
+var defaultCanaryQueries = []string{"github", "postgres", "filesystem", "slack", "kubernetes"}
+
+// canaryLinkTimeout bounds each link spot check
+const canaryLinkTimeout = 5 * time.Second
+
+// CanaryCheck is the outcome of one check of a candidate catalog
+type CanaryCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CanaryReport is the evaluation of a candidate catalog against the one
+// served. Checks are schema, diff_size, links and search.
+type CanaryReport struct {
+	Passed      bool          `json:"passed"`
+	EvaluatedAt time.Time     `json:"evaluated_at"`
+	Added       int           `json:"added"`
+	Changed     int           `json:"changed"`
+	Deleted     int           `json:"deleted"`
+	Checks      []CanaryCheck `json:"checks"`
+}
+
+// failed names the checks that did not pass
+func (report CanaryReport) failed() []string {
+	var names []string
+	for _, check := range report.Checks {
+		if !check.Passed {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// evaluateCanary checks a candidate catalog before it replaces the served
+// one: changed entries must validate, no more than canary.max_deleted of
+// the entries may go away, a sample of changed entries' links must
+// resolve, and the canary searches must keep finding the entries they
+// found
+func evaluateCanary(served, candidate map[string]interface{}) CanaryReport {
+	report := CanaryReport{EvaluatedAt: time.Now().UTC()}
+	servedContent, candidateContent := snapshotContent(served), snapshotContent(candidate)
+	var touched []string
+	for serverID, entry := range candidateContent {
+		previous, exists := servedContent[serverID]
+		switch {
+		case !exists:
+			report.Added++
+		case !reflect.DeepEqual(previous, entry):
+			report.Changed++
+		default:
+			continue
+		}
+		touched = append(touched, serverID)
+	}
+	sort.Strings(touched)
+	for serverID := range servedContent {
+		if _, kept := candidateContent[serverID]; !kept {
+			report.Deleted++
+		}
+	}
+
+	report.Checks = []CanaryCheck{
+		canarySchemaCheck(candidate, touched),
+		canaryDiffCheck(len(served), report.Deleted),
+		canaryLinkCheck(candidate, touched),
+		canarySearchCheck(served, candidate),
+	}
+	report.Passed = len(report.failed()) == 0
+	return report
+}
+
+// canarySchemaCheck validates the added and changed entries
+func canarySchemaCheck(candidate map[string]interface{}, touched []string) CanaryCheck {
+	var problems []string
+	for _, serverID := range touched {
+		for _, problem := range validateEntry(candidate[serverID]) {
+			problems = append(problems, serverID+": "+problem)
+		}
+	}
+	check := CanaryCheck{Name: "schema", Passed: len(problems) == 0}
+	if len(problems) > 5 {
+		problems = append(problems[:5], fmt.Sprintf("and %d more", len(problems)-5))
+	}
+	check.Detail = strings.Join(problems, "; ")
+	return check
+}
+
+// canaryDiffCheck refuses candidates deleting too many of the served entries
+func canaryDiffCheck(served, deleted int) CanaryCheck {
+	check := CanaryCheck{Name: "diff_size", Passed: true}
+	if served == 0 {
+		return check
+	}
+	share := float64(deleted) / float64(served)
+	check.Detail = fmt.Sprintf("%d of %d entries (%.0f%%) deleted; the limit is %.0f%%", deleted, served, share*100, cfg.CanaryMaxDeleted*100)
+	check.Passed = share <= cfg.CanaryMaxDeleted
+	return check
+}
+
+// canaryLinkCheck spot-checks the homepage or repository of a random
+// sample of added and changed entries. Links answering 4xx count as
+// broken; unreachable hosts do not, so an outage elsewhere does not hold
+// every sync. It fails when more than half of the checked links are broken.
+func canaryLinkCheck(candidate map[string]interface{}, touched []string) CanaryCheck {
+	check := CanaryCheck{Name: "links", Passed: true}
+	var links []string
+	for _, i := range rand.Perm(len(touched)) {
+		if len(links) >= cfg.CanaryLinkChecks {
+			break
+		}
+		config, _ := candidate[touched[i]].(map[string]interface{})
+		link := getString(config, "homepage", "")
+		if repo, ok := config["repository"].(map[string]interface{}); ok && link == "" {
+			link = getString(repo, "url", "")
+		}
+		if strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://") {
+			links = append(links, link)
+		}
+	}
+	if len(links) == 0 {
+		return check
+	}
+
+	client := outboundClient(canaryLinkTimeout)
+	var broken []string
+	unreachable := 0
+	for _, link := range links {
+		ctx, cancel := context.WithTimeout(context.Background(), canaryLinkTimeout)
+		err := checkURL(ctx, client, link)
+		cancel()
+		switch {
+		case err == nil:
+		case strings.HasPrefix(err.Error(), "HTTP 4"):
+			broken = append(broken, link+" ("+err.Error()+")")
+		default:
+			unreachable++
+		}
+	}
+	check.Passed = len(broken)*2 <= len(links)
+	check.Detail = fmt.Sprintf("%d of %d links broken, %d unreachable", len(broken), len(links), unreachable)
+	if len(broken) > 0 {
+		check.Detail += ": " + strings.Join(broken, ", ")
+	}
+	return check
+}
+
+// canarySearchCheck runs the canary searches over both catalogs and fails
+// when a kept entry stops matching one
+func canarySearchCheck(served, candidate map[string]interface{}) CanaryCheck {
+	matching := func(index []indexedEntry, query string) map[string]bool {
+		ids := make(map[string]bool)
+		parsed := newSearchQuery(query)
+		for _, entry := range index {
+			if len(entry.match(parsed)) > 0 {
+				ids[entry.id] = true
+			}
+		}
+		return ids
+	}
+	servedIndex, candidateIndex := indexEntries(served), indexEntries(candidate)
+	var regressions []string
+	for _, query := range cfg.CanaryQueries {
+		found := matching(candidateIndex, query)
+		var lost []string
+		for serverID := range matching(servedIndex, query) {
+			if _, kept := candidate[serverID]; kept && !found[serverID] {
+				lost = append(lost, serverID)
+			}
+		}
+		if len(lost) > 0 {
+			sort.Strings(lost)
+			regressions = append(regressions, fmt.Sprintf("'%s' no longer finds %s", query, strings.Join(lost, ", ")))
+		}
+	}
+	return CanaryCheck{Name: "search", Passed: len(regressions) == 0, Detail: strings.Join(regressions, "; ")}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SchemaField is one property found while flattening a tool's input or
+// output JSON Schema
+type SchemaField struct {
+	Path      string   `json:"path"`
+	Name      string   `json:"name"`
+	Types     []string `json:"types,omitempty"`
+	Format    string   `json:"format,omitempty"`
+	Required  bool     `json:"required,omitempty"`
+	Direction string   `json:"direction"`
+}
+
+// indexedTool holds a tool's flattened schema for capability search
+type indexedTool struct {
+	serverID string
+	tool     Tool
+	fields   []SchemaField
+}
+
+var capabilityIndex []indexedTool
+
+// schemaTypes returns the "type" of a schema, which may be a string or a list
+func schemaTypes(schema map[string]interface{}) []string {
+	switch value := schema["type"].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var types []string
+		for _, t := range value {
+			if str, ok := t.(string); ok {
+				types = append(types, str)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// flattenSchema lists every named property of a schema, descending into
+// nested objects and array items
+func flattenSchema(schema map[string]interface{}, prefix, direction string) []SchemaField {
+	var fields []SchemaField
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, name := range list {
+			if str, ok := name.(string); ok {
+				required[str] = true
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, raw := range properties {
+		property, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fields = append(fields, SchemaField{
+			Path:      path,
+			Name:      name,
+			Types:     schemaTypes(property),
+			Format:    getString(property, "format", ""),
+			Required:  required[name],
+			Direction: direction,
+		})
+		fields = append(fields, flattenSchema(property, path, direction)...)
+		if items, ok := property["items"].(map[string]interface{}); ok {
+			fields = append(fields, flattenSchema(items, path+"[]", direction)...)
+		}
+	}
+	return fields
+}
+
+// buildCapabilityIndex flattens the schemas of every declared tool
+func buildCapabilityIndex() {
+	var index []indexedTool
+	for serverID := range servers {
+		config, ok := getEntry(serverID)
+		if !ok {
+			continue
+		}
+		for _, tool := range entryTools(config) {
+			fields := flattenSchema(tool.InputSchema, "", "input")
+			fields = append(fields, flattenSchema(tool.OutputSchema, "", "output")...)
+			sort.Slice(fields, func(i, j int) bool {
+				if fields[i].Direction != fields[j].Direction {
+					return fields[i].Direction < fields[j].Direction
+				}
+				return fields[i].Path < fields[j].Path
+			})
+			index = append(index, indexedTool{serverID: serverID, tool: tool, fields: fields})
+		}
+	}
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].serverID != index[j].serverID {
+			return index[i].serverID < index[j].serverID
+		}
+		return index[i].tool.Name < index[j].tool.Name
+	})
+	capabilityIndex = index
+}
+
+// fieldMatches applies the parameter criteria of a capability query
+func fieldMatches(field SchemaField, direction, name, fieldType, format string) bool {
+	if direction != "any" && field.Direction != direction {
+		return false
+	}
+	if name != "" && !strings.EqualFold(field.Name, name) {
+		return false
+	}
+	if format != "" && !strings.EqualFold(field.Format, format) {
+		return false
+	}
+	if fieldType != "" {
+		for _, t := range field.Types {
+			if t == fieldType {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// capabilitySearchHandler finds tools by name and by the parameters their
+// schemas declare, e.g. ?param=sql&type=string for tools accepting a sql string
+func capabilitySearchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	toolQuery := strings.ToLower(query.Get("tool"))
+	param := query.Get("param")
+	fieldType := query.Get("type")
+	format := query.Get("format")
+	direction := query.Get("direction")
+	if direction == "" {
+		direction = "input"
+	}
+	if direction != "input" && direction != "output" && direction != "any" {
+		writeError(w, http.StatusBadRequest, "Query parameter 'direction' must be input, output or any")
+		return
+	}
+	if toolQuery == "" && param == "" && fieldType == "" && format == "" {
+		writeError(w, http.StatusBadRequest, "Query parameter 'tool', 'param', 'type' or 'format' required")
+		return
+	}
+	filters, err := parseEntryFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	matchFields := param != "" || fieldType != "" || format != ""
+
+	type capabilityMatch struct {
+		ServerID    string        `json:"server_id"`
+		ServerName  string        `json:"server_name"`
+		Tool        string        `json:"tool"`
+		Description string        `json:"description,omitempty"`
+		Matches     []SchemaField `json:"matches,omitempty"`
+	}
+	results := []capabilityMatch{}
+	for _, entry := range capabilityIndex {
+		if timedOut(r) {
+			writeTimeout(w, requestTimeoutFor(r.URL.Path), map[string]interface{}{
+				"partial_results": results,
+				"hint":            "Results are incomplete; add 'tool' or entry filters to narrow the search",
+			})
+			return
+		}
+		config, ok := getEntry(entry.serverID)
+		if !ok || !matchesFilters(filters, entry.serverID, config) {
+			continue
+		}
+		if toolQuery != "" && !strings.Contains(strings.ToLower(entry.tool.Name), toolQuery) &&
+			!strings.Contains(strings.ToLower(entry.tool.Description), toolQuery) {
+			continue
+		}
+		var matches []SchemaField
+		for _, field := range entry.fields {
+			if fieldMatches(field, direction, param, fieldType, format) {
+				matches = append(matches, field)
+			}
+		}
+		if matchFields && len(matches) == 0 {
+			continue
+		}
+		results = append(results, capabilityMatch{
+			ServerID:    entry.serverID,
+			ServerName:  getString(config, "name", entry.serverID),
+			Tool:        entry.tool.Name,
+			Description: entry.tool.Description,
+			Matches:     matches,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+		"total":   len(results),
+	})
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CategoryMeta is the curated landing data for a category
+type CategoryMeta struct {
+	Description     string   `json:"description,omitempty"`
+	LongDescription string   `json:"long_description,omitempty"`
+	Order           int      `json:"order,omitempty"`
+	Featured        []string `json:"featured,omitempty"`
+	// ReplacedBy marks a renamed or split category; requests for it are
+	// answered with its successors
+	ReplacedBy []string `json:"replaced_by,omitempty"`
+	// MovingTo are the successors of a split still in progress; they
+	// become ReplacedBy once no entry is left in the category
+	MovingTo []string `json:"moving_to,omitempty"`
+}
+
+// CategoryInfo is a category as returned by the categories endpoint
+type CategoryInfo struct {
+	Name            string   `json:"name"`
+	Count           int      `json:"count"`
+	Description     string   `json:"description,omitempty"`
+	LongDescription string   `json:"long_description,omitempty"`
+	Order           int      `json:"order,omitempty"`
+	Featured        []Server `json:"featured,omitempty"`
+}
+
+var (
+	categoryMetaMu   sync.RWMutex
+	categoryMeta     = make(map[string]CategoryMeta)
+	categoryMetaPath = "categories.json"
+)
+
+func loadCategoryMeta() {
+	paths := []string{
+		"../../mcp_catalog/categories.json",
+		"categories.json",
+	}
+
+	data, path, ok := readSourceFile("categories.json", paths)
+	if !ok {
+		return
+	}
+	meta := make(map[string]CategoryMeta)
+	if err := json.Unmarshal(data, &meta); err != nil {
+		log.Printf("❌ Ignoring %s: %v", path, err)
+		return
+	}
+	log.Printf("🗂️  Loaded metadata for %d categories from %s", len(meta), path)
+	categoryMetaMu.Lock()
+	categoryMeta = meta
+	// Metadata read from a bundle has no file to write back to
+	categoryMetaPath = ""
+	if bundleFiles == nil {
+		categoryMetaPath = path
+	}
+	categoryMetaMu.Unlock()
+}
+
+// saveCategoryMeta writes the metadata back to the file it came from.
+// Callers must hold categoryMetaMu.
+func saveCategoryMeta() error {
+	if categoryMetaPath == "" {
+		return fmt.Errorf("category metadata was loaded from a bundle and is read-only")
+	}
+	return writeJSONFile(categoryMetaPath, categoryMeta)
+}
+
+// categoryInfos aggregates category counts with their curated metadata,
+// sorted by explicit order first and then by name
+func categoryInfos() []CategoryInfo {
+	counts := make(map[string]int)
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		counts[getString(config, "category", "other")]++
+	}
+
+	categoryMetaMu.RLock()
+	defer categoryMetaMu.RUnlock()
+
+	result := make([]CategoryInfo, 0, len(counts))
+	for name, count := range counts {
+		meta := categoryMeta[name]
+		info := CategoryInfo{
+			Name:            name,
+			Count:           count,
+			Description:     meta.Description,
+			LongDescription: meta.LongDescription,
+			Order:           meta.Order,
+		}
+		for _, serverID := range meta.Featured {
+			serverID = canonicalID(serverID)
+			if config, exists := getEntry(serverID); exists {
+				info.Featured = append(info.Featured, serverSummary(serverID, config))
+			}
+		}
+		result = append(result, info)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if (a.Order == 0) != (b.Order == 0) {
+			return a.Order != 0
+		}
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+// resolveCategory maps a requested category to the categories it stands
+// for, following renames and splits. The result is nil when no category
+// was requested; renamed reports whether any alias was followed.
+func resolveCategory(name string) (resolved []string, renamed bool) {
+	if name == "" {
+		return nil, false
+	}
+	categoryMetaMu.RLock()
+	defer categoryMetaMu.RUnlock()
+
+	seen := map[string]bool{name: true}
+	pending := []string{name}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		successors := categoryMeta[current].ReplacedBy
+		if len(successors) == 0 {
+			resolved = append(resolved, current)
+			continue
+		}
+		renamed = true
+		for _, successor := range successors {
+			if !seen[successor] {
+				seen[successor] = true
+				pending = append(pending, successor)
+			}
+		}
+	}
+	return resolved, renamed
+}
+
+// requestedCategories resolves the ?category parameter, flagging the
+// response as deprecated when the client used an old category name
+func requestedCategories(w http.ResponseWriter, r *http.Request) []string {
+	category := r.URL.Query().Get("category")
+	resolved, renamed := resolveCategory(category)
+	if renamed {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Warning", fmt.Sprintf(`299 - "Category '%s' is deprecated; use %s"`, category, quotedList(resolved)))
+	}
+	return resolved
+}
+
+// quotedList renders names as 'a', 'b' or 'c'
+func quotedList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	if len(quoted) <= 1 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// inCategories reports whether an entry's category is one of categories;
+// no categories matches everything
+func inCategories(categories []string, config map[string]interface{}) bool {
+	if categories == nil {
+		return true
+	}
+	category := getString(config, "category", "other")
+	for _, candidate := range categories {
+		if candidate == category {
+			return true
+		}
+	}
+	return false
+}
+
+// adminCategoryHandler replaces (PUT) or clears (DELETE) the curated
+// metadata of a category
+func adminCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/v1/admin/categories/")
+	if name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusBadRequest, "Invalid category name")
+		return
+	}
+
+	if bundleFiles != nil {
+		writeError(w, http.StatusConflict, "Category metadata comes from the catalog bundle and cannot be edited")
+		return
+	}
+
+	switch r.Method {
+	case "PUT":
+		var meta CategoryMeta
+		if err := decodeJSONBody(w, r, &meta); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		for i, serverID := range meta.Featured {
+			meta.Featured[i] = canonicalID(serverID)
+			if _, exists := getEntry(meta.Featured[i]); !exists {
+				writeError(w, http.StatusBadRequest, "Featured server '"+serverID+"' not found")
+				return
+			}
+		}
+
+		categoryMetaMu.Lock()
+		previous, existed := categoryMeta[name]
+		categoryMeta[name] = meta
+		if err := saveCategoryMeta(); err != nil {
+			if existed {
+				categoryMeta[name] = previous
+			} else {
+				delete(categoryMeta, name)
+			}
+			categoryMetaMu.Unlock()
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		categoryMetaMu.Unlock()
+		bumpRevision()
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":     name,
+			"metadata": meta,
+		})
+	case "DELETE":
+		categoryMetaMu.Lock()
+		previous, existed := categoryMeta[name]
+		delete(categoryMeta, name)
+		if err := saveCategoryMeta(); err != nil {
+			if existed {
+				categoryMeta[name] = previous
+			}
+			categoryMetaMu.Unlock()
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		categoryMetaMu.Unlock()
+		bumpRevision()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChangelogEntry is the notes of one released version
+type ChangelogEntry struct {
+	Version string `json:"version"`
+	Date    string `json:"date,omitempty"`
+	Notes   string `json:"notes"`
+	URL     string `json:"url,omitempty"`
+}
+
+// Changelog is the ingested history of a server, newest version first
+type Changelog struct {
+	Source    string           `json:"source"`
+	FetchedAt time.Time        `json:"fetched_at"`
+	Entries   []ChangelogEntry `json:"entries"`
+}
+
+var (
+	changelogsMu sync.RWMutex
+	changelogs   = make(map[string]Changelog)
+)
+
+func loadChangelogs() {
+	var stored map[string]Changelog
+	if err := readJSONFile(dataPath("changelogs.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load changelogs: %v", err)
+		return
+	}
+	if stored != nil {
+		changelogsMu.Lock()
+		changelogs = canonicalKeys(stored)
+		changelogsMu.Unlock()
+	}
+}
+
+// changelogHeading matches version headings such as "## [1.2.0] - 2024-05-01",
+// "## v1.2.0 (2024-05-01)" or "# 1.2"
+var changelogHeading = regexp.MustCompile(`^#{1,3}\s*\[?v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)\]?(?:\s*[-–—(]*\s*(\d{4}-\d{2}-\d{2}))?`)
+
+// parseChangelog splits a CHANGELOG.md into version sections, keeping the
+// file's order. Sections without a version, such as "Unreleased", are
+// skipped.
+func parseChangelog(markdown string) []ChangelogEntry {
+	var entries []ChangelogEntry
+	var notes []string
+	flush := func() {
+		if len(entries) > 0 {
+			entries[len(entries)-1].Notes = strings.TrimSpace(strings.Join(notes, "\n"))
+		}
+		notes = nil
+	}
+	inVersion := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(line, "#") {
+			if match := changelogHeading.FindStringSubmatch(line); match != nil {
+				flush()
+				entries = append(entries, ChangelogEntry{Version: match[1], Date: match[2]})
+				inVersion = true
+				continue
+			}
+			// A heading at the level of version headings ends the section
+			if level := len(line) - len(strings.TrimLeft(line, "#")); level <= 2 && inVersion {
+				flush()
+				inVersion = false
+				continue
+			}
+		}
+		if inVersion {
+			notes = append(notes, line)
+		}
+	}
+	if inVersion {
+		flush()
+	}
+	return entries
+}
+
+// compareVersions orders dotted versions numerically; a pre-release sorts
+// before its release. It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	aCore, aPre, _ := strings.Cut(a, "-")
+	bCore, bPre, _ := strings.Cut(b, "-")
+	aParts, bParts := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	}
+	return 1
+}
+
+// fetchReleases reads the published GitHub releases of a repository
+func fetchReleases(ctx context.Context, client *http.Client, apiBase, owner, name string) ([]ChangelogEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/repos/%s/%s/releases?per_page=50", apiBase, owner, name), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := callDependency(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from GitHub releases", resp.StatusCode)
+	}
+	var releases []struct {
+		TagName     string `json:"tag_name"`
+		Body        string `json:"body"`
+		HTMLURL     string `json:"html_url"`
+		Draft       bool   `json:"draft"`
+		PublishedAt string `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, err
+	}
+	var entries []ChangelogEntry
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		// Monorepo tags look like "@scope/pkg@1.2.0"
+		version := release.TagName[strings.LastIndex(release.TagName, "@")+1:]
+		entries = append(entries, ChangelogEntry{
+			Version: strings.TrimPrefix(version, "v"),
+			Date:    strings.SplitN(release.PublishedAt, "T", 2)[0],
+			Notes:   strings.TrimSpace(release.Body),
+			URL:     release.HTMLURL,
+		})
+	}
+	return entries, nil
+}
+
+// changelogCommand ingests release notes for every entry with a GitHub
+// repository, preferring GitHub releases over CHANGELOG.md:
+//
+//	changelog [-ids a,b] [-o data/changelogs.json]
+//
+// GITHUB_TOKEN raises the API rate limit.
+func changelogCommand(args []string) error {
+	flags := flag.NewFlagSet("changelog", flag.ContinueOnError)
+	ids := flags.String("ids", "", "comma-separated server IDs to ingest")
+	output := flags.String("o", dataPath("changelogs.json"), "changelogs file")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout per request")
+	apiBase := flags.String("github-api", "https://api.github.com", "GitHub API base URL")
+	rawBase := flags.String("github-raw", "https://raw.githubusercontent.com", "base URL of raw repository files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var selected []string
+	if *ids != "" {
+		for _, serverID := range strings.Split(*ids, ",") {
+			serverID = strings.TrimSpace(serverID)
+			if _, exists := getEntry(serverID); !exists {
+				return fmt.Errorf("server '%s' not found", serverID)
+			}
+			selected = append(selected, serverID)
+		}
+	} else {
+		for serverID := range servers {
+			selected = append(selected, serverID)
+		}
+	}
+	sort.Strings(selected)
+
+	stored := make(map[string]Changelog)
+	if err := readJSONFile(*output, &stored); err != nil {
+		return err
+	}
+	if stored == nil {
+		stored = make(map[string]Changelog)
+	}
+
+	client := outboundClient(*timeout)
+	ingested := 0
+	for _, serverID := range selected {
+		config, _ := getEntry(serverID)
+		owner, name, ok := githubRepo(config)
+		if !ok {
+			continue
+		}
+		ctx := context.Background()
+		source := fmt.Sprintf("https://github.com/%s/%s/releases", owner, name)
+		entries, err := fetchReleases(ctx, client, *apiBase, owner, name)
+		if err == nil && len(entries) == 0 {
+			source = fmt.Sprintf("%s/%s/%s/HEAD/CHANGELOG.md", *rawBase, owner, name)
+			var markdown string
+			if markdown, err = fetchText(ctx, client, source); err == nil {
+				entries = parseChangelog(markdown)
+			}
+		}
+		if err != nil || len(entries) == 0 {
+			fmt.Printf("%s: no changelog (%v)\n", serverID, err)
+			continue
+		}
+		stored[serverID] = Changelog{Source: source, FetchedAt: time.Now().UTC(), Entries: entries}
+		ingested++
+		fmt.Printf("%s: %d versions from %s\n", serverID, len(entries), source)
+	}
+
+	if err := writeJSONFile(*output, stored); err != nil {
+		return err
+	}
+	fmt.Printf("Ingested changelogs for %d of %d servers into %s\n", ingested, len(selected), *output)
+	return nil
+}
+
+// serverChangelogHandler serves a server's changelog, optionally only the
+// versions newer than ?since, e.g. the version a config is pinned to
+func serverChangelogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serverID := r.PathValue("id")
+	config, exists := getEntry(serverID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+	changelogsMu.RLock()
+	changelog, ok := changelogs[serverID]
+	changelogsMu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No changelog for '%s'; run the changelog command", serverID))
+		return
+	}
+
+	since := r.URL.Query().Get("since")
+	entries := []ChangelogEntry{}
+	for _, entry := range changelog.Entries {
+		if since == "" || compareVersions(entry.Version, since) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	response := map[string]interface{}{
+		"id":         serverID,
+		"source":     changelog.Source,
+		"fetched_at": changelog.FetchedAt,
+		"entries":    entries,
+	}
+	if pkg, ok := entryPackage(config); ok {
+		if version := pinnedVersion(pkg, "exact"); version != "" {
+			response["pinned_version"] = version
+		}
+	}
+	if since != "" {
+		response["since"] = since
+	}
+	json.NewEncoder(w).Encode(response)
+}
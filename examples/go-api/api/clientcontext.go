@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// clientContextHeader describes the client a list or search request is
+// made for:
+//
+//	X-MCP-Client: cursor/0.45 darwin-arm64 runtimes=node,uv
+//
+// The client name is a generate-config format, with dashes allowed for
+// underscores; the version, platform and runtimes are optional.
+const clientContextHeader = "X-MCP-Client"
+
+// ClientContext is the parsed X-MCP-Client header
+type ClientContext struct {
+	Format  string `json:"client"`
+	Version string `json:"version,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	// Runtimes are the programs installed on the client machine; nil means
+	// the client did not say
+	Runtimes []string `json:"runtimes,omitempty"`
+
+	profile ClientProfile
+	known   bool
+}
+
+// platformArchs are the architectures "platforms" entries may name
+var platformArchs = []string{"amd64", "arm64"}
+
+// platformNames map the OS and architecture names of Node, Go and uname to
+// the ones the catalog uses
+var platformNames = map[string]string{
+	"darwin": "macos", "macos": "macos", "mac": "macos",
+	"win32": "windows", "windows": "windows", "win": "windows",
+	"linux": "linux",
+	"x64":   "amd64", "x86_64": "amd64", "amd64": "amd64",
+	"arm64": "arm64", "aarch64": "arm64",
+}
+
+// runtimeCommands are the launchers each runtime named in the header puts
+// on PATH
+var runtimeCommands = map[string][]string{
+	"node":   {"node", "npx", "npm"},
+	"python": {"python", "python3", "pip", "pip3"},
+	"uv":     {"uv", "uvx"},
+	"bun":    {"bun", "bunx"},
+	"deno":   {"deno"},
+	"docker": {"docker"},
+}
+
+var clientNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// parseClientContext reads the X-MCP-Client header. It returns nil when
+// the header is absent, ?personalize=false turns personalization off or
+// the personalization feature is off.
+func parseClientContext(r *http.Request) (*ClientContext, error) {
+	raw := strings.TrimSpace(r.Header.Get(clientContextHeader))
+	if raw == "" || !featureEnabled(r, "personalization") {
+		return nil, nil
+	}
+	personalize, present, err := parseBoolParam(r, "personalize")
+	if err != nil {
+		return nil, err
+	}
+	if present && !personalize {
+		return nil, nil
+	}
+	malformed := fmt.Errorf("Header '%s' must look like \"cursor/0.45 darwin-arm64 runtimes=node,uv\"", clientContextHeader)
+
+	fields := strings.Fields(strings.ToLower(raw))
+	name, version, _ := strings.Cut(fields[0], "/")
+	client := &ClientContext{Format: strings.ReplaceAll(name, "-", "_"), Version: version}
+	if !clientNamePattern.MatchString(client.Format) || version != "" && !clientVersionPattern.MatchString(version) {
+		return nil, malformed
+	}
+	for _, field := range fields[1:] {
+		if list, ok := strings.CutPrefix(field, "runtimes="); ok {
+			client.Runtimes = []string{}
+			for _, runtime := range strings.Split(list, ",") {
+				if runtime != "" {
+					client.Runtimes = append(client.Runtimes, runtime)
+				}
+			}
+			continue
+		}
+		os, arch, _ := strings.Cut(field, "-")
+		if client.OS = platformNames[os]; client.OS == "" || !slices.Contains(installOSes, client.OS) {
+			return nil, malformed
+		}
+		if arch != "" {
+			if client.Arch = platformNames[arch]; !slices.Contains(platformArchs, client.Arch) {
+				return nil, malformed
+			}
+		}
+	}
+
+	_, client.known = clientProfiles[client.Format]
+	client.profile, err = clientProfileVersion(client.Format, client.Version)
+	// A client older than file-based configuration still gets results; its
+	// oldest release is the closest description of what it can launch
+	var unsupported *UnsupportedClientError
+	if errors.As(err, &unsupported) {
+		client.profile.Transports = clientReleases[client.Format][0].Transports
+	}
+	return client, nil
+}
+
+// entryPlatforms returns the "platforms" of an entry: OS names, optionally
+// with an architecture as in "macos-arm64". None means every platform.
+func entryPlatforms(config map[string]interface{}) []string {
+	platforms, _ := stringList(config["platforms"])
+	return platforms
+}
+
+// isPlatform reports whether a "platforms" value is well-formed
+func isPlatform(platform string) bool {
+	os, arch, hasArch := strings.Cut(platform, "-")
+	return slices.Contains(installOSes, os) && (!hasArch || slices.Contains(platformArchs, arch))
+}
+
+// supportsPlatform reports whether an entry runs on the client's platform.
+// A client that did not name its platform sees every entry.
+func (c *ClientContext) supportsPlatform(config map[string]interface{}) bool {
+	platforms := entryPlatforms(config)
+	if c.OS == "" || len(platforms) == 0 {
+		return true
+	}
+	for _, platform := range platforms {
+		os, arch, hasArch := strings.Cut(platform, "-")
+		if os == c.OS && (!hasArch || c.Arch == "" || arch == c.Arch) {
+			return true
+		}
+	}
+	return false
+}
+
+// launchers returns the programs the client would start for an entry, and
+// false when the client cannot connect to it at all
+func (c *ClientContext) launchers(serverID string, config map[string]interface{}) ([]string, bool) {
+	mcpConfig, bridge, err := launchConfig(serverID, config, defaultConfigOptions, c.profile, 0)
+	if err != nil {
+		return nil, false
+	}
+	return launchCommands(mcpConfig, bridge), true
+}
+
+// compatible reports whether the client can use an entry at all. Unknown
+// clients are only matched on platform.
+func (c *ClientContext) compatible(serverID string, config map[string]interface{}) bool {
+	if !c.supportsPlatform(config) {
+		return false
+	}
+	if !c.known {
+		return true
+	}
+	_, ok := c.launchers(serverID, config)
+	return ok
+}
+
+// missingRuntimes lists the launchers of an entry the client did not say
+// it has installed
+func (c *ClientContext) missingRuntimes(serverID string, config map[string]interface{}) []string {
+	if c.Runtimes == nil {
+		return nil
+	}
+	installed := make(map[string]bool)
+	for _, runtime := range c.Runtimes {
+		installed[runtime] = true
+		for _, command := range runtimeCommands[runtime] {
+			installed[command] = true
+		}
+	}
+	commands, _ := c.launchers(serverID, config)
+	var missing []string
+	for _, command := range commands {
+		// Wrapper scripts and absolute paths are not runtimes
+		if !strings.Contains(command, "/") && !installed[command] {
+			missing = append(missing, command)
+		}
+	}
+	return missing
+}
+
+// parseClientFilter hides entries the X-MCP-Client cannot use
+func parseClientFilter(r *http.Request) (entryFilter, error) {
+	client, err := parseClientContext(r)
+	if client == nil || err != nil {
+		return nil, err
+	}
+	return client.compatible, nil
+}
+
+// Client context boosts: entries the client can launch with what it has
+// installed rank above those needing another runtime first
+const (
+	clientReadyBoost    = 0.5
+	missingRuntimeBoost = -1.0
+)
+
+// personalize adds the runtime boost of an entry to its explanation
+func (c *ClientContext) personalize(serverID string, config map[string]interface{}, explanation *SearchExplanation) {
+	if c.Runtimes == nil {
+		return
+	}
+	boost := ScoreBoost{Reason: "client_ready", Score: clientReadyBoost}
+	if len(c.missingRuntimes(serverID, config)) > 0 {
+		boost = ScoreBoost{Reason: "missing_runtime", Score: missingRuntimeBoost}
+	}
+	explanation.Boosts = append(explanation.Boosts, boost)
+	explanation.Score = roundScore(explanation.Score + boost.Score*explanation.PopularityFactor)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ClientProfile describes what an MCP client supports when consuming a
+// generated config. The generate-config format selects the profile.
+type ClientProfile struct {
+	Name string
+	// NamespacesTools means the client already prefixes tool names with
+	// the server name, so identical tool names cannot collide
+	NamespacesTools bool
+	// Transports lists the MCP transports the client can connect with
+	Transports []string
+	// Shape is how the client's config file lays out servers
+	Shape ConfigShape
+	// RecommendedServers is how many servers the client handles well at
+	// once; MaxTools is the hard cap on tools it exposes to the model.
+	// Zero means no known limit.
+	RecommendedServers int
+	MaxTools           int
+}
+
+// ConfigShape is how a config file lays out servers. Configs are built in
+// the mcpServers shape, remote servers as "url" and "transport", and
+// written in the client's shape when returned.
+type ConfigShape struct {
+	// ServersKey is the top-level key listing the servers
+	ServersKey string
+	// URLKey names the endpoint of a remote server
+	URLKey string
+	// TransportKey names the field declaring a server's transport; none
+	// means the client tells from the endpoint. TransportNames are the
+	// client's own spellings of transports, and TypedStdio means local
+	// servers declare "stdio" too.
+	TransportKey   string
+	TransportNames map[string]string
+	TypedStdio     bool
+}
+
+var (
+	mcpServersShape = ConfigShape{ServersKey: "mcpServers", URLKey: "url", TransportKey: "transport"}
+	// untypedShape is mcpServers with remote servers told by their URL
+	untypedShape = ConfigShape{ServersKey: "mcpServers", URLKey: "url"}
+	// typedShape declares remote transports in "type", streamable HTTP
+	// as "http"
+	typedShape = ConfigShape{ServersKey: "mcpServers", URLKey: "url", TransportKey: "type", TransportNames: map[string]string{"streamable-http": "http"}}
+	// vscodeShape lists "servers", each declaring its type
+	vscodeShape = ConfigShape{ServersKey: "servers", URLKey: "url", TransportKey: "type", TransportNames: map[string]string{"streamable-http": "http"}, TypedStdio: true}
+	// windsurfShape names endpoints "serverUrl"
+	windsurfShape = ConfigShape{ServersKey: "mcpServers", URLKey: "serverUrl"}
+)
+
+// clientProfiles are the known clients, keyed by generate-config format
+var clientProfiles = map[string]ClientProfile{
+	"claude_desktop": {Name: "Claude Desktop", Transports: []string{"stdio"}, Shape: mcpServersShape, RecommendedServers: 10},
+	"claude_code":    {Name: "Claude Code", NamespacesTools: true, Transports: []string{"stdio", "sse", "streamable-http"}, Shape: typedShape, RecommendedServers: 15},
+	"cursor":         {Name: "Cursor", Transports: []string{"stdio", "sse", "streamable-http"}, Shape: untypedShape, RecommendedServers: 8, MaxTools: 40},
+	"vscode":         {Name: "VS Code", Transports: []string{"stdio", "sse", "streamable-http"}, Shape: vscodeShape, RecommendedServers: 15, MaxTools: 128},
+	"windsurf":       {Name: "Windsurf", Transports: []string{"stdio", "sse"}, Shape: windsurfShape, RecommendedServers: 10, MaxTools: 100},
+	"chatgpt":        {Name: "ChatGPT", Transports: []string{"sse", "streamable-http"}, Shape: mcpServersShape, RecommendedServers: 5},
+}
+
+// clientProfile returns the profile for a format, falling back to a
+// conservative profile for unknown clients
+func clientProfile(format string) ClientProfile {
+	if profile, ok := clientProfiles[format]; ok {
+		return profile
+	}
+	return ClientProfile{Name: format, Transports: []string{"stdio"}, Shape: mcpServersShape}
+}
+
+// writeConfig lays out servers built in the mcpServers shape
+func (s ConfigShape) writeConfig(mcpServers map[string]interface{}) map[string]interface{} {
+	servers := make(map[string]interface{}, len(mcpServers))
+	for key, raw := range mcpServers {
+		if server, ok := raw.(map[string]interface{}); ok {
+			servers[key] = s.writeServer(server)
+		} else {
+			servers[key] = raw
+		}
+	}
+	return map[string]interface{}{s.ServersKey: servers}
+}
+
+// writeServer lays out one server built in the mcpServers shape
+func (s ConfigShape) writeServer(server map[string]interface{}) map[string]interface{} {
+	shaped := make(map[string]interface{}, len(server)+1)
+	for field, value := range server {
+		switch field {
+		case "url":
+			shaped[s.URLKey] = value
+		case "transport":
+			if transport, ok := value.(string); ok && s.TransportKey != "" {
+				shaped[s.TransportKey] = s.transportName(transport)
+			}
+		default:
+			shaped[field] = value
+		}
+	}
+	if _, local := server["command"]; local && s.TypedStdio {
+		shaped[s.TransportKey] = "stdio"
+	}
+	return shaped
+}
+
+// readServer returns a server of a config in this shape in the mcpServers
+// shape; a transport the client tells from the endpoint stays unset
+func (s ConfigShape) readServer(raw interface{}) interface{} {
+	server, ok := raw.(map[string]interface{})
+	if !ok {
+		return raw
+	}
+	read := make(map[string]interface{}, len(server))
+	for field, value := range server {
+		switch {
+		case field == s.URLKey:
+			read["url"] = value
+		case field == s.TransportKey:
+			if transport, ok := value.(string); ok && transport != "stdio" {
+				read["transport"] = s.readTransport(transport)
+			}
+		default:
+			read[field] = value
+		}
+	}
+	return read
+}
+
+// readClientConfig returns the servers of a config in any client's shape
+// in the mcpServers shape, and whether it lists servers at all
+func readClientConfig(config map[string]interface{}) (map[string]interface{}, bool) {
+	for _, shape := range []ConfigShape{mcpServersShape, vscodeShape} {
+		servers, ok := config[shape.ServersKey].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		read := make(map[string]interface{}, len(servers))
+		for key, raw := range servers {
+			server := shape.readServer(raw)
+			// Windsurf lists mcpServers too, naming endpoints serverUrl
+			if entry, ok := server.(map[string]interface{}); ok && entry["url"] == nil && entry[windsurfShape.URLKey] != nil {
+				server = windsurfShape.readServer(entry)
+			}
+			read[key] = server
+		}
+		return read, true
+	}
+	return nil, false
+}
+
+// transportName is the client's spelling of a transport
+func (s ConfigShape) transportName(transport string) string {
+	if name, ok := s.TransportNames[transport]; ok {
+		return name
+	}
+	return transport
+}
+
+// readTransport is the transport the client spells as name
+func (s ConfigShape) readTransport(name string) string {
+	for transport, spelling := range s.TransportNames {
+		if spelling == name {
+			return transport
+		}
+	}
+	return name
+}
+
+// supportsTransport reports whether the client can connect over a transport
+func (c ClientProfile) supportsTransport(transport string) bool {
+	for _, supported := range c.Transports {
+		if supported == transport {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientRelease is what a client's config file supports, and how it lays
+// out servers, from a release on
+type ClientRelease struct {
+	Since      string
+	Transports []string
+	Shape      ConfigShape
+}
+
+// clientReleases are the config capabilities of each client version,
+// oldest first. Versions before the first release cannot be configured
+// from a file at all.
+// The newest release matches the client's profile.
+var clientReleases = map[string][]ClientRelease{
+	"claude_desktop": {
+		{Since: "0.7.0", Transports: []string{"stdio"}, Shape: mcpServersShape},
+	},
+	"cursor": {
+		{Since: "0.46.0", Transports: []string{"stdio", "sse"}, Shape: untypedShape},
+		{Since: "1.0.0", Transports: []string{"stdio", "sse", "streamable-http"}, Shape: untypedShape},
+	},
+	"vscode": {
+		{Since: "1.99.0", Transports: []string{"stdio", "sse"}, Shape: vscodeShape},
+		{Since: "1.100.0", Transports: []string{"stdio", "sse", "streamable-http"}, Shape: vscodeShape},
+	},
+	"windsurf": {
+		{Since: "1.3.0", Transports: []string{"stdio", "sse"}, Shape: windsurfShape},
+	},
+}
+
+// clientVersionPattern matches the client_version parameter
+var clientVersionPattern = regexp.MustCompile(`^v?\d+(\.\d+){0,3}$`)
+
+// UnsupportedClientError means a client version predates file-based MCP
+// configuration
+type UnsupportedClientError struct {
+	Client         string
+	Version        string
+	MinimumVersion string
+}
+
+func (e *UnsupportedClientError) Error() string {
+	return fmt.Sprintf("unsupported: %s %s cannot load MCP servers from its config file; upgrade to %s or later", e.Client, e.Version, e.MinimumVersion)
+}
+
+// clientProfileVersion returns the profile of a specific client version.
+// An empty version, or a client without a release table, gets the current
+// profile.
+func clientProfileVersion(format, version string) (ClientProfile, error) {
+	profile := clientProfile(format)
+	releases := clientReleases[format]
+	if version == "" || len(releases) == 0 {
+		return profile, nil
+	}
+	if compareVersions(version, releases[0].Since) < 0 {
+		return profile, &UnsupportedClientError{Client: profile.Name, Version: version, MinimumVersion: releases[0].Since}
+	}
+	for _, release := range releases {
+		if compareVersions(version, release.Since) < 0 {
+			break
+		}
+		profile.Transports = release.Transports
+		profile.Shape = release.Shape
+	}
+	profile.Name += " " + strings.TrimPrefix(version, "v")
+	return profile, nil
+}
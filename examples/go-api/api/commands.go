@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// commands are the subcommands available besides serving the API
+var commands = map[string]func(args []string) error{
+	"bundle":         bundleCommand,
+	"changelog":      changelogCommand,
+	"import-awesome": importAwesomeCommand,
+	"migrate":        migrateCommand,
+	"mock":           mockCommand,
+	"provenance":     provenanceCommand,
+	"publish":        publishCommand,
+	"pull":           pullCommand,
+	"push":           pushCommand,
+	"recategorize":   recategorizeCommand,
+	"service":        serviceCommand,
+	"smoke":          smokeCommand,
+	"summarize":      summarizeCommand,
+	"synthetic":      syntheticCommand,
+	"verify":         verifyCommand,
+}
+
+// runCommand executes a subcommand if args name one. It reports whether a
+// subcommand was found so main can fall back to serving the API.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+	err := command(args[1:])
+	// Commands exit right after; let their notifications go out first
+	waitNotifications()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxCompareServers caps how many servers one comparison may include
+const maxCompareServers = 5
+
+// ComparisonRow is one attribute of a comparison, valued per server
+type ComparisonRow struct {
+	Attribute string                 `json:"attribute"`
+	Values    map[string]interface{} `json:"values"`
+	// Same is true when every compared server has the same value
+	Same bool `json:"same"`
+}
+
+// comparisonAttributes are the rows of a comparison, in display order
+var comparisonAttributes = []struct {
+	name  string
+	value func(serverID string, config map[string]interface{}) interface{}
+}{
+	{"name", func(serverID string, config map[string]interface{}) interface{} {
+		return getString(config, "name", serverID)
+	}},
+	{"vendor", func(_ string, config map[string]interface{}) interface{} { return entryVendor(config) }},
+	{"category", func(_ string, config map[string]interface{}) interface{} {
+		return getString(config, "category", "other")
+	}},
+	{"maturity", func(_ string, config map[string]interface{}) interface{} { return entryMaturity(config) }},
+	{"license", func(_ string, config map[string]interface{}) interface{} {
+		return getString(config, "license", "Unknown")
+	}},
+	{"transports", func(_ string, config map[string]interface{}) interface{} { return entryTransports(config) }},
+	{"tools", func(_ string, config map[string]interface{}) interface{} {
+		names := []string{}
+		for _, tool := range entryTools(config) {
+			names = append(names, tool.Name)
+		}
+		return names
+	}},
+	{"risk", func(_ string, config map[string]interface{}) interface{} { return entryRisk(config) }},
+	{"installs", func(serverID string, _ map[string]interface{}) interface{} {
+		installStats.Lock()
+		defer installStats.Unlock()
+		return installStats.installs[serverID]
+	}},
+	{"installs_30d", func(serverID string, _ map[string]interface{}) interface{} {
+		return serverTrend(serverID, 30, time.Now()).Installs
+	}},
+	{"rating", func(serverID string, _ map[string]interface{}) interface{} { return serverRating(serverID) }},
+	{"runtime", func(serverID string, config map[string]interface{}) interface{} {
+		mcpConfig, bridge, err := launchConfig(serverID, config, defaultConfigOptions, clientProfile("claude_desktop"), 0)
+		if err != nil {
+			return []string{}
+		}
+		return append([]string{}, launchCommands(mcpConfig, bridge)...)
+	}},
+	{"requires", func(_ string, config map[string]interface{}) interface{} {
+		return append([]string{}, entryRelations(config, "requires")...)
+	}},
+	{"system_binaries", func(_ string, config map[string]interface{}) interface{} {
+		return append([]string{}, entrySystemBinaries(config)...)
+	}},
+	{"env_vars", func(_ string, config map[string]interface{}) interface{} {
+		keys := []string{}
+		for _, question := range envQuestions(config) {
+			keys = append(keys, question.Key)
+		}
+		return keys
+	}},
+}
+
+// parseCompareIDs reads the comma-separated ids parameter
+func parseCompareIDs(r *http.Request) ([]string, error) {
+	raw := r.URL.Query().Get("ids")
+	if raw == "" {
+		return nil, badRequest("Query parameter 'ids' is required")
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(raw, ",") {
+		id = canonicalID(strings.TrimSpace(id))
+		if id == "" {
+			return nil, badRequest("Server IDs must not be empty")
+		}
+		if seen[id] {
+			return nil, badRequest("Server '%s' is listed twice", id)
+		}
+		if _, exists := getEntry(id); !exists {
+			return nil, &requestError{status: http.StatusNotFound, message: fmt.Sprintf("Server '%s' not found", id)}
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) < 2 || len(ids) > maxCompareServers {
+		return nil, badRequest("Query parameter 'ids' must list 2 to %d servers", maxCompareServers)
+	}
+	return ids, nil
+}
+
+// compareHandler returns a side-by-side matrix of the key attributes of a
+// few servers, one row per attribute
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ids, err := parseCompareIDs(r)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	rows := make([]ComparisonRow, 0, len(comparisonAttributes))
+	var differences []string
+	for _, attribute := range comparisonAttributes {
+		row := ComparisonRow{Attribute: attribute.name, Values: make(map[string]interface{}), Same: true}
+		var first []byte
+		for i, serverID := range ids {
+			config, _ := getEntry(serverID)
+			value := attribute.value(serverID, config)
+			row.Values[serverID] = value
+			encoded, _ := json.Marshal(value)
+			if i == 0 {
+				first = encoded
+			} else if string(encoded) != string(first) {
+				row.Same = false
+			}
+		}
+		if !row.Same {
+			differences = append(differences, attribute.name)
+		}
+		rows = append(rows, row)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"servers":     ids,
+		"rows":        rows,
+		"differences": differences,
+	})
+}
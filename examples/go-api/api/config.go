@@ -0,0 +1,776 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds every runtime setting of the API. Values are resolved with
+// the precedence defaults < environment < config file < flags.
+type Config struct {
+	Addr        string
+	CatalogPath string
+	BundlePath  string
+	AssetsDir   string
+	DataDir     string
+	AdminToken  string
+	// APIKeys are "KEY=USER" pairs identifying API users
+	APIKeys     []string
+	TLSCertFile string
+	TLSKeyFile  string
+
+	MaxBodyBytes int64
+
+	// ReadOnly refuses every mutating request, for public replicas
+	ReadOnly bool
+	// UI serves the embedded browse UI at /
+	UI bool
+
+	// PolicyDir holds the egress allowlists ?egress_within may name
+	PolicyDir string
+
+	// RequestTimeout bounds every request; RouteTimeouts are per-route
+	// overrides, "PREFIX=DURATION", where 0 disables the timeout
+	RequestTimeout time.Duration
+	RouteTimeouts  []string
+
+	// CacheListing, CacheStatic and CacheImmutable are the Cache-Control
+	// values of those endpoint classes; CacheRoutes assign classes to
+	// paths, "PREFIX=CLASS"
+	CacheListing   string
+	CacheStatic    string
+	CacheImmutable string
+	CacheRoutes    []string
+
+	// SearchBackend is memory or opensearch; SearchURL and SearchIndex
+	// locate the OpenSearch index alias
+	SearchBackend string
+	SearchURL     string
+	SearchIndex   string
+
+	// EmbeddingsProvider is none, local or api; the api embedder posts to
+	// the OpenAI-compatible EmbeddingsURL with EmbeddingsModel
+	EmbeddingsProvider string
+	EmbeddingsURL      string
+	EmbeddingsModel    string
+	EmbeddingsAPIKey   string
+
+	// SlowQueryThreshold logs searches slower than this; 0 disables
+	SlowQueryThreshold time.Duration
+
+	// ConsistencyInterval is how often the served registry is compared
+	// with the catalog file; 0 disables the checks
+	ConsistencyInterval time.Duration
+
+	// EnrichConcurrency bounds the icon, README and capability fetches of
+	// remote enrichment in flight; EnrichTimeout bounds each fetch
+	EnrichConcurrency int
+	EnrichTimeout     time.Duration
+	// EnrichRuntimes detects the runtime of entries with a GitHub
+	// repository and no enrichment.runtime of their own from the
+	// repository's raw files
+	EnrichRuntimes bool
+
+	// OutboundConcurrency caps the requests to external services in flight
+	// at once; OutboundRateLimits are "DEPENDENCY=REQUESTS_PER_SECOND"
+	// limits, "*" for any other host, and OutboundRetries how often a
+	// failed GET is retried. OutboundProxy is the proxy they go through,
+	// by default that of HTTP_PROXY and HTTPS_PROXY.
+	OutboundConcurrency int
+	OutboundRateLimits  []string
+	OutboundRetries     int
+	OutboundProxy       string
+
+	// LoadMode is strict (refuse invalid entries) or lenient (skip them)
+	LoadMode string
+	// Synthetic adds this many generated entries for load testing
+	Synthetic int
+
+	// ReportThreshold is the number of open abuse reports that flags an
+	// entry as under review; ReportsPerHour limits reports per reporter
+	ReportThreshold int
+	ReportsPerHour  int
+
+	// PopularInstalls is the install count from which an entry is popular
+	// enough for lookalike submissions to be flagged; featured and
+	// verified entries always are
+	PopularInstalls int
+
+	// QuotaDaily and QuotaMonthly limit the requests of each API key user
+	// unless an admin overrides them; 0 means unlimited
+	QuotaDaily   int
+	QuotaMonthly int
+
+	// GeneratedConfigTTL is how long the generate-config calls of API key
+	// users and admins are kept for GET /api/v1/generated-configs/{id}; 0
+	// records nothing
+	GeneratedConfigTTL time.Duration
+
+	// TryTimeout bounds a "Try it" preview of a hosted server; TryPerClient
+	// and TryPerServer limit previews per hour, 0 disabling them
+	TryTimeout   time.Duration
+	TryPerClient int
+	TryPerServer int
+
+	// Webhooks are "TOPIC_PATTERN=URL" subscriptions, e.g. "advisory.database.*=https://..."
+	Webhooks []string
+
+	// NotifyChannels are maintainer notification destinations,
+	// "NAME=slack|webhook|email:TARGET"; NotifyRoutes send events to them,
+	// "[MAINTAINER:]EVENT_PATTERN=CHANNEL[+CHANNEL...]". Email goes
+	// through the SMTP relay at SMTPAddr.
+	NotifyChannels []string
+	NotifyRoutes   []string
+	SMTPAddr       string
+	SMTPFrom       string
+	SMTPUsername   string
+	SMTPPassword   string
+
+	// Upstreams are federated catalogs, "NAMESPACE=URL", highest precedence first
+	Upstreams    []string
+	SyncInterval time.Duration
+	// CanaryMaxDeleted is the share of entries a sync may delete before it
+	// is held; CanaryLinkChecks links of changed entries are spot-checked
+	// and CanaryQueries must find what they found before
+	CanaryMaxDeleted float64
+	CanaryLinkChecks int
+	CanaryQueries    []string
+	// MergePolicies decide fields where a local entry and an upstream copy
+	// disagree, "FIELD=POLICY" with "*" as the default
+	MergePolicies []string
+
+	// FeatureFlags switch features on or off, "FLAG=on|off";
+	// FeatureTenants override them per API key user, "USER:FLAG=on|off"
+	FeatureFlags   []string
+	FeatureTenants []string
+
+	// APIV1Deprecation and APIV1Sunset are the YYYY-MM-DD dates v1 is
+	// deprecated and retired on; empty means not scheduled.
+	// APIMigrationURL documents the move to v2.
+	APIV1Deprecation string
+	APIV1Sunset      string
+	APIMigrationURL  string
+
+	// ArtifactsBucket turns on publishing the catalog, bundle and
+	// ArtifactsOpenAPI spec to an S3-compatible bucket on every change
+	ArtifactsEndpoint  string
+	ArtifactsBucket    string
+	ArtifactsPrefix    string
+	ArtifactsRegion    string
+	ArtifactsAccessKey string
+	ArtifactsSecretKey string
+	ArtifactsOpenAPI   string
+
+	// OCIUsername and OCIPassword authenticate push, pull and oci://
+	// bundles against a container registry; empty pulls anonymously
+	OCIUsername string
+	OCIPassword string
+
+	CORSOrigins          []string
+	CORSMethods          []string
+	CORSHeaders          []string
+	CORSMaxAge           time.Duration
+	CORSAllowCredentials bool
+	// CORSRoutes are per-route origin overrides, "PREFIX=ORIGIN [ORIGIN...]"
+	CORSRoutes []string
+
+	// AccessLog is stdout or a file to log every request to as JSON; empty
+	// disables it. AccessLogQuery and AccessLogBodies are full, redact or
+	// omit; AccessLogAllow are parameters and body fields never redacted.
+	// Files rotate past AccessLogMaxSize bytes, keeping AccessLogMaxFiles;
+	// AccessLogSample is the share of successful requests logged.
+	AccessLog         string
+	AccessLogQuery    string
+	AccessLogBodies   string
+	AccessLogAllow    []string
+	AccessLogMaxBody  int64
+	AccessLogMaxSize  int64
+	AccessLogMaxFiles int
+	AccessLogSample   float64
+
+	// sources records where each setting's value came from
+	sources map[string]string
+}
+
+// setting binds one Config field to its file key, env var and flag
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	target interface{}
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Addr:                ":8000",
+		LoadMode:            "lenient",
+		AssetsDir:           "assets",
+		UI:                  true,
+		DataDir:             "data",
+		PolicyDir:           "policies",
+		MaxBodyBytes:        1 << 20,
+		RequestTimeout:      15 * time.Second,
+		RouteTimeouts:       defaultRouteTimeouts,
+		CacheListing:        "public, max-age=0, s-maxage=30, stale-while-revalidate=60",
+		CacheStatic:         "public, max-age=300, s-maxage=3600",
+		CacheImmutable:      "public, max-age=31536000, immutable",
+		CacheRoutes:         defaultCacheRoutes,
+		SearchBackend:       "memory",
+		SearchIndex:         "mcp-catalog",
+		EmbeddingsProvider:  "local",
+		EmbeddingsURL:       "https://api.openai.com/v1/embeddings",
+		EmbeddingsModel:     "text-embedding-3-small",
+		SlowQueryThreshold:  250 * time.Millisecond,
+		ConsistencyInterval: 5 * time.Minute,
+		EnrichConcurrency:   4,
+		ArtifactsEndpoint:   "https://s3.amazonaws.com",
+		ArtifactsRegion:     "us-east-1",
+		EnrichTimeout:       10 * time.Second,
+		OutboundConcurrency: 16,
+		OutboundRateLimits:  defaultOutboundRates,
+		OutboundRetries:     2,
+		ReportThreshold:     3,
+		ReportsPerHour:      5,
+		PopularInstalls:     100,
+		TryTimeout:          10 * time.Second,
+		GeneratedConfigTTL:  30 * 24 * time.Hour,
+		TryPerClient:        10,
+		TryPerServer:        100,
+		CORSOrigins:         []string{"*"},
+		CORSMethods:         []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		CORSHeaders:         []string{"Content-Type", "Authorization", clientContextHeader, apiVersionHeader},
+		CORSMaxAge:          10 * time.Minute,
+		CanaryMaxDeleted:    0.3,
+		CanaryLinkChecks:    5,
+		CanaryQueries:       defaultCanaryQueries,
+		AccessLogQuery:      "redact",
+		AccessLogBodies:     "omit",
+		AccessLogAllow:      defaultAccessLogAllow,
+		AccessLogMaxBody:    4096,
+		AccessLogMaxSize:    100 << 20,
+		AccessLogMaxFiles:   5,
+		AccessLogSample:     1,
+		sources:             make(map[string]string),
+	}
+}
+
+// cfg is the active configuration
+var cfg = defaultConfig()
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "addr", env: "CATALOG_ADDR", flag: "addr", usage: "listen address", target: &c.Addr},
+		{key: "catalog.path", env: "CATALOG_PATH", flag: "catalog", usage: "path to known_servers.json", target: &c.CatalogPath},
+		{key: "catalog.bundle", env: "CATALOG_BUNDLE", flag: "bundle", usage: "serve entirely from an offline bundle tarball, or an oci://REGISTRY/REPOSITORY:TAG reference to pull one from", target: &c.BundlePath},
+		{key: "catalog.synthetic", env: "CATALOG_SYNTHETIC", flag: "synthetic", usage: "add this many synthetic entries for load testing", target: &c.Synthetic},
+		{key: "catalog.load_mode", env: "CATALOG_LOAD_MODE", flag: "load-mode", usage: "strict refuses to start on invalid entries, lenient skips them", target: &c.LoadMode},
+		{key: "catalog.assets_dir", env: "CATALOG_ASSETS_DIR", flag: "assets", usage: "directory holding icons/ and readmes/", target: &c.AssetsDir},
+		{key: "admin.token", env: "CATALOG_ADMIN_TOKEN", flag: "admin-token", usage: "bearer token for the admin API", secret: true, target: &c.AdminToken},
+		{key: "auth.api_keys", env: "CATALOG_API_KEYS", flag: "api-keys", usage: "comma-separated KEY=USER pairs", secret: true, target: &c.APIKeys},
+		{key: "data.dir", env: "CATALOG_DATA_DIR", flag: "data-dir", usage: "directory for persisted state such as reviews", target: &c.DataDir},
+		{key: "policy.dir", env: "CATALOG_POLICY_DIR", flag: "policy-dir", usage: "directory of egress allowlists for ?egress_within", target: &c.PolicyDir},
+		{key: "tls.cert_file", env: "CATALOG_TLS_CERT", flag: "tls-cert", usage: "TLS certificate file", target: &c.TLSCertFile},
+		{key: "tls.key_file", env: "CATALOG_TLS_KEY", flag: "tls-key", usage: "TLS private key file", target: &c.TLSKeyFile},
+		{key: "webhooks.subscriptions", env: "CATALOG_WEBHOOKS", flag: "webhooks", usage: "comma-separated webhook subscriptions, TOPIC_PATTERN=URL", secret: true, target: &c.Webhooks},
+		{key: "notifications.channels", env: "CATALOG_NOTIFY_CHANNELS", flag: "notify-channels", usage: "comma-separated notification channels, NAME=slack|webhook|email:TARGET", secret: true, target: &c.NotifyChannels},
+		{key: "notifications.routes", env: "CATALOG_NOTIFY_ROUTES", flag: "notify-routes", usage: "comma-separated notification routes, [MAINTAINER:]EVENT_PATTERN=CHANNEL[+CHANNEL...]", target: &c.NotifyRoutes},
+		{key: "notifications.smtp_addr", env: "CATALOG_SMTP_ADDR", flag: "smtp-addr", usage: "SMTP relay host:port for email notifications", target: &c.SMTPAddr},
+		{key: "notifications.smtp_from", env: "CATALOG_SMTP_FROM", flag: "smtp-from", usage: "sender address of email notifications", target: &c.SMTPFrom},
+		{key: "notifications.smtp_username", env: "CATALOG_SMTP_USERNAME", flag: "smtp-username", usage: "SMTP username; empty sends without authentication", target: &c.SMTPUsername},
+		{key: "notifications.smtp_password", env: "CATALOG_SMTP_PASSWORD", flag: "smtp-password", usage: "SMTP password", secret: true, target: &c.SMTPPassword},
+		{key: "sync.upstreams", env: "CATALOG_UPSTREAMS", flag: "upstreams", usage: "comma-separated upstream catalogs, NAMESPACE=URL, highest precedence first", target: &c.Upstreams},
+		{key: "sync.merge_policies", env: "CATALOG_MERGE_POLICIES", flag: "merge-policies", usage: "comma-separated FIELD=POLICY merge policies for local entries shadowing upstream ones: local-wins, upstream-wins or manual", target: &c.MergePolicies},
+		{key: "sync.interval", env: "CATALOG_SYNC_INTERVAL", flag: "sync-interval", usage: "how often to re-sync upstreams (0 syncs once at startup)", target: &c.SyncInterval},
+		{key: "canary.max_deleted", env: "CATALOG_CANARY_MAX_DELETED", flag: "canary-max-deleted", usage: "share of entries, 0 to 1, a sync may delete before it is held for approval", target: &c.CanaryMaxDeleted},
+		{key: "canary.link_checks", env: "CATALOG_CANARY_LINK_CHECKS", flag: "canary-link-checks", usage: "links of added or changed entries spot-checked before a sync is served", target: &c.CanaryLinkChecks},
+		{key: "canary.search_queries", env: "CATALOG_CANARY_QUERIES", flag: "canary-queries", usage: "comma-separated searches that must keep finding their entries after a sync", target: &c.CanaryQueries},
+		{key: "features.flags", env: "CATALOG_FEATURES", flag: "features", usage: "comma-separated feature switches, FLAG=on|off; see /api/v1/features", target: &c.FeatureFlags},
+		{key: "features.tenants", env: "CATALOG_TENANT_FEATURES", flag: "tenant-features", usage: "comma-separated per-user feature overrides, USER:FLAG=on|off", target: &c.FeatureTenants},
+		{key: "read_only", env: "CATALOG_READ_ONLY", flag: "read-only", usage: "refuse every mutating request with 403, for public replicas", target: &c.ReadOnly},
+		{key: "ui.enabled", env: "CATALOG_UI", flag: "ui", usage: "serve the embedded browse UI at /", target: &c.UI},
+		{key: "limits.max_body_bytes", env: "CATALOG_MAX_BODY_BYTES", flag: "max-body-bytes", usage: "maximum accepted request body size", target: &c.MaxBodyBytes},
+		{key: "timeouts.request", env: "CATALOG_REQUEST_TIMEOUT", flag: "request-timeout", usage: "maximum time to serve a request (0 disables)", target: &c.RequestTimeout},
+		{key: "timeouts.routes", env: "CATALOG_ROUTE_TIMEOUTS", flag: "route-timeouts", usage: "comma-separated per-route timeouts, PREFIX=DURATION", target: &c.RouteTimeouts},
+		{key: "cache.listing", env: "CATALOG_CACHE_LISTING", flag: "cache-listing", usage: "Cache-Control of list, search and detail responses", target: &c.CacheListing},
+		{key: "cache.static", env: "CATALOG_CACHE_STATIC", flag: "cache-static", usage: "Cache-Control of schemas and assets", target: &c.CacheStatic},
+		{key: "cache.immutable", env: "CATALOG_CACHE_IMMUTABLE", flag: "cache-immutable", usage: "Cache-Control of revision- and hash-keyed URLs", target: &c.CacheImmutable},
+		{key: "cache.routes", env: "CATALOG_CACHE_ROUTES", flag: "cache-routes", usage: "comma-separated endpoint classes, PREFIX=CLASS", target: &c.CacheRoutes},
+		{key: "search.backend", env: "CATALOG_SEARCH_BACKEND", flag: "search-backend", usage: "search backend: memory or opensearch", target: &c.SearchBackend},
+		{key: "search.url", env: "CATALOG_SEARCH_URL", flag: "search-url", usage: "OpenSearch URL, with credentials as userinfo", secret: true, target: &c.SearchURL},
+		{key: "search.index", env: "CATALOG_SEARCH_INDEX", flag: "search-index", usage: "OpenSearch index alias; give each deployment its own", target: &c.SearchIndex},
+		{key: "search.embeddings.provider", env: "CATALOG_EMBEDDINGS_PROVIDER", flag: "embeddings-provider", usage: "embedder for semantic search: none, local or api", target: &c.EmbeddingsProvider},
+		{key: "search.embeddings.url", env: "CATALOG_EMBEDDINGS_URL", flag: "embeddings-url", usage: "OpenAI-compatible embeddings endpoint of the api embedder", target: &c.EmbeddingsURL},
+		{key: "search.embeddings.model", env: "CATALOG_EMBEDDINGS_MODEL", flag: "embeddings-model", usage: "model the api embedder asks", target: &c.EmbeddingsModel},
+		{key: "search.embeddings.api_key", env: "CATALOG_EMBEDDINGS_API_KEY", flag: "embeddings-api-key", usage: "API key of the api embedder", secret: true, target: &c.EmbeddingsAPIKey},
+		{key: "search.slow_query_threshold", env: "CATALOG_SLOW_QUERY_THRESHOLD", flag: "slow-query-threshold", usage: "log searches slower than this (0 disables)", target: &c.SlowQueryThreshold},
+		{key: "consistency.interval", env: "CATALOG_CONSISTENCY_INTERVAL", flag: "consistency-interval", usage: "how often to compare the served catalog with the catalog file (0 disables)", target: &c.ConsistencyInterval},
+		{key: "enrichment.concurrency", env: "CATALOG_ENRICH_CONCURRENCY", flag: "enrich-concurrency", usage: "remote enrichment fetches in flight at once", target: &c.EnrichConcurrency},
+		{key: "enrichment.timeout", env: "CATALOG_ENRICH_TIMEOUT", flag: "enrich-timeout", usage: "maximum time of one remote enrichment fetch", target: &c.EnrichTimeout},
+		{key: "enrichment.detect_runtimes", env: "CATALOG_ENRICH_DETECT_RUNTIMES", flag: "enrich-detect-runtimes", usage: "detect the runtime of entries with a GitHub repository from its raw files", target: &c.EnrichRuntimes},
+		{key: "outbound.concurrency", env: "CATALOG_OUTBOUND_CONCURRENCY", flag: "outbound-concurrency", usage: "requests to external services in flight at once", target: &c.OutboundConcurrency},
+		{key: "outbound.rate_limits", env: "CATALOG_OUTBOUND_RATE_LIMITS", flag: "outbound-rate-limits", usage: "comma-separated requests per second to external services, DEPENDENCY=N with * for other hosts (0 is unlimited)", target: &c.OutboundRateLimits},
+		{key: "outbound.retries", env: "CATALOG_OUTBOUND_RETRIES", flag: "outbound-retries", usage: "retries of a GET to an external service that failed or was throttled", target: &c.OutboundRetries},
+		{key: "outbound.proxy", env: "CATALOG_OUTBOUND_PROXY", flag: "outbound-proxy", usage: "proxy URL for requests to external services (default from HTTP_PROXY and HTTPS_PROXY)", secret: true, target: &c.OutboundProxy},
+		{key: "submissions.popular_installs", env: "CATALOG_POPULAR_INSTALLS", flag: "popular-installs", usage: "installs that make an entry popular enough to flag lookalike submissions of", target: &c.PopularInstalls},
+		{key: "generate.audit_ttl", env: "CATALOG_GENERATE_AUDIT_TTL", flag: "generate-audit-ttl", usage: "how long generate-config calls of API key users and admins are kept for support (0 records nothing)", target: &c.GeneratedConfigTTL},
+		{key: "quotas.daily", env: "CATALOG_QUOTA_DAILY", flag: "quota-daily", usage: "requests each API key user may make per UTC day (0 is unlimited)", target: &c.QuotaDaily},
+		{key: "quotas.monthly", env: "CATALOG_QUOTA_MONTHLY", flag: "quota-monthly", usage: "requests each API key user may make per calendar month (0 is unlimited)", target: &c.QuotaMonthly},
+		{key: "reports.threshold", env: "CATALOG_REPORT_THRESHOLD", flag: "report-threshold", usage: "open abuse reports that mark an entry as under review", target: &c.ReportThreshold},
+		{key: "reports.per_hour", env: "CATALOG_REPORTS_PER_HOUR", flag: "reports-per-hour", usage: "abuse reports accepted per reporter per hour", target: &c.ReportsPerHour},
+		{key: "try_it.timeout", env: "CATALOG_TRY_TIMEOUT", flag: "try-timeout", usage: "maximum time of a hosted server preview", target: &c.TryTimeout},
+		{key: "try_it.per_client", env: "CATALOG_TRY_PER_CLIENT", flag: "try-per-client", usage: "previews allowed per client per hour (0 disables previews)", target: &c.TryPerClient},
+		{key: "try_it.per_server", env: "CATALOG_TRY_PER_SERVER", flag: "try-per-server", usage: "previews relayed to each server per hour (0 disables previews)", target: &c.TryPerServer},
+		{key: "api.v1_deprecation", env: "CATALOG_API_V1_DEPRECATION", flag: "api-v1-deprecation", usage: "YYYY-MM-DD date API v1 is deprecated on; v1 responses then carry Deprecation headers", target: &c.APIV1Deprecation},
+		{key: "api.v1_sunset", env: "CATALOG_API_V1_SUNSET", flag: "api-v1-sunset", usage: "YYYY-MM-DD date API v1 is retired on; v1 answers 410 from then", target: &c.APIV1Sunset},
+		{key: "api.migration_url", env: "CATALOG_API_MIGRATION_URL", flag: "api-migration-url", usage: "v1 to v2 migration guide linked from deprecated responses", target: &c.APIMigrationURL},
+		{key: "artifacts.endpoint", env: "CATALOG_ARTIFACTS_ENDPOINT", flag: "artifacts-endpoint", usage: "S3-compatible endpoint, e.g. https://storage.googleapis.com for GCS", target: &c.ArtifactsEndpoint},
+		{key: "artifacts.bucket", env: "CATALOG_ARTIFACTS_BUCKET", flag: "artifacts-bucket", usage: "bucket to publish catalog artifacts to on every change (empty disables)", target: &c.ArtifactsBucket},
+		{key: "artifacts.prefix", env: "CATALOG_ARTIFACTS_PREFIX", flag: "artifacts-prefix", usage: "key prefix of published artifacts", target: &c.ArtifactsPrefix},
+		{key: "artifacts.region", env: "CATALOG_ARTIFACTS_REGION", flag: "artifacts-region", usage: "region artifact uploads are signed for", target: &c.ArtifactsRegion},
+		{key: "artifacts.access_key", env: "CATALOG_ARTIFACTS_ACCESS_KEY", flag: "artifacts-access-key", usage: "access key ID of the artifacts bucket", secret: true, target: &c.ArtifactsAccessKey},
+		{key: "artifacts.secret_key", env: "CATALOG_ARTIFACTS_SECRET_KEY", flag: "artifacts-secret-key", usage: "secret access key of the artifacts bucket", secret: true, target: &c.ArtifactsSecretKey},
+		{key: "artifacts.openapi_spec", env: "CATALOG_ARTIFACTS_OPENAPI", flag: "artifacts-openapi", usage: "captured OpenAPI spec to publish alongside the catalog", target: &c.ArtifactsOpenAPI},
+		{key: "oci.username", env: "CATALOG_OCI_USERNAME", flag: "oci-username", usage: "container registry user for oci:// catalogs", target: &c.OCIUsername},
+		{key: "oci.password", env: "CATALOG_OCI_PASSWORD", flag: "oci-password", usage: "container registry password or token for oci:// catalogs", secret: true, target: &c.OCIPassword},
+		{key: "cors.allowed_origins", env: "CATALOG_CORS_ORIGINS", flag: "cors-origins", usage: "comma-separated allowed CORS origins", target: &c.CORSOrigins},
+		{key: "cors.allowed_methods", env: "CATALOG_CORS_METHODS", flag: "cors-methods", usage: "comma-separated allowed CORS methods", target: &c.CORSMethods},
+		{key: "cors.allowed_headers", env: "CATALOG_CORS_HEADERS", flag: "cors-headers", usage: "comma-separated allowed CORS request headers", target: &c.CORSHeaders},
+		{key: "cors.max_age", env: "CATALOG_CORS_MAX_AGE", flag: "cors-max-age", usage: "how long browsers may cache preflight results", target: &c.CORSMaxAge},
+		{key: "cors.allow_credentials", env: "CATALOG_CORS_CREDENTIALS", flag: "cors-credentials", usage: "allow cookies and auth headers on cross-origin requests", target: &c.CORSAllowCredentials},
+		{key: "access_log.output", env: "CATALOG_ACCESS_LOG", flag: "access-log", usage: "log every request as JSON to stdout or this file (empty disables)", target: &c.AccessLog},
+		{key: "access_log.query", env: "CATALOG_ACCESS_LOG_QUERY", flag: "access-log-query", usage: "query strings in the access log: full, redact or omit", target: &c.AccessLogQuery},
+		{key: "access_log.bodies", env: "CATALOG_ACCESS_LOG_BODIES", flag: "access-log-bodies", usage: "request bodies in the access log: full, redact or omit", target: &c.AccessLogBodies},
+		{key: "access_log.allow", env: "CATALOG_ACCESS_LOG_ALLOW", flag: "access-log-allow", usage: "comma-separated query parameters and body fields logged unredacted", target: &c.AccessLogAllow},
+		{key: "access_log.max_body", env: "CATALOG_ACCESS_LOG_MAX_BODY", flag: "access-log-max-body", usage: "largest request body logged, in bytes", target: &c.AccessLogMaxBody},
+		{key: "access_log.max_size", env: "CATALOG_ACCESS_LOG_MAX_SIZE", flag: "access-log-max-size", usage: "bytes after which the access log file rotates (0 never rotates)", target: &c.AccessLogMaxSize},
+		{key: "access_log.max_files", env: "CATALOG_ACCESS_LOG_MAX_FILES", flag: "access-log-max-files", usage: "rotated access log files kept", target: &c.AccessLogMaxFiles},
+		{key: "access_log.sample", env: "CATALOG_ACCESS_LOG_SAMPLE", flag: "access-log-sample", usage: "share of successful requests logged, 0 to 1; errors are always logged", target: &c.AccessLogSample},
+		{key: "cors.routes", env: "CATALOG_CORS_ROUTES", flag: "cors-routes", usage: "comma-separated per-route origin overrides, PREFIX=ORIGIN [ORIGIN...]", target: &c.CORSRoutes},
+	}
+}
+
+// flagValue holds a flag's raw value until it is applied; flags of
+// boolean settings may be given bare, as in --read-only
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.raw
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// setValue parses raw into the setting's target type
+func setValue(target interface{}, raw string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = raw
+	case *int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		*t = value
+	case *int64:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		*t = value
+	case *float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		*t = value
+	case *bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		*t = value
+	case *time.Duration:
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		*t = value
+	case *[]string:
+		var values []string
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		*t = values
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+	return nil
+}
+
+// formatValue renders a setting's current value for introspection
+func formatValue(target interface{}) interface{} {
+	switch t := target.(type) {
+	case *string:
+		return *t
+	case *int:
+		return *t
+	case *int64:
+		return *t
+	case *float64:
+		return *t
+	case *bool:
+		return *t
+	case *time.Duration:
+		return t.String()
+	case *[]string:
+		return *t
+	}
+	return nil
+}
+
+// loadConfig resolves the configuration from env, an optional config file
+// and command line flags, returning the remaining non-flag arguments
+func loadConfig(args []string) (*Config, []string, error) {
+	c := defaultConfig()
+	settings := c.settings()
+
+	for _, s := range settings {
+		if raw, ok := os.LookupEnv(s.env); ok {
+			if err := setValue(s.target, raw); err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", s.env, err)
+			}
+			c.sources[s.key] = "env"
+		}
+	}
+
+	// Flags are parsed before the file is applied but only copied into the
+	// config afterwards, so they win over both env and file values
+	flags := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	configPath := flags.String("config", os.Getenv("CATALOG_CONFIG"), "path to a YAML or JSON config file")
+	flagValues := make(map[string]*flagValue)
+	for _, s := range settings {
+		_, isBool := s.target.(*bool)
+		flagValues[s.flag] = &flagValue{isBool: isBool}
+		flags.Var(flagValues[s.flag], s.flag, s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configPath != "" {
+		values, err := readConfigFile(*configPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, s := range settings {
+			if raw, ok := values[s.key]; ok {
+				if err := setValue(s.target, raw); err != nil {
+					return nil, nil, fmt.Errorf("%s: %s: %v", *configPath, s.key, err)
+				}
+				c.sources[s.key] = "file"
+			}
+		}
+	}
+
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, s := range settings {
+		if !explicit[s.flag] {
+			continue
+		}
+		if err := setValue(s.target, flagValues[s.flag].raw); err != nil {
+			return nil, nil, fmt.Errorf("-%s: %v", s.flag, err)
+		}
+		c.sources[s.key] = "flag"
+	}
+
+	if c.LoadMode != "strict" && c.LoadMode != "lenient" {
+		return nil, nil, fmt.Errorf("catalog.load_mode must be strict or lenient, not '%s'", c.LoadMode)
+	}
+	if err := validateCORS(c); err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseRouteTimeouts(c.RouteTimeouts); err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseCacheRoutes(c.CacheRoutes); err != nil {
+		return nil, nil, err
+	}
+	if err := validateSearchBackend(c); err != nil {
+		return nil, nil, err
+	}
+	if err := validateEmbeddings(c); err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseMergePolicies(c.MergePolicies); err != nil {
+		return nil, nil, err
+	}
+	if err := validateArtifacts(c); err != nil {
+		return nil, nil, err
+	}
+	if err := validateAPIPolicy(c); err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseFeatureSettings(c.FeatureFlags); err != nil {
+		return nil, nil, err
+	}
+	if _, err := parseTenantFeatures(c.FeatureTenants); err != nil {
+		return nil, nil, err
+	}
+	if err := validateNotifications(c); err != nil {
+		return nil, nil, err
+	}
+	if err := validateAccessLog(c); err != nil {
+		return nil, nil, err
+	}
+	if strings.HasPrefix(c.BundlePath, "oci://") {
+		if _, err := parseOCIReference(c.BundlePath); err != nil {
+			return nil, nil, fmt.Errorf("catalog.bundle: %v", err)
+		}
+	}
+	if c.CanaryMaxDeleted < 0 || c.CanaryMaxDeleted > 1 || c.CanaryLinkChecks < 0 {
+		return nil, nil, fmt.Errorf("canary.max_deleted must be between 0 and 1 and canary.link_checks not negative")
+	}
+	if c.EnrichConcurrency < 1 || c.EnrichTimeout <= 0 {
+		return nil, nil, fmt.Errorf("enrichment.concurrency and enrichment.timeout must be positive")
+	}
+	if err := validateOutbound(c); err != nil {
+		return nil, nil, err
+	}
+	if c.PopularInstalls < 0 {
+		return nil, nil, fmt.Errorf("submissions.popular_installs must not be negative")
+	}
+	if c.GeneratedConfigTTL < 0 {
+		return nil, nil, fmt.Errorf("generate.audit_ttl must not be negative")
+	}
+	if c.QuotaDaily < 0 || c.QuotaMonthly < 0 {
+		return nil, nil, fmt.Errorf("quotas.daily and quotas.monthly must not be negative")
+	}
+	if c.TryTimeout <= 0 || c.TryPerClient < 0 || c.TryPerServer < 0 {
+		return nil, nil, fmt.Errorf("try_it.timeout must be positive and the preview limits not negative")
+	}
+
+	return c, flags.Args(), nil
+}
+
+// readConfigFile reads a config file into flattened dotted keys
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &tree)
+	} else {
+		tree, err = parseSimpleYAML(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	values := make(map[string]string)
+	flattenConfig("", tree, values)
+	return values, nil
+}
+
+func flattenConfig(prefix string, tree map[string]interface{}, out map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenConfig(key, v, out)
+		case []interface{}:
+			parts := make([]string, len(v))
+			for i, item := range v {
+				parts[i] = fmt.Sprint(item)
+			}
+			out[key] = strings.Join(parts, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+type yamlLine struct {
+	indent int
+	text   string
+	number int
+}
+
+// parseSimpleYAML parses the subset of YAML used by config files: nested
+// mappings, block and flow sequences of scalars, quoted strings and comments
+func parseSimpleYAML(data string) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(data, "\n") {
+		text := stripYAMLComment(strings.TrimRight(raw, " \t\r"))
+		if strings.TrimSpace(text) == "" || text == "---" {
+			continue
+		}
+		trimmed := strings.TrimLeft(text, " ")
+		lines = append(lines, yamlLine{indent: len(text) - len(trimmed), text: trimmed, number: i + 1})
+	}
+
+	pos := 0
+	result, err := parseYAMLMapping(lines, &pos, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pos < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[pos].number)
+	}
+	return result, nil
+}
+
+func parseYAMLMapping(lines []yamlLine, pos *int, indent int) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for *pos < len(lines) && lines[*pos].indent == indent {
+		line := lines[*pos]
+		colon := strings.Index(line.text, ":")
+		if colon <= 0 || strings.HasPrefix(line.text, "- ") {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", line.number)
+		}
+		key := strings.TrimSpace(line.text[:colon])
+		rest := strings.TrimSpace(line.text[colon+1:])
+		*pos++
+
+		if rest != "" {
+			result[key] = parseYAMLScalar(rest)
+			continue
+		}
+		if *pos >= len(lines) || lines[*pos].indent < indent ||
+			(lines[*pos].indent == indent && !strings.HasPrefix(lines[*pos].text, "- ")) {
+			result[key] = nil
+			continue
+		}
+
+		child := lines[*pos]
+		if strings.HasPrefix(child.text, "- ") || child.text == "-" {
+			var items []interface{}
+			for *pos < len(lines) && lines[*pos].indent == child.indent &&
+				(strings.HasPrefix(lines[*pos].text, "- ") || lines[*pos].text == "-") {
+				items = append(items, parseYAMLScalar(strings.TrimSpace(strings.TrimPrefix(lines[*pos].text, "-"))))
+				*pos++
+			}
+			result[key] = items
+			continue
+		}
+
+		nested, err := parseYAMLMapping(lines, pos, child.indent)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = nested
+	}
+	if *pos < len(lines) && lines[*pos].indent > indent {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[*pos].number)
+	}
+	return result, nil
+}
+
+func parseYAMLScalar(text string) interface{} {
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		var items []interface{}
+		for _, part := range strings.Split(text[1:len(text)-1], ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				items = append(items, parseYAMLScalar(part))
+			}
+		}
+		return items
+	}
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		if text[0] == '"' {
+			if unquoted, err := strconv.Unquote(text); err == nil {
+				return unquoted
+			}
+		}
+		return text[1 : len(text)-1]
+	}
+	return text
+}
+
+// stripYAMLComment removes a trailing "# comment" outside of quotes
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// configHandler exposes the effective configuration with secrets
+// redacted. Admin only.
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	values := make(map[string]interface{})
+	sources := make(map[string]string)
+	for _, s := range cfg.settings() {
+		value := formatValue(s.target)
+		if s.secret {
+			switch v := value.(type) {
+			case string:
+				if v != "" {
+					value = "***"
+				}
+			case []string:
+				redacted := make([]string, len(v))
+				for i := range v {
+					redacted[i] = "***"
+				}
+				value = redacted
+			}
+		}
+		values[s.key] = value
+		source := cfg.sources[s.key]
+		if source == "" {
+			source = "default"
+		}
+		sources[s.key] = source
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": values,
+		"sources":  sources,
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ToolConflict is a tool name exposed by more than one selected server
+type ToolConflict struct {
+	Tool    string   `json:"tool"`
+	Servers []string `json:"servers"`
+}
+
+// detectToolConflicts finds tool names declared by several of the servers.
+// A server selected by both a legacy and its current ID counts once.
+func detectToolConflicts(ids []string) []ToolConflict {
+	owners := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, serverID := range ids {
+		serverID = canonicalID(serverID)
+		config, exists := getEntry(serverID)
+		if !exists || seen[serverID] {
+			continue
+		}
+		seen[serverID] = true
+		for _, tool := range entryTools(config) {
+			owners[tool.Name] = append(owners[tool.Name], serverID)
+		}
+	}
+
+	var conflicts []ToolConflict
+	for tool, serverIDs := range owners {
+		if len(serverIDs) < 2 {
+			continue
+		}
+		sort.Strings(serverIDs)
+		conflicts = append(conflicts, ToolConflict{Tool: tool, Servers: serverIDs})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Tool < conflicts[j].Tool
+	})
+	return conflicts
+}
+
+// conflictWarnings explains conflicts in terms of the target client
+func conflictWarnings(conflicts []ToolConflict, client ClientProfile) []string {
+	if client.NamespacesTools {
+		return nil
+	}
+	var warnings []string
+	for _, conflict := range conflicts {
+		warnings = append(warnings, fmt.Sprintf("Tool '%s' is exposed by %s; %s may call the wrong one",
+			conflict.Tool, strings.Join(conflict.Servers, ", "), client.Name))
+	}
+	return warnings
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EntryDrift is one entry whose served copy differs from the catalog file
+type EntryDrift struct {
+	ServerID string `json:"server_id"`
+	// Kind is missing_in_serving, missing_in_store or checksum_mismatch
+	Kind            string `json:"kind"`
+	StoreChecksum   string `json:"store_checksum,omitempty"`
+	ServingChecksum string `json:"serving_checksum,omitempty"`
+}
+
+// ConsistencyCheck is the outcome of one comparison
+type ConsistencyCheck struct {
+	CheckedAt time.Time    `json:"checked_at"`
+	Entries   int          `json:"entries"`
+	Drift     []EntryDrift `json:"drift"`
+	Repaired  bool         `json:"repaired"`
+	// Skipped says why drift was left alone, e.g. a pinned snapshot
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+var consistency = struct {
+	sync.Mutex
+	checks       int64
+	driftedTotal int64
+	repairs      int64
+	lastDriftAt  *time.Time
+	last         *ConsistencyCheck
+}{}
+
+// entryChecksum hashes an entry's canonical JSON; encoding/json sorts keys
+func entryChecksum(entry interface{}) string {
+	data, _ := json.Marshal(entry)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// storedEntries reads the catalog file the way loadServers does, so
+// entries skipped as invalid at load are not reported as drift
+func storedEntries() (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(loadedCatalogPath)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if _, err := migrateCatalog(doc); err != nil {
+		return nil, err
+	}
+	entries, _ := validEntries(loadedCatalogPath, catalogEntries(doc))
+	return entries, nil
+}
+
+// servedLocalEntries returns the local entries of the served registry with
+// the origin federation stamps on them removed
+func servedLocalEntries() map[string]interface{} {
+	local := make(map[string]interface{})
+	for serverID := range servers {
+		config, ok := getEntry(serverID)
+		if !ok {
+			continue
+		}
+		origin, federated := config["origin"].(map[string]interface{})
+		if !federated {
+			local[serverID] = config
+			continue
+		}
+		if getString(origin, "namespace", "") != "local" {
+			continue
+		}
+		stripped := make(map[string]interface{}, len(config))
+		for key, value := range config {
+			if key != "origin" {
+				stripped[key] = value
+			}
+		}
+		local[serverID] = stripped
+	}
+	return local
+}
+
+// compareEntries lists every entry that differs between the two copies
+func compareEntries(store, serving map[string]interface{}) []EntryDrift {
+	drift := []EntryDrift{}
+	for serverID, entry := range store {
+		stored := entryChecksum(entry)
+		servedEntry, exists := serving[serverID]
+		switch {
+		case !exists:
+			drift = append(drift, EntryDrift{ServerID: serverID, Kind: "missing_in_serving", StoreChecksum: stored})
+		case entryChecksum(servedEntry) != stored:
+			drift = append(drift, EntryDrift{ServerID: serverID, Kind: "checksum_mismatch", StoreChecksum: stored, ServingChecksum: entryChecksum(servedEntry)})
+		}
+	}
+	for serverID, entry := range serving {
+		if _, exists := store[serverID]; !exists {
+			drift = append(drift, EntryDrift{ServerID: serverID, Kind: "missing_in_store", ServingChecksum: entryChecksum(entry)})
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].ServerID < drift[j].ServerID })
+	return drift
+}
+
+// checkConsistency compares the catalog file with the served registry and,
+// unless a rollback is pinned, serves the file again when they drifted
+// apart, e.g. after a write that reached the file but not memory
+func checkConsistency() ConsistencyCheck {
+	check := ConsistencyCheck{CheckedAt: time.Now().UTC(), Drift: []EntryDrift{}}
+	store, err := storedEntries()
+	if err != nil {
+		check.Error = err.Error()
+		log.Printf("❌ Consistency check cannot read %s: %v", loadedCatalogPath, err)
+	} else {
+		check.Entries = len(store)
+		check.Drift = compareEntries(store, servedLocalEntries())
+	}
+
+	if len(check.Drift) > 0 {
+		for _, drift := range check.Drift {
+			log.Printf("⚠️  Catalog drift: '%s' is %s", drift.ServerID, drift.Kind)
+		}
+		if syncsPaused() {
+			check.Skipped = "the catalog is pinned to a snapshot"
+		} else {
+			repairFromStore(store)
+			check.Repaired = true
+			log.Printf("🩺 Repaired %d drifted entries from %s", len(check.Drift), loadedCatalogPath)
+		}
+	}
+
+	consistency.Lock()
+	consistency.checks++
+	consistency.driftedTotal += int64(len(check.Drift))
+	if len(check.Drift) > 0 {
+		consistency.lastDriftAt = &check.CheckedAt
+	}
+	if check.Repaired {
+		consistency.repairs++
+	}
+	consistency.last = &check
+	consistency.Unlock()
+	return check
+}
+
+// repairFromStore serves the catalog file again, merged with the last good
+// upstream catalogs when federating
+func repairFromStore(store map[string]interface{}) {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	localServers = store
+	merged := store
+	if upstreams, _ := parseUpstreams(cfg.Upstreams); len(upstreams) > 0 {
+		merged, _ = federate(store, upstreams, lastFetched, upstreamStatuses())
+	}
+	recordSnapshot("repair", merged)
+	setServers(merged)
+}
+
+// startConsistencyChecks verifies the served registry on the configured
+// interval. Bundles are immutable and synthetic entries exist only in
+// memory, so neither is checked.
+func startConsistencyChecks() {
+	if cfg.ConsistencyInterval <= 0 || loadedCatalogPath == "" || cfg.BundlePath != "" || cfg.Synthetic > 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(cfg.ConsistencyInterval) {
+			checkConsistency()
+		}
+	}()
+}
+
+// consistencyHandler reports drift metrics and the last check (GET) or
+// runs a check now (POST). Admin only.
+func consistencyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	switch r.Method {
+	case "GET":
+	case "POST":
+		if loadedCatalogPath == "" || cfg.BundlePath != "" {
+			writeError(w, http.StatusConflict, "The catalog was not loaded from a file")
+			return
+		}
+		checkConsistency()
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	consistency.Lock()
+	defer consistency.Unlock()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"store":           loadedCatalogPath,
+		"interval":        cfg.ConsistencyInterval.String(),
+		"checks":          consistency.checks,
+		"drifted_entries": consistency.driftedTotal,
+		"repairs":         consistency.repairs,
+		"last_drift_at":   consistency.lastDriftAt,
+		"last_check":      consistency.last,
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsPolicy is the resolved CORS behaviour for a route
+type corsPolicy struct {
+	origins     []string
+	methods     string
+	headers     string
+	maxAge      string
+	credentials bool
+}
+
+func (p corsPolicy) allowsAnyOrigin() bool {
+	for _, origin := range p.origins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p corsPolicy) allowsOrigin(origin string) bool {
+	if p.allowsAnyOrigin() {
+		return true
+	}
+	for _, allowed := range p.origins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p corsPolicy) allowsMethod(method string) bool {
+	for _, allowed := range strings.Split(p.methods, ",") {
+		if strings.EqualFold(strings.TrimSpace(allowed), method) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsRoute overrides the allowed origins for paths under a prefix
+type corsRoute struct {
+	prefix  string
+	origins []string
+}
+
+// parseCORSRoutes parses "PREFIX=ORIGIN [ORIGIN...]" overrides
+func parseCORSRoutes(specs []string) ([]corsRoute, error) {
+	var routes []corsRoute
+	for _, spec := range specs {
+		eq := strings.Index(spec, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid CORS route override '%s', want PREFIX=ORIGIN", spec)
+		}
+		routes = append(routes, corsRoute{
+			prefix:  strings.TrimSpace(spec[:eq]),
+			origins: strings.Fields(spec[eq+1:]),
+		})
+	}
+	return routes, nil
+}
+
+// validateCORS rejects combinations browsers refuse, such as credentials
+// with a wildcard origin
+func validateCORS(c *Config) error {
+	routes, err := parseCORSRoutes(c.CORSRoutes)
+	if err != nil {
+		return err
+	}
+	if !c.CORSAllowCredentials {
+		return nil
+	}
+	policy := corsPolicy{origins: c.CORSOrigins}
+	if policy.allowsAnyOrigin() {
+		return fmt.Errorf("cors.allow_credentials cannot be combined with a '*' origin")
+	}
+	for _, route := range routes {
+		if (corsPolicy{origins: route.origins}).allowsAnyOrigin() {
+			return fmt.Errorf("cors.allow_credentials cannot be combined with a '*' origin on %s", route.prefix)
+		}
+	}
+	return nil
+}
+
+// corsPolicyFor resolves the policy for a path; the longest matching
+// route override wins
+func corsPolicyFor(path string) corsPolicy {
+	policy := corsPolicy{
+		origins:     cfg.CORSOrigins,
+		methods:     strings.Join(cfg.CORSMethods, ", "),
+		headers:     strings.Join(cfg.CORSHeaders, ", "),
+		maxAge:      strconv.Itoa(int(cfg.CORSMaxAge.Seconds())),
+		credentials: cfg.CORSAllowCredentials,
+	}
+
+	routes, _ := parseCORSRoutes(cfg.CORSRoutes)
+	matched := ""
+	for _, route := range routes {
+		if strings.HasPrefix(path, route.prefix) && len(route.prefix) > len(matched) {
+			matched = route.prefix
+			policy.origins = route.origins
+		}
+	}
+	return policy
+}
+
+// corsMiddleware applies the configured CORS policy to every response and
+// answers preflight requests without reaching the handlers
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		policy := corsPolicyFor(r.URL.Path)
+		preflight := r.Method == "OPTIONS" && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// Answers differ by Origin, so caches must key on it even for
+		// requests without one
+		w.Header().Add("Vary", "Origin")
+		if origin != "" && policy.allowsOrigin(origin) {
+			if policy.allowsAnyOrigin() && !policy.credentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if preflight {
+			requested := r.Header.Get("Access-Control-Request-Method")
+			if origin == "" || !policy.allowsOrigin(origin) || !policy.allowsMethod(requested) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", policy.methods)
+			w.Header().Set("Access-Control-Allow-Headers", policy.headers)
+			w.Header().Set("Access-Control-Max-Age", policy.maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if r.Method == "OPTIONS" {
+			w.Header().Set("Allow", policy.methods)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
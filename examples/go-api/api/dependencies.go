@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxGraphDepth bounds how far the dependency graph endpoint walks
+const maxGraphDepth = 3
+
+// entryRelations decodes a "requires" or "recommends" list of server IDs
+func entryRelations(config map[string]interface{}, key string) []string {
+	raw, _ := config[key].([]interface{})
+	var ids []string
+	for _, id := range raw {
+		if str, ok := id.(string); ok && str != "" {
+			ids = append(ids, canonicalID(str))
+		}
+	}
+	return ids
+}
+
+// withRequiredDependencies appends every transitively required server to a
+// selection and explains each addition
+func withRequiredDependencies(selected []string) ([]string, []string) {
+	result := append([]string(nil), selected...)
+	present := make(map[string]bool, len(selected))
+	for _, serverID := range selected {
+		present[serverID] = true
+	}
+
+	var notes []string
+	for i := 0; i < len(result); i++ {
+		config, exists := getEntry(result[i])
+		if !exists {
+			continue
+		}
+		for _, dependency := range entryRelations(config, "requires") {
+			if present[dependency] {
+				continue
+			}
+			if _, exists := getEntry(dependency); !exists {
+				notes = append(notes, fmt.Sprintf("'%s' requires '%s', which is not in the catalog", result[i], dependency))
+				continue
+			}
+			present[dependency] = true
+			result = append(result, dependency)
+			notes = append(notes, fmt.Sprintf("Added '%s' because '%s' requires it", dependency, result[i]))
+		}
+	}
+
+	for _, serverID := range selected {
+		config, _ := getEntry(serverID)
+		for _, pairing := range entryRelations(config, "recommends") {
+			if _, exists := getEntry(pairing); exists && !present[pairing] {
+				notes = append(notes, fmt.Sprintf("'%s' works best together with '%s'", serverID, pairing))
+			}
+		}
+	}
+	return result, notes
+}
+
+// GraphNode and GraphEdge describe the dependency graph around a server
+type GraphNode struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Missing  bool   `json:"missing,omitempty"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is requires or recommends
+	Type string `json:"type"`
+}
+
+// dependencyGraph walks requires/recommends relations in both directions
+// from a server up to depth hops
+func dependencyGraph(root string, depth int) ([]GraphNode, []GraphEdge) {
+	// Incoming edges need a reverse index over the whole catalog
+	var allEdges []GraphEdge
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		for _, relation := range []string{"requires", "recommends"} {
+			for _, target := range entryRelations(config, relation) {
+				allEdges = append(allEdges, GraphEdge{From: serverID, To: target, Type: relation})
+			}
+		}
+	}
+
+	seen := map[string]bool{root: true}
+	frontier := []string{root}
+	edgeSeen := make(map[GraphEdge]bool)
+	var edges []GraphEdge
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, edge := range allEdges {
+			for _, serverID := range frontier {
+				if edge.From != serverID && edge.To != serverID {
+					continue
+				}
+				if !edgeSeen[edge] {
+					edgeSeen[edge] = true
+					edges = append(edges, edge)
+				}
+				for _, neighbour := range []string{edge.From, edge.To} {
+					if !seen[neighbour] {
+						seen[neighbour] = true
+						next = append(next, neighbour)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+
+	nodes := make([]GraphNode, 0, len(seen))
+	for serverID := range seen {
+		config, exists := getEntry(serverID)
+		nodes = append(nodes, GraphNode{
+			ID:       serverID,
+			Name:     getString(config, "name", serverID),
+			Category: getString(config, "category", "other"),
+			Missing:  !exists,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return nodes, edges
+}
+
+// graphDOT renders a dependency graph in Graphviz DOT
+func graphDOT(root string, nodes []GraphNode, edges []GraphEdge) string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n  rankdir=LR;\n")
+	for _, node := range nodes {
+		attrs := fmt.Sprintf("label=%q", node.Name)
+		if node.ID == root {
+			attrs += ", style=bold"
+		}
+		if node.Missing {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", node.ID, attrs)
+	}
+	for _, edge := range edges {
+		style := "solid"
+		if edge.Type == "recommends" {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=%s];\n", edge.From, edge.To, edge.Type, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dependencyGraphHandler serves the requires/recommends graph around a
+// server as JSON, or as Graphviz DOT with ?format=dot
+func dependencyGraphHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serverID := r.PathValue("id")
+	if _, exists := getEntry(serverID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+	depth := 1
+	if raw := r.URL.Query().Get("depth"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxGraphDepth {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Query parameter 'depth' must be between 1 and %d", maxGraphDepth))
+			return
+		}
+		depth = parsed
+	}
+
+	nodes, edges := dependencyGraph(serverID, depth)
+	if r.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(graphDOT(serverID, nodes, edges)))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":    serverID,
+		"depth": depth,
+		"nodes": nodes,
+		"edges": edges,
+	})
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// distributionChannels are the ways a server is distributed: a package on
+// npm or PyPI, a Docker image, a hosted endpoint or prebuilt binaries
+var distributionChannels = []string{"npm", "pypi", "docker", "remote", "binary"}
+
+// sha256Pattern matches a hex SHA-256 checksum
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// BinaryAsset is the download of a prebuilt binary for one platform
+type BinaryAsset struct {
+	// Platform is an OS and architecture such as linux-amd64
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+	SHA256   string `json:"sha256"`
+}
+
+// Distribution is one way to install a server, from an entry's
+// "distributions" list. Entries without the list have the distributions
+// their "package" and "url" describe.
+type Distribution struct {
+	Channel string `json:"channel"`
+	// Package is the npm or PyPI package, Image the Docker image and URL
+	// the hosted endpoint, reached over Transport
+	Package   string `json:"package,omitempty"`
+	Image     string `json:"image,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Transport string `json:"transport,omitempty"`
+	// Binary is the program a binary distribution installs from the
+	// download for the platform among Assets
+	Binary string        `json:"binary,omitempty"`
+	Assets []BinaryAsset `json:"assets,omitempty"`
+	// Platforms the distribution runs on; none means every platform
+	Platforms        []string `json:"platforms,omitempty"`
+	Version          string   `json:"version,omitempty"`
+	KnownGoodVersion string   `json:"known_good_version,omitempty"`
+}
+
+// entryDistributions returns an entry's distributions in the maintainer's
+// order of preference
+func entryDistributions(config map[string]interface{}) []Distribution {
+	if raw, ok := config["distributions"]; ok {
+		data, _ := json.Marshal(raw)
+		var distributions []Distribution
+		if err := json.Unmarshal(data, &distributions); err != nil {
+			return nil
+		}
+		// Binaries run where they can be downloaded for
+		for i, distribution := range distributions {
+			if distribution.Channel == "binary" && len(distribution.Platforms) == 0 {
+				for _, asset := range distribution.Assets {
+					distributions[i].Platforms = append(distributions[i].Platforms, asset.Platform)
+				}
+			}
+		}
+		return distributions
+	}
+	var distributions []Distribution
+	if pkg, ok := entryPackage(config); ok {
+		distribution := Distribution{Channel: pkg.Registry, Package: pkg.Name, Version: pkg.Version, KnownGoodVersion: pkg.KnownGoodVersion}
+		if pkg.Registry == "docker" {
+			distribution.Package, distribution.Image = "", pkg.Name
+		}
+		distributions = append(distributions, distribution)
+	}
+	if endpoint := getString(config, "url", ""); endpoint != "" {
+		for _, transport := range entryTransports(config) {
+			if transport != "stdio" {
+				distributions = append(distributions, Distribution{Channel: "remote", URL: endpoint, Transport: transport})
+				break
+			}
+		}
+	}
+	return distributions
+}
+
+// runsOn reports whether a distribution runs on an OS; an empty OS
+// matches every distribution
+func (d Distribution) runsOn(os string) bool {
+	if os == "" || len(d.Platforms) == 0 {
+		return true
+	}
+	for _, platform := range d.Platforms {
+		if platformOS, _, _ := strings.Cut(platform, "-"); platformOS == os {
+			return true
+		}
+	}
+	return false
+}
+
+// packageSpec is the package a package or image distribution installs
+func (d Distribution) packageSpec() (PackageSpec, bool) {
+	switch d.Channel {
+	case "npm", "pypi":
+		return PackageSpec{Name: d.Package, Registry: d.Channel, Version: d.Version, KnownGoodVersion: d.KnownGoodVersion}, true
+	case "docker":
+		return PackageSpec{Name: d.Image, Registry: "docker", Version: d.Version, KnownGoodVersion: d.KnownGoodVersion}, true
+	case "binary":
+		return PackageSpec{Name: d.Binary, Registry: "binary", Version: d.Version}, true
+	}
+	return PackageSpec{}, false
+}
+
+// orderedDistributions lists the distributions of an entry that run on
+// os, those of the preferred channels first in that order, then the rest
+// in the entry's own order
+func orderedDistributions(config map[string]interface{}, preferred []string, os string) []Distribution {
+	var ordered []Distribution
+	distributions := entryDistributions(config)
+	for _, channel := range preferred {
+		for _, distribution := range distributions {
+			if distribution.Channel == channel && distribution.runsOn(os) {
+				ordered = append(ordered, distribution)
+			}
+		}
+	}
+	for _, distribution := range distributions {
+		if !slices.Contains(preferred, distribution.Channel) && distribution.runsOn(os) {
+			ordered = append(ordered, distribution)
+		}
+	}
+	return ordered
+}
+
+// withDistribution returns a copy of an entry that installs through one
+// distribution only, so launch configs and pins follow it
+func withDistribution(config map[string]interface{}, distribution Distribution) map[string]interface{} {
+	chosen := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		chosen[key] = value
+	}
+	delete(chosen, "package")
+	delete(chosen, "url")
+	delete(chosen, "transport")
+	if pkg, ok := distribution.packageSpec(); ok {
+		chosen["package"] = map[string]interface{}{"name": pkg.Name, "registry": pkg.Registry, "version": pkg.Version, "known_good_version": pkg.KnownGoodVersion}
+		chosen["transports"] = []interface{}{"stdio"}
+	} else {
+		chosen["url"] = distribution.URL
+		chosen["transports"] = []interface{}{distribution.Transport}
+	}
+	if len(distribution.Platforms) > 0 {
+		platforms := make([]interface{}, len(distribution.Platforms))
+		for i, platform := range distribution.Platforms {
+			platforms[i] = platform
+		}
+		chosen["platforms"] = platforms
+	}
+	return chosen
+}
+
+// launchDistribution launches a server from the first of its ordered
+// distributions the client can reach, returning the entry narrowed to it.
+// Entries with neither distributions nor a package or URL launch as
+// before, with no channel.
+func launchDistribution(serverID string, config map[string]interface{}, preferred []string, os string, opts ConfigOptions, client ClientProfile, bridgeIndex int) (map[string]interface{}, Distribution, map[string]interface{}, *Bridge, error) {
+	if _, listed := config["distributions"]; !listed && len(entryDistributions(config)) == 0 {
+		launch, bridge, err := launchConfig(serverID, config, opts, client, bridgeIndex)
+		return config, Distribution{}, launch, bridge, err
+	}
+	distributions := orderedDistributions(config, preferred, os)
+	if len(distributions) == 0 && os != "" {
+		return nil, Distribution{}, nil, nil, fmt.Errorf("'%s' has no distribution for %s", serverID, os)
+	} else if len(distributions) == 0 {
+		return nil, Distribution{}, nil, nil, fmt.Errorf("'%s' has no distribution", serverID)
+	}
+	var firstErr error
+	for _, distribution := range distributions {
+		chosen := withDistribution(config, distribution)
+		launch, bridge, err := launchConfig(serverID, chosen, opts, client, bridgeIndex)
+		if err == nil {
+			return chosen, distribution, launch, bridge, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, Distribution{}, nil, nil, firstErr
+}
+
+// validateDistributions checks the "distributions" list of an entry
+func validateDistributions(config map[string]interface{}) []string {
+	raw, present := config["distributions"]
+	if !present {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return []string{"'distributions' must be a list of objects"}
+	}
+	var problems []string
+	for i, item := range list {
+		path := fmt.Sprintf("distributions[%d]", i)
+		distribution, ok := item.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("'%s' must be an object", path))
+			continue
+		}
+		for _, key := range []string{"channel", "package", "image", "url", "transport", "binary", "version", "known_good_version"} {
+			if value, present := distribution[key]; present {
+				if _, ok := value.(string); !ok {
+					problems = append(problems, fmt.Sprintf("'%s.%s' must be a string", path, key))
+				}
+			}
+		}
+		switch channel := getString(distribution, "channel", ""); channel {
+		case "npm", "pypi":
+			if getString(distribution, "package", "") == "" {
+				problems = append(problems, fmt.Sprintf("'%s.package' is required for %s", path, channel))
+			}
+		case "docker":
+			if getString(distribution, "image", "") == "" {
+				problems = append(problems, fmt.Sprintf("'%s.image' is required for docker", path))
+			}
+		case "remote":
+			if parsed, err := url.Parse(getString(distribution, "url", "")); err != nil || parsed.Scheme != "https" && parsed.Scheme != "http" || parsed.Host == "" {
+				problems = append(problems, fmt.Sprintf("'%s.url' must be an http(s) URL", path))
+			}
+			if transport := getString(distribution, "transport", ""); transport != "sse" && transport != "streamable-http" {
+				problems = append(problems, fmt.Sprintf("'%s.transport' must be sse or streamable-http", path))
+			}
+		case "binary":
+			problems = append(problems, validateBinaryDistribution(distribution, path)...)
+		default:
+			problems = append(problems, fmt.Sprintf("'%s.channel' must be one of %s", path, strings.Join(distributionChannels, ", ")))
+		}
+		if err := stringListField(distribution, "platforms", path+".platforms"); err != nil {
+			problems = append(problems, err.Error())
+		}
+		platforms, _ := stringList(distribution["platforms"])
+		for _, platform := range platforms {
+			if !isPlatform(platform) {
+				problems = append(problems, fmt.Sprintf("'%s.platforms' entry '%s' must be macos, windows or linux, optionally with -amd64 or -arm64", path, platform))
+			}
+		}
+	}
+	return problems
+}
+
+// validateBinaryDistribution checks the program name, version and assets
+// of a binary distribution. Every asset needs an architecture, an https
+// URL and a SHA-256 checksum, since install scripts refuse a download that
+// does not match it.
+func validateBinaryDistribution(distribution map[string]interface{}, path string) []string {
+	var problems []string
+	if binary := getString(distribution, "binary", ""); !binaryNamePattern.MatchString(binary) {
+		problems = append(problems, fmt.Sprintf("'%s.binary' must be a program name such as \"mcp-server\"", path))
+	}
+	if getString(distribution, "version", "") == "" {
+		problems = append(problems, fmt.Sprintf("'%s.version' is required for binary, as the checksums are of one release", path))
+	}
+	assets, ok := distribution["assets"].([]interface{})
+	if !ok || len(assets) == 0 {
+		return append(problems, fmt.Sprintf("'%s.assets' must list a download per platform", path))
+	}
+	seen := make(map[string]bool)
+	for j, item := range assets {
+		assetPath := fmt.Sprintf("%s.assets[%d]", path, j)
+		asset, ok := item.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("'%s' must be an object", assetPath))
+			continue
+		}
+		platform := getString(asset, "platform", "")
+		if _, _, hasArch := strings.Cut(platform, "-"); !hasArch || !isPlatform(platform) {
+			problems = append(problems, fmt.Sprintf("'%s.platform' must be macos, windows or linux with -amd64 or -arm64", assetPath))
+		} else if seen[platform] {
+			problems = append(problems, fmt.Sprintf("'%s.platform' repeats %s", assetPath, platform))
+		}
+		seen[platform] = true
+		if parsed, err := url.Parse(getString(asset, "url", "")); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("'%s.url' must be an https URL", assetPath))
+		}
+		if !sha256Pattern.MatchString(getString(asset, "sha256", "")) {
+			problems = append(problems, fmt.Sprintf("'%s.sha256' must be a hex SHA-256 checksum", assetPath))
+		}
+	}
+	return problems
+}
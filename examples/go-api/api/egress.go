@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// entryEgress returns the external hosts an entry declares it talks to,
+// e.g. "api.github.com" or "*.slack.com". declared is false when the entry
+// says nothing, which is not the same as an empty list: a server that
+// declares no hosts makes no outbound connections.
+func entryEgress(config map[string]interface{}) (hosts []string, declared bool) {
+	raw, ok := config["egress"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	hosts = []string{}
+	for _, host := range raw {
+		if str, ok := host.(string); ok && str != "" {
+			hosts = append(hosts, strings.ToLower(str))
+		}
+	}
+	return hosts, true
+}
+
+// hostAllowed reports whether an allowlist pattern covers a declared host.
+// "*.example.com" covers every subdomain of example.com, including
+// wildcard declarations under it, but not example.com itself.
+func hostAllowed(host, pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	if pattern == "*" || host == pattern {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(host, suffix)
+	}
+	return false
+}
+
+// egressWithin reports whether every host an entry declares is covered by
+// the allowlist. Entries that declare nothing are never within a policy.
+func egressWithin(config map[string]interface{}, allowlist []string) bool {
+	hosts, declared := entryEgress(config)
+	if !declared {
+		return false
+	}
+	for _, host := range hosts {
+		covered := false
+		for _, pattern := range allowlist {
+			if hostAllowed(host, pattern) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// loadAllowlist reads a named allowlist from the policy directory. The file
+// holds either a JSON array of host patterns or an object with a "hosts"
+// array.
+func loadAllowlist(name string) ([]string, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("Allowlist '%s' must be a file name in the policy directory", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(cfg.PolicyDir, name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("Allowlist '%s' not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	if err := json.Unmarshal(data, &hosts); err != nil {
+		var doc struct {
+			Hosts []string `json:"hosts"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("Allowlist '%s' must be a JSON list of hosts", name)
+		}
+		hosts = doc.Hosts
+	}
+	return hosts, nil
+}
+
+func parseEgressFilter(r *http.Request) (entryFilter, error) {
+	name := r.URL.Query().Get("egress_within")
+	if name == "" {
+		return nil, nil
+	}
+	allowlist, err := loadAllowlist(name)
+	if err != nil {
+		return nil, err
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		return egressWithin(config, allowlist)
+	}, nil
+}
+
+// EgressHost is one external host and the configured servers that reach it
+type EgressHost struct {
+	Host    string   `json:"host"`
+	Servers []string `json:"servers"`
+}
+
+// FirewallNotes tell network admins which outbound HTTPS destinations a
+// generated config needs
+type FirewallNotes struct {
+	Hosts []EgressHost `json:"hosts"`
+	// Undeclared servers do not document their egress
+	Undeclared []string `json:"undeclared,omitempty"`
+}
+
+// firewallNotes collects the declared egress of the included servers
+func firewallNotes(serverIDs []string) *FirewallNotes {
+	byHost := make(map[string][]string)
+	notes := &FirewallNotes{Hosts: []EgressHost{}}
+	for _, serverID := range serverIDs {
+		config, _ := getEntry(serverID)
+		hosts, declared := entryEgress(config)
+		if !declared {
+			notes.Undeclared = append(notes.Undeclared, serverID)
+			continue
+		}
+		for _, host := range hosts {
+			byHost[host] = append(byHost[host], serverID)
+		}
+	}
+	for host, serverIDs := range byHost {
+		notes.Hosts = append(notes.Hosts, EgressHost{Host: host, Servers: serverIDs})
+	}
+	sort.Slice(notes.Hosts, func(i, j int) bool { return notes.Hosts[i].Host < notes.Hosts[j].Host })
+	return notes
+}
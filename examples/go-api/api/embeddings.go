@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Embedder turns texts into vectors whose cosine similarity tracks how
+// related the texts are. The local embedder needs nothing; an API
+// embedder calls an OpenAI-compatible embeddings endpoint.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the vector space; vectors of different models never mix
+	Model() string
+}
+
+// localDimensions is the size of local embedder vectors
+const localDimensions = 512
+
+// semanticConcepts group terms that mean the same kind of thing, so a
+// query for "database" lands near postgres and mysql entries. Synonym
+// groups are added on top.
+var semanticConcepts = map[string][]string{
+	"database":  {"database", "databases", "db", "sql", "postgres", "postgresql", "mysql", "mariadb", "sqlite", "mongodb", "mongo", "redis", "query", "queries", "table", "tables", "schema"},
+	"files":     {"file", "files", "filesystem", "fs", "directory", "folder", "folders", "disk", "document", "documents"},
+	"code":      {"code", "git", "github", "gitlab", "repository", "repositories", "repo", "commit", "commits", "pull", "branch", "issues"},
+	"web":       {"web", "browser", "browse", "browsing", "website", "websites", "page", "pages", "url", "scrape", "scraping", "crawl", "fetch", "http"},
+	"search":    {"search", "searching", "find", "lookup", "index", "retrieval"},
+	"chat":      {"chat", "message", "messages", "messaging", "slack", "discord", "teams", "channel", "channels"},
+	"cloud":     {"cloud", "aws", "gcp", "azure", "kubernetes", "k8s", "cluster", "deploy", "deployment", "infrastructure"},
+	"memory":    {"memory", "remember", "knowledge", "graph", "notes", "vector", "embeddings", "rag"},
+	"email":     {"email", "emails", "mail", "inbox", "gmail", "smtp", "imap"},
+	"calendar":  {"calendar", "event", "events", "meeting", "meetings", "schedule"},
+	"payments":  {"payment", "payments", "billing", "invoice", "invoices", "stripe", "checkout"},
+	"analytics": {"analytics", "metrics", "monitoring", "logs", "observability", "dashboard", "dashboards"},
+}
+
+// embeddingStopWords carry no meaning for similarity
+var embeddingStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "to": true, "of": true, "in": true, "on": true,
+	"for": true, "with": true, "my": true, "me": true, "i": true, "it": true, "that": true, "this": true,
+	"is": true, "are": true, "from": true, "by": true, "or": true, "can": true, "talks": true, "talk": true,
+	"server": true, "mcp": true, "access": true, "use": true, "using": true, "via": true, "your": true,
+}
+
+// localEmbedder hashes words, the concepts they belong to and their
+// character trigrams into a fixed-size vector
+type localEmbedder struct{}
+
+func (localEmbedder) Model() string { return "local" }
+
+func (localEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	concepts := termConcepts()
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, localDimensions)
+		for _, token := range tokenize(text) {
+			if embeddingStopWords[token] {
+				continue
+			}
+			addFeature(vector, "w:"+token, 1)
+			for _, concept := range concepts[token] {
+				addFeature(vector, "c:"+concept, 1.5)
+			}
+			padded := "<" + token + ">"
+			for j := 0; j+3 <= len(padded); j++ {
+				addFeature(vector, "t:"+padded[j:j+3], 0.2)
+			}
+		}
+		normalize(vector)
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// termConcepts maps each term to its concepts and synonym groups
+func termConcepts() map[string][]string {
+	concepts := make(map[string][]string)
+	for concept, terms := range semanticConcepts {
+		for _, term := range terms {
+			concepts[term] = append(concepts[term], concept)
+		}
+	}
+	for _, group := range synonyms {
+		if len(group) == 0 {
+			continue
+		}
+		for _, term := range group {
+			folded := foldText(term)
+			concepts[folded] = append(concepts[folded], "syn:"+foldText(group[0]))
+		}
+	}
+	return concepts
+}
+
+func addFeature(vector []float32, feature string, weight float32) {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	sum := h.Sum32()
+	// The top bit picks the sign, so unrelated features cancel out
+	if sum&(1<<31) != 0 {
+		weight = -weight
+	}
+	vector[sum%uint32(len(vector))] += weight
+}
+
+func normalize(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+}
+
+// cosine is the cosine similarity of two vectors of the same model
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// apiEmbedder asks an OpenAI-compatible embeddings endpoint
+type apiEmbedder struct {
+	client *http.Client
+	url    string
+	model  string
+	apiKey string
+}
+
+func (e apiEmbedder) Model() string { return "api:" + e.model }
+
+func (e apiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{"model": e.model, "input": texts})
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+	resp, err := callDependency(e.client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, e.url)
+	}
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// embeddingBatch bounds the texts sent to an embeddings endpoint at once
+const embeddingBatch = 64
+
+// StoredEmbeddings is the embeddings.json file: vectors keyed by the hash
+// of the text they embed, so unchanged entries are not embedded again
+type StoredEmbeddings struct {
+	Model   string               `json:"model"`
+	Vectors map[string][]float32 `json:"vectors"`
+}
+
+// embeddings holds the vectors of the served entries
+var embeddings = struct {
+	sync.RWMutex
+	embedder Embedder
+	// byText caches vectors by text hash; byEntry is what searches use
+	byText  map[string][]float32
+	byEntry map[string][]float32
+	// generation discards results of embedding runs a newer catalog replaced
+	generation int
+}{}
+
+// embeddingDocument is the text embedded for one entry
+type embeddingDocument struct {
+	id   string
+	hash string
+	text string
+}
+
+// entryEmbeddingText is what an entry is embedded as: its name,
+// description, category, tags and tool descriptions
+func entryEmbeddingText(serverID string, config map[string]interface{}) string {
+	parts := []string{getString(config, "name", serverID), getString(config, "description", ""), getString(config, "category", "")}
+	parts = append(parts, entryTags(config)...)
+	for _, tool := range entryTools(config) {
+		parts = append(parts, strings.ReplaceAll(tool.Name, "_", " "), tool.Description)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// startEmbeddings selects the configured embedder, reads stored vectors
+// and embeds the catalog. Subcommands never reach it.
+func startEmbeddings() {
+	var embedder Embedder
+	switch cfg.EmbeddingsProvider {
+	case "local":
+		embedder = localEmbedder{}
+	case "api":
+		embedder = apiEmbedder{client: outboundClient(30 * time.Second), url: cfg.EmbeddingsURL, model: cfg.EmbeddingsModel, apiKey: cfg.EmbeddingsAPIKey}
+	default:
+		return
+	}
+
+	var stored StoredEmbeddings
+	if err := readJSONFile(dataPath("embeddings.json"), &stored); err != nil {
+		log.Printf("⚠️  Ignoring stored embeddings: %v", err)
+	}
+	embeddings.Lock()
+	embeddings.embedder = embedder
+	embeddings.byText = make(map[string][]float32)
+	if stored.Model == embedder.Model() {
+		embeddings.byText = stored.Vectors
+	}
+	embeddings.Unlock()
+	log.Printf("🧭 Semantic search with the %s embedder", embedder.Model())
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	refreshEmbeddings()
+}
+
+// refreshEmbeddings embeds the served entries whose text changed. It is
+// called with the catalog locked whenever the search index is rebuilt;
+// the embedder runs in the background, and until it is done searches use
+// the vectors of the previous catalog.
+func refreshEmbeddings() {
+	embeddings.Lock()
+	embedder := embeddings.embedder
+	if embedder == nil {
+		embeddings.Unlock()
+		return
+	}
+	embeddings.generation++
+	generation := embeddings.generation
+	var documents []embeddingDocument
+	for serverID := range servers {
+		config, ok := getEntry(serverID)
+		if !ok {
+			continue
+		}
+		text := entryEmbeddingText(serverID, config)
+		documents = append(documents, embeddingDocument{id: serverID, hash: textHash(text), text: text})
+	}
+	embeddings.Unlock()
+	sort.Slice(documents, func(i, j int) bool { return documents[i].id < documents[j].id })
+
+	if _, local := embedder.(localEmbedder); local {
+		embedDocuments(context.Background(), embedder, documents, generation)
+		return
+	}
+	go embedDocuments(context.Background(), embedder, documents, generation)
+}
+
+// embedDocuments embeds the documents missing from the cache in batches,
+// then swaps in the vectors of every document
+func embedDocuments(ctx context.Context, embedder Embedder, documents []embeddingDocument, generation int) {
+	embeddings.RLock()
+	var missing []embeddingDocument
+	for _, document := range documents {
+		if _, ok := embeddings.byText[document.hash]; !ok {
+			missing = append(missing, document)
+		}
+	}
+	embeddings.RUnlock()
+
+	computed := make(map[string][]float32)
+	for start := 0; start < len(missing); start += embeddingBatch {
+		batch := missing[start:min(start+embeddingBatch, len(missing))]
+		texts := make([]string, len(batch))
+		for i, document := range batch {
+			texts[i] = document.text
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			log.Printf("❌ Embedding %d entries failed, semantic search will miss them: %v", len(batch), err)
+			continue
+		}
+		for i, document := range batch {
+			computed[document.hash] = vectors[i]
+		}
+	}
+
+	embeddings.Lock()
+	defer embeddings.Unlock()
+	if generation != embeddings.generation {
+		return
+	}
+	byText := make(map[string][]float32, len(documents))
+	byEntry := make(map[string][]float32, len(documents))
+	for _, document := range documents {
+		vector, ok := computed[document.hash]
+		if !ok {
+			vector, ok = embeddings.byText[document.hash]
+		}
+		if ok {
+			byText[document.hash] = vector
+			byEntry[document.id] = vector
+		}
+	}
+	embeddings.byText = byText
+	embeddings.byEntry = byEntry
+
+	if _, local := embedder.(localEmbedder); local || len(computed) == 0 {
+		return
+	}
+	stored := StoredEmbeddings{Model: embedder.Model(), Vectors: byText}
+	if err := writeJSONFile(dataPath("embeddings.json"), stored); err != nil {
+		log.Printf("❌ Could not store embeddings: %v", err)
+	}
+	log.Printf("🧭 Embedded %d entries (%d cached)", len(computed), len(byEntry)-len(computed))
+}
+
+// semanticEnabled reports whether an embedder is configured
+func semanticEnabled() bool {
+	embeddings.RLock()
+	defer embeddings.RUnlock()
+	return embeddings.embedder != nil
+}
+
+// Semantic search thresholds: hits below minSemanticSimilarity are noise,
+// and a similarity scores semanticWeight times itself
+const (
+	minSemanticSimilarity = 0.2
+	semanticWeight        = 4.0
+)
+
+// semanticSearch embeds the query and returns the entries whose vectors
+// are close enough to it, most similar first
+func semanticSearch(ctx context.Context, query string) ([]SearchHit, error) {
+	embeddings.RLock()
+	embedder := embeddings.embedder
+	embeddings.RUnlock()
+	vectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	embeddings.RLock()
+	defer embeddings.RUnlock()
+	var hits []SearchHit
+	for serverID, vector := range embeddings.byEntry {
+		similarity := cosine(vectors[0], vector)
+		if similarity < minSemanticSimilarity {
+			continue
+		}
+		hits = append(hits, SearchHit{ID: serverID, Matches: []FieldMatch{{
+			Field: "semantic",
+			Kind:  "similar",
+			Score: roundScore(semanticWeight * similarity),
+		}}})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Matches[0].Score != hits[j].Matches[0].Score {
+			return hits[i].Matches[0].Score > hits[j].Matches[0].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits, nil
+}
+
+// searchModes are the values of the search "mode" parameter
+var searchModes = []string{"keyword", "semantic", "hybrid"}
+
+// parseSearchMode reads ?mode=keyword|semantic|hybrid, keyword by default
+func parseSearchMode(r *http.Request) (string, error) {
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "", "keyword":
+		return "keyword", nil
+	case "semantic", "hybrid":
+		if !semanticEnabled() || !featureEnabled(r, "semantic_search") {
+			return "", fmt.Errorf("Semantic search is not enabled on this server")
+		}
+		return mode, nil
+	}
+	return "", fmt.Errorf("Query parameter 'mode' must be one of %s", strings.Join(searchModes, ", "))
+}
+
+// searchWithMode runs a query in the given mode. Hybrid adds the semantic
+// match to the keyword matches of an entry, so entries both find rank
+// first. When the embedder fails, hybrid falls back to keyword matches.
+func searchWithMode(ctx context.Context, query, mode string) ([]SearchHit, error) {
+	if mode == "keyword" {
+		return searchEntries(ctx, query)
+	}
+	semantic, err := semanticSearch(ctx, query)
+	if mode == "semantic" {
+		return semantic, err
+	}
+	if err != nil {
+		log.Printf("⚠️  Semantic search failed, serving keyword matches: %v", err)
+		return searchEntries(ctx, query)
+	}
+	keyword, err := searchEntries(ctx, query)
+	if err != nil {
+		return keyword, err
+	}
+	position := make(map[string]int, len(keyword))
+	for i, hit := range keyword {
+		position[hit.ID] = i
+	}
+	for _, hit := range semantic {
+		if i, ok := position[hit.ID]; ok {
+			keyword[i].Matches = append(keyword[i].Matches, hit.Matches...)
+			continue
+		}
+		keyword = append(keyword, hit)
+	}
+	return keyword, nil
+}
+
+func validateEmbeddings(c *Config) error {
+	switch c.EmbeddingsProvider {
+	case "none", "local":
+		return nil
+	case "api":
+		if c.EmbeddingsURL == "" || c.EmbeddingsModel == "" {
+			return fmt.Errorf("search.embeddings.url and search.embeddings.model are required with the api embedder")
+		}
+		return nil
+	}
+	return fmt.Errorf("search.embeddings.provider must be none, local or api, not '%s'", c.EmbeddingsProvider)
+}
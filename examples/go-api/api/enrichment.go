@@ -0,0 +1,393 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// enrichmentKinds are the keys of an entry's "enrichment" block, each the
+// URL of remote data fetched after startup: an icon, a README, a
+// capabilities document listing the entry's tools and the raw files of the
+// repository its runtime is detected from
+var enrichmentKinds = []string{"icon", "readme", "capabilities", "runtime"}
+
+// entryEnrichment decodes the "enrichment" block of an entry, kind to URL.
+// With enrichment.detect_runtimes, entries with a GitHub repository detect
+// their runtime from it unless the block names other raw files.
+func entryEnrichment(config map[string]interface{}) map[string]string {
+	raw, _ := config["enrichment"].(map[string]interface{})
+	sources := make(map[string]string, len(raw)+1)
+	for _, kind := range enrichmentKinds {
+		if source := getString(raw, kind, ""); source != "" {
+			sources[kind] = source
+		}
+	}
+	if cfg != nil && cfg.EnrichRuntimes && sources["runtime"] == "" {
+		if base, ok := repositoryRawBase(config); ok {
+			sources["runtime"] = base
+		}
+	}
+	return sources
+}
+
+// EnrichmentError is a failed enrichment fetch. FetchedAt is set when an
+// earlier run's copy is served instead.
+type EnrichmentError struct {
+	ServerID  string     `json:"server_id"`
+	Kind      string     `json:"kind"`
+	Source    string     `json:"source"`
+	Error     string     `json:"error"`
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+}
+
+// EnrichmentProgress is the state of remote enrichment reported by /readyz.
+// Status is idle until an entry declares enrichment, then loading while
+// fetches are pending and complete once they all finished. Stale counts
+// the failed fetches served from an earlier run's copy.
+type EnrichmentProgress struct {
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Fetched    int               `json:"fetched"`
+	Failed     int               `json:"failed"`
+	Stale      int               `json:"stale"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Errors     []EnrichmentError `json:"errors,omitempty"`
+}
+
+// enrichment holds what remote enrichment fetched: icons and READMEs as
+// assets, tools by capabilities URL and runtimes by repository base. The
+// API serves the catalog's own fields and the last good fetches of earlier
+// runs from startup; fetched data shows up as it arrives.
+var enrichment = struct {
+	sync.Mutex
+	started   bool
+	attempted map[string]bool
+	assets    map[string][]byte
+	tools     map[string][]Tool
+	runtimes  map[string]RuntimeInfo
+	stored    map[string]storedEnrichment
+	progress  EnrichmentProgress
+}{attempted: make(map[string]bool), assets: make(map[string][]byte), tools: make(map[string][]Tool), runtimes: make(map[string]RuntimeInfo), stored: make(map[string]storedEnrichment), progress: EnrichmentProgress{Status: "idle"}}
+
+type enrichmentTask struct {
+	serverID string
+	kind     string
+	source   string
+}
+
+func (task enrichmentTask) key() string {
+	return task.kind + " " + task.serverID + " " + task.source
+}
+
+// storedEnrichment is a fetched item as kept in data/enrichment.json, so
+// a restart while a source is unreachable still serves it
+type storedEnrichment struct {
+	ServerID  string    `json:"server_id"`
+	Kind      string    `json:"kind"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Data      []byte    `json:"data,omitempty"`
+	Tools     []Tool    `json:"tools,omitempty"`
+}
+
+// applyEnrichment serves a fetched item. It is called with enrichment
+// locked.
+func applyEnrichment(item storedEnrichment) {
+	switch item.Kind {
+	case "icon":
+		enrichment.assets["icons/"+item.ServerID+iconExtension(item.Source, item.Data)] = item.Data
+	case "readme":
+		enrichment.assets["readmes/"+item.ServerID+".md"] = item.Data
+	case "capabilities":
+		enrichment.tools[item.Source] = item.Tools
+	case "runtime":
+		var info RuntimeInfo
+		if err := json.Unmarshal(item.Data, &info); err == nil {
+			enrichment.runtimes[item.Source] = info
+		}
+	}
+	task := enrichmentTask{serverID: item.ServerID, kind: item.Kind, source: item.Source}
+	enrichment.stored[task.key()] = item
+}
+
+// loadEnrichment serves the items earlier runs fetched
+func loadEnrichment() {
+	var stored struct {
+		Items []storedEnrichment `json:"items"`
+	}
+	if err := readJSONFile(dataPath("enrichment.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load fetched enrichment: %v", err)
+		return
+	}
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	for _, item := range stored.Items {
+		applyEnrichment(item)
+	}
+}
+
+// saveEnrichment keeps the fetched items in data/enrichment.json
+func saveEnrichment() {
+	enrichment.Lock()
+	items := make([]storedEnrichment, 0, len(enrichment.stored))
+	for _, item := range enrichment.stored {
+		items = append(items, item)
+	}
+	enrichment.Unlock()
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ServerID != items[j].ServerID {
+			return items[i].ServerID < items[j].ServerID
+		}
+		return items[i].Kind < items[j].Kind
+	})
+	if err := writeJSONFile(dataPath("enrichment.json"), map[string]interface{}{"items": items}); err != nil {
+		log.Printf("❌ Failed to save fetched enrichment: %v", err)
+	}
+}
+
+// startEnrichment begins fetching the enrichment of the served entries.
+// Commands never get here, so they do not touch the network.
+func startEnrichment() {
+	loadEnrichment()
+	enrichment.Lock()
+	enrichment.started = true
+	enrichment.Unlock()
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	refreshEnrichment()
+}
+
+// refreshEnrichment fetches in the background the enrichment no earlier
+// run attempted. It is called with the catalog locked whenever the search
+// index is rebuilt, so new and edited entries are enriched too.
+func refreshEnrichment() {
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	if !enrichment.started {
+		return
+	}
+	var tasks []enrichmentTask
+	for serverID := range servers {
+		config, ok := getEntry(serverID)
+		if !ok {
+			continue
+		}
+		for kind, source := range entryEnrichment(config) {
+			task := enrichmentTask{serverID: serverID, kind: kind, source: source}
+			if !enrichment.attempted[task.key()] {
+				enrichment.attempted[task.key()] = true
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].serverID != tasks[j].serverID {
+			return tasks[i].serverID < tasks[j].serverID
+		}
+		return tasks[i].kind < tasks[j].kind
+	})
+
+	progress := &enrichment.progress
+	if progress.Status != "loading" {
+		now := time.Now().UTC()
+		progress.StartedAt, progress.FinishedAt = &now, nil
+	}
+	progress.Status = "loading"
+	progress.Total += len(tasks)
+	log.Printf("🖼️  Fetching %d enrichment items, %d at a time", len(tasks), cfg.EnrichConcurrency)
+	go runEnrichment(tasks)
+}
+
+// runEnrichment fetches the tasks with at most cfg.EnrichConcurrency in
+// flight. New capabilities are indexed once every fetch has finished. A
+// failed fetch keeps serving an earlier run's copy, marked stale.
+func runEnrichment(tasks []enrichmentTask) {
+	client := outboundClient(0)
+	slots := make(chan struct{}, max(cfg.EnrichConcurrency, 1))
+	var wg sync.WaitGroup
+	reindex, fetched := false, false
+	for _, task := range tasks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			err := fetchEnrichment(client, task)
+			var failure EnrichmentError
+			if err != nil {
+				failure = EnrichmentError{ServerID: task.serverID, Kind: task.kind, Source: task.source, Error: err.Error()}
+			}
+			// An open breaker says the source's host is down, not the link
+			if err != nil && !errors.Is(err, errCircuitOpen) {
+				notify(Notification{
+					Event:   "link.broken",
+					Title:   fmt.Sprintf("Broken %s link of %s", task.kind, task.serverID),
+					Message: fmt.Sprintf("Fetching %s failed: %v", task.source, err),
+					Servers: []string{task.serverID},
+					Data:    failure,
+				})
+			}
+
+			enrichment.Lock()
+			defer enrichment.Unlock()
+			progress := &enrichment.progress
+			if err != nil {
+				if item, ok := enrichment.stored[task.key()]; ok {
+					fetchedAt := item.FetchedAt
+					failure.FetchedAt = &fetchedAt
+					progress.Stale++
+				}
+				progress.Failed++
+				progress.Errors = append(progress.Errors, failure)
+				return
+			}
+			progress.Fetched++
+			fetched = true
+			reindex = reindex || task.kind == "capabilities"
+		}()
+	}
+	wg.Wait()
+
+	if fetched {
+		saveEnrichment()
+	}
+	if reindex {
+		catalogMu.Lock()
+		buildSearchIndex()
+		bumpRevision()
+		catalogMu.Unlock()
+	}
+
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	progress := &enrichment.progress
+	if progress.Fetched+progress.Failed == progress.Total {
+		now := time.Now().UTC()
+		progress.Status, progress.FinishedAt = "complete", &now
+		log.Printf("🖼️  Enrichment complete: %d fetched, %d failed", progress.Fetched, progress.Failed)
+	}
+}
+
+// fetchEnrichment fetches one item and stores it
+func fetchEnrichment(client *http.Client, task enrichmentTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.EnrichTimeout)
+	defer cancel()
+	item := storedEnrichment{ServerID: task.serverID, Kind: task.kind, Source: task.source}
+	if task.kind == "runtime" {
+		info, err := fetchRuntime(ctx, client, task.source)
+		if err != nil {
+			return err
+		}
+		if item.Data, err = json.Marshal(info); err != nil {
+			return err
+		}
+		item.FetchedAt = time.Now().UTC()
+		enrichment.Lock()
+		applyEnrichment(item)
+		enrichment.Unlock()
+		return nil
+	}
+	body, err := fetchText(ctx, client, task.source)
+	if err != nil {
+		return err
+	}
+
+	item.FetchedAt = time.Now().UTC()
+	if task.kind == "capabilities" {
+		if item.Tools, err = decodeCapabilities([]byte(body)); err != nil {
+			return err
+		}
+	} else {
+		item.Data = []byte(body)
+	}
+	enrichment.Lock()
+	applyEnrichment(item)
+	enrichment.Unlock()
+	return nil
+}
+
+// iconExtension names a fetched icon after its URL, or its content when
+// the URL has no extension
+func iconExtension(source string, data []byte) string {
+	if parsed, err := url.Parse(source); err == nil {
+		if ext := path.Ext(parsed.Path); ext != "" {
+			return ext
+		}
+	}
+	if exts, _ := mime.ExtensionsByType(http.DetectContentType(data)); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".png"
+}
+
+// decodeCapabilities reads a capabilities document, {"tools": [...]} or a
+// bare list of tools
+func decodeCapabilities(data []byte) ([]Tool, error) {
+	var doc struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		if err := json.Unmarshal(data, &doc.Tools); err != nil {
+			return nil, fmt.Errorf("capabilities must be a tools list or an object with one")
+		}
+	}
+	for i, tool := range doc.Tools {
+		if tool.Name == "" {
+			return nil, fmt.Errorf("capabilities tool %d has no name", i)
+		}
+	}
+	return doc.Tools, nil
+}
+
+// enrichedTools returns the fetched tools of an entry that declares none
+// itself
+func enrichedTools(config map[string]interface{}) []Tool {
+	source := entryEnrichment(config)["capabilities"]
+	if source == "" {
+		return nil
+	}
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	return enrichment.tools[source]
+}
+
+// enrichedAssets returns the fetched icons and READMEs by asset name
+func enrichedAssets() map[string][]byte {
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	assets := make(map[string][]byte, len(enrichment.assets))
+	for name, data := range enrichment.assets {
+		assets[name] = data
+	}
+	return assets
+}
+
+// enrichmentProgress returns a copy of the loader's progress
+func enrichmentProgress() EnrichmentProgress {
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	progress := enrichment.progress
+	progress.Errors = append([]EnrichmentError(nil), progress.Errors...)
+	sort.Slice(progress.Errors, func(i, j int) bool {
+		if progress.Errors[i].ServerID != progress.Errors[j].ServerID {
+			return progress.Errors[i].ServerID < progress.Errors[j].ServerID
+		}
+		return progress.Errors[i].Kind < progress.Errors[j].Kind
+	})
+	return progress
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Example is a sample prompt from an entry's "examples" list: something a
+// user can ask an agent once the server is installed
+type Example struct {
+	Title  string `json:"title"`
+	Prompt string `json:"prompt"`
+	// ExpectedBehavior says what the agent does with the server
+	ExpectedBehavior string `json:"expected_behavior,omitempty"`
+}
+
+// entryExamples decodes the "examples" list of an entry, empty when it
+// has none
+func entryExamples(config map[string]interface{}) []Example {
+	examples := []Example{}
+	if raw, ok := config["examples"]; ok {
+		data, _ := json.Marshal(raw)
+		if err := json.Unmarshal(data, &examples); err != nil {
+			return []Example{}
+		}
+	}
+	return examples
+}
+
+// validateExamples checks the "examples" list of an entry
+func validateExamples(config map[string]interface{}) []string {
+	raw, present := config["examples"]
+	if !present {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return []string{"'examples' must be a list of objects"}
+	}
+	var problems []string
+	for i, item := range list {
+		example, ok := item.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("'examples[%d]' must be an object", i))
+			continue
+		}
+		for _, key := range []string{"title", "prompt"} {
+			if value, _ := example[key].(string); strings.TrimSpace(value) == "" {
+				problems = append(problems, fmt.Sprintf("'examples[%d].%s' is required", i, key))
+			}
+		}
+		if value, present := example["expected_behavior"]; present {
+			if _, ok := value.(string); !ok {
+				problems = append(problems, fmt.Sprintf("'examples[%d].expected_behavior' must be a string", i))
+			}
+		}
+	}
+	return problems
+}
+
+// examplesText is what search matches of an entry's examples: their
+// titles and prompts, one per line
+func examplesText(config map[string]interface{}) string {
+	var lines []string
+	for _, example := range entryExamples(config) {
+		lines = append(lines, example.Title, example.Prompt)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// serverExamplesHandler lists an entry's examples (GET). Admins curate
+// them: POST appends one example, PUT replaces the list, which also
+// reorders and removes examples. Upstream entries are curated upstream.
+func serverExamplesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serverID := r.PathValue("id")
+	config, exists := getEntry(serverID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+
+	var examples []Example
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"server_id": serverID,
+			"examples":  entryExamples(config),
+		})
+		return
+	case "POST":
+		if !requireAdmin(w, r) {
+			return
+		}
+		var example Example
+		if err := decodeJSONBody(w, r, &example); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		examples = append(entryExamples(config), example)
+	case "PUT":
+		if !requireAdmin(w, r) {
+			return
+		}
+		var req struct {
+			Examples []Example `json:"examples"`
+		}
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		examples = req.Examples
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, local := localCatalog()[serverID]; !local {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Server '%s' comes from an upstream catalog; curate its examples there", serverID))
+		return
+	}
+
+	list := make([]interface{}, len(examples))
+	for i, example := range examples {
+		item := map[string]interface{}{"title": strings.TrimSpace(example.Title), "prompt": strings.TrimSpace(example.Prompt)}
+		if behavior := strings.TrimSpace(example.ExpectedBehavior); behavior != "" {
+			item["expected_behavior"] = behavior
+		}
+		list[i] = item
+	}
+	if problems := validateExamples(map[string]interface{}{"examples": list}); len(problems) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, strings.Join(problems, "; "))
+		return
+	}
+
+	entry, err := rewriteCatalogEntry(newFieldOrigin("examples", requestEditor(r)), serverID, func(existing map[string]interface{}, exists bool) (map[string]interface{}, error) {
+		if !exists {
+			return nil, &requestError{status: http.StatusConflict, message: fmt.Sprintf("Server '%s' is not in %s", serverID, loadedCatalogPath)}
+		}
+		updated := make(map[string]interface{}, len(existing)+1)
+		for key, value := range existing {
+			updated[key] = value
+		}
+		if len(list) == 0 {
+			delete(updated, "examples")
+		} else {
+			updated["examples"] = list
+		}
+		return updated, nil
+	})
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		writeRequestError(w, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("💡 Curated %d examples of '%s'", len(list), serverID)
+	if r.Method == "POST" {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"server_id": serverID,
+		"examples":  entryExamples(entry),
+	})
+}
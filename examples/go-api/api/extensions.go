@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Extensions are entry fields whose name starts with "x-", such as
+// "x-acme": {"cost_center": "CC-12"} or "x-review-ticket": "SEC-42". They
+// carry metadata of whoever runs the catalog without changing its schema:
+// any JSON value is accepted, they travel with the entry through exports,
+// bundles and syncs, where they merge like other fields, and lists and
+// searches filter on them with ?x.NAME=VALUE, or ?x.NAME.KEY=VALUE for a
+// key of an object.
+
+// extensionPrefix starts the name of an extension field
+const extensionPrefix = "x-"
+
+// maxExtensionBytes bounds the encoded extensions of one entry
+const maxExtensionBytes = 16 << 10
+
+// extensionNamePattern matches the name of an extension after "x-"; dots
+// are left out because they separate keys in ?x. filters
+var extensionNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// entryExtensions returns the extension fields of an entry, nil when it
+// has none
+func entryExtensions(config map[string]interface{}) map[string]interface{} {
+	var extensions map[string]interface{}
+	for key, value := range config {
+		if strings.HasPrefix(key, extensionPrefix) {
+			if extensions == nil {
+				extensions = make(map[string]interface{})
+			}
+			extensions[key] = value
+		}
+	}
+	return extensions
+}
+
+// validateExtensions checks that extension fields are named for filtering
+// and encode as JSON within maxExtensionBytes; their content is free
+func validateExtensions(config map[string]interface{}) []string {
+	extensions := entryExtensions(config)
+	if extensions == nil {
+		return nil
+	}
+	var problems []string
+	for key := range extensions {
+		if !extensionNamePattern.MatchString(strings.TrimPrefix(key, extensionPrefix)) {
+			problems = append(problems, fmt.Sprintf("extension '%s' must be named x- followed by letters, digits, dashes or underscores", key))
+		}
+	}
+	encoded, err := json.Marshal(extensions)
+	switch {
+	case err != nil:
+		problems = append(problems, "extensions must be JSON values: "+err.Error())
+	case len(encoded) > maxExtensionBytes:
+		problems = append(problems, fmt.Sprintf("extensions must encode to at most %d bytes, not %d", maxExtensionBytes, len(encoded)))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// extensionValue follows a path of keys into an entry's extensions: the
+// first names the field after "x-", the rest keys of nested objects
+func extensionValue(config map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := config[extensionPrefix+path[0]]
+	for _, key := range path[1:] {
+		if !ok {
+			break
+		}
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return nil, false
+		}
+		value, ok = object[key]
+	}
+	return value, ok
+}
+
+// extensionMatches compares an extension value with a filter value: a
+// string as is, other values as their JSON and a list when any element
+// matches. An empty filter value only asks for the field to be set.
+func extensionMatches(value interface{}, wanted string) bool {
+	if wanted == "" {
+		return value != nil
+	}
+	switch v := value.(type) {
+	case string:
+		return v == wanted
+	case []interface{}:
+		for _, element := range v {
+			if extensionMatches(element, wanted) {
+				return true
+			}
+		}
+		return false
+	}
+	encoded, err := json.Marshal(value)
+	return err == nil && string(encoded) == wanted
+}
+
+// parseExtensionFilter keeps entries whose extensions match every
+// ?x.NAME[.KEY...]=VALUE parameter
+func parseExtensionFilter(r *http.Request) (entryFilter, error) {
+	type condition struct {
+		path   []string
+		wanted string
+	}
+	var conditions []condition
+	for param, values := range r.URL.Query() {
+		field, ok := strings.CutPrefix(param, "x.")
+		if !ok {
+			continue
+		}
+		path := strings.Split(field, ".")
+		if !extensionNamePattern.MatchString(path[0]) || slices.Contains(path[1:], "") {
+			return nil, fmt.Errorf("Query parameter '%s' must name an extension such as x.acme.cost_center", param)
+		}
+		for _, wanted := range values {
+			conditions = append(conditions, condition{path: path, wanted: wanted})
+		}
+	}
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		for _, c := range conditions {
+			value, ok := extensionValue(config, c.path)
+			if !ok || !extensionMatches(value, c.wanted) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
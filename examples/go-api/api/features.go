@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// FeatureFlag is a subsystem of the API that can be switched off at
+// runtime. Routes answer 404 while their feature is off; other behavior
+// checks featureEnabled.
+type FeatureFlag struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Default     bool     `json:"default"`
+	Routes      []string `json:"routes,omitempty"`
+}
+
+// featureFlags are the known features. A route of the form
+// /api/v1/servers/{id}/SUB names a per-server subresource.
+var featureFlags = []FeatureFlag{
+	{Name: "semantic_search", Description: "mode=semantic and mode=hybrid on search", Default: true},
+	{Name: "personalization", Description: "Filtering and ranking by the X-MCP-Client header", Default: true},
+	{Name: "federation", Description: "Upstream sync, the sync wire format and the sync conflict queue", Default: true, Routes: []string{"/api/v1/export/sync", "/api/v1/admin/sync-conflicts"}},
+	{Name: "canary", Description: "Evaluating synced catalogs before serving them; failures are held for approval", Default: true},
+	{Name: "reviews", Description: "User reviews and their moderation", Default: true, Routes: []string{"/api/v1/servers/{id}/reviews", "/api/v1/admin/reviews"}},
+	{Name: "reports", Description: "Abuse reports and their moderation", Default: true, Routes: []string{"/api/v1/servers/{id}/report", "/api/v1/admin/reports"}},
+	{Name: "try_it", Description: "Anonymous previews of hosted servers", Default: true, Routes: []string{"/api/v1/servers/{id}/try", "/api/v1/admin/try-audit"}},
+}
+
+func featureFlag(name string) (FeatureFlag, bool) {
+	i := slices.IndexFunc(featureFlags, func(flag FeatureFlag) bool { return flag.Name == name })
+	if i < 0 {
+		return FeatureFlag{}, false
+	}
+	return featureFlags[i], true
+}
+
+// parseFeatureValue reads on/off as well as the strconv booleans
+func parseFeatureValue(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "true", "1", "yes":
+		return true, true
+	case "off", "false", "0", "no":
+		return false, true
+	}
+	return false, false
+}
+
+// parseFeatureSettings parses "FLAG=on|off" specs
+func parseFeatureSettings(specs []string) (map[string]bool, error) {
+	settings := make(map[string]bool)
+	for _, spec := range specs {
+		name, value, _ := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		enabled, ok := parseFeatureValue(value)
+		if _, known := featureFlag(name); !known || !ok {
+			return nil, fmt.Errorf("invalid feature setting '%s', want FLAG=on|off for a known flag", spec)
+		}
+		settings[name] = enabled
+	}
+	return settings, nil
+}
+
+// parseTenantFeatures parses "USER:FLAG=on|off" overrides by API key user
+func parseTenantFeatures(specs []string) (map[string]map[string]bool, error) {
+	tenants := make(map[string]map[string]bool)
+	for _, spec := range specs {
+		user, setting, ok := strings.Cut(spec, ":")
+		if !ok || strings.TrimSpace(user) == "" {
+			return nil, fmt.Errorf("invalid tenant feature setting '%s', want USER:FLAG=on|off", spec)
+		}
+		settings, err := parseFeatureSettings([]string{setting})
+		if err != nil {
+			return nil, err
+		}
+		user = strings.TrimSpace(user)
+		if tenants[user] == nil {
+			tenants[user] = make(map[string]bool)
+		}
+		for name, enabled := range settings {
+			tenants[user][name] = enabled
+		}
+	}
+	return tenants, nil
+}
+
+// featureState resolves a flag for an API key user, "" for anonymous
+// requests: the user's override, then the configured value, then the
+// flag's default. It also names where the value came from.
+func featureState(user, name string) (bool, string) {
+	if user != "" {
+		tenants, _ := parseTenantFeatures(cfg.FeatureTenants)
+		if enabled, ok := tenants[user][name]; ok {
+			return enabled, "tenant"
+		}
+	}
+	settings, _ := parseFeatureSettings(cfg.FeatureFlags)
+	if enabled, ok := settings[name]; ok {
+		return enabled, "config"
+	}
+	flag, _ := featureFlag(name)
+	return flag.Default, "default"
+}
+
+// featureEnabled reports whether a feature is on for the caller of a
+// request
+func featureEnabled(r *http.Request, name string) bool {
+	user, _ := apiKeyUser(r)
+	enabled, _ := featureState(user, name)
+	return enabled
+}
+
+// featureForPath returns the feature gating a request path, if any
+func featureForPath(path string) (string, bool) {
+	for _, flag := range featureFlags {
+		for _, route := range flag.Routes {
+			if prefix, sub, perServer := strings.Cut(route, "{id}/"); perServer {
+				id, found := strings.CutPrefix(path, prefix)
+				parent, last, _ := cutLast(id)
+				if _, exact := getEntry(canonicalID(id)); found && parent != "" && last == sub && !exact {
+					return flag.Name, true
+				}
+				continue
+			}
+			if path == route || strings.HasPrefix(path, route+"/") {
+				return flag.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// featureMiddleware answers 404 for the routes of features that are off
+// for the caller, as if the route did not exist
+func featureMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, gated := featureForPath(r.URL.Path); gated && !featureEnabled(r, name) {
+			w.Header().Set("Content-Type", "application/json")
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Feature '%s' is not enabled", name))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// FeatureStatus is one flag in the /api/v1/features listing
+type FeatureStatus struct {
+	FeatureFlag
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// featuresHandler lists every feature and whether it is on for the
+// caller, so clients can adapt to what this instance offers
+func featuresHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, _ := apiKeyUser(r)
+	features := make([]FeatureStatus, 0, len(featureFlags))
+	for _, flag := range featureFlags {
+		enabled, source := featureState(user, flag.Name)
+		features = append(features, FeatureStatus{FeatureFlag: flag, Enabled: enabled, Source: source})
+	}
+	response := map[string]interface{}{"features": features}
+	if user != "" {
+		response["tenant"] = user
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Upstream is another catalog whose entries are merged into this one
+type Upstream struct {
+	Namespace string
+	Source    string
+}
+
+// parseUpstreams parses "NAMESPACE=URL|PATH" specs, listed in precedence order
+func parseUpstreams(specs []string) ([]Upstream, error) {
+	var upstreams []Upstream
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		eq := strings.Index(spec, "=")
+		if eq <= 0 || eq == len(spec)-1 {
+			return nil, fmt.Errorf("invalid upstream '%s', want NAMESPACE=URL", spec)
+		}
+		namespace := strings.TrimSpace(spec[:eq])
+		if strings.Contains(namespace, "/") || seen[namespace] {
+			return nil, fmt.Errorf("invalid or duplicate upstream namespace '%s'", namespace)
+		}
+		seen[namespace] = true
+		upstreams = append(upstreams, Upstream{Namespace: namespace, Source: strings.TrimSpace(spec[eq+1:])})
+	}
+	return upstreams, nil
+}
+
+// localServers are the entries of the local catalog file, before merging.
+// Writers hold syncMu and replace the map rather than change it; readers
+// outside syncMu go through localCatalog.
+var localServers map[string]interface{}
+
+// localCatalog returns the entries of the local catalog file
+func localCatalog() map[string]interface{} {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	return localServers
+}
+
+// catalogMu serializes replacements of the served registry
+var catalogMu sync.Mutex
+
+// setServers replaces the served registry and rebuilds derived indexes
+func setServers(entries map[string]interface{}) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	servers = entries
+	indexLegacyIDs()
+	buildSearchIndex()
+	bumpRevision()
+	recordComponent("catalog", nil)
+}
+
+// fetchUpstream loads the catalog of an upstream: from a catalog instance
+// over the sync wire format, falling back to its JSON export for instances
+// without it, or from a local file
+func fetchUpstream(ctx context.Context, client *http.Client, upstream Upstream) (map[string]interface{}, error) {
+	var data []byte
+	var doc map[string]interface{}
+	remote := strings.HasPrefix(upstream.Source, "http://") || strings.HasPrefix(upstream.Source, "https://")
+	if remote {
+		var err error
+		doc, err = fetchSyncDocument(ctx, client, upstream)
+		if err != nil && !errors.Is(err, errSyncUnsupported) {
+			return nil, err
+		}
+	}
+	if remote && doc == nil {
+		req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(upstream.Source, "/")+"/api/v1/export/catalog", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := callDependency(client, req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		if data, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	} else if !remote {
+		var err error
+		if data, err = ioutil.ReadFile(upstream.Source); err != nil {
+			return nil, err
+		}
+	}
+
+	if doc == nil {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := migrateCatalog(doc); err != nil {
+		return nil, err
+	}
+	// Upstreams are never trusted to be well-formed, whatever the load mode
+	entries, rejected := validEntries("upstream:"+upstream.Namespace, catalogEntries(doc))
+	if len(rejected) > 0 {
+		log.Printf("⚠️  Skipped %d invalid entries from upstream %s", len(rejected), upstream.Namespace)
+	}
+	setLoadErrors("upstream:"+upstream.Namespace, rejected)
+	return entries, nil
+}
+
+// entryIdentity identifies the same server across catalogs by package,
+// falling back to repository URL and then name
+func entryIdentity(serverID string, config map[string]interface{}) string {
+	if pkg, ok := entryPackage(config); ok {
+		return pkg.Registry + ":" + pkg.Name
+	}
+	if repo, ok := config["repository"].(map[string]interface{}); ok {
+		if url := getString(repo, "url", ""); url != "" {
+			return "repo:" + strings.TrimSuffix(strings.ToLower(url), "/")
+		}
+	}
+	return "name:" + getString(config, "name", serverID)
+}
+
+// federate merges upstream catalogs into the local one. Local entries keep
+// their IDs and win over everything; upstream entries are namespaced as
+// "namespace/id" and an earlier upstream wins over a later one. Every
+// entry records its origin, including where duplicates were dropped.
+// Fields where a local entry and a dropped upstream copy disagree follow
+// the merge policies; the conflicts left to a maintainer are returned.
+// Entries of an upstream whose last sync failed are marked stale.
+func federate(local map[string]interface{}, upstreams []Upstream, fetched map[string]map[string]interface{}, status map[string]UpstreamStatus) (map[string]interface{}, []SyncConflict) {
+	merged := make(map[string]interface{}, len(local))
+	owners := make(map[string]map[string]interface{})
+	ownerIDs := make(map[string]string)
+	var conflicts []SyncConflict
+
+	add := func(id string, config map[string]interface{}, origin map[string]interface{}) {
+		identity := entryIdentity(id, config)
+		if owner, exists := owners[identity]; exists {
+			ownerOrigin := owner["origin"].(map[string]interface{})
+			alsoIn, _ := ownerOrigin["also_in"].([]string)
+			upstreamID := origin["namespace"].(string) + "/" + origin["upstream_id"].(string)
+			ownerOrigin["also_in"] = append(alsoIn, upstreamID)
+			if ownerOrigin["namespace"] == "local" {
+				conflicts = append(conflicts, mergeUpstreamCopy(ownerIDs[identity], owner, config, upstreamID)...)
+			}
+			return
+		}
+		entry := make(map[string]interface{}, len(config)+1)
+		for key, value := range config {
+			entry[key] = value
+		}
+		entry["origin"] = origin
+		merged[id] = entry
+		owners[identity] = entry
+		ownerIDs[identity] = id
+	}
+
+	for serverID, configInterface := range local {
+		config, ok := configInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		add(serverID, config, map[string]interface{}{"namespace": "local", "upstream_id": serverID})
+	}
+	for _, upstream := range upstreams {
+		synced := status[upstream.Namespace]
+		for serverID, configInterface := range fetched[upstream.Namespace] {
+			config, ok := configInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Legacy IDs resolve within the upstream's namespace too
+			if legacy := entryLegacyIDs(config); len(legacy) > 0 {
+				namespaced := make([]interface{}, len(legacy))
+				for i, id := range legacy {
+					namespaced[i] = upstream.Namespace + "/" + id
+				}
+				renamed := make(map[string]interface{}, len(config))
+				for key, value := range config {
+					renamed[key] = value
+				}
+				renamed["legacy_ids"] = namespaced
+				config = renamed
+			}
+			origin := map[string]interface{}{
+				"namespace":   upstream.Namespace,
+				"upstream":    upstream.Source,
+				"upstream_id": serverID,
+			}
+			if synced.SyncedAt != nil {
+				origin["synced_at"] = synced.SyncedAt.UTC().Format(time.RFC3339)
+			}
+			if synced.Stale {
+				origin["stale"] = true
+			}
+			add(upstream.Namespace+"/"+serverID, config, origin)
+		}
+	}
+	return merged, conflicts
+}
+
+// lastFetched keeps each upstream's last good catalog so one unreachable
+// upstream does not drop its entries from the merged view. It is kept in
+// data/upstreams/NAMESPACE.json, so a restart while an upstream is
+// unreachable still serves its entries.
+var lastFetched = make(map[string]map[string]interface{})
+
+// UpstreamStatus is an upstream's sync state, reported by /readyz. An
+// upstream is stale when its last sync failed and its entries are the
+// last good ones.
+type UpstreamStatus struct {
+	Namespace string     `json:"namespace"`
+	SyncedAt  *time.Time `json:"synced_at,omitempty"`
+	Stale     bool       `json:"stale"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// upstreamStatus is guarded by its own lock so /readyz never waits for a
+// sync in flight
+var upstreamStatus = struct {
+	sync.Mutex
+	byNamespace map[string]UpstreamStatus
+}{byNamespace: make(map[string]UpstreamStatus)}
+
+// storedUpstream is an upstream's last good catalog on disk
+type storedUpstream struct {
+	SyncedAt time.Time              `json:"synced_at"`
+	Servers  map[string]interface{} `json:"servers"`
+}
+
+func upstreamCachePath(namespace string) string {
+	return dataPath("upstreams/" + url.PathEscape(namespace) + ".json")
+}
+
+// loadUpstreamCache restores the last good catalogs of the upstreams and
+// reports whether any was found
+func loadUpstreamCache(upstreams []Upstream) bool {
+	found := false
+	for _, upstream := range upstreams {
+		var stored storedUpstream
+		if err := readJSONFile(upstreamCachePath(upstream.Namespace), &stored); err != nil {
+			log.Printf("❌ Cannot load last good catalog of upstream %s: %v", upstream.Namespace, err)
+			continue
+		}
+		if stored.Servers == nil {
+			continue
+		}
+		lastFetched[upstream.Namespace] = stored.Servers
+		syncedAt := stored.SyncedAt
+		setUpstreamStatus(UpstreamStatus{Namespace: upstream.Namespace, SyncedAt: &syncedAt, Stale: true, LastError: "not synced since restart"})
+		found = true
+	}
+	return found
+}
+
+func setUpstreamStatus(status UpstreamStatus) {
+	upstreamStatus.Lock()
+	defer upstreamStatus.Unlock()
+	upstreamStatus.byNamespace[status.Namespace] = status
+}
+
+// upstreamStatuses returns the sync state of every upstream by namespace
+func upstreamStatuses() map[string]UpstreamStatus {
+	upstreamStatus.Lock()
+	defer upstreamStatus.Unlock()
+	statuses := make(map[string]UpstreamStatus, len(upstreamStatus.byNamespace))
+	for namespace, status := range upstreamStatus.byNamespace {
+		statuses[namespace] = status
+	}
+	return statuses
+}
+
+// syncMu serializes writes of the catalog: syncs and repairs rebuilding
+// the merged catalog, which read lastFetched and localServers, and edits
+// of the catalog file. It is taken before syncConflictsMu.
+var syncMu sync.Mutex
+
+// syncUpstreams fetches every upstream and republishes the merged catalog
+func syncUpstreams(upstreams []Upstream) {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	client := outboundClient(30 * time.Second)
+	for _, upstream := range upstreams {
+		entries, err := fetchUpstream(context.Background(), client, upstream)
+		if err != nil {
+			log.Printf("⚠️  Upstream %s (%s) failed, serving its last good catalog: %v", upstream.Namespace, upstream.Source, err)
+			status := upstreamStatuses()[upstream.Namespace]
+			status.Namespace, status.Stale, status.LastError = upstream.Namespace, true, err.Error()
+			setUpstreamStatus(status)
+			continue
+		}
+		now := time.Now().UTC()
+		lastFetched[upstream.Namespace] = entries
+		setUpstreamStatus(UpstreamStatus{Namespace: upstream.Namespace, SyncedAt: &now})
+		if err := writeJSONFile(upstreamCachePath(upstream.Namespace), storedUpstream{SyncedAt: now, Servers: entries}); err != nil {
+			log.Printf("❌ Failed to save last good catalog of upstream %s: %v", upstream.Namespace, err)
+		}
+		log.Printf("🔗 Synced %d servers from upstream %s", len(entries), upstream.Namespace)
+	}
+	merged, conflicts := federate(localServers, upstreams, lastFetched, upstreamStatuses())
+	queueSyncConflicts(conflicts)
+	if enabled, _ := featureState("", "canary"); enabled && !syncsPaused() {
+		catalogMu.Lock()
+		served := servers
+		catalogMu.Unlock()
+		if report := evaluateCanary(served, merged); !report.Passed {
+			holdSnapshot("sync", merged, report)
+			return
+		}
+	}
+	recordSnapshot("sync", merged)
+	if syncsPaused() {
+		log.Printf("📸 Catalog is pinned to a snapshot; not serving the synced catalog")
+		return
+	}
+	setServers(merged)
+}
+
+// startFederation syncs upstreams once and then on the configured
+// interval. When last good catalogs were saved, they are served at once
+// and the first sync runs in the background, so startup never waits on
+// an unreachable upstream.
+func startFederation() {
+	upstreams, err := parseUpstreams(cfg.Upstreams)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if enabled, _ := featureState("", "federation"); len(upstreams) == 0 || !enabled {
+		return
+	}
+
+	if loadUpstreamCache(upstreams) {
+		merged, _ := federate(localServers, upstreams, lastFetched, upstreamStatuses())
+		if !syncsPaused() {
+			setServers(merged)
+		}
+		go syncUpstreams(upstreams)
+	} else {
+		syncUpstreams(upstreams)
+	}
+	if cfg.SyncInterval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(cfg.SyncInterval) {
+			syncUpstreams(upstreams)
+		}
+	}()
+}
+
+// exportCatalogHandler serves the full catalog document used by mirrors.
+// Only local entries are exported unless include_upstream=true. Keyed by
+// the current ?revision, the response is immutable.
+func exportCatalogHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !revisionKeyed(w, r) {
+		return
+	}
+
+	includeUpstream, _, err := parseBoolParam(r, "include_upstream")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries := localCatalog()
+	if includeUpstream {
+		entries = servers
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"servers":        entries,
+	})
+}
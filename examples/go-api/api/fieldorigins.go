@@ -0,0 +1,178 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FieldOrigin records which source last wrote a field of an entry.
+// Sources are "catalog" for fields that predate tracking, "sync:NAMESPACE",
+// "submission:SOURCE" for approved submissions and imports, "edit",
+// "tags", "recategorize" and "enrichment:BACKEND".
+type FieldOrigin struct {
+	Source string     `json:"source"`
+	At     *time.Time `json:"at,omitempty"`
+	Editor string     `json:"editor,omitempty"`
+}
+
+// newFieldOrigin stamps a write by a source and editor with the current time
+func newFieldOrigin(source, editor string) FieldOrigin {
+	now := time.Now().UTC()
+	return FieldOrigin{Source: source, At: &now, Editor: editor}
+}
+
+// requestEditor names the editor of a write made by a request: the user
+// of its API key, else "admin" for the admin token
+func requestEditor(r *http.Request) string {
+	if user, ok := apiKeyUser(r); ok {
+		return user
+	}
+	return "admin"
+}
+
+// FieldOverride is a field where a local entry differs from the upstream
+// entry it shadows; the local value is served
+type FieldOverride struct {
+	Field         string      `json:"field"`
+	Upstream      string      `json:"upstream"`
+	UpstreamValue interface{} `json:"upstream_value"`
+	LocalValue    interface{} `json:"local_value"`
+}
+
+// FieldProvenance is the ?include_provenance=true view of an entry
+type FieldProvenance struct {
+	Fields    map[string]FieldOrigin `json:"fields"`
+	Overrides []FieldOverride        `json:"overrides,omitempty"`
+}
+
+// fieldOrigins holds the recorded origin of every written field of the
+// local catalog, by server ID and field
+var (
+	fieldOriginsMu sync.Mutex
+	fieldOrigins   = make(map[string]map[string]FieldOrigin)
+)
+
+func loadFieldOrigins() {
+	var stored map[string]map[string]FieldOrigin
+	if err := readJSONFile(dataPath("field_origins.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load field provenance: %v", err)
+		return
+	}
+	if stored != nil {
+		fieldOriginsMu.Lock()
+		fieldOrigins = canonicalKeys(stored)
+		fieldOriginsMu.Unlock()
+	}
+}
+
+// recordFieldOrigins stamps every top-level field a write added or
+// changed, and forgets fields it removed. before and after hold the
+// written entries by server ID; a new entry has no before.
+func recordFieldOrigins(before, after map[string]map[string]interface{}, origin FieldOrigin) {
+	fieldOriginsMu.Lock()
+	defer fieldOriginsMu.Unlock()
+
+	for serverID, entry := range after {
+		origins := fieldOrigins[serverID]
+		if origins == nil {
+			origins = make(map[string]FieldOrigin)
+			fieldOrigins[serverID] = origins
+		}
+		for field, value := range entry {
+			if previous, existed := before[serverID][field]; !existed || !reflect.DeepEqual(previous, value) {
+				origins[field] = origin
+			}
+		}
+		for field := range before[serverID] {
+			if _, kept := entry[field]; !kept {
+				delete(origins, field)
+			}
+		}
+	}
+	if err := writeJSONFile(dataPath("field_origins.json"), fieldOrigins); err != nil {
+		log.Printf("❌ Failed to save field provenance: %v", err)
+	}
+}
+
+// entryFieldProvenance explains where each field of a served entry came
+// from. Upstream entries carry their sync in "origin"; a local entry that
+// shadows upstream copies lists the fields where they disagree.
+func entryFieldProvenance(serverID string, config map[string]interface{}) *FieldProvenance {
+	provenance := &FieldProvenance{Fields: make(map[string]FieldOrigin)}
+	origin, _ := config["origin"].(map[string]interface{})
+	namespace := getString(origin, "namespace", "local")
+
+	// Upstream-wins merge policies copy single fields into local entries
+	upstreamFields, _ := origin["upstream_fields"].(map[string]string)
+
+	fieldOriginsMu.Lock()
+	defer fieldOriginsMu.Unlock()
+	for field := range config {
+		if field == "origin" {
+			continue
+		}
+		switch recorded, ok := fieldOrigins[serverID][field]; {
+		case namespace != "local":
+			synced := FieldOrigin{Source: "sync:" + namespace}
+			if at, err := time.Parse(time.RFC3339, getString(origin, "synced_at", "")); err == nil {
+				synced.At = &at
+			}
+			provenance.Fields[field] = synced
+		case upstreamFields[field] != "":
+			upstreamNamespace, _, _ := strings.Cut(upstreamFields[field], "/")
+			provenance.Fields[field] = FieldOrigin{Source: "sync:" + upstreamNamespace}
+		case ok:
+			provenance.Fields[field] = recorded
+		default:
+			provenance.Fields[field] = FieldOrigin{Source: "catalog"}
+		}
+	}
+
+	generatedMu.RLock()
+	generated, ok := generatedDescriptions[serverID]
+	generatedMu.RUnlock()
+	if ok {
+		at := generated.GeneratedAt
+		provenance.Fields["generated_description"] = FieldOrigin{Source: "enrichment:" + generated.Backend, At: &at}
+	}
+
+	if namespace == "local" {
+		alsoIn, _ := origin["also_in"].([]string)
+		provenance.Overrides = upstreamOverrides(config, alsoIn)
+	}
+	return provenance
+}
+
+// upstreamOverrides compares a local entry with the upstream copies that
+// federation dropped in its favor
+func upstreamOverrides(config map[string]interface{}, shadowed []string) []FieldOverride {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	var overrides []FieldOverride
+	for _, upstreamID := range shadowed {
+		namespace, id, _ := strings.Cut(upstreamID, "/")
+		upstream, ok := lastFetched[namespace][id].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for field, value := range upstream {
+			local, present := config[field]
+			if field == "origin" || present && reflect.DeepEqual(local, value) {
+				continue
+			}
+			overrides = append(overrides, FieldOverride{Field: field, Upstream: upstreamID, UpstreamValue: value, LocalValue: local})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Field != overrides[j].Field {
+			return overrides[i].Field < overrides[j].Field
+		}
+		return overrides[i].Upstream < overrides[j].Upstream
+	})
+	return overrides
+}
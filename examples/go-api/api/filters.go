@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// entryFilter decides whether a catalog entry is included in list/search results
+type entryFilter func(serverID string, config map[string]interface{}) bool
+
+// filterParsers build entry filters from query parameters; each parser
+// returns nil when there is nothing to filter
+var filterParsers = []func(r *http.Request) (entryFilter, error){
+	parseProvenanceFilter,
+	parseMinRatingFilter,
+	parseEgressFilter,
+	parseMaxRiskFilter,
+	parseVendorFilter,
+	parseMaturityFilter,
+	parseTagFilter,
+	parseClientFilter,
+	parseExtensionFilter,
+}
+
+// parseEntryFilters collects the filters requested on a list/search call
+func parseEntryFilters(r *http.Request) ([]entryFilter, error) {
+	var filters []entryFilter
+	for _, parse := range filterParsers {
+		filter, err := parse(r)
+		if err != nil {
+			return nil, err
+		}
+		if filter != nil {
+			filters = append(filters, filter)
+		}
+	}
+	return filters, nil
+}
+
+// matchesFilters reports whether an entry passes every filter
+func matchesFilters(filters []entryFilter, serverID string, config map[string]interface{}) bool {
+	for _, filter := range filters {
+		if !filter(serverID, config) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBoolParam parses an optional boolean query parameter
+func parseBoolParam(r *http.Request, name string) (value bool, present bool, err error) {
+	switch r.URL.Query().Get(name) {
+	case "":
+		return false, false, nil
+	case "true", "1":
+		return true, true, nil
+	case "false", "0":
+		return false, true, nil
+	default:
+		return false, true, fmt.Errorf("Query parameter '%s' must be true or false", name)
+	}
+}
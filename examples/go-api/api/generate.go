@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ConfigOptions are caller preferences applied while generating configs
+type ConfigOptions struct {
+	// Pin is one of "exact", "minor" or "latest"
+	Pin string
+	// SecretsBackend selects how required secrets are referenced
+	SecretsBackend string
+	// SecretsPrefix is the vault, path or namespace secrets live under
+	SecretsPrefix string
+}
+
+// defaultConfigOptions pins exact versions so generated configs are reproducible
+var defaultConfigOptions = ConfigOptions{Pin: "exact", SecretsBackend: "placeholder"}
+
+var pinModes = map[string]bool{
+	"exact":  true,
+	"minor":  true,
+	"latest": true,
+}
+
+// PackageSpec is the installable package declared by a catalog entry
+type PackageSpec struct {
+	Name     string `json:"name"`
+	Registry string `json:"registry"`
+	Version  string `json:"version"`
+	// KnownGoodVersion is the newest version enrichment verified to work
+	KnownGoodVersion string `json:"known_good_version,omitempty"`
+}
+
+// entryPackage decodes the "package" block of a catalog entry
+func entryPackage(config map[string]interface{}) (PackageSpec, bool) {
+	raw, ok := config["package"].(map[string]interface{})
+	if !ok {
+		return PackageSpec{}, false
+	}
+	pkg := PackageSpec{
+		Name:             getString(raw, "name", ""),
+		Registry:         getString(raw, "registry", "npm"),
+		Version:          getString(raw, "version", "latest"),
+		KnownGoodVersion: getString(raw, "known_good_version", ""),
+	}
+	return pkg, pkg.Name != ""
+}
+
+// pinnedVersion resolves the version to emit for a package under a pin
+// mode. An empty result means the package floats to latest.
+func pinnedVersion(pkg PackageSpec, pin string) string {
+	version := pkg.KnownGoodVersion
+	if version == "" && pkg.Version != "latest" {
+		version = pkg.Version
+	}
+	if version == "" || pin == "latest" {
+		return ""
+	}
+	if pin == "minor" {
+		if parts := strings.SplitN(version, ".", 3); len(parts) >= 2 {
+			return parts[0] + "." + parts[1]
+		}
+	}
+	return version
+}
+
+// mcpServerConfig builds the client-side launch config for a server
+func mcpServerConfig(serverID string, opts ConfigOptions) map[string]interface{} {
+	config, _ := getEntry(serverID)
+	return packageLaunchConfig(serverID, config, opts)
+}
+
+// packageLaunchConfig builds the launch config for the package of an
+// entry, which may be narrowed to one of its distributions
+func packageLaunchConfig(serverID string, config map[string]interface{}, opts ConfigOptions) map[string]interface{} {
+	pkg, ok := entryPackage(config)
+	if !ok {
+		return map[string]interface{}{
+			"command": "npx",
+			"args":    []string{"-y", fmt.Sprintf("@modelcontextprotocol/server-%s", serverID)},
+		}
+	}
+
+	version := pinnedVersion(pkg, opts.Pin)
+	var command string
+	var args []string
+	switch pkg.Registry {
+	case "pypi":
+		command = "uvx"
+		switch {
+		case version == "":
+			args = []string{pkg.Name}
+		case opts.Pin == "minor":
+			args = []string{pkg.Name + "==" + version + ".*"}
+		default:
+			args = []string{pkg.Name + "==" + version}
+		}
+	case "docker":
+		command = "docker"
+		image := pkg.Name
+		if version != "" {
+			image += ":" + version
+		}
+		args = []string{"run", "-i", "--rm", image}
+	case "binary":
+		// Installed on PATH or, by generate-config, in the binary directory
+		command = pkg.Name
+		args = []string{}
+	default:
+		command = "npx"
+		name := pkg.Name
+		if version != "" {
+			name += "@" + version
+		}
+		args = []string{"-y", name}
+	}
+
+	serverConfig, _ := config["config"].(map[string]interface{})
+	extraArgs, _ := serverConfig["args"].([]interface{})
+	for _, arg := range extraArgs {
+		if str, ok := arg.(string); ok {
+			args = append(args, str)
+		}
+	}
+
+	return map[string]interface{}{
+		"command": command,
+		"args":    args,
+	}
+}
+
+func generateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts := defaultConfigOptions
+	if pin := r.URL.Query().Get("pin"); pin != "" {
+		if !pinModes[pin] {
+			writeError(w, http.StatusBadRequest, "Query parameter 'pin' must be one of latest, minor, exact")
+			return
+		}
+		opts.Pin = pin
+	}
+
+	limitBody(w, r)
+	req, err := parseGenerateConfigRequest(r.Body)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if err := checkExperimental(req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if tenant, policy, ok := requestPolicy(r); ok {
+		if violations := evaluatePolicy(r, policy, req.Servers); len(violations) > 0 {
+			writePolicyViolations(w, tenant, violations)
+			return
+		}
+	}
+
+	if req.SecretsBackend != "" {
+		opts.SecretsBackend = req.SecretsBackend
+	}
+	opts.SecretsPrefix = req.SecretsPrefix
+	if req.Format == "terraform" {
+		writeTerraformConfig(w, r, req, opts)
+		return
+	}
+
+	mcpServers := make(map[string]interface{})
+	pinned := make(map[string]string)
+	channels := make(map[string]string)
+	commands := make(map[string][]string)
+	wrappers := make(map[string]*WrapperScript)
+	var binaries []*BinaryInstall
+	placeholders := false
+	var included []string
+	var warnings []string
+
+	client, err := clientProfileVersion(req.Format, req.ClientVersion)
+	if unsupported, ok := err.(*UnsupportedClientError); ok {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":           unsupported.Error(),
+			"minimum_version": unsupported.MinimumVersion,
+		})
+		return
+	}
+	var bridges []*Bridge
+
+	selected, dependencyNotes := withRequiredDependencies(req.Servers)
+	for _, serverID := range selected {
+		entry, exists := tenantEntry(r, serverID)
+		if !exists {
+			continue
+		}
+		var mcpConfig map[string]interface{}
+		var bridge *Bridge
+		var binary *BinaryInstall
+		if _, listed := entry["distributions"]; listed || len(req.Channels) > 0 {
+			var distribution Distribution
+			entry, distribution, mcpConfig, bridge, err = launchDistribution(serverID, entry, req.Channels, req.OS, opts, client, len(bridges))
+			if err == nil && distribution.Channel != "" {
+				channels[serverID] = distribution.Channel
+				if len(req.Channels) > 0 && !slices.Contains(req.Channels, distribution.Channel) {
+					warnings = append(warnings, fmt.Sprintf("'%s' is not available over %s; installing it from %s", serverID, strings.Join(req.Channels, "/"), distribution.Channel))
+				}
+				if distribution.Channel == "binary" {
+					binary = installBinary(serverID, distribution, mcpConfig, req.BinaryDir, req.OS)
+				}
+			}
+		} else {
+			mcpConfig, bridge, err = launchConfig(serverID, entry, opts, client, len(bridges))
+		}
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		included = append(included, serverID)
+		// The install script puts binaries in place, so they are no
+		// prerequisite
+		if binary != nil {
+			binaries = append(binaries, binary)
+		} else {
+			commands[serverID] = launchCommands(mcpConfig, bridge)
+		}
+		if wrapper := wrapLaunchConfig(serverID, entry, mcpConfig, req.WrapperDir); wrapper != nil {
+			wrappers[serverID] = wrapper
+		}
+		if env := secretEnv(serverID, entry, opts); len(env) > 0 {
+			placeholders = placeholders || opts.SecretsBackend == "placeholder"
+			if bridge != nil && len(bridge.Command) > 0 {
+				bridge.Env = env
+			} else if _, local := mcpConfig["command"]; local {
+				mcpConfig["env"] = env
+			}
+		}
+		if bridge != nil {
+			bridges = append(bridges, bridge)
+		}
+		mcpServers[serverID] = mcpConfig
+
+		if pkg, ok := entryPackage(entry); ok {
+			if version := pinnedVersion(pkg, opts.Pin); version != "" {
+				pinned[serverID] = version
+			} else if opts.Pin != "latest" {
+				warnings = append(warnings, fmt.Sprintf("No known-good version for '%s'; it will float to latest", serverID))
+			}
+		}
+	}
+
+	recordInstall(included)
+
+	conflicts := detectToolConflicts(included)
+	warnings = append(warnings, conflictWarnings(conflicts, client)...)
+	warnings = append(warnings, clientLimitWarnings(client, included)...)
+	prerequisites := collectPrerequisites(commands)
+	notes := installationNotes(req.Format, noteOS(req.OS, r), noteLanguage(req.Lang, r), included, placeholders)
+	installSteps := binaryInstallSteps(binaries)
+	if req.InlineWrappers {
+		installSteps += wrapperInstallSteps(wrappers, included)
+	}
+
+	config := client.Shape.writeConfig(mcpServers)
+	response := map[string]interface{}{
+		"format":             req.Format,
+		"config":             config,
+		"servers_included":   included,
+		"pin":                opts.Pin,
+		"pinned_versions":    pinned,
+		"secrets_backend":    opts.SecretsBackend,
+		"recommendations":    recommendFor(included),
+		"tool_conflicts":     conflicts,
+		"bridges":            bridges,
+		"firewall_notes":     firewallNotes(included),
+		"risk_summary":       riskSummary(included),
+		"prerequisites":      prerequisites,
+		"install_script":     installScript(prerequisites, installSteps, config),
+		"installation_notes": notes.Text(),
+		"installation_steps": notes,
+		"post_install":       postInstallChecks(req.Format, included, mcpServers, bridges),
+	}
+	if req.ClientVersion != "" {
+		response["client_version"] = req.ClientVersion
+	}
+	if len(channels) > 0 {
+		response["channels"] = channels
+	}
+	if len(binaries) > 0 {
+		response["binaries"] = binaries
+	}
+	if len(dependencyNotes) > 0 {
+		response["dependency_notes"] = dependencyNotes
+	}
+	if len(wrappers) > 0 {
+		response["wrapper_scripts"] = wrappers
+		if !req.InlineWrappers {
+			warnings = append(warnings, fmt.Sprintf("Install the wrapper scripts under %s before starting the client, or set inline_wrappers", req.WrapperDir))
+		}
+	}
+	if req.SuggestProfiles && exceedsClientLimits(client, included) {
+		response["suggested_profiles"] = suggestProfiles(client, included)
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	recordGeneratedConfig(r, req, response)
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GeneratedConfig is a recorded generate-config call: what was asked and
+// exactly what was answered, kept for cfg.GeneratedConfigTTL so support
+// can see what a user was given
+type GeneratedConfig struct {
+	ID string `json:"id"`
+	// Tenant is the API key user that asked; only they and admins can
+	// read the record. Calls made with the admin token have no tenant.
+	Tenant    string    `json:"tenant,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Snapshot is the catalog served at the time, readable again with
+	// ?at_revision
+	Snapshot string                `json:"snapshot,omitempty"`
+	Request  GenerateConfigRequest `json:"request"`
+	Query    string                `json:"query,omitempty"`
+	// Headers are the request headers that shape the answer
+	Headers  map[string]string      `json:"headers,omitempty"`
+	Response map[string]interface{} `json:"response"`
+}
+
+// generatedConfigHeaders pick the installation notes' OS and language
+// when the request does not
+var generatedConfigHeaders = []string{"User-Agent", "Accept-Language"}
+
+// generatedConfigSweep is how often expired records are deleted
+const generatedConfigSweep = time.Hour
+
+func generatedConfigPath(id string) string {
+	return dataPath("generated-configs/" + id + ".json")
+}
+
+// recordGeneratedConfig stores a generate-config answer and stamps its ID
+// on the response. Only calls of API key users and admins are stored, so
+// anonymous callers cannot fill the disk; nothing is stored when
+// generate.audit_ttl is 0.
+func recordGeneratedConfig(r *http.Request, req GenerateConfigRequest, response map[string]interface{}) {
+	if cfg.GeneratedConfigTTL <= 0 {
+		return
+	}
+	user, identified := apiKeyUser(r)
+	if !identified && !isAdmin(r) {
+		return
+	}
+	now := time.Now().UTC()
+	record := GeneratedConfig{
+		ID:        newID(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(cfg.GeneratedConfigTTL),
+		Request:   req,
+		Query:     r.URL.RawQuery,
+		Response:  response,
+	}
+	record.Tenant = user
+	snapshotsMu.Lock()
+	record.Snapshot = activeSnapshot
+	snapshotsMu.Unlock()
+	for _, name := range generatedConfigHeaders {
+		if value := r.Header.Get(name); value != "" {
+			if record.Headers == nil {
+				record.Headers = make(map[string]string)
+			}
+			record.Headers[name] = value
+		}
+	}
+
+	response["id"] = record.ID
+	if err := writeJSONFile(generatedConfigPath(record.ID), record); err != nil {
+		log.Printf("❌ Failed to record generated config %s: %v", record.ID, err)
+		delete(response, "id")
+	}
+}
+
+// startGeneratedConfigSweep deletes expired records now and every
+// generatedConfigSweep
+func startGeneratedConfigSweep() {
+	go func() {
+		for {
+			sweepGeneratedConfigs(time.Now())
+			time.Sleep(generatedConfigSweep)
+		}
+	}()
+}
+
+func sweepGeneratedConfigs(now time.Time) {
+	paths, err := filepath.Glob(generatedConfigPath("*"))
+	if err != nil {
+		return
+	}
+	removed := 0
+	for _, path := range paths {
+		var record GeneratedConfig
+		if err := readJSONFile(path, &record); err != nil || now.After(record.ExpiresAt) {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	}
+	if removed > 0 {
+		log.Printf("🧹 Deleted %d expired generated configs", removed)
+	}
+}
+
+// generatedConfigHandler returns a recorded generate-config call to the
+// tenant that made it or to an admin. Other callers, like expired and
+// unknown IDs, get 404.
+func generatedConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.PathValue("id")
+	notFound := fmt.Sprintf("Generated config '%s' not found", id)
+	if strings.ContainsAny(id, `/\.`) {
+		writeError(w, http.StatusNotFound, notFound)
+		return
+	}
+	var record GeneratedConfig
+	if err := readJSONFile(generatedConfigPath(id), &record); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	user, _ := apiKeyUser(r)
+	allowed := isAdmin(r) || record.Tenant != "" && record.Tenant == user
+	if record.ID == "" || time.Now().After(record.ExpiresAt) || !allowed {
+		writeError(w, http.StatusNotFound, notFound)
+		return
+	}
+	json.NewEncoder(w).Encode(record)
+}
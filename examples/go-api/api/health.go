@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthComponents are the parts /health reports on. A failing critical
+// component makes the service unhealthy and /health answer 503; anything
+// else failing or degraded makes it degraded.
+var healthComponents = []struct {
+	name     string
+	critical bool
+}{
+	{"catalog", true},
+	{"store", true},
+	{"search_index", false},
+	{"sync", false},
+	{"enrichment", false},
+	{"webhooks", false},
+	{"notifications", false},
+}
+
+// storeFailingAfter is how many store writes failing in a row make the
+// store fail; fewer, such as one write hitting a full disk for a moment,
+// only degrade it
+const storeFailingAfter = 3
+
+// ComponentHealth is the state of one component. Status is ok, degraded,
+// failing or disabled; Failures counts the errors since the last success
+// of a component that reports them.
+type ComponentHealth struct {
+	Status      string     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	Failures    int        `json:"failures,omitempty"`
+	Detail      string     `json:"detail,omitempty"`
+}
+
+// componentEvents holds the last success and error of the components that
+// report them as they work: catalog, store, webhooks and notifications
+var componentEvents = struct {
+	sync.Mutex
+	byName map[string]ComponentHealth
+}{byName: make(map[string]ComponentHealth)}
+
+// recordComponent notes a success (nil) or failure of a component
+func recordComponent(name string, err error) {
+	now := time.Now().UTC()
+	componentEvents.Lock()
+	defer componentEvents.Unlock()
+	health := componentEvents.byName[name]
+	if err == nil {
+		health.LastSuccess, health.Failures = &now, 0
+	} else {
+		health.LastError, health.LastErrorAt = err.Error(), &now
+		health.Failures++
+	}
+	componentEvents.byName[name] = health
+}
+
+// recordedHealth is a recorded component's state: failing while its last
+// event is an error, disabled before any event
+func recordedHealth(name string) ComponentHealth {
+	componentEvents.Lock()
+	health, seen := componentEvents.byName[name]
+	componentEvents.Unlock()
+	switch {
+	case !seen:
+		health.Status = "disabled"
+	case health.LastErrorAt != nil && (health.LastSuccess == nil || health.LastErrorAt.After(*health.LastSuccess)):
+		health.Status = "failing"
+	default:
+		health.Status = "ok"
+	}
+	return health
+}
+
+// componentHealth checks one component
+func componentHealth(r *http.Request, name string) ComponentHealth {
+	switch name {
+	case "store":
+		health := recordedHealth(name)
+		switch {
+		case health.Status == "disabled":
+			// Nothing was written yet, which is fine
+			health.Status = "ok"
+		case health.Status == "failing" && health.Failures < storeFailingAfter:
+			health.Status = "degraded"
+		}
+		health.Detail = cfg.DataDir
+		return health
+	case "search_index":
+		index := searchBackend.Health(r.Context())
+		health := ComponentHealth{Status: index.Status, LastSuccess: index.IndexedAt, LastError: index.Error,
+			Detail: fmt.Sprintf("%s, %d documents", index.Backend, index.Documents)}
+		switch index.Status {
+		case "reindexing":
+			health.Status = "ok"
+		case "unavailable":
+			health.Status = "failing"
+		}
+		return health
+	case "sync":
+		upstreams := upstreamStatuses()
+		if len(upstreams) == 0 {
+			return ComponentHealth{Status: "disabled"}
+		}
+		health := ComponentHealth{Status: "ok"}
+		stale := 0
+		for namespace, upstream := range upstreams {
+			if upstream.SyncedAt != nil && (health.LastSuccess == nil || upstream.SyncedAt.After(*health.LastSuccess)) {
+				health.LastSuccess = upstream.SyncedAt
+			}
+			if upstream.LastError != "" {
+				health.LastError = namespace + ": " + upstream.LastError
+			}
+			if upstream.Stale {
+				stale++
+			}
+		}
+		switch {
+		case stale == len(upstreams):
+			health.Status = "failing"
+		case stale > 0:
+			health.Status = "degraded"
+		}
+		health.Detail = fmt.Sprintf("%d of %d upstreams stale", stale, len(upstreams))
+		return health
+	case "enrichment":
+		progress := enrichmentProgress()
+		if progress.Total == 0 && progress.Status == "idle" {
+			return ComponentHealth{Status: "disabled"}
+		}
+		health := ComponentHealth{Status: "ok", LastSuccess: progress.FinishedAt,
+			Detail: fmt.Sprintf("%s: %d fetched, %d failed, %d stale of %d", progress.Status, progress.Fetched, progress.Failed, progress.Stale, progress.Total)}
+		if n := len(progress.Errors); n > 0 {
+			last := progress.Errors[n-1]
+			health.LastError = fmt.Sprintf("%s %s: %s", last.ServerID, last.Kind, last.Error)
+		}
+		if progress.Failed > 0 || progress.Stale > 0 {
+			health.Status = "degraded"
+		}
+		return health
+	}
+	return recordedHealth(name)
+}
+
+// healthHandler reports the health of every component and the overall
+// status they add up to; an unhealthy service answers 503 so load
+// balancers take it out of rotation
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := "healthy"
+	components := make(map[string]ComponentHealth, len(healthComponents))
+	for _, component := range healthComponents {
+		health := componentHealth(r, component.name)
+		health.Critical = component.critical
+		components[component.name] = health
+		switch {
+		case health.Status == "failing" && component.critical:
+			status = "unhealthy"
+		case (health.Status == "failing" || health.Status == "degraded") && status == "healthy":
+			status = "degraded"
+		}
+	}
+
+	response := map[string]interface{}{
+		"status":          status,
+		"server_count":    len(servers),
+		"catalog_version": "2.0.0",
+		"api_version":     "v1",
+		"components":      components,
+	}
+	if cfg.ReadOnly {
+		response["read_only"] = true
+	}
+	if status == "unhealthy" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
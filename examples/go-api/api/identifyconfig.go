@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// IdentifiedServer is an mcpServers entry of a client config mapped back
+// to the catalog entry it runs
+type IdentifiedServer struct {
+	Key      string `json:"key"`
+	ServerID string `json:"server_id"`
+	// MatchedBy is package, url or name: the launched package, the remote
+	// endpoint or, failing both, the config key
+	MatchedBy string `json:"matched_by"`
+	Package   string `json:"package,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// UnknownServer is an mcpServers entry no catalog entry matches
+type UnknownServer struct {
+	Key     string `json:"key"`
+	Command string `json:"command,omitempty"`
+	Package string `json:"package,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// OutdatedPin is an identified server pinned below the version the
+// catalog knows to be good
+type OutdatedPin struct {
+	Key       string `json:"key"`
+	ServerID  string `json:"server_id"`
+	Package   string `json:"package"`
+	Version   string `json:"version"`
+	Available string `json:"available"`
+	// Yanked is set when the pinned version was yanked upstream
+	Yanked bool `json:"yanked,omitempty"`
+}
+
+// AdvisedServer is an identified server with open advisories
+type AdvisedServer struct {
+	Key        string     `json:"key"`
+	ServerID   string     `json:"server_id"`
+	Advisories []Advisory `json:"advisories"`
+}
+
+// launcherAliases map other ways of running a package to the launcher
+// parseLaunchedPackage knows, with the arguments they drop
+var launcherAliases = []struct {
+	command  string
+	prefix   []string
+	launcher string
+}{
+	{"bunx", nil, "npx"},
+	{"pnpm", []string{"dlx"}, "npx"},
+	{"yarn", []string{"dlx"}, "npx"},
+	{"pipx", []string{"run"}, "uvx"},
+	{"uv", []string{"tool", "run"}, "uvx"},
+}
+
+// identifyLaunch recognizes the package a hand-written launch command
+// runs: the command may be a full path or a Windows "cmd /c" wrapper, and
+// may use another launcher than the ones generate-config emits
+func identifyLaunch(command string, args []string) (launchedPackage, bool) {
+	command = strings.TrimSuffix(path.Base(strings.ReplaceAll(command, `\`, "/")), ".cmd")
+	command = strings.TrimSuffix(command, ".exe")
+	if command == "cmd" && len(args) >= 2 && strings.EqualFold(args[0], "/c") {
+		return identifyLaunch(args[1], args[2:])
+	}
+	for _, alias := range launcherAliases {
+		if command != alias.command || len(args) < len(alias.prefix) {
+			continue
+		}
+		if prefix := args[:len(alias.prefix)]; strings.Join(prefix, " ") == strings.Join(alias.prefix, " ") {
+			return parseLaunchedPackage(alias.launcher, args[len(alias.prefix):])
+		}
+	}
+	return parseLaunchedPackage(command, args)
+}
+
+// identifyServer finds the catalog entry an mcpServers entry runs: by the
+// package it launches or the endpoint it connects to in any of its
+// distributions, or else its key as an ID, legacy ID or name
+func identifyServer(key string, launched launchedPackage, endpoint string) (string, string, bool) {
+	var ids []string
+	for serverID := range servers {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+	if launched.Name != "" {
+		for _, serverID := range ids {
+			config, _ := getEntry(serverID)
+			for _, distribution := range entryDistributions(config) {
+				if pkg, ok := distribution.packageSpec(); ok && pkg.Name == launched.Name && pkg.Registry == launched.Registry {
+					return serverID, "package", true
+				}
+			}
+		}
+	}
+	if endpoint != "" {
+		for _, serverID := range ids {
+			config, _ := getEntry(serverID)
+			for _, distribution := range entryDistributions(config) {
+				if distribution.URL != "" && strings.TrimSuffix(distribution.URL, "/") == strings.TrimSuffix(endpoint, "/") {
+					return serverID, "url", true
+				}
+			}
+		}
+	}
+	if _, exists := getEntry(canonicalID(key)); exists {
+		return canonicalID(key), "name", true
+	}
+	for _, serverID := range ids {
+		config, _ := getEntry(serverID)
+		if foldText(getString(config, "name", "")) == foldText(key) {
+			return serverID, "name", true
+		}
+	}
+	return "", "", false
+}
+
+// configuredServer is an mcpServers entry of a client config and the
+// catalog entry it runs, if any
+type configuredServer struct {
+	key      string
+	command  string
+	args     []string
+	endpoint string
+	launched launchedPackage
+	// serverID is empty when no catalog entry matches
+	serverID  string
+	matchedBy string
+}
+
+// readConfiguredServer reads and identifies one mcpServers entry
+func readConfiguredServer(key string, raw interface{}) configuredServer {
+	server, _ := raw.(map[string]interface{})
+	configured := configuredServer{key: key, command: getString(server, "command", ""), endpoint: getString(server, "url", "")}
+	configured.args, _ = stringList(server["args"])
+	configured.launched, _ = identifyLaunch(configured.command, configured.args)
+	// mcp-remote relays stdio to the remote server it names
+	if configured.launched.Name == "mcp-remote" {
+		configured.launched = launchedPackage{}
+		for _, arg := range configured.args {
+			if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+				configured.endpoint = arg
+			}
+		}
+	}
+	configured.serverID, configured.matchedBy, _ = identifyServer(key, configured.launched, configured.endpoint)
+	return configured
+}
+
+// packageName is the launched package as "registry:name"
+func (c configuredServer) packageName() string {
+	if c.launched.Name == "" {
+		return ""
+	}
+	return c.launched.Registry + ":" + c.launched.Name
+}
+
+// launchedSpec is the package of the identified entry's distribution that
+// the server launches
+func (c configuredServer) launchedSpec() (PackageSpec, bool) {
+	config, _ := getEntry(c.serverID)
+	for _, distribution := range entryDistributions(config) {
+		if pkg, ok := distribution.packageSpec(); ok && pkg.Name == c.launched.Name && pkg.Registry == c.launched.Registry {
+			return pkg, true
+		}
+	}
+	return PackageSpec{}, false
+}
+
+// outdatedPin reports the known-good version an identified server's pin
+// is behind, and whether the pinned version was yanked. A pin naming a
+// minor version, such as ==1.2.*, is compared and bumped at that precision.
+func (c configuredServer) outdatedPin() (available string, yanked bool, outdated bool) {
+	pkg, ok := c.launchedSpec()
+	if !ok || c.launched.Version == "" || c.launched.Version == "latest" {
+		return "", false, false
+	}
+	available = pinnedVersion(pkg, "exact")
+	precision := strings.Count(c.launched.Version, ".") + 1
+	if parts := strings.Split(available, "."); precision < len(parts) {
+		available = strings.Join(parts[:precision], ".")
+	}
+	if available == "" || compareVersions(c.launched.Version, available) >= 0 {
+		return "", false, false
+	}
+	if result := entryVerification(c.serverID); result != nil {
+		yanked = slices.Contains(result.YankedVersions, c.launched.Version)
+	}
+	return available, yanked, true
+}
+
+// sortedServerKeys returns the keys of an mcpServers object in order
+func sortedServerKeys(mcpServers map[string]interface{}) []string {
+	keys := make([]string, 0, len(mcpServers))
+	for key := range mcpServers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// identifyConfigHandler maps the servers of an existing client config, in
+// any client's shape, back to catalog entries: what is
+// installed, what the catalog does not know, which pins are behind the
+// known-good version and which servers have open advisories
+func identifyConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limitBody(w, r)
+	req, err := parseIdentifyConfigRequest(r.Body)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	mcpServers, ok := readClientConfig(req.Config)
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "The config needs an 'mcpServers' or 'servers' object")
+		return
+	}
+	keys := sortedServerKeys(mcpServers)
+
+	advisories := serverAdvisories()
+	matches, unknown := []IdentifiedServer{}, []UnknownServer{}
+	outdated, advised := []OutdatedPin{}, []AdvisedServer{}
+	for _, key := range keys {
+		configured := readConfiguredServer(key, mcpServers[key])
+		if configured.serverID == "" {
+			unknown = append(unknown, UnknownServer{Key: key, Command: configured.command, Package: configured.packageName(), URL: configured.endpoint})
+			continue
+		}
+		serverID := configured.serverID
+		matches = append(matches, IdentifiedServer{Key: key, ServerID: serverID, MatchedBy: configured.matchedBy, Package: configured.packageName(), Version: configured.launched.Version})
+		if available, yanked, behind := configured.outdatedPin(); behind {
+			outdated = append(outdated, OutdatedPin{Key: key, ServerID: serverID, Package: configured.packageName(), Version: configured.launched.Version, Available: available, Yanked: yanked})
+		}
+		if open := advisories[serverID]; len(open) > 0 {
+			advised = append(advised, AdvisedServer{Key: key, ServerID: serverID, Advisories: open})
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":      len(keys),
+		"matches":    matches,
+		"unknown":    unknown,
+		"outdated":   outdated,
+		"advisories": advised,
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// serverNamePattern matches the name part of a "vendor/name" server ID
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// namespacedID joins a vendor ID and a server name into a server ID
+func namespacedID(vendor, name string) string {
+	return vendor + "/" + name
+}
+
+// entryLegacyIDs returns the flat IDs an entry was served under before
+// server IDs were namespaced
+func entryLegacyIDs(config map[string]interface{}) []string {
+	ids, _ := stringList(config["legacy_ids"])
+	return ids
+}
+
+// legacyIDs maps every legacy ID of the served entries to the entry's ID
+var (
+	legacyIDsMu sync.RWMutex
+	legacyIDs   = make(map[string]string)
+)
+
+// indexLegacyIDs rebuilds the legacy ID index from the served registry
+func indexLegacyIDs() {
+	index := make(map[string]string)
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		for _, legacy := range entryLegacyIDs(config) {
+			if _, taken := servers[legacy]; !taken {
+				index[legacy] = serverID
+			}
+		}
+	}
+	legacyIDsMu.Lock()
+	legacyIDs = index
+	legacyIDsMu.Unlock()
+}
+
+// canonicalID returns the ID of the entry a legacy ID now names, or id
+// itself
+func canonicalID(id string) string {
+	legacyIDsMu.RLock()
+	defer legacyIDsMu.RUnlock()
+	if current, ok := legacyIDs[id]; ok {
+		return current
+	}
+	return id
+}
+
+// canonicalKeys moves the records of a store keyed by server ID from
+// legacy IDs to the current ones
+func canonicalKeys[V any](records map[string]V) map[string]V {
+	for id, record := range records {
+		if current := canonicalID(id); current != id {
+			if _, exists := records[current]; !exists {
+				records[current] = record
+			}
+			delete(records, id)
+		}
+	}
+	return records
+}
+
+// checkNewServerID applies the ID policy to entries added through the
+// admin API and imports: IDs are "vendor/name" under the entry's own
+// vendor, and unique among current and legacy IDs ignoring case, so
+// lookalikes such as "acme/GitHub" next to "acme/github" are refused
+func checkNewServerID(serverID string, entry map[string]interface{}) error {
+	vendor, name, ok := strings.Cut(serverID, "/")
+	if !ok || !vendorIDPattern.MatchString(vendor) || !serverNamePattern.MatchString(name) {
+		return badRequest("Server ID '%s' must be vendor/name, e.g. %s", serverID, namespacedID(entryVendor(entry), "my-server"))
+	}
+	if vendor != entryVendor(entry) {
+		return badRequest("Server ID '%s' must start with the entry's vendor, '%s/'", serverID, entryVendor(entry))
+	}
+	for existing := range servers {
+		if strings.EqualFold(existing, serverID) {
+			return &requestError{status: http.StatusConflict, message: fmt.Sprintf("Server '%s' already exists as '%s'", serverID, existing)}
+		}
+	}
+	legacyIDsMu.RLock()
+	defer legacyIDsMu.RUnlock()
+	for legacy, current := range legacyIDs {
+		if strings.EqualFold(legacy, serverID) {
+			return &requestError{status: http.StatusConflict, message: fmt.Sprintf("Server ID '%s' is the legacy ID of '%s'", serverID, current)}
+		}
+	}
+	return nil
+}
+
+// serverSubresources are the per-server endpoints under
+// /api/v1/servers/{id}/
+var serverSubresources = map[string]http.HandlerFunc{
+	"related":   relatedServersHandler,
+	"graph":     dependencyGraphHandler,
+	"reviews":   serverReviewsHandler,
+	"changelog": serverChangelogHandler,
+	"stats":     serverStatsHandler,
+	"try":       tryServerHandler,
+	"report":    reportServerHandler,
+	"examples":  serverExamplesHandler,
+}
+
+// serverPathHandler routes /api/v1/servers/{id}[/{subresource}]. An exact
+// entry ID wins over a subresource suffix, and legacy IDs are redirected
+// to the current URL with 308 so clients keep the method and body.
+func serverPathHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/servers/")
+	var handler http.HandlerFunc = getServerHandler
+	suffix := ""
+	if _, exists := getEntry(canonicalID(id)); !exists {
+		if parent, sub, ok := cutLast(id); ok && serverSubresources[sub] != nil {
+			id, handler, suffix = parent, serverSubresources[sub], "/"+sub
+		}
+	}
+	if current := canonicalID(id); current != id {
+		target := "/api/v1/servers/" + current + suffix
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+		return
+	}
+	r.SetPathValue("id", id)
+	handler(w, r)
+}
+
+// cutLast splits a path at its last slash
+func cutLast(path string) (before, after string, found bool) {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i], path[i+1:], true
+	}
+	return path, "", false
+}
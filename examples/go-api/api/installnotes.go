@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// installOSes are the values of the generate-config "os" field
+var installOSes = []string{"macos", "windows", "linux"}
+
+// clientConfigPaths is where each client reads its MCP config, per OS.
+// "*" applies to every OS; clients without an entry are configured in
+// their UI.
+var clientConfigPaths = map[string]map[string]string{
+	"claude_desktop": {
+		"macos":   "~/Library/Application Support/Claude/claude_desktop_config.json",
+		"windows": `%APPDATA%\Claude\claude_desktop_config.json`,
+		"linux":   "~/.config/Claude/claude_desktop_config.json",
+	},
+	"claude_code": {"*": ".mcp.json"},
+	"cursor": {
+		"macos":   "~/.cursor/mcp.json",
+		"windows": `%USERPROFILE%\.cursor\mcp.json`,
+		"linux":   "~/.cursor/mcp.json",
+	},
+	"vscode": {"*": ".vscode/mcp.json"},
+	"windsurf": {
+		"macos":   "~/.codeium/windsurf/mcp_config.json",
+		"windows": `%USERPROFILE%\.codeium\windsurf\mcp_config.json`,
+		"linux":   "~/.codeium/windsurf/mcp_config.json",
+	},
+}
+
+// installNoteTemplates are the step templates per language. "restart."
+// keys are per client with "restart" as the fallback; the data is
+// installNoteData.
+var installNoteTemplates = map[string]map[string]string{
+	"en": {
+		"file":                   "Open {{.Path}} (create it if it does not exist yet).",
+		"no_file":                "{{.Client}} is not configured with a file: add each server in its settings under connectors.",
+		"merge":                  "Merge the generated mcpServers block into the file, keeping servers that are already configured.",
+		"secrets":                "Replace the ${...} placeholders with your own credentials.",
+		"restart":                "Restart {{.Client}}.",
+		"restart.claude_desktop": "{{if eq .OS \"macos\"}}Quit Claude Desktop with Cmd+Q{{else}}Quit Claude Desktop from the system tray{{end}} and open it again; closing the window is not enough.",
+		"restart.claude_code":    "Start a new Claude Code session in the project and approve the project servers when asked.",
+		"restart.cursor":         "Open Cursor Settings > MCP and refresh the server list.",
+		"restart.vscode":         "Run \"MCP: List Servers\" from the Command Palette and start the new servers.",
+		"restart.windsurf":       "Open Windsurf Settings > Cascade > MCP servers and press Refresh.",
+		"verify":                 "Check that {{.Servers}} appear in the tool list of {{.Client}}. If one is missing, look at the MCP logs of {{.Client}}.",
+	},
+	"de": {
+		"file":                   "Öffnen Sie {{.Path}} (legen Sie die Datei an, falls sie noch nicht existiert).",
+		"no_file":                "{{.Client}} wird nicht über eine Datei konfiguriert: Fügen Sie jeden Server in den Einstellungen unter Konnektoren hinzu.",
+		"merge":                  "Übernehmen Sie den erzeugten mcpServers-Block in die Datei und behalten Sie bereits konfigurierte Server bei.",
+		"secrets":                "Ersetzen Sie die Platzhalter ${...} durch Ihre eigenen Zugangsdaten.",
+		"restart":                "Starten Sie {{.Client}} neu.",
+		"restart.claude_desktop": "{{if eq .OS \"macos\"}}Beenden Sie Claude Desktop mit Cmd+Q{{else}}Beenden Sie Claude Desktop über den Infobereich der Taskleiste{{end}} und öffnen Sie es erneut; das Fenster zu schließen reicht nicht.",
+		"restart.claude_code":    "Starten Sie eine neue Claude-Code-Sitzung im Projekt und bestätigen Sie die Projektserver, wenn Sie gefragt werden.",
+		"restart.cursor":         "Öffnen Sie Cursor Settings > MCP und aktualisieren Sie die Serverliste.",
+		"restart.vscode":         "Führen Sie \"MCP: List Servers\" in der Befehlspalette aus und starten Sie die neuen Server.",
+		"restart.windsurf":       "Öffnen Sie Windsurf Settings > Cascade > MCP servers und klicken Sie auf Refresh.",
+		"verify":                 "Prüfen Sie, ob {{.Servers}} in der Werkzeugliste von {{.Client}} erscheinen. Fehlt einer, sehen Sie in den MCP-Logs von {{.Client}} nach.",
+	},
+	"fr": {
+		"file":                   "Ouvrez {{.Path}} (créez le fichier s'il n'existe pas encore).",
+		"no_file":                "{{.Client}} ne se configure pas par fichier : ajoutez chaque serveur dans ses paramètres, rubrique connecteurs.",
+		"merge":                  "Fusionnez le bloc mcpServers généré dans le fichier en conservant les serveurs déjà configurés.",
+		"secrets":                "Remplacez les espaces réservés ${...} par vos propres identifiants.",
+		"restart":                "Redémarrez {{.Client}}.",
+		"restart.claude_desktop": "{{if eq .OS \"macos\"}}Quittez Claude Desktop avec Cmd+Q{{else}}Quittez Claude Desktop depuis la zone de notification{{end}} puis rouvrez-le ; fermer la fenêtre ne suffit pas.",
+		"restart.claude_code":    "Ouvrez une nouvelle session Claude Code dans le projet et approuvez les serveurs du projet lorsque cela vous est demandé.",
+		"restart.cursor":         "Ouvrez Cursor Settings > MCP et actualisez la liste des serveurs.",
+		"restart.vscode":         "Exécutez « MCP: List Servers » depuis la palette de commandes et démarrez les nouveaux serveurs.",
+		"restart.windsurf":       "Ouvrez Windsurf Settings > Cascade > MCP servers et cliquez sur Refresh.",
+		"verify":                 "Vérifiez que {{.Servers}} apparaissent dans la liste des outils de {{.Client}}. S'il en manque un, consultez les journaux MCP de {{.Client}}.",
+	},
+	"es": {
+		"file":                   "Abra {{.Path}} (créelo si todavía no existe).",
+		"no_file":                "{{.Client}} no se configura con un archivo: añada cada servidor en sus ajustes, en la sección de conectores.",
+		"merge":                  "Combine el bloque mcpServers generado con el archivo y conserve los servidores que ya estén configurados.",
+		"secrets":                "Sustituya los marcadores ${...} por sus propias credenciales.",
+		"restart":                "Reinicie {{.Client}}.",
+		"restart.claude_desktop": "{{if eq .OS \"macos\"}}Salga de Claude Desktop con Cmd+Q{{else}}Salga de Claude Desktop desde la bandeja del sistema{{end}} y vuelva a abrirlo; cerrar la ventana no basta.",
+		"restart.claude_code":    "Inicie una nueva sesión de Claude Code en el proyecto y apruebe los servidores del proyecto cuando se le pida.",
+		"restart.cursor":         "Abra Cursor Settings > MCP y actualice la lista de servidores.",
+		"restart.vscode":         "Ejecute \"MCP: List Servers\" desde la paleta de comandos e inicie los servidores nuevos.",
+		"restart.windsurf":       "Abra Windsurf Settings > Cascade > MCP servers y pulse Refresh.",
+		"verify":                 "Compruebe que {{.Servers}} aparecen en la lista de herramientas de {{.Client}}. Si falta alguno, revise los registros MCP de {{.Client}}.",
+	},
+}
+
+// installNoteData is what the note templates can refer to
+type installNoteData struct {
+	Client  string
+	OS      string
+	Path    string
+	Servers string
+}
+
+// InstallationNotes are the localized steps to install a generated config
+type InstallationNotes struct {
+	OS    string   `json:"os"`
+	Lang  string   `json:"lang"`
+	Steps []string `json:"steps"`
+}
+
+// Text numbers the steps for the plain installation_notes string
+func (n InstallationNotes) Text() string {
+	lines := make([]string, len(n.Steps))
+	for i, step := range n.Steps {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, step)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// noteLanguage picks the requested language, then the first supported one
+// of Accept-Language, then English
+func noteLanguage(requested string, r *http.Request) string {
+	candidates := []string{requested}
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		candidates = append(candidates, tag)
+	}
+	for _, candidate := range candidates {
+		lang, _, _ := strings.Cut(strings.ToLower(candidate), "-")
+		if _, ok := installNoteTemplates[lang]; ok {
+			return lang
+		}
+	}
+	return "en"
+}
+
+// noteOS picks the requested OS or guesses it from the User-Agent,
+// defaulting to macOS
+func noteOS(requested string, r *http.Request) string {
+	if requested != "" {
+		return requested
+	}
+	agent := r.Header.Get("User-Agent")
+	switch {
+	case strings.Contains(agent, "Windows"):
+		return "windows"
+	case strings.Contains(agent, "Linux") && !strings.Contains(agent, "Android"):
+		return "linux"
+	}
+	return "macos"
+}
+
+// renderNote fills one step template, falling back to English for keys a
+// language lacks
+func renderNote(lang, key string, data installNoteData) string {
+	text, ok := installNoteTemplates[lang][key]
+	if !ok {
+		text = installNoteTemplates["en"][key]
+	}
+	tmpl, err := template.New(key).Parse(text)
+	if err != nil {
+		return text
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return text
+	}
+	return b.String()
+}
+
+// installationNotes builds the steps for a client, OS and language: where
+// the file goes, what to fill in, how to reload the client and how to
+// check the servers arrived
+func installationNotes(format, os, lang string, included []string, hasSecrets bool) InstallationNotes {
+	data := installNoteData{Client: clientProfile(format).Name, OS: os, Servers: strings.Join(included, ", ")}
+	notes := InstallationNotes{OS: os, Lang: lang}
+
+	paths := clientConfigPaths[format]
+	path, ok := paths[os]
+	if !ok {
+		path, ok = paths["*"]
+	}
+	if ok {
+		data.Path = path
+		notes.Steps = append(notes.Steps, renderNote(lang, "file", data), renderNote(lang, "merge", data))
+	} else {
+		notes.Steps = append(notes.Steps, renderNote(lang, "no_file", data))
+	}
+	if hasSecrets {
+		notes.Steps = append(notes.Steps, renderNote(lang, "secrets", data))
+	}
+	restart := "restart." + format
+	if _, ok := installNoteTemplates["en"][restart]; !ok {
+		restart = "restart"
+	}
+	notes.Steps = append(notes.Steps, renderNote(lang, restart, data), renderNote(lang, "verify", data))
+	return notes
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// jsonAPIMediaType opts a request into JSON:API envelopes
+const jsonAPIMediaType = "application/vnd.api+json"
+
+// wantsJSONAPI reports whether the client asked for JSON:API responses
+func wantsJSONAPI(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), jsonAPIMediaType)
+}
+
+// apiBase is the path prefix of the API version serving a request. Newer
+// versions reach the v1 handlers with their version in the API-Version
+// header, so links built from it stay within the version.
+func apiBase(r *http.Request) string {
+	version, _, err := requestAPIVersion(r)
+	if err != nil {
+		version = 1
+	}
+	return fmt.Sprintf("/api/v%d", version)
+}
+
+// versionedURI is the request URL under the path of its API version
+func versionedURI(r *http.Request) string {
+	return apiBase(r) + strings.TrimPrefix(r.URL.RequestURI(), "/api/v1")
+}
+
+// writeJSONAPI writes a JSON:API top-level document whose self link is the
+// request URL. links adds others, such as the pages of a listing.
+func writeJSONAPI(w http.ResponseWriter, r *http.Request, data interface{}, meta map[string]interface{}, links map[string]string) {
+	w.Header().Set("Content-Type", jsonAPIMediaType)
+	w.Header().Add("Vary", "Accept")
+
+	documentLinks := map[string]string{"self": versionedURI(r)}
+	for rel, link := range links {
+		documentLinks[rel] = link
+	}
+	doc := map[string]interface{}{
+		"jsonapi": map[string]string{"version": "1.1"},
+		"data":    data,
+		"links":   documentLinks,
+	}
+	if len(meta) > 0 {
+		doc["meta"] = meta
+	}
+	json.NewEncoder(w).Encode(doc)
+}
+
+// serverLink is the canonical URL of a server resource under base
+func serverLink(base, serverID string) string {
+	return base + "/servers/" + serverID
+}
+
+// categoryLink lists the servers of a category under base
+func categoryLink(base, category string) string {
+	return base + "/servers/search?category=" + url.QueryEscape(category)
+}
+
+// serverResource wraps a server in a JSON:API resource object with links to
+// itself, its category and related servers under base
+func serverResource(server Server, base string) map[string]interface{} {
+	var attributes map[string]interface{}
+	data, _ := json.Marshal(server)
+	json.Unmarshal(data, &attributes)
+	delete(attributes, "id")
+
+	return map[string]interface{}{
+		"type":       "servers",
+		"id":         server.ID,
+		"attributes": attributes,
+		"relationships": map[string]interface{}{
+			"category": map[string]interface{}{
+				"data":  map[string]string{"type": "categories", "id": server.Category},
+				"links": map[string]string{"related": categoryLink(base, server.Category)},
+			},
+			"related": map[string]interface{}{
+				"links": map[string]string{"related": serverLink(base, server.ID) + "/related"},
+			},
+		},
+		"links": map[string]string{"self": serverLink(base, server.ID)},
+	}
+}
+
+// serverResources wraps a list of servers; an empty list stays an array
+func serverResources(list []Server, base string) []map[string]interface{} {
+	resources := make([]map[string]interface{}, 0, len(list))
+	for _, server := range list {
+		resources = append(resources, serverResource(server, base))
+	}
+	return resources
+}
+
+// toolResources wraps tools of the tool registry, relating each to the
+// server exposing it
+func toolResources(tools []ToolRecord, base string) []map[string]interface{} {
+	resources := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		var attributes map[string]interface{}
+		data, _ := json.Marshal(tool)
+		json.Unmarshal(data, &attributes)
+		delete(attributes, "server_id")
+
+		resources = append(resources, map[string]interface{}{
+			"type":       "tools",
+			"id":         tool.ServerID + "/" + tool.Name,
+			"attributes": attributes,
+			"relationships": map[string]interface{}{
+				"server": map[string]interface{}{
+					"data":  map[string]string{"type": "servers", "id": tool.ServerID},
+					"links": map[string]string{"related": serverLink(base, tool.ServerID)},
+				},
+			},
+		})
+	}
+	return resources
+}
+
+// categoryResources wraps categories, linking each to its servers
+func categoryResources(categories []CategoryInfo, base string) []map[string]interface{} {
+	resources := make([]map[string]interface{}, 0, len(categories))
+	for _, category := range categories {
+		resources = append(resources, map[string]interface{}{
+			"type": "categories",
+			"id":   category.Name,
+			"attributes": map[string]interface{}{
+				"count":            category.Count,
+				"description":      category.Description,
+				"long_description": category.LongDescription,
+				"order":            category.Order,
+			},
+			"relationships": map[string]interface{}{
+				"servers": map[string]interface{}{
+					"links": map[string]string{"related": categoryLink(base, category.Name)},
+				},
+				"featured": map[string]interface{}{
+					"data": featuredIdentifiers(category.Featured),
+				},
+			},
+		})
+	}
+	return resources
+}
+
+// featuredIdentifiers are the resource identifiers of featured servers
+func featuredIdentifiers(featured []Server) []map[string]string {
+	identifiers := make([]map[string]string, 0, len(featured))
+	for _, server := range featured {
+		identifiers = append(identifiers, map[string]string{"type": "servers", "id": server.ID})
+	}
+	return identifiers
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchOperation is one RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+	// hasValue tells a null value from a missing one
+	hasValue bool
+}
+
+func (op *PatchOperation) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for key := range fields {
+		switch key {
+		case "op", "path", "from", "value":
+		default:
+			return fmt.Errorf("unknown patch operation member '%s'", key)
+		}
+	}
+	type plain PatchOperation
+	if err := json.Unmarshal(data, (*plain)(op)); err != nil {
+		return err
+	}
+	_, op.hasValue = fields["value"]
+	return nil
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path '%s' must be empty or start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// escapePointerToken escapes an object key for a JSON Pointer
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// arrayIndex resolves a pointer token into an index of a list of length n;
+// "-" is n, the position after the last element, when allowed
+func arrayIndex(token string, n int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return n, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("'%s' is not an array index", token)
+	}
+	if index > n || (index == n && !allowEnd) {
+		return 0, fmt.Errorf("index %d is out of range", index)
+	}
+	return index, nil
+}
+
+// pointerGet returns the value a pointer locates in doc
+func pointerGet(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path '%s' does not exist", pointer)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, fmt.Errorf("path '%s': %v", pointer, err)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path '%s' does not exist", pointer)
+		}
+	}
+	return current, nil
+}
+
+// pointerSet adds, replaces or removes (value == nil, remove) the value at
+// pointer, returning the new document. Lists are copied rather than grown
+// in place, so callers must use the returned document.
+func pointerSet(doc interface{}, tokens []string, value interface{}, op string) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op == "remove" {
+			return nil, errors.New("the whole entry cannot be removed")
+		}
+		return value, nil
+	}
+	token, rest := tokens[0], tokens[1:]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, exists := node[token]
+		if len(rest) > 0 {
+			if !exists {
+				return nil, fmt.Errorf("member '%s' does not exist", token)
+			}
+			updated, err := pointerSet(child, rest, value, op)
+			if err != nil {
+				return nil, err
+			}
+			node[token] = updated
+			return node, nil
+		}
+		switch op {
+		case "add":
+			node[token] = value
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("member '%s' does not exist", token)
+			}
+			node[token] = value
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("member '%s' does not exist", token)
+			}
+			delete(node, token)
+		}
+		return node, nil
+	case []interface{}:
+		index, err := arrayIndex(token, len(node), len(rest) == 0 && op == "add")
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			updated, err := pointerSet(node[index], rest, value, op)
+			if err != nil {
+				return nil, err
+			}
+			node[index] = updated
+			return node, nil
+		}
+		switch op {
+		case "add":
+			list := make([]interface{}, 0, len(node)+1)
+			list = append(append(append(list, node[:index]...), value), node[index:]...)
+			return list, nil
+		case "replace":
+			node[index] = value
+			return node, nil
+		}
+		return append(append([]interface{}{}, node[:index]...), node[index+1:]...), nil
+	}
+	return nil, fmt.Errorf("'%s' is not inside an object or array", token)
+}
+
+// deepCopyJSON copies a decoded JSON value so patches never modify the
+// entry being served
+func deepCopyJSON(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(node))
+		for key, child := range node {
+			copied[key] = deepCopyJSON(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(node))
+		for i, child := range node {
+			copied[i] = deepCopyJSON(child)
+		}
+		return copied
+	}
+	return value
+}
+
+// applyJSONPatch applies RFC 6902 operations in order; any failing
+// operation fails the whole patch
+func applyJSONPatch(doc interface{}, operations []PatchOperation) (interface{}, error) {
+	doc = deepCopyJSON(doc)
+	for i, op := range operations {
+		fail := func(err error) (interface{}, error) {
+			return nil, fmt.Errorf("operation %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return fail(err)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if !op.hasValue {
+				return fail(errors.New("missing 'value'"))
+			}
+		case "move", "copy":
+			if _, err := parsePointer(op.From); err != nil {
+				return fail(err)
+			}
+		}
+
+		switch op.Op {
+		case "add", "replace", "remove":
+			doc, err = pointerSet(doc, tokens, deepCopyJSON(op.Value), op.Op)
+		case "move":
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return fail(errors.New("a value cannot be moved into itself"))
+			}
+			var value interface{}
+			if value, err = pointerGet(doc, op.From); err == nil {
+				fromTokens, _ := parsePointer(op.From)
+				if doc, err = pointerSet(doc, fromTokens, nil, "remove"); err == nil {
+					doc, err = pointerSet(doc, tokens, value, "add")
+				}
+			}
+		case "copy":
+			var value interface{}
+			if value, err = pointerGet(doc, op.From); err == nil {
+				doc, err = pointerSet(doc, tokens, deepCopyJSON(value), "add")
+			}
+		case "test":
+			var value interface{}
+			if value, err = pointerGet(doc, op.Path); err == nil && !reflect.DeepEqual(value, op.Value) {
+				err = errors.New("test failed")
+			}
+		default:
+			err = fmt.Errorf("unknown op '%s'", op.Op)
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+	return doc, nil
+}
+
+// applyMergePatch applies an RFC 7386 merge patch: objects merge member by
+// member, null removes a member and anything else replaces the target
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopyJSON(patch)
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	result := make(map[string]interface{}, len(targetObject))
+	for key, value := range targetObject {
+		result[key] = value
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = applyMergePatch(result[key], value)
+	}
+	return result
+}
+
+// parseEntryPatch reads a JSON Patch or merge patch body, chosen by its
+// Content-Type, into a function applying it to an entry
+func parseEntryPatch(r *http.Request) (func(interface{}) (interface{}, error), error) {
+	mediaType := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	switch mediaType {
+	case "application/json-patch+json":
+		var operations []PatchOperation
+		if err := decodeStrict(r.Body, &operations); err != nil {
+			return nil, err
+		}
+		return func(entry interface{}) (interface{}, error) {
+			return applyJSONPatch(entry, operations)
+		}, nil
+	case "application/merge-patch+json":
+		var patch interface{}
+		if err := decodeStrict(r.Body, &patch); err != nil {
+			return nil, err
+		}
+		return func(entry interface{}) (interface{}, error) {
+			return applyMergePatch(entry, patch), nil
+		}, nil
+	}
+	return nil, &requestError{
+		status:  http.StatusUnsupportedMediaType,
+		message: "Content-Type must be application/json-patch+json or application/merge-patch+json",
+	}
+}
+
+// patchServerHandler edits one entry of the catalog file in place with a
+// JSON Patch or merge patch (admin). The patched entry must pass the same
+// validation as entries being loaded; nothing is written otherwise.
+func patchServerHandler(w http.ResponseWriter, r *http.Request, serverID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Accept-Patch", "application/json-patch+json, application/merge-patch+json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	if _, exists := getEntry(serverID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+	if _, local := localCatalog()[serverID]; !local {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Server '%s' comes from an upstream catalog; patch it there", serverID))
+		return
+	}
+
+	limitBody(w, r)
+	patch, err := parseEntryPatch(r)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	entry, err := rewriteCatalogEntry(newFieldOrigin("edit", requestEditor(r)), serverID, func(existing map[string]interface{}, exists bool) (map[string]interface{}, error) {
+		if !exists {
+			return nil, &requestError{status: http.StatusConflict, message: fmt.Sprintf("Server '%s' is not in %s", serverID, loadedCatalogPath)}
+		}
+		patched, err := patch(existing)
+		if err != nil {
+			return nil, &requestError{status: http.StatusUnprocessableEntity, message: "Patch cannot be applied: " + err.Error()}
+		}
+		if problems := validateEntry(patched); len(problems) > 0 {
+			return nil, &requestError{status: http.StatusUnprocessableEntity, message: "Patched entry is invalid: " + strings.Join(problems, "; ")}
+		}
+		// The vendor is part of the ID, so moving an entry takes a new ID
+		if vendor, _, ok := strings.Cut(serverID, "/"); ok && entryVendor(patched.(map[string]interface{})) != vendor {
+			return nil, &requestError{status: http.StatusUnprocessableEntity, message: fmt.Sprintf("Patched entry must keep vendor '%s' of its ID", vendor)}
+		}
+		return patched.(map[string]interface{}), nil
+	})
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		writeRequestError(w, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("🩹 Patched '%s' in %s", serverID, loadedCatalogPath)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":    serverID,
+		"entry": entry,
+	})
+}
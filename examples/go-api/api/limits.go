@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// exceedsClientLimits reports whether a selection is larger than the
+// client handles well
+func exceedsClientLimits(client ClientProfile, serverIDs []string) bool {
+	return (client.RecommendedServers > 0 && len(serverIDs) > client.RecommendedServers) ||
+		(client.MaxTools > 0 && declaredToolCount(serverIDs) > client.MaxTools)
+}
+
+// declaredToolCount sums the tools the catalog declares for the servers
+func declaredToolCount(serverIDs []string) int {
+	total := 0
+	for _, serverID := range serverIDs {
+		config, _ := getEntry(serverID)
+		total += len(entryTools(config))
+	}
+	return total
+}
+
+// clientLimitWarnings explains how a selection exceeds the client's limits
+func clientLimitWarnings(client ClientProfile, serverIDs []string) []string {
+	var warnings []string
+	if client.RecommendedServers > 0 && len(serverIDs) > client.RecommendedServers {
+		warnings = append(warnings, fmt.Sprintf("%d servers selected; %s works best with at most %d attached at once",
+			len(serverIDs), client.Name, client.RecommendedServers))
+	}
+	if tools := declaredToolCount(serverIDs); client.MaxTools > 0 && tools > client.MaxTools {
+		warnings = append(warnings, fmt.Sprintf("The selection declares %d tools but %s only exposes %d; some tools will be unavailable",
+			tools, client.Name, client.MaxTools))
+	}
+	return warnings
+}
+
+// suggestProfiles splits a selection into profiles that each fit the
+// client, keeping servers of the same category together. The result has
+// the shape of the bulk endpoint's "profiles" field.
+func suggestProfiles(client ClientProfile, serverIDs []string) map[string]BulkProfile {
+	maxServers := client.RecommendedServers
+	if maxServers <= 0 {
+		maxServers = len(serverIDs)
+	}
+
+	byCategory := make(map[string][]string)
+	for _, serverID := range serverIDs {
+		config, _ := getEntry(serverID)
+		category := getString(config, "category", "other")
+		byCategory[category] = append(byCategory[category], serverID)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	// Largest categories first so small ones can share the remaining room
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := len(byCategory[categories[i]]), len(byCategory[categories[j]])
+		if a != b {
+			return a > b
+		}
+		return categories[i] < categories[j]
+	})
+
+	type draft struct {
+		name    string
+		servers []string
+		tools   int
+	}
+	var drafts []*draft
+	fits := func(d *draft, serverID string) bool {
+		config, _ := getEntry(serverID)
+		tools := len(entryTools(config))
+		return len(d.servers) < maxServers && (client.MaxTools <= 0 || d.tools+tools <= client.MaxTools)
+	}
+	for _, category := range categories {
+		for _, serverID := range byCategory[category] {
+			var target *draft
+			for _, d := range drafts {
+				if fits(d, serverID) {
+					target = d
+					break
+				}
+			}
+			if target == nil {
+				target = &draft{name: fmt.Sprintf("profile-%d", len(drafts)+1)}
+				drafts = append(drafts, target)
+			}
+			config, _ := getEntry(serverID)
+			target.servers = append(target.servers, serverID)
+			target.tools += len(entryTools(config))
+		}
+	}
+
+	profiles := make(map[string]BulkProfile, len(drafts))
+	for _, d := range drafts {
+		sort.Strings(d.servers)
+		profiles[d.name] = BulkProfile{Servers: d.servers}
+	}
+	return profiles
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// llmDefaultBudget is the token budget of /api/v1/llm/context unless
+// ?budget sets one between llmMinBudget and llmMaxBudget. Tokens are
+// estimated at llmCharsPerToken characters each, about what common
+// tokenizers average on English prose.
+const (
+	llmDefaultBudget = 2000
+	llmMinBudget     = 100
+	llmMaxBudget     = 100000
+	llmCharsPerToken = 4
+)
+
+// estimateTokens approximates the tokens a text costs in a context window
+func estimateTokens(text string) int {
+	return (len(text) + llmCharsPerToken - 1) / llmCharsPerToken
+}
+
+// llmInstall is the one-line install of an entry: the launch command of
+// its package, or its remote endpoint
+func llmInstall(serverID string, config map[string]interface{}) string {
+	if _, ok := entryPackage(config); !ok {
+		if endpoint := getString(config, "url", ""); endpoint != "" {
+			return "remote " + endpoint
+		}
+	}
+	launch := packageLaunchConfig(serverID, config, defaultConfigOptions)
+	command, _ := launch["command"].(string)
+	args, _ := launch["args"].([]string)
+	return strings.TrimSpace(command + " " + strings.Join(args, " "))
+}
+
+// llmBlock renders an entry as a compact markdown section: what it does,
+// how to install it, what it needs and what its tools take
+func llmBlock(serverID string, config map[string]interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n", serverID)
+	if description := getString(config, "description", ""); description != "" {
+		b.WriteString(description + "\n")
+	}
+	fmt.Fprintf(&b, "- install: %s\n", llmInstall(serverID, config))
+	var env []string
+	for _, question := range envQuestions(config) {
+		if question.Required {
+			env = append(env, question.Key+" (required)")
+		} else {
+			env = append(env, question.Key)
+		}
+	}
+	if len(env) > 0 {
+		sort.Strings(env)
+		fmt.Fprintf(&b, "- env: %s\n", strings.Join(env, ", "))
+	}
+	var tools []string
+	for _, tool := range entryTools(config) {
+		required, _ := stringList(tool.InputSchema["required"])
+		tools = append(tools, tool.Name+"("+strings.Join(required, ", ")+")")
+	}
+	if len(tools) > 0 {
+		fmt.Fprintf(&b, "- tools: %s\n", strings.Join(tools, ", "))
+	}
+	if risk := entryRisk(config); risk != nil && len(risk.Flags) > 0 {
+		fmt.Fprintf(&b, "- risk: %s (%s)\n", risk.Level, strings.Join(risk.Flags, ", "))
+	}
+	return b.String()
+}
+
+// llmContextHandler answers with a markdown digest of the servers a
+// search matches, sized for an agent's context window. Takes the q,
+// category and filter parameters of the search endpoint, plus ?budget,
+// the most tokens the digest may cost. Servers are added best first as
+// full sections; once one no longer fits, the rest are listed as single
+// lines while those fit. The X-Token-Estimate header reports the cost.
+func llmContextHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query().Get("q")
+	budget := llmDefaultBudget
+	if raw := r.URL.Query().Get("budget"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < llmMinBudget || parsed > llmMaxBudget {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Query parameter 'budget' must be a token count between %d and %d", llmMinBudget, llmMaxBudget))
+			return
+		}
+		budget = parsed
+	}
+	filters, err := parseEntryFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	categories := requestedCategories(w, r)
+	entries, past, ok := catalogForRead(w, r)
+	if !ok {
+		return
+	}
+
+	var hits []SearchHit
+	switch {
+	case query != "" && past != nil:
+		hits, err = matchIndex(r.Context(), past.index, query)
+	case query != "":
+		hits, err = searchEntries(r.Context(), query)
+	default:
+		for serverID := range entries {
+			hits = append(hits, SearchHit{ID: serverID})
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "Search is unavailable: "+err.Error())
+		return
+	}
+	var ranked []rankedResult
+	for _, hit := range hits {
+		config, exists := entries[hit.ID].(map[string]interface{})
+		if !exists || !inCategories(categories, config) || !matchesFilters(filters, hit.ID, config) {
+			continue
+		}
+		ranked = append(ranked, rankedResult{server: Server{ID: hit.ID}, explanation: explainScore(hit.ID, config, hit.Matches)})
+	}
+	rankResults(ranked)
+
+	title := "# MCP servers"
+	if query != "" {
+		title += fmt.Sprintf(" matching %q", query)
+	}
+	// The footer is reserved up front so the digest always says what it left out
+	const footerReserve = 24
+	used := estimateTokens(title) + 1 + footerReserve
+	var sections, lines []string
+	compact := false
+	shown := 0
+	for _, result := range ranked {
+		config := entries[result.server.ID].(map[string]interface{})
+		if !compact {
+			block := llmBlock(result.server.ID, config)
+			if cost := estimateTokens(block) + 1; used+cost <= budget {
+				sections = append(sections, block)
+				used += cost
+				shown++
+				continue
+			}
+			compact = true
+		}
+		line := fmt.Sprintf("- %s: %s", result.server.ID, getString(config, "description", ""))
+		cost := estimateTokens(line) + 1
+		if used+cost > budget {
+			break
+		}
+		lines = append(lines, line)
+		used += cost
+		shown++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d of %d shown)\n\n", title, shown, len(ranked))
+	for _, section := range sections {
+		b.WriteString(section + "\n")
+	}
+	if len(lines) > 0 {
+		b.WriteString("## More servers\n" + strings.Join(lines, "\n") + "\n\n")
+	}
+	if omitted := len(ranked) - shown; omitted > 0 {
+		fmt.Fprintf(&b, "%d more servers omitted; narrow the query or raise the budget.\n", omitted)
+	}
+	digest := b.String()
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("X-Token-Estimate", strconv.Itoa(estimateTokens(digest)))
+	fmt.Fprint(w, digest)
+}
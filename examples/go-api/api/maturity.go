@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maturityLevels are the values of an entry's "maturity", least stable first
+var maturityLevels = []string{"experimental", "beta", "stable"}
+
+// entryMaturity returns an entry's maturity; entries without one are stable
+func entryMaturity(config map[string]interface{}) string {
+	return getString(config, "maturity", "stable")
+}
+
+// isMaturityLevel reports whether a value is a known maturity
+func isMaturityLevel(value string) bool {
+	for _, level := range maturityLevels {
+		if level == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMaturityFilter hides experimental entries unless the request names
+// the maturities it wants (maturity=experimental,beta) or opts in with
+// include_experimental=true. Unlike the other filters it applies when no
+// parameter is given.
+func parseMaturityFilter(r *http.Request) (entryFilter, error) {
+	includeExperimental, _, err := parseBoolParam(r, "include_experimental")
+	if err != nil {
+		return nil, err
+	}
+	if raw := r.URL.Query().Get("maturity"); raw != "" {
+		wanted := make(map[string]bool)
+		for _, level := range strings.Split(raw, ",") {
+			level = strings.TrimSpace(level)
+			if !isMaturityLevel(level) {
+				return nil, fmt.Errorf("Query parameter 'maturity' must be a list of %s", strings.Join(maturityLevels, ", "))
+			}
+			wanted[level] = true
+		}
+		return func(serverID string, config map[string]interface{}) bool {
+			return wanted[entryMaturity(config)]
+		}, nil
+	}
+	if includeExperimental {
+		return nil, nil
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		return entryMaturity(config) != "experimental"
+	}, nil
+}
+
+// checkExperimental refuses a config that would pull in experimental
+// servers, directly or as required dependencies, unless the request sets
+// allow_experimental
+func checkExperimental(req GenerateConfigRequest) error {
+	if req.AllowExperimental {
+		return nil
+	}
+	selected, _ := withRequiredDependencies(req.Servers)
+	var experimental []string
+	for _, serverID := range selected {
+		if config, exists := getEntry(serverID); exists && entryMaturity(config) == "experimental" {
+			experimental = append(experimental, serverID)
+		}
+	}
+	if len(experimental) == 0 {
+		return nil
+	}
+	return &requestError{
+		status:  http.StatusUnprocessableEntity,
+		message: fmt.Sprintf("Experimental servers need \"allow_experimental\": true: %s", strings.Join(experimental, ", ")),
+	}
+}
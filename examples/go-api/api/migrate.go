@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// currentSchemaVersion is the catalog file format this build writes
+const currentSchemaVersion = 5
+
+// migrations[i] upgrades a catalog document from version i+1 to i+2
+var migrations = []func(doc map[string]interface{}) error{
+	migrateV1ToV2,
+	migrateV2ToV3,
+	migrateV3ToV4,
+	migrateV4ToV5,
+}
+
+// schemaVersion reads the version of a catalog document. Files predating
+// versioning are a flat map of server ID to entry and count as version 1.
+func schemaVersion(doc map[string]interface{}) int {
+	if version, ok := doc["schema_version"].(float64); ok {
+		return int(version)
+	}
+	return 1
+}
+
+// migrateCatalog upgrades a decoded catalog file in place to the current
+// schema version and returns the version it started from
+func migrateCatalog(doc map[string]interface{}) (int, error) {
+	from := schemaVersion(doc)
+	if from < 1 {
+		return from, fmt.Errorf("catalog schema version %d is invalid; versions start at 1", from)
+	}
+	if from > currentSchemaVersion {
+		return from, fmt.Errorf("catalog schema version %d is newer than supported version %d", from, currentSchemaVersion)
+	}
+	for version := from; version < currentSchemaVersion; version++ {
+		if err := migrations[version-1](doc); err != nil {
+			return from, fmt.Errorf("migrating schema v%d to v%d: %v", version, version+1, err)
+		}
+		doc["schema_version"] = float64(version + 1)
+	}
+	return from, nil
+}
+
+// migrateV1ToV2 moves the flat server map into a versioned envelope.
+// Malformed entries are carried over for entry validation to report.
+func migrateV1ToV2(doc map[string]interface{}) error {
+	entries := make(map[string]interface{}, len(doc))
+	for serverID, entry := range doc {
+		entries[serverID] = entry
+		delete(doc, serverID)
+	}
+	doc["servers"] = entries
+	return nil
+}
+
+// migrateV2ToV3 gives every entry a primary "category", taken from the
+// first of its "categories" that is not a tier marker
+func migrateV2ToV3(doc map[string]interface{}) error {
+	entries, ok := doc["servers"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("missing 'servers' object")
+	}
+	for _, entryInterface := range entries {
+		entry, ok := entryInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, exists := entry["category"]; exists {
+			continue
+		}
+		categories, _ := entry["categories"].([]interface{})
+		for _, categoryInterface := range categories {
+			if category, ok := categoryInterface.(string); ok && category != "official" {
+				entry["category"] = category
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// migrateV3ToV4 replaces free-text vendor names with vendor IDs, the keys
+// of vendors.json. A display name the ID does not spell becomes the name
+// of an unverified vendor record unless the vendor has one; migrate -write
+// saves those records to vendors.json.
+func migrateV3ToV4(doc map[string]interface{}) error {
+	entries, ok := doc["servers"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("missing 'servers' object")
+	}
+	for _, entryInterface := range entries {
+		entry, ok := entryInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, ok := entry["vendor"].(string)
+		if !ok {
+			continue
+		}
+		if id := vendorSlug(name); id != "" {
+			entry["vendor"] = id
+			adoptVendorName(id, name)
+		} else {
+			delete(entry, "vendor")
+		}
+	}
+	return nil
+}
+
+// migrateV4ToV5 namespaces flat server IDs as "vendor/id". The old ID is
+// kept in "legacy_ids", so it still resolves, and "requires" and
+// "recommends" references follow the rename.
+func migrateV4ToV5(doc map[string]interface{}) error {
+	entries, ok := doc["servers"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("missing 'servers' object")
+	}
+	renamed := make(map[string]string)
+	for serverID, entryInterface := range entries {
+		entry, ok := entryInterface.(map[string]interface{})
+		if !ok || strings.Contains(serverID, "/") {
+			continue
+		}
+		renamed[serverID] = namespacedID(entryVendor(entry), serverID)
+	}
+	for old, current := range renamed {
+		if _, exists := entries[current]; exists {
+			return fmt.Errorf("'%s' cannot become '%s', which already exists", old, current)
+		}
+	}
+
+	for old, current := range renamed {
+		entry := entries[old].(map[string]interface{})
+		legacy, _ := entry["legacy_ids"].([]interface{})
+		entry["legacy_ids"] = append(legacy, old)
+		entries[current] = entry
+		delete(entries, old)
+	}
+	for _, entryInterface := range entries {
+		entry, ok := entryInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"requires", "recommends"} {
+			references, _ := entry[key].([]interface{})
+			for i, reference := range references {
+				if current, ok := renamed[fmt.Sprint(reference)]; ok {
+					references[i] = current
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// catalogEntries returns the server map of a current-version document
+func catalogEntries(doc map[string]interface{}) map[string]interface{} {
+	entries, _ := doc["servers"].(map[string]interface{})
+	if entries == nil {
+		entries = make(map[string]interface{})
+	}
+	return entries
+}
+
+// diffLines returns the changed lines between a and b prefixed with - or +
+func diffLines(a, b []string) []string {
+	// Longest common subsequence table, fine for per-entry sized inputs
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+"+b[j])
+	}
+	return out
+}
+
+func indentedLines(v interface{}) []string {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	return strings.Split(string(data), "\n")
+}
+
+// migrateCommand rewrites a catalog file in the newest schema version,
+// previewing the per-entry diff first:
+//
+//	migrate [-file known_servers.json] [-write]
+func migrateCommand(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	path := flags.String("file", loadedCatalogPath, "catalog file to migrate")
+	write := flags.Bool("write", false, "rewrite the file instead of only previewing the diff")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("no catalog file found; pass -file")
+	}
+
+	data, err := ioutil.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+	var original map[string]interface{}
+	if err := json.Unmarshal(data, &original); err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	json.Unmarshal(data, &doc)
+
+	from, err := migrateCatalog(doc)
+	if err != nil {
+		return err
+	}
+	if from == currentSchemaVersion {
+		fmt.Printf("%s is already at schema version %d\n", *path, currentSchemaVersion)
+		return nil
+	}
+
+	before := original
+	if from >= 2 {
+		before = catalogEntries(original)
+	}
+	after := catalogEntries(doc)
+
+	fmt.Printf("--- %s (schema v%d)\n+++ %s (schema v%d)\n", *path, from, *path, currentSchemaVersion)
+	ids := make([]string, 0, len(after))
+	for serverID := range after {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+	for _, serverID := range ids {
+		changes := diffLines(indentedLines(before[serverID]), indentedLines(after[serverID]))
+		if len(changes) == 0 {
+			continue
+		}
+		fmt.Printf("@@ %s @@\n", serverID)
+		for _, line := range changes {
+			fmt.Println(line)
+		}
+	}
+
+	if !*write {
+		fmt.Println("\nDry run; pass -write to rewrite the file")
+		return nil
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(*path)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*path, append(out, '\n'), info.Mode()); err != nil {
+		return err
+	}
+	fmt.Printf("Rewrote %s at schema version %d\n", *path, currentSchemaVersion)
+	if from <= 3 {
+		return saveMigratedVendors(filepath.Join(filepath.Dir(*path), "vendors.json"))
+	}
+	return nil
+}
+
+// saveMigratedVendors writes the vendor records, with the display names
+// migrateV3ToV4 adopted, to the loaded vendors.json or else to fallback
+func saveMigratedVendors(fallback string) error {
+	vendorsMu.RLock()
+	defer vendorsMu.RUnlock()
+	if len(vendors) == 0 {
+		return nil
+	}
+	path := vendorsPath
+	if path == "" {
+		path = fallback
+	}
+	out, err := json.MarshalIndent(vendors, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d vendors to %s\n", len(vendors), path)
+	return nil
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Tool is a tool declared by a catalog entry
+type Tool struct {
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description,omitempty"`
+	InputSchema  map[string]interface{} `json:"inputSchema,omitempty"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+}
+
+// entryTools decodes the optional "tools" list of a catalog entry, or
+// the tools its capabilities enrichment fetched
+func entryTools(config map[string]interface{}) []Tool {
+	raw, ok := config["tools"]
+	if !ok {
+		return enrichedTools(config)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var tools []Tool
+	if err := json.Unmarshal(data, &tools); err != nil {
+		return nil
+	}
+	return tools
+}
+
+// sampleValue fabricates a value that satisfies a JSON schema
+func sampleValue(schema map[string]interface{}) interface{} {
+	if schema == nil {
+		return nil
+	}
+	if value, ok := schema["default"]; ok {
+		return value
+	}
+	if examples, ok := schema["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	switch getString(schema, "type", "object") {
+	case "string":
+		return "example"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		return []interface{}{sampleValue(items)}
+	default:
+		result := make(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, propertyInterface := range properties {
+			property, _ := propertyInterface.(map[string]interface{})
+			result[name] = sampleValue(property)
+		}
+		return result
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// mockServer answers MCP requests with canned responses for an entry's tools
+type mockServer struct {
+	serverID string
+	tools    []Tool
+}
+
+// handle returns the response for a request, or nil for notifications
+func (m *mockServer) handle(req rpcRequest) *rpcResponse {
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+
+	switch req.Method {
+	case "initialize":
+		resp.Result = map[string]interface{}{
+			"protocolVersion": "2024-11-05",
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo": map[string]interface{}{
+				"name":    m.serverID + "-mock",
+				"version": "0.0.0",
+			},
+		}
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	case "tools/list":
+		resp.Result = map[string]interface{}{"tools": m.tools}
+	case "tools/call":
+		var params struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal(req.Params, &params)
+		for _, tool := range m.tools {
+			if tool.Name != params.Name {
+				continue
+			}
+			text := fmt.Sprintf("mock response from %s/%s", m.serverID, tool.Name)
+			result := map[string]interface{}{}
+			if tool.OutputSchema != nil {
+				structured := sampleValue(tool.OutputSchema)
+				data, _ := json.Marshal(structured)
+				text = string(data)
+				result["structuredContent"] = structured
+			}
+			result["content"] = []map[string]string{{"type": "text", "text": text}}
+			resp.Result = result
+			return resp
+		}
+		resp.Error = &rpcError{Code: -32602, Message: fmt.Sprintf("Unknown tool '%s'", params.Name)}
+	default:
+		resp.Error = &rpcError{Code: -32601, Message: fmt.Sprintf("Method '%s' not found", req.Method)}
+	}
+	return resp
+}
+
+// serveStdio speaks newline-delimited JSON-RPC over stdin/stdout
+func (m *mockServer) serveStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			encoder.Encode(rpcResponse{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &rpcError{Code: -32700, Message: "Parse error"},
+			})
+			continue
+		}
+		if resp := m.handle(req); resp != nil {
+			encoder.Encode(resp)
+		}
+	}
+	return scanner.Err()
+}
+
+// serveSSE implements the HTTP+SSE transport: clients hold open GET /sse
+// and post requests to the endpoint announced in the first event
+func (m *mockServer) serveSSE(addr string) error {
+	var mu sync.Mutex
+	sessions := make(map[string]chan *rpcResponse)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		sessionID := fmt.Sprintf("%d", time.Now().UnixNano())
+		outbox := make(chan *rpcResponse, 16)
+		mu.Lock()
+		sessions[sessionID] = outbox
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			delete(sessions, sessionID)
+			mu.Unlock()
+		}()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		fmt.Fprintf(w, "event: endpoint\ndata: /message?sessionId=%s\n\n", sessionID)
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case resp := <-outbox:
+				data, _ := json.Marshal(resp)
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+		}
+	})
+	mux.HandleFunc("/message", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		mu.Lock()
+		outbox, ok := sessions[r.URL.Query().Get("sessionId")]
+		mu.Unlock()
+		if !ok {
+			http.Error(w, "Unknown session", http.StatusNotFound)
+			return
+		}
+
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if resp := m.handle(req); resp != nil {
+			select {
+			case outbox <- resp:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	log.Printf("🧪 Mock MCP server for %s listening on %s (SSE at /sse)", m.serverID, addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// mockCommand runs a stub MCP server for a catalog entry:
+//
+//	mock [-transport stdio|sse] [-addr :9000] <server-id>
+func mockCommand(args []string) error {
+	flags := flag.NewFlagSet("mock", flag.ContinueOnError)
+	transport := flags.String("transport", "stdio", "transport to serve: stdio or sse")
+	addr := flags.String("addr", ":9000", "listen address for the sse transport")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: mock [-transport stdio|sse] [-addr :9000] <server-id>")
+	}
+
+	serverID := flags.Arg(0)
+	config, exists := getEntry(serverID)
+	if !exists {
+		return fmt.Errorf("server '%s' not found", serverID)
+	}
+	tools := entryTools(config)
+	if len(tools) == 0 {
+		log.Printf("⚠️  %s declares no tools; mock will serve an empty tool list", serverID)
+	}
+
+	mock := &mockServer{serverID: serverID, tools: tools}
+	switch *transport {
+	case "stdio":
+		return mock.serveStdio(os.Stdin, os.Stdout)
+	case "sse":
+		return mock.serveSSE(*addr)
+	default:
+		return fmt.Errorf("unknown transport '%s'", *transport)
+	}
+}
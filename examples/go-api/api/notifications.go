@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notificationEvents are the events maintainers can be notified of. Routes
+// match them with topic patterns such as "smoke.*" or "*".
+var notificationEvents = map[string]string{
+	"submission.created":  "A draft entry was queued for review",
+	"advisory.published":  "A security advisory names an entry",
+	"link.broken":         "An entry's package, icon, README or capabilities URL stopped resolving",
+	"smoke.failed":        "An entry that passed or was untested failed its smoke test",
+	"sync.conflict":       "An upstream copy of a local entry disagrees under the manual merge policy",
+	"canary.held":         "A synced catalog failed its canary evaluation and waits for approval",
+	"notification.sample": "Sent on demand to check the channels",
+}
+
+// notificationChannelKinds deliver a notification to a Slack incoming
+// webhook, a JSON webhook or an email address
+var notificationChannelKinds = []string{"slack", "webhook", "email"}
+
+// NotificationChannel is a named destination, "NAME=KIND:TARGET"
+type NotificationChannel struct {
+	Name   string
+	Kind   string
+	Target string
+}
+
+// NotificationRoute sends events matching Pattern to Channels. A route
+// with a Maintainer only fires for events about entries that list the
+// maintainer in "maintainers".
+type NotificationRoute struct {
+	Maintainer string
+	Pattern    string
+	Channels   []string
+}
+
+// Notification is one event, as webhook channels receive it
+type Notification struct {
+	Event   string   `json:"event"`
+	Title   string   `json:"title"`
+	Message string   `json:"message"`
+	Servers []string `json:"servers,omitempty"`
+	// Maintainers own the servers the event is about
+	Maintainers []string    `json:"maintainers,omitempty"`
+	Data        interface{} `json:"data,omitempty"`
+	At          time.Time   `json:"at"`
+}
+
+// notificationsWG tracks deliveries in flight so commands can wait for
+// them before exiting
+var notificationsWG sync.WaitGroup
+
+// parseNotificationChannels parses "NAME=KIND:TARGET" channel specs
+func parseNotificationChannels(specs []string) (map[string]NotificationChannel, error) {
+	channels := make(map[string]NotificationChannel)
+	for _, spec := range specs {
+		name, rest, _ := strings.Cut(spec, "=")
+		kind, target, _ := strings.Cut(rest, ":")
+		name, kind, target = strings.TrimSpace(name), strings.TrimSpace(kind), strings.TrimSpace(target)
+		if name == "" || !slices.Contains(notificationChannelKinds, kind) || target == "" {
+			return nil, fmt.Errorf("invalid notification channel '%s', want NAME=slack|webhook|email:TARGET", spec)
+		}
+		switch kind {
+		case "email":
+			if _, err := mail.ParseAddress(target); err != nil {
+				return nil, fmt.Errorf("notification channel '%s' needs an email address: %v", name, err)
+			}
+		default:
+			if parsed, err := url.Parse(target); err != nil || parsed.Scheme != "https" && parsed.Scheme != "http" || parsed.Host == "" {
+				return nil, fmt.Errorf("notification channel '%s' needs an http(s) URL", name)
+			}
+		}
+		channels[name] = NotificationChannel{Name: name, Kind: kind, Target: target}
+	}
+	return channels, nil
+}
+
+// parseNotificationRoutes parses "[MAINTAINER:]EVENT_PATTERN=CHANNEL[+CHANNEL...]"
+// route specs against the known channels
+func parseNotificationRoutes(specs []string, channels map[string]NotificationChannel) ([]NotificationRoute, error) {
+	var routes []NotificationRoute
+	for _, spec := range specs {
+		scope, targets, ok := strings.Cut(spec, "=")
+		maintainer, pattern, scoped := strings.Cut(scope, ":")
+		if !scoped {
+			maintainer, pattern = "", scope
+		}
+		route := NotificationRoute{Maintainer: strings.TrimSpace(maintainer), Pattern: strings.TrimSpace(pattern)}
+		if !ok || route.Pattern == "" || scoped && route.Maintainer == "" {
+			return nil, fmt.Errorf("invalid notification route '%s', want [MAINTAINER:]EVENT_PATTERN=CHANNEL", spec)
+		}
+		matched := false
+		for event := range notificationEvents {
+			matched = matched || topicMatches(route.Pattern, event)
+		}
+		if !matched {
+			return nil, fmt.Errorf("notification route '%s' matches no event", spec)
+		}
+		for _, name := range strings.Split(targets, "+") {
+			name = strings.TrimSpace(name)
+			if _, known := channels[name]; !known {
+				return nil, fmt.Errorf("notification route '%s' names unknown channel '%s'", spec, name)
+			}
+			route.Channels = append(route.Channels, name)
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// validateNotifications checks the channels, the routes and, when a route
+// may send email, the SMTP settings
+func validateNotifications(c *Config) error {
+	channels, err := parseNotificationChannels(c.NotifyChannels)
+	if err != nil {
+		return err
+	}
+	if _, err := parseNotificationRoutes(c.NotifyRoutes, channels); err != nil {
+		return err
+	}
+	for _, channel := range channels {
+		if channel.Kind == "email" && (c.SMTPAddr == "" || c.SMTPFrom == "") {
+			return fmt.Errorf("email notification channels need notifications.smtp_addr and notifications.smtp_from")
+		}
+	}
+	return nil
+}
+
+// entryMaintainers returns the maintainers an entry lists
+func entryMaintainers(config map[string]interface{}) []string {
+	maintainers, _ := stringList(config["maintainers"])
+	return maintainers
+}
+
+// notify delivers a notification in the background to every channel a
+// route sends its event to. Maintainer routes fire when the maintainer
+// owns one of the notification's servers. It returns the notification
+// as sent.
+func notify(n Notification) Notification {
+	if n.At.IsZero() {
+		n.At = time.Now().UTC()
+	}
+	owners := make(map[string]bool)
+	for _, serverID := range n.Servers {
+		config, _ := getEntry(serverID)
+		for _, maintainer := range entryMaintainers(config) {
+			owners[maintainer] = true
+		}
+	}
+	n.Maintainers = nil
+	for maintainer := range owners {
+		n.Maintainers = append(n.Maintainers, maintainer)
+	}
+	sort.Strings(n.Maintainers)
+
+	channels, _ := parseNotificationChannels(cfg.NotifyChannels)
+	routes, _ := parseNotificationRoutes(cfg.NotifyRoutes, channels)
+	targets := make(map[string]bool)
+	for _, route := range routes {
+		if topicMatches(route.Pattern, n.Event) && (route.Maintainer == "" || owners[route.Maintainer]) {
+			for _, name := range route.Channels {
+				targets[name] = true
+			}
+		}
+	}
+	for name := range targets {
+		channel := channels[name]
+		notificationsWG.Add(1)
+		go func() {
+			defer notificationsWG.Done()
+			err := deliverNotification(channel, n)
+			if err != nil {
+				log.Printf("⚠️  Notification %s to channel '%s' failed: %v", n.Event, channel.Name, err)
+				err = fmt.Errorf("channel '%s': %v", channel.Name, err)
+			}
+			recordComponent("notifications", err)
+		}()
+	}
+	return n
+}
+
+// waitNotifications blocks until the deliveries in flight are done
+func waitNotifications() {
+	notificationsWG.Wait()
+}
+
+// deliverNotification sends one notification to one channel
+func deliverNotification(channel NotificationChannel, n Notification) error {
+	switch channel.Kind {
+	case "email":
+		return sendNotificationEmail(channel.Target, n)
+	case "slack":
+		text := fmt.Sprintf("*%s*\n%s", n.Title, n.Message)
+		if len(n.Maintainers) > 0 {
+			text += "\nMaintainers: " + strings.Join(n.Maintainers, ", ")
+		}
+		return postNotification(channel.Target, map[string]string{"text": text}, n.Event)
+	default:
+		return postNotification(channel.Target, n, n.Event)
+	}
+}
+
+// postNotification posts a JSON body to a Slack or webhook channel
+func postNotification(target string, payload interface{}, event string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Catalog-Event", event)
+	client := outboundClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendNotificationEmail mails a plain-text notification through the
+// configured SMTP relay, authenticating when a username is set
+func sendNotificationEmail(address string, n Notification) error {
+	var auth smtp.Auth
+	if cfg.SMTPUsername != "" {
+		host, _, _ := strings.Cut(cfg.SMTPAddr, ":")
+		auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, host)
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\nTo: %s\r\nSubject: [MCP catalog] %s\r\n", cfg.SMTPFrom, address, n.Title)
+	fmt.Fprintf(&body, "Date: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n", n.At.Format(time.RFC1123Z))
+	body.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n") + "\r\n")
+	if len(n.Servers) > 0 {
+		fmt.Fprintf(&body, "\r\nServers: %s\r\n", strings.Join(n.Servers, ", "))
+	}
+	from, err := mail.ParseAddress(cfg.SMTPFrom)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(cfg.SMTPAddr, auth, from.Address, []string{to.Address}, []byte(body.String()))
+}
+
+// adminNotificationsHandler shows the notification routing (GET) or sends
+// a notification.sample through it (POST), optionally about a server so
+// its maintainers' routes fire too. Channel targets are secret and left out.
+func adminNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	channels, _ := parseNotificationChannels(cfg.NotifyChannels)
+	routes, _ := parseNotificationRoutes(cfg.NotifyRoutes, channels)
+
+	switch r.Method {
+	case "GET":
+		kinds := make(map[string]string, len(channels))
+		for name, channel := range channels {
+			kinds[name] = channel.Kind
+		}
+		listed := make([]map[string]interface{}, 0, len(routes))
+		for _, route := range routes {
+			entry := map[string]interface{}{"pattern": route.Pattern, "channels": route.Channels}
+			if route.Maintainer != "" {
+				entry["maintainer"] = route.Maintainer
+			}
+			listed = append(listed, entry)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"events":   notificationEvents,
+			"channels": kinds,
+			"routes":   listed,
+		})
+	case "POST":
+		var req struct {
+			ServerID string `json:"server_id"`
+		}
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		sample := Notification{Event: "notification.sample", Title: "Sample notification", Message: "Notifications from the MCP catalog reach this channel."}
+		if req.ServerID != "" {
+			serverID := canonicalID(req.ServerID)
+			if _, exists := getEntry(serverID); !exists {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", req.ServerID))
+				return
+			}
+			sample.Servers = []string{serverID}
+		}
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(notify(sample))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The catalog is distributed through container registries as an OCI
+// artifact, laid out the way ORAS pushes files: the offline bundle is the
+// only layer of a manifest with an empty config. `push oci://REGISTRY/
+// REPOSITORY:TAG` tags each revision rev-HASH besides TAG, `pull` fetches
+// a bundle back, and catalog.bundle takes an oci:// reference to serve from.
+
+const (
+	ociArtifactType      = "application/vnd.dblitz.mcp-catalog.v1"
+	ociBundleMediaType   = "application/vnd.dblitz.mcp-catalog.bundle.v1.tar+gzip"
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociEmptyMediaType    = "application/vnd.oci.empty.v1+json"
+)
+
+// ociTimeout bounds a whole push or pull
+const ociTimeout = 5 * time.Minute
+
+// ociEmptyConfig is the config blob of an artifact that has none
+var ociEmptyConfig = []byte("{}")
+
+var (
+	ociRepositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	ociTagPattern        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+	ociDigestPattern     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	ociChallengePattern  = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// ociReference is a parsed oci://REGISTRY/REPOSITORY[:TAG|@DIGEST]; the
+// tag defaults to latest
+type ociReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+func parseOCIReference(reference string) (ociReference, error) {
+	rest, ok := strings.CutPrefix(reference, "oci://")
+	if !ok {
+		return ociReference{}, fmt.Errorf("'%s' is not an oci:// reference", reference)
+	}
+	registry, path, _ := strings.Cut(rest, "/")
+	if registry == "" || path == "" {
+		return ociReference{}, fmt.Errorf("'%s' needs a registry and a repository, e.g. oci://ghcr.io/org/catalog:latest", reference)
+	}
+	ref := ociReference{Registry: registry, Tag: "latest"}
+	if repository, digest, ok := strings.Cut(path, "@"); ok {
+		ref.Repository, ref.Tag, ref.Digest = repository, "", digest
+		if !ociDigestPattern.MatchString(digest) {
+			return ociReference{}, fmt.Errorf("'%s' is not a sha256 digest", digest)
+		}
+	} else if i := strings.LastIndex(path, ":"); i >= 0 {
+		ref.Repository, ref.Tag = path[:i], path[i+1:]
+	} else {
+		ref.Repository = path
+	}
+	if !ociRepositoryPattern.MatchString(ref.Repository) {
+		return ociReference{}, fmt.Errorf("'%s' is not a valid repository name", ref.Repository)
+	}
+	if ref.Digest == "" && !ociTagPattern.MatchString(ref.Tag) {
+		return ociReference{}, fmt.Errorf("'%s' is not a valid tag", ref.Tag)
+	}
+	return ref, nil
+}
+
+func (ref ociReference) String() string {
+	if ref.Digest != "" {
+		return "oci://" + ref.Registry + "/" + ref.Repository + "@" + ref.Digest
+	}
+	return "oci://" + ref.Registry + "/" + ref.Repository + ":" + ref.Tag
+}
+
+// endpoint is the URL of a registry API path under the repository.
+// Registries on localhost are spoken to over plain HTTP, like docker does.
+func (ref ociReference) endpoint(path string) string {
+	scheme := "https"
+	if registry, err := url.Parse("//" + ref.Registry); err == nil {
+		switch registry.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			scheme = "http"
+		}
+	}
+	return scheme + "://" + ref.Registry + "/v2/" + ref.Repository + "/" + path
+}
+
+// ociDescriptor points at a blob from a manifest
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is an OCI image manifest carrying an artifact
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ociClient talks to the repository of a reference. It answers the
+// registry's Basic or Bearer challenge with oci.username and oci.password,
+// or anonymously without them.
+type ociClient struct {
+	ref    ociReference
+	client *http.Client
+	// token is the bearer token of the last challenge answered; basic is
+	// set once the registry asked for Basic auth
+	token string
+	basic bool
+}
+
+func newOCIClient(ref ociReference) *ociClient {
+	return &ociClient{ref: ref, client: outboundClient(ociTimeout)}
+}
+
+// do sends a request, answering an authentication challenge and retrying
+// once. Registries widen the token scope per request, e.g. from pull to
+// pull and push, so every 401 is answered anew.
+func (c *ociClient) do(ctx context.Context, method, target string, body []byte, header http.Header) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, reader)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		switch {
+		case c.token != "":
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		case c.basic:
+			req.SetBasicAuth(cfg.OCIUsername, cfg.OCIPassword)
+		}
+		return callDependency(c.client, req)
+	}
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	if err := c.authenticate(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+// authenticate answers a WWW-Authenticate challenge
+func (c *ociClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if cfg.OCIUsername == "" {
+			return fmt.Errorf("%s wants credentials; set oci.username and oci.password", c.ref.Registry)
+		}
+		c.basic = true
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("%s answered 401 without a usable challenge", c.ref.Registry)
+	}
+
+	values := make(map[string]string)
+	for _, match := range ociChallengePattern.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("%s answered 401 without a token realm", c.ref.Registry)
+	}
+	query := realm.Query()
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+	if values["scope"] != "" {
+		query.Set("scope", values["scope"])
+	}
+	realm.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if cfg.OCIUsername != "" {
+		req.SetBasicAuth(cfg.OCIUsername, cfg.OCIPassword)
+	}
+	resp, err := callDependency(c.client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ociError(resp, "token")
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("decoding the token of %s: %v", c.ref.Registry, err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("%s issued an empty token", c.ref.Registry)
+	}
+	return nil
+}
+
+// ociError turns an unexpected registry response into an error
+func ociError(resp *http.Response, what string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s: %s", what, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// pushBlob uploads a blob unless the repository has it already
+func (c *ociClient) pushBlob(ctx context.Context, mediaType string, data []byte) (ociDescriptor, error) {
+	descriptor := ociDescriptor{MediaType: mediaType, Digest: "sha256:" + sha256Hex(data), Size: int64(len(data))}
+	resp, err := c.do(ctx, "HEAD", c.ref.endpoint("blobs/"+descriptor.Digest), nil, nil)
+	if err != nil {
+		return descriptor, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return descriptor, nil
+	}
+
+	resp, err = c.do(ctx, "POST", c.ref.endpoint("blobs/uploads/"), nil, nil)
+	if err != nil {
+		return descriptor, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return descriptor, ociError(resp, "starting the upload of "+descriptor.Digest)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.Header.Get("Location") == "" {
+		return descriptor, fmt.Errorf("%s did not say where to upload %s", c.ref.Registry, descriptor.Digest)
+	}
+	query := location.Query()
+	query.Set("digest", descriptor.Digest)
+	location.RawQuery = query.Encode()
+
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err = c.do(ctx, "PUT", location.String(), data, header)
+	if err != nil {
+		return descriptor, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return descriptor, ociError(resp, "uploading "+descriptor.Digest)
+	}
+	return descriptor, nil
+}
+
+// pushOCI pushes a bundle as a catalog artifact under every tag and
+// returns the manifest digest
+func pushOCI(ctx context.Context, ref ociReference, bundle []byte, annotations map[string]string, tags []string) (string, error) {
+	c := newOCIClient(ref)
+	config, err := c.pushBlob(ctx, ociEmptyMediaType, ociEmptyConfig)
+	if err != nil {
+		return "", err
+	}
+	layer, err := c.pushBlob(ctx, ociBundleMediaType, bundle)
+	if err != nil {
+		return "", err
+	}
+	layer.Annotations = map[string]string{"org.opencontainers.image.title": "catalog-bundle.tar.gz"}
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  ociArtifactType,
+		Config:        config,
+		Layers:        []ociDescriptor{layer},
+		Annotations:   annotations,
+	})
+	if err != nil {
+		return "", err
+	}
+	header := http.Header{"Content-Type": {ociManifestMediaType}}
+	for _, tag := range tags {
+		resp, err := c.do(ctx, "PUT", ref.endpoint("manifests/"+tag), manifest, header)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			return "", ociError(resp, "tagging "+tag)
+		}
+	}
+	return "sha256:" + sha256Hex(manifest), nil
+}
+
+// pullOCI fetches the bundle of a catalog artifact, checking it against
+// the digests of the manifest and, for a digest reference, the manifest
+// against the reference. It returns the bundle and the manifest digest.
+func pullOCI(ctx context.Context, ref ociReference) ([]byte, string, error) {
+	c := newOCIClient(ref)
+	reference := ref.Tag
+	if ref.Digest != "" {
+		reference = ref.Digest
+	}
+	resp, err := c.do(ctx, "GET", ref.endpoint("manifests/"+reference), nil, http.Header{"Accept": {ociManifestMediaType}})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", ociError(resp, "fetching the manifest of "+ref.String())
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, "", err
+	}
+	digest := "sha256:" + sha256Hex(data)
+	if ref.Digest != "" && digest != ref.Digest {
+		return nil, "", fmt.Errorf("the manifest of %s has digest %s", ref.String(), digest)
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, "", fmt.Errorf("decoding the manifest of %s: %v", ref.String(), err)
+	}
+
+	var layer *ociDescriptor
+	for i := range manifest.Layers {
+		if manifest.Layers[i].MediaType == ociBundleMediaType {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return nil, "", fmt.Errorf("%s is not a catalog artifact: no %s layer", ref.String(), ociBundleMediaType)
+	}
+	if !ociDigestPattern.MatchString(layer.Digest) {
+		return nil, "", fmt.Errorf("%s has an unsupported layer digest %s", ref.String(), layer.Digest)
+	}
+	blob, err := c.do(ctx, "GET", ref.endpoint("blobs/"+layer.Digest), nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer blob.Body.Close()
+	if blob.StatusCode != http.StatusOK {
+		return nil, "", ociError(blob, "fetching "+layer.Digest)
+	}
+	bundle, err := ioutil.ReadAll(io.LimitReader(blob.Body, layer.Size+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(bundle)) != layer.Size || "sha256:"+sha256Hex(bundle) != layer.Digest {
+		return nil, "", fmt.Errorf("the bundle of %s does not match its digest %s", ref.String(), layer.Digest)
+	}
+	return bundle, digest, nil
+}
+
+// pullBundle fetches the bundle an oci:// catalog.bundle names into the
+// data directory and returns its path. When the registry cannot be reached
+// the copy of the last pull is served instead.
+func pullBundle(reference string) (string, error) {
+	ref, err := parseOCIReference(reference)
+	if err != nil {
+		return "", err
+	}
+	name := strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(ref.Registry + "/" + ref.Repository + ":" + ref.Tag + ref.Digest)
+	cached := dataPath(filepath.Join("oci", name+".tar.gz"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), ociTimeout)
+	defer cancel()
+	bundle, digest, err := pullOCI(ctx, ref)
+	if err != nil {
+		if _, statErr := os.Stat(cached); statErr == nil {
+			log.Printf("⚠️ Cannot pull %s, serving the copy pulled before: %v", reference, err)
+			return cached, nil
+		}
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return "", err
+	}
+	tmp := cached + ".tmp"
+	if err := ioutil.WriteFile(tmp, bundle, 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, cached); err != nil {
+		return "", err
+	}
+	log.Printf("📥 Pulled %s (%s)", reference, digest)
+	return cached, nil
+}
+
+// pushCommand pushes the loaded catalog to a registry, tagged with the
+// reference's tag, rev-HASH of the catalog content and any -tags
+func pushCommand(args []string) error {
+	flags := flag.NewFlagSet("push", flag.ContinueOnError)
+	extra := flags.String("tags", "", "comma-separated additional tags")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: push [-tags TAG,...] oci://REGISTRY/REPOSITORY[:TAG]")
+	}
+	ref, err := parseOCIReference(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if ref.Digest != "" {
+		return fmt.Errorf("push needs a tag, not a digest")
+	}
+
+	hash, err := catalogHash(servers)
+	if err != nil {
+		return err
+	}
+	tags := []string{ref.Tag, "rev-" + hash[:12]}
+	for _, tag := range strings.Split(*extra, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !ociTagPattern.MatchString(tag) {
+			return fmt.Errorf("'%s' is not a valid tag", tag)
+		}
+		if tag != ref.Tag && tag != tags[1] {
+			tags = append(tags, tag)
+		}
+	}
+
+	var bundle bytes.Buffer
+	if err := writeBundle(&bundle); err != nil {
+		return err
+	}
+	_, createdAt := revisionInfo()
+	annotations := map[string]string{
+		"org.opencontainers.image.created": createdAt.UTC().Format(time.RFC3339),
+		"com.dblitz.mcp-catalog.hash":      hash,
+		"com.dblitz.mcp-catalog.servers":   strconv.Itoa(len(servers)),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ociTimeout)
+	defer cancel()
+	digest, err := pushOCI(ctx, ref, bundle.Bytes(), annotations, tags)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pushed %d servers to oci://%s/%s@%s, tagged %s\n", len(servers), ref.Registry, ref.Repository, digest, strings.Join(tags, ", "))
+	return nil
+}
+
+// pullCommand writes the bundle of a catalog artifact to a file
+func pullCommand(args []string) error {
+	flags := flag.NewFlagSet("pull", flag.ContinueOnError)
+	output := flags.String("o", "catalog-bundle.tar.gz", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: pull [-o FILE] oci://REGISTRY/REPOSITORY[:TAG|@DIGEST]")
+	}
+	ref, err := parseOCIReference(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ociTimeout)
+	defer cancel()
+	bundle, digest, err := pullOCI(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*output, bundle, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s from %s (%s)\n", *output, ref.String(), digest)
+	return nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// openSearchFields are the indexed fields, in searchFieldNames order
+// followed by aliases. Each is stored case-folded and, under a "plain_"
+// prefix, transliterated, so matching mirrors the in-memory index.
+var openSearchFields = []string{"id", "name", "description", "examples", "aliases"}
+
+// matchKindRank orders match kinds from best to worst
+var matchKindRank = map[string]int{"exact": 3, "prefix": 2, "contains": 1}
+
+// openSearchIndex keeps the catalog in an OpenSearch (or Elasticsearch)
+// index. Every reindex builds a fresh concrete index and moves the alias
+// searches use onto it, so searches never see a half-built index.
+// Credentials go in the URL's userinfo.
+type openSearchIndex struct {
+	baseURL string
+	alias   string
+	client  *http.Client
+
+	// reindexMu serializes reindexes
+	reindexMu sync.Mutex
+
+	stateMu sync.Mutex
+	// latest numbers reindex requests, so one that waited behind a newer
+	// request gives up
+	latest     int64
+	reindexing bool
+	documents  int
+	indexedAt  *time.Time
+	lastErr    error
+}
+
+func newOpenSearchIndex(baseURL, alias string) *openSearchIndex {
+	return &openSearchIndex{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		alias:   alias,
+		// OpenSearch is the catalog's own backend, not an external
+		// service: searches must not queue behind the outbound limits
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// redactURL hides the password of a URL for logging
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return parsed.Redacted()
+}
+
+// do sends a request and decodes a JSON response into out, if given
+func (o *openSearchIndex) do(ctx context.Context, method, path string, body io.Reader, contentType string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+// document is what gets indexed for one entry: the normalized fields and
+// synonym-expanded aliases of the in-memory index
+func (o *openSearchIndex) document(entry indexedEntry) map[string]interface{} {
+	doc := map[string]interface{}{
+		"server_id":     entry.id,
+		"aliases":       entry.aliases,
+		"plain_aliases": entry.plainAliases,
+	}
+	for i, field := range entry.fields {
+		doc[openSearchFields[i]] = field
+		doc["plain_"+openSearchFields[i]] = entry.plainFields[i]
+	}
+	return doc
+}
+
+// Reindex rebuilds the index in the background; the previous index keeps
+// serving until the new one is complete
+func (o *openSearchIndex) Reindex(entries []indexedEntry) {
+	o.stateMu.Lock()
+	o.latest++
+	generation := o.latest
+	o.reindexing = true
+	o.stateMu.Unlock()
+
+	go func() {
+		o.reindexMu.Lock()
+		defer o.reindexMu.Unlock()
+		o.stateMu.Lock()
+		superseded := generation < o.latest
+		o.stateMu.Unlock()
+		if superseded {
+			return
+		}
+
+		count, err := o.rebuild(entries)
+		o.stateMu.Lock()
+		defer o.stateMu.Unlock()
+		o.lastErr = err
+		if err != nil {
+			log.Printf("❌ OpenSearch reindex failed: %v", err)
+		} else {
+			now := time.Now().UTC()
+			o.documents, o.indexedAt = count, &now
+			log.Printf("🔎 Indexed %d servers into OpenSearch", count)
+		}
+		if generation == o.latest {
+			o.reindexing = false
+		}
+	}()
+}
+
+// rebuild creates a new concrete index, bulk-loads the catalog, points the
+// alias at it and drops the indexes the alias pointed at before
+func (o *openSearchIndex) rebuild(entries []indexedEntry) (int, error) {
+	ctx := context.Background()
+	index := fmt.Sprintf("%s-%d", o.alias, time.Now().UnixNano())
+
+	properties := map[string]interface{}{"server_id": map[string]string{"type": "keyword"}}
+	for _, field := range openSearchFields {
+		properties[field] = map[string]string{"type": "keyword"}
+		properties["plain_"+field] = map[string]string{"type": "keyword"}
+	}
+	mapping, _ := json.Marshal(map[string]interface{}{
+		"mappings": map[string]interface{}{"dynamic": "strict", "properties": properties},
+	})
+	if err := o.do(ctx, "PUT", "/"+index, bytes.NewReader(mapping), "application/json", nil); err != nil {
+		return 0, err
+	}
+
+	var bulk bytes.Buffer
+	for _, entry := range entries {
+		action, _ := json.Marshal(map[string]interface{}{"index": map[string]string{"_index": index, "_id": entry.id}})
+		doc, _ := json.Marshal(o.document(entry))
+		bulk.Write(action)
+		bulk.WriteByte('\n')
+		bulk.Write(doc)
+		bulk.WriteByte('\n')
+	}
+	if len(entries) > 0 {
+		var result struct {
+			Errors bool `json:"errors"`
+		}
+		if err := o.do(ctx, "POST", "/_bulk?refresh=true", &bulk, "application/x-ndjson", &result); err != nil {
+			o.do(ctx, "DELETE", "/"+index, nil, "", nil)
+			return 0, err
+		}
+		if result.Errors {
+			o.do(ctx, "DELETE", "/"+index, nil, "", nil)
+			return 0, fmt.Errorf("bulk indexing into %s reported errors", index)
+		}
+	}
+
+	// The alias may not exist yet; a missing alias just has nothing to drop
+	previous := map[string]interface{}{}
+	if err := o.do(ctx, "GET", "/_alias/"+o.alias, nil, "", &previous); err != nil && !strings.Contains(err.Error(), "HTTP 404") {
+		return 0, err
+	}
+	actions := []map[string]interface{}{{"add": map[string]string{"index": index, "alias": o.alias}}}
+	for old := range previous {
+		actions = append(actions, map[string]interface{}{"remove": map[string]string{"index": old, "alias": o.alias}})
+	}
+	swap, _ := json.Marshal(map[string]interface{}{"actions": actions})
+	if err := o.do(ctx, "POST", "/_aliases", bytes.NewReader(swap), "application/json", nil); err != nil {
+		o.do(ctx, "DELETE", "/"+index, nil, "", nil)
+		return 0, err
+	}
+	for old := range previous {
+		if err := o.do(ctx, "DELETE", "/"+old, nil, "", nil); err != nil {
+			log.Printf("⚠️  Cannot delete old OpenSearch index %s: %v", old, err)
+		}
+	}
+	return len(entries), nil
+}
+
+// escapeWildcard escapes the characters wildcard queries treat specially
+func escapeWildcard(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(value)
+}
+
+// Search asks for every kind of match on every field as named queries, so
+// the hits report how they matched the same way the in-memory index does
+func (o *openSearchIndex) Search(ctx context.Context, query string) ([]SearchHit, error) {
+	normalized := newSearchQuery(query)
+	var should []map[string]interface{}
+	for _, form := range []struct{ prefix, value string }{{"", normalized.folded}, {"plain_", normalized.plain}} {
+		for _, field := range openSearchFields {
+			name := form.prefix + field
+			should = append(should,
+				map[string]interface{}{"term": map[string]interface{}{name: map[string]string{"value": form.value, "_name": name + "|exact"}}},
+				map[string]interface{}{"prefix": map[string]interface{}{name: map[string]string{"value": form.value, "_name": name + "|prefix"}}},
+				map[string]interface{}{"wildcard": map[string]interface{}{name: map[string]string{"value": "*" + escapeWildcard(form.value) + "*", "_name": name + "|contains"}}},
+			)
+		}
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"size":    max(1, min(len(servers), 10000)),
+		"_source": []string{"server_id", "aliases", "plain_aliases"},
+		"query":   map[string]interface{}{"bool": map[string]interface{}{"should": should, "minimum_should_match": 1}},
+	})
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					ServerID     string   `json:"server_id"`
+					Aliases      []string `json:"aliases"`
+					PlainAliases []string `json:"plain_aliases"`
+				} `json:"_source"`
+				MatchedQueries []string `json:"matched_queries"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := o.do(ctx, "POST", "/"+o.alias+"/_search", bytes.NewReader(body), "application/json", &result); err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		// Best kind per field, separately for folded and plain matches
+		best := map[bool]map[string]string{false: {}, true: {}}
+		for _, named := range hit.MatchedQueries {
+			field, kind, _ := strings.Cut(named, "|")
+			plain := strings.HasPrefix(field, "plain_")
+			field = strings.TrimPrefix(field, "plain_")
+			if matchKindRank[kind] > matchKindRank[best[plain][field]] {
+				best[plain][field] = kind
+			}
+		}
+		plain, aliases, value := false, hit.Source.Aliases, normalized.folded
+		if len(best[false]) == 0 {
+			plain, aliases, value = true, hit.Source.PlainAliases, normalized.plain
+		}
+
+		var matches []FieldMatch
+		for _, field := range searchFieldNames {
+			if kind := best[plain][field]; kind != "" {
+				matches = append(matches, newFieldMatch(field, "", kind, plain))
+			}
+		}
+		if best[plain]["aliases"] != "" {
+			// The index says an alias matched; find which one scores best
+			var bestAlias *FieldMatch
+			for i, alias := range aliases {
+				if kind := matchKind(alias, value); kind != "" && i < len(hit.Source.Aliases) {
+					candidate := newFieldMatch("alias", hit.Source.Aliases[i], kind, plain)
+					if bestAlias == nil || candidate.Score > bestAlias.Score {
+						bestAlias = &candidate
+					}
+				}
+			}
+			if bestAlias != nil {
+				matches = append(matches, *bestAlias)
+			}
+		}
+		if len(matches) > 0 {
+			hits = append(hits, SearchHit{ID: hit.Source.ServerID, Matches: matches})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].ID < hits[j].ID })
+	return hits, nil
+}
+
+// Health counts the documents behind the alias
+func (o *openSearchIndex) Health(ctx context.Context) IndexHealth {
+	o.stateMu.Lock()
+	health := IndexHealth{Backend: "opensearch", Status: "ok", Documents: o.documents, IndexedAt: o.indexedAt}
+	reindexing, lastErr := o.reindexing, o.lastErr
+	o.stateMu.Unlock()
+
+	var count struct {
+		Count int `json:"count"`
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	err := o.do(ctx, "GET", "/"+o.alias+"/_count", nil, "", &count)
+	switch {
+	case err != nil:
+		health.Status, health.Error = "unavailable", err.Error()
+	case reindexing:
+		health.Status, health.Documents = "reindexing", count.Count
+	case lastErr != nil:
+		health.Status, health.Documents, health.Error = "degraded", count.Count, "last reindex failed: "+lastErr.Error()
+	default:
+		health.Documents = count.Count
+	}
+	return health
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Enrichment, link checks, syncs and the other fetches from external
+// services share one HTTP transport, so connections to a host are pooled
+// across them, and every request it sends is throttled so that GitHub and
+// the package registries do not ban the catalog: at most
+// outbound.concurrency requests are in flight at once, requests to one
+// dependency (named as breakers name them, so the GitHub hosts share a
+// limit) are spaced to its outbound.rate_limits per second, and GET and
+// HEAD requests failing with a transport error, 429, 502, 503 or 504 are
+// retried up to outbound.retries times after Retry-After or a jittered
+// exponential backoff. Requests go through outbound.proxy when set, else
+// the proxy of HTTP_PROXY, HTTPS_PROXY and NO_PROXY. /readyz?verbose=true
+// reports what was sent to each dependency.
+
+// outboundRetryBase is the backoff before the first retry; it doubles for
+// every retry after
+const outboundRetryBase = 500 * time.Millisecond
+
+// outboundRetryMax bounds a backoff; an answer asking to retry after
+// longer than this is returned as is
+const outboundRetryMax = 10 * time.Second
+
+// outboundIdlePerHost is how many idle connections are kept to one host
+const outboundIdlePerHost = 16
+
+// defaultOutboundRates are the default outbound.rate_limits; "*" is the
+// limit of each host with no limit of its own
+var defaultOutboundRates = []string{"github=5", "npm=10", "pypi=10", "docker=5", "*=20"}
+
+// OutboundStatus counts what was sent to a dependency. Requests include
+// retries; Failures are requests that failed after their last retry, and
+// ThrottledMS is the time requests waited on the rate limit.
+type OutboundStatus struct {
+	RateLimit   float64 `json:"rate_limit,omitempty"`
+	Requests    int64   `json:"requests"`
+	Retries     int64   `json:"retries"`
+	Failures    int64   `json:"failures"`
+	Throttled   int64   `json:"throttled"`
+	ThrottledMS int64   `json:"throttled_ms"`
+}
+
+// OutboundReport is the shared client as /readyz?verbose=true reports it
+type OutboundReport struct {
+	Concurrency  int                       `json:"concurrency"`
+	InFlight     int                       `json:"in_flight"`
+	Dependencies map[string]OutboundStatus `json:"dependencies"`
+}
+
+type outboundDependency struct {
+	interval time.Duration
+	next     time.Time
+	status   OutboundStatus
+}
+
+// throttledTransport is the RoundTripper of the shared client. It is set
+// up from cfg on first use.
+type throttledTransport struct {
+	once    sync.Once
+	base    http.RoundTripper
+	slots   chan struct{}
+	rates   map[string]float64
+	retries int
+
+	mu           sync.Mutex
+	dependencies map[string]*outboundDependency
+}
+
+var outbound = &throttledTransport{}
+
+// outboundClient returns a client of the shared transport for calls to
+// external services; timeout bounds a call including its retries
+func outboundClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: outbound}
+}
+
+// parseOutboundRates parses "DEPENDENCY=REQUESTS_PER_SECOND" limits; 0 is
+// unlimited
+func parseOutboundRates(specs []string) (map[string]float64, error) {
+	rates := make(map[string]float64, len(specs))
+	for _, spec := range specs {
+		name, raw, ok := strings.Cut(spec, "=")
+		rate, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if !ok || strings.TrimSpace(name) == "" || err != nil || rate < 0 {
+			return nil, fmt.Errorf("outbound.rate_limits: '%s' must be DEPENDENCY=REQUESTS_PER_SECOND, such as github=5 or *=20", spec)
+		}
+		rates[strings.ToLower(strings.TrimSpace(name))] = rate
+	}
+	return rates, nil
+}
+
+// validateOutbound checks the outbound settings
+func validateOutbound(c *Config) error {
+	if c.OutboundConcurrency < 1 || c.OutboundRetries < 0 {
+		return fmt.Errorf("outbound.concurrency must be positive and outbound.retries not negative")
+	}
+	if _, err := parseOutboundRates(c.OutboundRateLimits); err != nil {
+		return err
+	}
+	if c.OutboundProxy != "" {
+		proxy, err := url.Parse(c.OutboundProxy)
+		if err != nil || proxy.Host == "" || (proxy.Scheme != "http" && proxy.Scheme != "https" && proxy.Scheme != "socks5") {
+			return fmt.Errorf("outbound.proxy must be an http, https or socks5 URL")
+		}
+	}
+	return nil
+}
+
+func (t *throttledTransport) init() {
+	t.once.Do(func() {
+		c := cfg
+		if c == nil {
+			c = defaultConfig()
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = outboundIdlePerHost
+		if c.OutboundProxy != "" {
+			if proxy, err := url.Parse(c.OutboundProxy); err == nil {
+				transport.Proxy = http.ProxyURL(proxy)
+			}
+		}
+		t.base = transport
+		t.slots = make(chan struct{}, max(c.OutboundConcurrency, 1))
+		t.rates, _ = parseOutboundRates(c.OutboundRateLimits)
+		t.retries = c.OutboundRetries
+		t.dependencies = make(map[string]*outboundDependency)
+	})
+}
+
+// RoundTrip sends a request once it has a slot and its dependency's rate
+// allows, retrying it when it may
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.init()
+	ctx := req.Context()
+	name := dependencyName(req)
+	idempotent := (req.Method == "GET" || req.Method == "HEAD") && (req.Body == nil || req.Body == http.NoBody)
+	for attempt := 0; ; attempt++ {
+		if err := t.throttle(ctx, name, attempt > 0); err != nil {
+			return nil, err
+		}
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		resp, err := t.base.RoundTrip(req)
+		last := !idempotent || attempt >= t.retries || ctx.Err() != nil
+		if err != nil {
+			<-t.slots
+			if last {
+				t.count(name, func(s *OutboundStatus) { s.Failures++ })
+				return nil, err
+			}
+			if err := sleepContext(ctx, retryBackoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		release := sync.OnceFunc(func() { <-t.slots })
+		if retryableStatus(resp.StatusCode) {
+			delay, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				delay = retryBackoff(attempt)
+			}
+			if !last && delay <= outboundRetryMax {
+				io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+				resp.Body.Close()
+				release()
+				if err := sleepContext(ctx, delay); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			t.count(name, func(s *OutboundStatus) { s.Failures++ })
+		}
+		resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	}
+}
+
+// throttle counts a request to a dependency and waits for its turn under
+// the dependency's rate limit
+func (t *throttledTransport) throttle(ctx context.Context, name string, retry bool) error {
+	now := time.Now()
+	t.mu.Lock()
+	dependency := t.dependency(name)
+	dependency.status.Requests++
+	if retry {
+		dependency.status.Retries++
+	}
+	at := now
+	if dependency.interval > 0 {
+		if dependency.next.After(now) {
+			at = dependency.next
+		}
+		dependency.next = at.Add(dependency.interval)
+	}
+	wait := at.Sub(now)
+	if wait > 0 {
+		dependency.status.Throttled++
+		dependency.status.ThrottledMS += wait.Milliseconds()
+	}
+	t.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, wait)
+}
+
+// dependency returns a dependency's state, limited to its own rate or
+// else to "*". t.mu must be held.
+func (t *throttledTransport) dependency(name string) *outboundDependency {
+	dependency := t.dependencies[name]
+	if dependency == nil {
+		rate, ok := t.rates[name]
+		if !ok {
+			rate = t.rates["*"]
+		}
+		dependency = &outboundDependency{status: OutboundStatus{RateLimit: rate}}
+		if rate > 0 {
+			dependency.interval = time.Duration(float64(time.Second) / rate)
+		}
+		t.dependencies[name] = dependency
+	}
+	return dependency
+}
+
+func (t *throttledTransport) count(name string, update func(*OutboundStatus)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	update(&t.dependency(name).status)
+}
+
+// outboundReport returns the shared client's slots in use and what each
+// dependency was sent
+func outboundReport() OutboundReport {
+	outbound.init()
+	outbound.mu.Lock()
+	defer outbound.mu.Unlock()
+	report := OutboundReport{Concurrency: cap(outbound.slots), InFlight: len(outbound.slots), Dependencies: make(map[string]OutboundStatus, len(outbound.dependencies))}
+	for name, dependency := range outbound.dependencies {
+		report.Dependencies[name] = dependency.status
+	}
+	return report
+}
+
+// releasingBody gives back a request's slot once its answer is read or
+// closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// retryableStatus reports whether an answer asks the request to be tried
+// again later
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryBackoff is the wait before retry attempt+1: outboundRetryBase
+// doubled per attempt up to outboundRetryMax, of which a random half is
+// waited so retries of many requests spread out
+func retryBackoff(attempt int) time.Duration {
+	backoff := outboundRetryMax
+	if attempt < 16 {
+		backoff = min(outboundRetryBase<<attempt, outboundRetryMax)
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Overlays let a tenant, an API key user, change fields of public entries
+// without forking them: pin versions, point args at an internal proxy or
+// drop env variables. An overlay is an RFC 7386 merge patch per server ID,
+// applied when the tenant reads the catalog or generates a config. The
+// precedence is the served entry, with upstream syncs and local edits
+// already merged, then the tenant's overlay, which wins; null removes a
+// field. Overlays never add entries, and past catalogs read with
+// ?at_revision or ?at_time are served as they were.
+
+// overlayHeader names the tenant whose overlays a read applied
+const overlayHeader = "X-Catalog-Overlay"
+
+// overlays holds the merge patches of every tenant by server ID, kept in
+// data/overlays.json
+var (
+	overlaysMu sync.RWMutex
+	overlays   = make(map[string]map[string]map[string]interface{})
+)
+
+func loadOverlays() {
+	var stored map[string]map[string]map[string]interface{}
+	if err := readJSONFile(dataPath("overlays.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load tenant overlays: %v", err)
+		return
+	}
+	if stored != nil {
+		overlaysMu.Lock()
+		overlays = stored
+		overlaysMu.Unlock()
+	}
+}
+
+// overlayEntry applies a patch to an entry; the ID cannot be overridden
+func overlayEntry(config, patch map[string]interface{}) map[string]interface{} {
+	overlaid := applyMergePatch(config, patch).(map[string]interface{})
+	if id, ok := config["id"]; ok {
+		overlaid["id"] = id
+	}
+	return overlaid
+}
+
+// tenantOverlays returns the overlays of the tenant a request comes from
+func tenantOverlays(r *http.Request) (string, map[string]map[string]interface{}) {
+	tenant, ok := apiKeyUser(r)
+	if !ok {
+		return "", nil
+	}
+	overlaysMu.RLock()
+	defer overlaysMu.RUnlock()
+	return tenant, overlays[tenant]
+}
+
+// overlaidCatalog returns the entries with the requesting tenant's
+// overlays applied. A tenant's view must not reach a shared cache, so the
+// response is marked private.
+func overlaidCatalog(w http.ResponseWriter, r *http.Request, entries map[string]interface{}) map[string]interface{} {
+	tenant, patches := tenantOverlays(r)
+	if len(patches) == 0 {
+		return entries
+	}
+	overlaid := make(map[string]interface{}, len(entries))
+	for serverID, entry := range entries {
+		overlaid[serverID] = entry
+	}
+	for serverID, patch := range patches {
+		if config, ok := entries[serverID].(map[string]interface{}); ok {
+			overlaid[serverID] = overlayEntry(config, patch)
+		}
+	}
+	w.Header().Set(overlayHeader, tenant)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	return overlaid
+}
+
+// tenantEntry is getEntry with the requesting tenant's overlay applied
+func tenantEntry(r *http.Request, serverID string) (map[string]interface{}, bool) {
+	config, exists := getEntry(serverID)
+	if !exists {
+		return nil, false
+	}
+	if _, patches := tenantOverlays(r); patches[serverID] != nil {
+		return overlayEntry(config, patches[serverID]), true
+	}
+	return config, true
+}
+
+// overlaidFields names the top-level fields the requesting tenant's
+// overlay changes in an entry
+func overlaidFields(r *http.Request, serverID string) []string {
+	_, patches := tenantOverlays(r)
+	var fields []string
+	for field := range patches[serverID] {
+		if field != "id" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// TenantOverlay is the overlay set of one tenant in the admin API
+type TenantOverlay struct {
+	Tenant  string                            `json:"tenant"`
+	Servers map[string]map[string]interface{} `json:"servers"`
+}
+
+// adminOverlaysHandler lists the overlays of every tenant
+func adminOverlaysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	overlaysMu.RLock()
+	defer overlaysMu.RUnlock()
+	result := []TenantOverlay{}
+	for _, tenant := range keyUsers() {
+		if patches, ok := overlays[tenant]; ok {
+			result = append(result, TenantOverlay{Tenant: tenant, Servers: patches})
+		}
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// adminOverlayHandler reads (GET), replaces (PUT {"servers": {ID: PATCH}})
+// or removes (DELETE) the overlays of a tenant. Every patched entry must
+// exist and still validate with the patch applied.
+func adminOverlayHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	tenant := r.PathValue("tenant")
+	if !slices.Contains(keyUsers(), tenant) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No API key is configured for user '%s'", tenant))
+		return
+	}
+
+	overlaysMu.Lock()
+	defer overlaysMu.Unlock()
+	switch r.Method {
+	case "GET":
+		patches, ok := overlays[tenant]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Tenant '%s' has no overlays", tenant))
+			return
+		}
+		json.NewEncoder(w).Encode(TenantOverlay{Tenant: tenant, Servers: patches})
+		return
+	case "PUT":
+		var req struct {
+			Servers map[string]map[string]interface{} `json:"servers"`
+		}
+		limitBody(w, r)
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		if len(req.Servers) == 0 {
+			writeError(w, http.StatusBadRequest, "Field 'servers' must map server IDs to merge patches; DELETE removes every overlay")
+			return
+		}
+		patches := make(map[string]map[string]interface{}, len(req.Servers))
+		var problems []string
+		for serverID, patch := range req.Servers {
+			canonical := canonicalID(serverID)
+			config, exists := getEntry(canonical)
+			if !exists {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+				return
+			}
+			if _, ok := patch["id"]; ok || len(patch) == 0 {
+				problems = append(problems, fmt.Sprintf("%s: the overlay must patch at least one field other than 'id'", canonical))
+				continue
+			}
+			for _, problem := range validateEntry(overlayEntry(config, patch)) {
+				problems = append(problems, canonical+": "+problem)
+			}
+			patches[canonical] = patch
+		}
+		if len(problems) > 0 {
+			sort.Strings(problems)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":    "The overlaid entries do not validate",
+				"problems": problems,
+			})
+			return
+		}
+		overlays[tenant] = patches
+		ids := make([]string, 0, len(patches))
+		for serverID := range patches {
+			ids = append(ids, serverID)
+		}
+		sort.Strings(ids)
+		log.Printf("🩹 Set overlays of '%s' for %s", tenant, strings.Join(ids, ", "))
+	case "DELETE":
+		delete(overlays, tenant)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := writeJSONFile(dataPath("overlays.json"), overlays); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if r.Method == "DELETE" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(TenantOverlay{Tenant: tenant, Servers: overlays[tenant]})
+}
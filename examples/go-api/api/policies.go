@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Config policies restrict what generate-config and the bulk endpoint emit
+// for a tenant, an API key user. The policy under "*" applies to anonymous
+// callers and to tenants without a policy of their own; a tenant's policy
+// replaces it rather than adding to it. A request breaking its policy is
+// answered 422 with every violation instead of a config.
+
+// defaultPolicyTenant is the key of the policy for everyone else
+const defaultPolicyTenant = "*"
+
+// policyTiers are the tiers of an entry, from the "official" tier marker
+// and its vendor's verification
+var policyTiers = []string{"official", "verified", "community"}
+
+// ConfigPolicy is what a tenant may install. Empty lists and a zero
+// MaxServers do not restrict.
+type ConfigPolicy struct {
+	AllowedTiers     []string `json:"allowed_tiers,omitempty"`
+	AllowedLicenses  []string `json:"allowed_licenses,omitempty"`
+	BannedRiskFlags  []string `json:"banned_risk_flags,omitempty"`
+	BannedVendors    []string `json:"banned_vendors,omitempty"`
+	MaxServers       int      `json:"max_servers,omitempty"`
+	MandatoryServers []string `json:"mandatory_servers,omitempty"`
+}
+
+// PolicyViolation is one way a selection breaks a policy. Rules are tier,
+// license, risk, vendor, max_servers and mandatory.
+type PolicyViolation struct {
+	Rule     string `json:"rule"`
+	ServerID string `json:"server_id,omitempty"`
+	Message  string `json:"message"`
+}
+
+// policies holds the config policies by tenant, kept in data/policies.json
+var (
+	policiesMu sync.RWMutex
+	policies   = make(map[string]ConfigPolicy)
+)
+
+func loadPolicies() {
+	var stored map[string]ConfigPolicy
+	if err := readJSONFile(dataPath("policies.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load config policies: %v", err)
+		return
+	}
+	if stored != nil {
+		policiesMu.Lock()
+		policies = stored
+		policiesMu.Unlock()
+	}
+}
+
+// entryTier is official for entries with the official tier marker,
+// verified for those of a verified vendor and community otherwise
+func entryTier(config map[string]interface{}) string {
+	categories, _ := stringList(config["categories"])
+	if slices.Contains(categories, "official") {
+		return "official"
+	}
+	if vendor, _ := vendorRecord(entryVendor(config)); vendor.Verification == "verified" {
+		return "verified"
+	}
+	return "community"
+}
+
+// requestPolicy returns the policy of the tenant a request comes from and
+// the key it is stored under
+func requestPolicy(r *http.Request) (string, ConfigPolicy, bool) {
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	if tenant, ok := apiKeyUser(r); ok {
+		if policy, ok := policies[tenant]; ok {
+			return tenant, policy, true
+		}
+	}
+	policy, ok := policies[defaultPolicyTenant]
+	return defaultPolicyTenant, policy, ok
+}
+
+// evaluatePolicy checks a selection, required dependencies included,
+// against a policy. Legacy IDs count as the entries they name; entries
+// are read with the tenant's overlays.
+func evaluatePolicy(r *http.Request, policy ConfigPolicy, requested []string) []PolicyViolation {
+	canonical := make([]string, len(requested))
+	for i, serverID := range requested {
+		canonical[i] = canonicalID(serverID)
+	}
+	selected, _ := withRequiredDependencies(canonical)
+	var violations []PolicyViolation
+	for _, serverID := range selected {
+		violations = append(violations, entryPolicyViolations(r, policy, serverID)...)
+	}
+	if policy.MaxServers > 0 && len(selected) > policy.MaxServers {
+		violations = append(violations, PolicyViolation{Rule: "max_servers",
+			Message: fmt.Sprintf("%d servers are selected, dependencies included; at most %d are allowed", len(selected), policy.MaxServers)})
+	}
+	for _, serverID := range policy.MandatoryServers {
+		if !slices.Contains(selected, canonicalID(serverID)) {
+			violations = append(violations, PolicyViolation{Rule: "mandatory", ServerID: serverID,
+				Message: fmt.Sprintf("'%s' is mandatory and must be selected", serverID)})
+		}
+	}
+	return violations
+}
+
+// entryPolicyViolations checks one entry against the tier, license, risk
+// and vendor rules of a policy
+func entryPolicyViolations(r *http.Request, policy ConfigPolicy, serverID string) []PolicyViolation {
+	config, exists := tenantEntry(r, serverID)
+	if !exists {
+		return nil
+	}
+	var violations []PolicyViolation
+	if tier := entryTier(config); len(policy.AllowedTiers) > 0 && !slices.Contains(policy.AllowedTiers, tier) {
+		violations = append(violations, PolicyViolation{Rule: "tier", ServerID: serverID,
+			Message: fmt.Sprintf("'%s' is %s; allowed tiers are %s", serverID, tier, strings.Join(policy.AllowedTiers, ", "))})
+	}
+	license := getString(config, "license", "Unknown")
+	if len(policy.AllowedLicenses) > 0 && !slices.ContainsFunc(policy.AllowedLicenses, func(allowed string) bool { return strings.EqualFold(allowed, license) }) {
+		violations = append(violations, PolicyViolation{Rule: "license", ServerID: serverID,
+			Message: fmt.Sprintf("'%s' is licensed %s; allowed licenses are %s", serverID, license, strings.Join(policy.AllowedLicenses, ", "))})
+	}
+	if risk := entryRisk(config); risk != nil {
+		for _, flag := range risk.Flags {
+			if slices.Contains(policy.BannedRiskFlags, flag) {
+				violations = append(violations, PolicyViolation{Rule: "risk", ServerID: serverID,
+					Message: fmt.Sprintf("'%s' has the banned risk flag %s", serverID, flag)})
+			}
+		}
+	}
+	if vendor := entryVendor(config); slices.Contains(policy.BannedVendors, vendor) {
+		violations = append(violations, PolicyViolation{Rule: "vendor", ServerID: serverID,
+			Message: fmt.Sprintf("'%s' is from the banned vendor %s", serverID, vendor)})
+	}
+	return violations
+}
+
+// writePolicyViolations answers a request that breaks its policy
+func writePolicyViolations(w http.ResponseWriter, tenant string, violations interface{}) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":      fmt.Sprintf("The selection violates the config policy of '%s'", tenant),
+		"policy":     tenant,
+		"violations": violations,
+	})
+}
+
+// validatePolicy checks the values of a policy
+func validatePolicy(policy ConfigPolicy) []string {
+	var problems []string
+	for _, tier := range policy.AllowedTiers {
+		if !slices.Contains(policyTiers, tier) {
+			problems = append(problems, fmt.Sprintf("'allowed_tiers' entry '%s' must be one of %s", tier, strings.Join(policyTiers, ", ")))
+		}
+	}
+	for _, flag := range policy.BannedRiskFlags {
+		if _, ok := riskFlagLevels[flag]; !ok {
+			problems = append(problems, fmt.Sprintf("'banned_risk_flags' entry '%s' is not a risk flag", flag))
+		}
+	}
+	if policy.MaxServers < 0 {
+		problems = append(problems, "'max_servers' must not be negative; 0 means unlimited")
+	}
+	for _, serverID := range policy.MandatoryServers {
+		if _, exists := getEntry(serverID); !exists {
+			problems = append(problems, fmt.Sprintf("'mandatory_servers' entry '%s' is not in the catalog", serverID))
+		}
+	}
+	if policy.MaxServers > 0 && len(policy.MandatoryServers) > policy.MaxServers {
+		problems = append(problems, "'mandatory_servers' must not exceed 'max_servers'")
+	}
+	return problems
+}
+
+// TenantPolicy is the policy of one tenant in the admin API
+type TenantPolicy struct {
+	Tenant string       `json:"tenant"`
+	Policy ConfigPolicy `json:"policy"`
+}
+
+// adminPoliciesHandler lists the config policies
+func adminPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	policiesMu.RLock()
+	defer policiesMu.RUnlock()
+	tenants := make([]string, 0, len(policies))
+	for tenant := range policies {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	result := []TenantPolicy{}
+	for _, tenant := range tenants {
+		result = append(result, TenantPolicy{Tenant: tenant, Policy: policies[tenant]})
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// adminPolicyHandler reads (GET), sets (PUT) or removes (DELETE) the
+// policy of a key user, or of everyone else under "*"
+func adminPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	tenant := r.PathValue("tenant")
+	if tenant != defaultPolicyTenant && !slices.Contains(keyUsers(), tenant) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No API key is configured for user '%s'", tenant))
+		return
+	}
+
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	switch r.Method {
+	case "GET":
+		policy, ok := policies[tenant]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Tenant '%s' has no policy", tenant))
+			return
+		}
+		json.NewEncoder(w).Encode(TenantPolicy{Tenant: tenant, Policy: policy})
+		return
+	case "PUT":
+		var policy ConfigPolicy
+		limitBody(w, r)
+		if err := decodeJSONBody(w, r, &policy); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		for i, serverID := range policy.MandatoryServers {
+			policy.MandatoryServers[i] = canonicalID(serverID)
+		}
+		if problems := validatePolicy(policy); len(problems) > 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":    "Invalid policy",
+				"problems": problems,
+			})
+			return
+		}
+		policies[tenant] = policy
+		log.Printf("📜 Set the config policy of '%s'", tenant)
+	case "DELETE":
+		delete(policies, tenant)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := writeJSONFile(dataPath("policies.json"), policies); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if r.Method == "DELETE" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(TenantPolicy{Tenant: tenant, Policy: policies[tenant]})
+}
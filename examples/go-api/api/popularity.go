@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// popularityRetentionDays bounds how much history is kept per server
+	popularityRetentionDays = 365
+	// defaultTrendWindowDays is the window of ?sort=trending and the stats
+	// endpoint when ?window is not given
+	defaultTrendWindowDays = 30
+	// dayLayout keys the daily counters
+	dayLayout = "2006-01-02"
+	// popularityFlushInterval is how often install counts are written to
+	// disk
+	popularityFlushInterval = 30 * time.Second
+)
+
+// DailyCount is a server's installs on one UTC day
+type DailyCount struct {
+	Date     string `json:"date"`
+	Installs int    `json:"installs"`
+}
+
+// Trend compares a server's installs in a window with the window before
+type Trend struct {
+	Installs         int `json:"installs"`
+	PreviousInstalls int `json:"previous_installs"`
+	Growth           int `json:"growth"`
+}
+
+// popularity holds install counts per server and UTC day, persisted in
+// data/popularity.json by startPopularityFlush rather than on every
+// install
+var (
+	popularityMu    sync.Mutex
+	popularity      = make(map[string]map[string]int)
+	popularityDirty bool
+)
+
+// loadPopularity restores the daily series and seeds the install totals
+// search ranking uses
+func loadPopularity() {
+	var stored map[string]map[string]int
+	if err := readJSONFile(dataPath("popularity.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load popularity: %v", err)
+		return
+	}
+	if stored == nil {
+		return
+	}
+	stored = canonicalKeys(stored)
+	popularityMu.Lock()
+	popularity = stored
+	popularityMu.Unlock()
+
+	installStats.Lock()
+	defer installStats.Unlock()
+	for serverID, days := range stored {
+		for _, count := range days {
+			installStats.installs[serverID] += count
+		}
+	}
+}
+
+// recordDailyInstalls adds one install of each server to today's counters
+func recordDailyInstalls(ids []string, now time.Time) {
+	today := now.UTC().Format(dayLayout)
+
+	popularityMu.Lock()
+	defer popularityMu.Unlock()
+	for _, serverID := range ids {
+		if popularity[serverID] == nil {
+			popularity[serverID] = make(map[string]int)
+		}
+		popularity[serverID][today]++
+	}
+	popularityDirty = len(ids) > 0 || popularityDirty
+}
+
+// startPopularityFlush periodically persists install counts, dropping days
+// past the retention
+func startPopularityFlush() {
+	go func() {
+		for range time.Tick(popularityFlushInterval) {
+			flushPopularity(time.Now())
+		}
+	}()
+}
+
+func flushPopularity(now time.Time) {
+	popularityMu.Lock()
+	defer popularityMu.Unlock()
+	if !popularityDirty {
+		return
+	}
+	cutoff := now.UTC().AddDate(0, 0, -popularityRetentionDays).Format(dayLayout)
+	for _, days := range popularity {
+		for day := range days {
+			if day < cutoff {
+				delete(days, day)
+			}
+		}
+	}
+	if err := writeJSONFile(dataPath("popularity.json"), popularity); err != nil {
+		log.Printf("❌ Failed to save popularity: %v", err)
+		return
+	}
+	popularityDirty = false
+}
+
+// parseWindow reads a window of days such as "90d" from a query parameter
+func parseWindow(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("window")
+	if raw == "" {
+		return defaultTrendWindowDays, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(raw, "d"))
+	if err != nil || !strings.HasSuffix(raw, "d") || days < 1 || days > popularityRetentionDays {
+		return 0, fmt.Errorf("Query parameter 'window' must be a number of days from 1d to %dd", popularityRetentionDays)
+	}
+	return days, nil
+}
+
+// dailyInstalls returns a server's installs for each of the last days
+// ending today, oldest first, with days without installs as zero
+func dailyInstalls(serverID string, days int, now time.Time) []DailyCount {
+	popularityMu.Lock()
+	defer popularityMu.Unlock()
+	series := make([]DailyCount, days)
+	for i := range series {
+		day := now.UTC().AddDate(0, 0, i-days+1).Format(dayLayout)
+		series[i] = DailyCount{Date: day, Installs: popularity[serverID][day]}
+	}
+	return series
+}
+
+// serverTrend sums a server's installs over the days up to now and the
+// same number of days before them
+func serverTrend(serverID string, days int, now time.Time) Trend {
+	end := now.UTC().Format(dayLayout)
+	start := now.UTC().AddDate(0, 0, -days+1).Format(dayLayout)
+	previousStart := now.UTC().AddDate(0, 0, -2*days+1).Format(dayLayout)
+
+	popularityMu.Lock()
+	defer popularityMu.Unlock()
+	var trend Trend
+	for day, count := range popularity[serverID] {
+		switch {
+		case day > end:
+		case day >= start:
+			trend.Installs += count
+		case day >= previousStart:
+			trend.PreviousInstalls += count
+		}
+	}
+	trend.Growth = trend.Installs - trend.PreviousInstalls
+	return trend
+}
+
+// sortTrending orders search results by install growth over the window,
+// then by installs in the window, keeping relevance order for ties
+func sortTrending(results []rankedResult, days int, now time.Time) {
+	for i := range results {
+		trend := serverTrend(results[i].server.ID, days, now)
+		results[i].server.Trend = &trend
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].server.Trend, results[j].server.Trend
+		if a.Growth != b.Growth {
+			return a.Growth > b.Growth
+		}
+		return a.Installs > b.Installs
+	})
+}
+
+// serverStatsHandler serves a server's daily installs over ?window=90d,
+// for trend charts
+func serverStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serverID := r.PathValue("id")
+	if _, exists := getEntry(serverID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+	days, err := parseWindow(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	installStats.Lock()
+	total := installStats.installs[serverID]
+	installStats.Unlock()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":             serverID,
+		"window":         fmt.Sprintf("%dd", days),
+		"daily":          dailyInstalls(serverID, days, now),
+		"trend":          serverTrend(serverID, days, now),
+		"installs_total": total,
+	})
+}
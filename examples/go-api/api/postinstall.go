@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// inspectorCLI lists the tools of a server from a shell, without a client
+var inspectorCLI = []string{"npx", "-y", "@modelcontextprotocol/inspector", "--cli"}
+
+// mcpDebuggingDocs is the protocol's general troubleshooting guide
+const mcpDebuggingDocs = "https://modelcontextprotocol.io/docs/tools/debugging"
+
+// ClientCheck is how to confirm inside a client that it loaded the servers
+type ClientCheck struct {
+	Command string `json:"command,omitempty"`
+	Steps   string `json:"steps"`
+	Docs    string `json:"docs,omitempty"`
+}
+
+// clientChecks are the known clients' own views of their MCP servers
+var clientChecks = map[string]ClientCheck{
+	"claude_desktop": {Steps: "Open a new chat and click the tools icon; every server should list its tools", Docs: "https://modelcontextprotocol.io/quickstart/user"},
+	"claude_code":    {Command: "claude mcp list", Steps: "Every server should be listed as connected", Docs: "https://docs.anthropic.com/en/docs/claude-code/mcp"},
+	"cursor":         {Steps: "Open Settings > MCP; every server should show a green dot and its tools", Docs: "https://docs.cursor.com/context/model-context-protocol"},
+	"vscode":         {Steps: "Run \"MCP: List Servers\" from the command palette; every server should be running", Docs: "https://code.visualstudio.com/docs/copilot/chat/mcp-servers"},
+	"windsurf":       {Steps: "Open the Cascade MCP panel and refresh; every server should list its tools", Docs: "https://docs.windsurf.com/windsurf/cascade/mcp"},
+}
+
+// PostInstallCheck verifies one configured server from a shell
+type PostInstallCheck struct {
+	Server  string `json:"server"`
+	Command string `json:"command"`
+	Expect  string `json:"expect"`
+	// ExpectedTools are the tools the entry declares
+	ExpectedTools []string `json:"expected_tools,omitempty"`
+	// RequiresEnv must be exported before running the command
+	RequiresEnv     []string `json:"requires_env,omitempty"`
+	Notes           string   `json:"notes,omitempty"`
+	Troubleshooting []string `json:"troubleshooting"`
+}
+
+// PostInstall is the post_install block of a generate-config response
+type PostInstall struct {
+	Client  *ClientCheck       `json:"client,omitempty"`
+	Servers []PostInstallCheck `json:"servers"`
+}
+
+// entryVerify decodes the optional "verify" block of an entry, a command
+// and its expected output that replace the generic inspector check
+func entryVerify(config map[string]interface{}) (command, expect string) {
+	verify, _ := config["verify"].(map[string]interface{})
+	return getString(verify, "command", ""), getString(verify, "expect", "")
+}
+
+// postInstallChecks builds a verification command for every included
+// server from the launch config the client received
+func postInstallChecks(format string, included []string, mcpServers map[string]interface{}, bridges []*Bridge) *PostInstall {
+	result := &PostInstall{Servers: []PostInstallCheck{}}
+	if check, ok := clientChecks[format]; ok {
+		result.Client = &check
+	}
+	daemons := make(map[string]*Bridge)
+	for _, bridge := range bridges {
+		if len(bridge.Command) > 0 {
+			daemons[bridge.Server] = bridge
+		}
+	}
+
+	for _, serverID := range included {
+		entry, _ := getEntry(serverID)
+		mcpConfig, _ := mcpServers[serverID].(map[string]interface{})
+		check := PostInstallCheck{Server: serverID, Command: inspectorCommand(mcpConfig), Troubleshooting: troubleshootingLinks(entry, format)}
+		for _, tool := range entryTools(entry) {
+			check.ExpectedTools = append(check.ExpectedTools, tool.Name)
+		}
+		if env, _ := mcpConfig["env"].(map[string]string); len(env) > 0 {
+			for key := range env {
+				check.RequiresEnv = append(check.RequiresEnv, key)
+			}
+			sort.Strings(check.RequiresEnv)
+		}
+		if bridge := daemons[serverID]; bridge != nil {
+			check.Notes = fmt.Sprintf("Start the %s bridge first: %s", bridge.Tool, shellCommand(bridge.Command[0], bridge.Command[1:]))
+		}
+
+		switch command, expect := entryVerify(entry); {
+		case command != "":
+			check.Command, check.Expect = command, expect
+		case len(check.ExpectedTools) > 0:
+			check.Expect = "A JSON tools list naming " + strings.Join(check.ExpectedTools, ", ")
+		default:
+			check.Expect = "A JSON object with a non-empty \"tools\" list"
+		}
+		result.Servers = append(result.Servers, check)
+	}
+	return result
+}
+
+// inspectorCommand lists a server's tools over the connection the client
+// will use; env values are taken from the shell
+func inspectorCommand(mcpConfig map[string]interface{}) string {
+	args := append([]string{}, inspectorCLI[1:]...)
+	if url, ok := mcpConfig["url"].(string); ok {
+		transport := "http"
+		if mcpConfig["transport"] == "sse" {
+			transport = "sse"
+		}
+		args = append(args, url, "--transport", transport)
+		return shellCommand(inspectorCLI[0], append(args, "--method", "tools/list"))
+	}
+
+	env, _ := mcpConfig["env"].(map[string]string)
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var envFlags []string
+	for _, key := range keys {
+		envFlags = append(envFlags, fmt.Sprintf("-e %s=\"$%s\"", key, key))
+	}
+
+	command, _ := mcpConfig["command"].(string)
+	serverArgs, _ := mcpConfig["args"].([]string)
+	line := shellCommand(inspectorCLI[0], args)
+	if len(envFlags) > 0 {
+		line += " " + strings.Join(envFlags, " ")
+	}
+	return line + " " + shellCommand(command, slices.Concat(serverArgs, []string{"--method", "tools/list"}))
+}
+
+// troubleshootingLinks points at the entry's own troubleshooting docs or
+// issue tracker, then the client's and the protocol's debugging guides
+func troubleshootingLinks(config map[string]interface{}, format string) []string {
+	var links []string
+	if docs := getString(config, "troubleshooting", ""); docs != "" {
+		links = append(links, docs)
+	} else if owner, name, ok := githubRepo(config); ok {
+		links = append(links, fmt.Sprintf("https://github.com/%s/%s/issues", owner, name))
+	} else if homepage := getString(config, "homepage", ""); homepage != "" {
+		links = append(links, homepage)
+	}
+	if check, ok := clientChecks[format]; ok && check.Docs != "" {
+		links = append(links, check.Docs)
+	}
+	return append(links, mcpDebuggingDocs)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// binaryNamePattern matches the program names an entry may declare in
+// "system_binaries". Names end up in generated shell scripts, so nothing
+// that needs quoting is allowed.
+var binaryNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// Prerequisite is a program that must be on PATH before a config works
+type Prerequisite struct {
+	Binary  string   `json:"binary"`
+	Servers []string `json:"servers"`
+	// Runtime marks launchers the config itself invokes, such as npx
+	Runtime bool `json:"runtime,omitempty"`
+}
+
+// entrySystemBinaries lists the programs an entry shells out to
+func entrySystemBinaries(config map[string]interface{}) []string {
+	binaries, _ := stringList(config["system_binaries"])
+	return binaries
+}
+
+// collectPrerequisites merges the launch commands of the included servers
+// with the binaries their entries declare, one row per program
+func collectPrerequisites(commands map[string][]string) []Prerequisite {
+	byBinary := make(map[string]*Prerequisite)
+	add := func(binary, serverID string, runtime bool) {
+		prerequisite, ok := byBinary[binary]
+		if !ok {
+			prerequisite = &Prerequisite{Binary: binary}
+			byBinary[binary] = prerequisite
+		}
+		prerequisite.Runtime = prerequisite.Runtime || runtime
+		for _, existing := range prerequisite.Servers {
+			if existing == serverID {
+				return
+			}
+		}
+		prerequisite.Servers = append(prerequisite.Servers, serverID)
+	}
+	for serverID, launchers := range commands {
+		for _, command := range launchers {
+			add(command, serverID, true)
+		}
+		config, _ := getEntry(serverID)
+		for _, binary := range entrySystemBinaries(config) {
+			add(binary, serverID, false)
+		}
+	}
+
+	prerequisites := make([]Prerequisite, 0, len(byBinary))
+	for _, prerequisite := range byBinary {
+		sort.Strings(prerequisite.Servers)
+		prerequisites = append(prerequisites, *prerequisite)
+	}
+	sort.Slice(prerequisites, func(i, j int) bool { return prerequisites[i].Binary < prerequisites[j].Binary })
+	return prerequisites
+}
+
+// launchCommands lists the programs a generated server config starts:
+// its command and, for daemon bridges, the bridge launcher
+func launchCommands(mcpConfig map[string]interface{}, bridge *Bridge) []string {
+	var commands []string
+	if command, ok := mcpConfig["command"].(string); ok {
+		commands = append(commands, command)
+	}
+	if bridge != nil && len(bridge.Command) > 0 {
+		commands = append(commands, bridge.Command[0])
+	}
+	return commands
+}
+
+// prerequisiteChecks renders POSIX sh that reports every missing program
+// and exits non-zero if any is missing
+func prerequisiteChecks(prerequisites []Prerequisite) string {
+	var b strings.Builder
+	b.WriteString("missing=0\n")
+	for _, prerequisite := range prerequisites {
+		fmt.Fprintf(&b, "if ! command -v %s >/dev/null 2>&1; then\n", prerequisite.Binary)
+		fmt.Fprintf(&b, "  echo %s >&2\n", shellQuote(fmt.Sprintf("Missing %s, needed by %s", prerequisite.Binary, strings.Join(prerequisite.Servers, ", "))))
+		b.WriteString("  missing=1\nfi\n")
+	}
+	b.WriteString("[ \"$missing\" -eq 0 ] || exit 1\n")
+	return b.String()
+}
+
+// installScript renders a script that checks the prerequisites, runs the
+// given setup steps and then writes the config to the path given as its
+// first argument
+func installScript(prerequisites []Prerequisite, steps string, config interface{}) string {
+	data, _ := json.MarshalIndent(config, "", "  ")
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n# Generated by the MCP catalog: checks prerequisites, then writes the client config\nset -e\n\n")
+	b.WriteString(prerequisiteChecks(prerequisites))
+	if steps != "" {
+		b.WriteString("\n" + steps)
+	}
+	b.WriteString("\ntarget=\"${1:-mcp-config.json}\"\ncat > \"$target\" <<'MCP_CONFIG'\n")
+	b.Write(data)
+	b.WriteString("\nMCP_CONFIG\necho \"Wrote $target\"\n")
+	return b.String()
+}
+
+// PreflightRequest is the body of POST /api/v1/preflight
+type PreflightRequest struct {
+	Servers []string `json:"servers"`
+	Format  string   `json:"format"`
+}
+
+func parsePreflightRequest(body io.Reader) (PreflightRequest, error) {
+	var req PreflightRequest
+	if err := decodeStrict(body, &req); err != nil {
+		return req, err
+	}
+	if err := validateServerIDs(req.Servers); err != nil {
+		return req, err
+	}
+	if req.Format == "" {
+		req.Format = "claude_desktop"
+	}
+	return req, nil
+}
+
+// preflightHandler lists the programs a selection of servers needs on the
+// client machine, with a shell snippet that checks for them
+func preflightHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limitBody(w, r)
+	req, err := parsePreflightRequest(r.Body)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	client := clientProfile(req.Format)
+	selected, _ := withRequiredDependencies(req.Servers)
+	commands := make(map[string][]string)
+	var unknown []string
+	for _, serverID := range selected {
+		entry, exists := getEntry(serverID)
+		if !exists {
+			unknown = append(unknown, serverID)
+			continue
+		}
+		mcpConfig, bridge, err := launchConfig(serverID, entry, defaultConfigOptions, client, 0)
+		if err != nil {
+			continue
+		}
+		commands[serverID] = launchCommands(mcpConfig, bridge)
+	}
+	prerequisites := collectPrerequisites(commands)
+
+	response := map[string]interface{}{
+		"servers":       selected,
+		"prerequisites": prerequisites,
+		"check_script":  prerequisiteChecks(prerequisites),
+	}
+	if len(unknown) > 0 {
+		response["unknown_servers"] = unknown
+	}
+	json.NewEncoder(w).Encode(response)
+}
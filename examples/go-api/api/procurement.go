@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportColumns are the columns of the procurement report
+var reportColumns = []string{
+	"ID", "Name", "Vendor", "License", "Category", "Package", "Homepage",
+	"Risk Level", "Risk Flags", "Advisories", "Highest Severity",
+	"Verification", "Latest Version", "Last Release",
+}
+
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// serverAdvisories indexes advisories by affected server
+func serverAdvisories() map[string][]Advisory {
+	advisoriesMu.RLock()
+	defer advisoriesMu.RUnlock()
+	byServer := make(map[string][]Advisory)
+	for _, advisory := range advisories {
+		for _, serverID := range advisory.Servers {
+			byServer[serverID] = append(byServer[serverID], advisory)
+		}
+	}
+	return byServer
+}
+
+// reportRow is one server's line in the procurement report
+func reportRow(serverID string, config map[string]interface{}, affecting []Advisory) []string {
+	pkg := ""
+	if spec, ok := entryPackage(config); ok {
+		pkg = spec.Registry + ":" + spec.Name
+	}
+	riskLevel, riskFlags := "unclassified", ""
+	if labels := entryRisk(config); labels != nil {
+		if labels.Declared {
+			riskLevel = labels.Level
+		}
+		riskFlags = strings.Join(labels.Flags, ", ")
+	}
+
+	var advisoryIDs []string
+	highest := ""
+	for _, advisory := range affecting {
+		advisoryIDs = append(advisoryIDs, advisory.ID)
+		if severityRank[advisory.Severity] > severityRank[highest] {
+			highest = advisory.Severity
+		}
+	}
+
+	verificationStatus, latestVersion, lastRelease := "", "", ""
+	if result := entryVerification(serverID); result != nil {
+		verificationStatus, latestVersion = result.Status, result.LatestVersion
+		if result.LatestReleaseAt != nil {
+			lastRelease = result.LatestReleaseAt.Format("2006-01-02")
+		}
+	}
+
+	vendor, _ := vendorRecord(entryVendor(config))
+
+	return []string{
+		serverID,
+		getString(config, "name", serverID),
+		vendor.Name,
+		getString(config, "license", "Unknown"),
+		getString(config, "category", "other"),
+		pkg,
+		getString(config, "homepage", ""),
+		riskLevel,
+		riskFlags,
+		strings.Join(advisoryIDs, ", "),
+		highest,
+		verificationStatus,
+		latestVersion,
+		lastRelease,
+	}
+}
+
+// csvSafe defuses cells spreadsheet apps would run as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
+
+// exportReportHandler serves the catalog as a spreadsheet for procurement
+// and security review, one row per server: ?format=csv or xlsx. The list
+// filters apply.
+func exportReportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusBadRequest, "Query parameter 'format' must be csv or xlsx")
+		return
+	}
+	filters, err := parseEntryFilters(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ids := make([]string, 0, len(servers))
+	for serverID := range servers {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+
+	affecting := serverAdvisories()
+	rows := [][]string{reportColumns}
+	for _, serverID := range ids {
+		config, _ := getEntry(serverID)
+		if !matchesFilters(filters, serverID, config) {
+			continue
+		}
+		rows = append(rows, reportRow(serverID, config, affecting[serverID]))
+	}
+
+	filename := fmt.Sprintf("mcp-catalog-report-%s.%s", time.Now().UTC().Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "xlsx" {
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		writeXLSX(w, "MCP servers", rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	out := csv.NewWriter(w)
+	for _, row := range rows {
+		for i := range row {
+			row[i] = csvSafe(row[i])
+		}
+		out.Write(row)
+	}
+	out.Flush()
+}
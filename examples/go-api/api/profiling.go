@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The runtime profiles are served to admins only. net/http/pprof is not
+// used because importing it registers open /debug/pprof/ routes on the
+// default mux.
+
+// maxCPUProfile bounds ?seconds of a CPU profile
+const maxCPUProfile = 60 * time.Second
+
+// ProfileInfo describes one runtime profile
+type ProfileInfo struct {
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
+	Path  string `json:"path"`
+}
+
+// profilesHandler lists the runtime profiles. Admin only.
+func profilesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	result := []ProfileInfo{{Name: "profile", Path: "/api/v1/admin/debug/pprof/profile?seconds=30"}}
+	for _, profile := range pprof.Profiles() {
+		result = append(result, ProfileInfo{Name: profile.Name(), Count: profile.Count(), Path: "/api/v1/admin/debug/pprof/" + profile.Name()})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	json.NewEncoder(w).Encode(result)
+}
+
+// profileHandler serves a runtime profile in the format `go tool pprof`
+// reads: "profile" samples the CPU for ?seconds (default 30), any other
+// name is a snapshot such as heap or goroutine. ?debug=1 renders a
+// snapshot as text and ?gc=1 collects garbage before a heap profile.
+// Admin only.
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	name := r.PathValue("profile")
+	debug, _ := strconv.Atoi(r.URL.Query().Get("debug"))
+
+	if name == "profile" {
+		duration := 30 * time.Second
+		if raw := r.URL.Query().Get("seconds"); raw != "" {
+			seconds, err := strconv.Atoi(raw)
+			if err != nil || seconds < 1 || time.Duration(seconds)*time.Second > maxCPUProfile {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Query parameter 'seconds' must be between 1 and %d", int(maxCPUProfile.Seconds())))
+				return
+			}
+			duration = time.Duration(seconds) * time.Second
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+		if err := pprof.StartCPUProfile(w); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Del("Content-Disposition")
+			writeError(w, http.StatusConflict, "Cannot profile the CPU: "+err.Error())
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), duration)
+		defer cancel()
+		<-ctx.Done()
+		pprof.StopCPUProfile()
+		return
+	}
+
+	profile := pprof.Lookup(name)
+	if profile == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown profile '%s'", name))
+		return
+	}
+	if name == "heap" && r.URL.Query().Get("gc") == "1" {
+		runtime.GC()
+	}
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	}
+	profile.WriteTo(w, debug)
+}
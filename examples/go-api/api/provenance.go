@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Signature describes how a server's package is signed
+type Signature struct {
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	// Identity is the expected signer, e.g. a Sigstore certificate identity
+	Identity string `json:"identity,omitempty"`
+}
+
+// Provenance holds supply-chain metadata for a catalog entry
+type Provenance struct {
+	SBOMURL        string     `json:"sbom_url,omitempty"`
+	AttestationURL string     `json:"attestation_url,omitempty"`
+	SLSALevel      int        `json:"slsa_level,omitempty"`
+	Signature      *Signature `json:"signature,omitempty"`
+}
+
+// entryProvenance decodes the optional "provenance" block of an entry
+func entryProvenance(config map[string]interface{}) *Provenance {
+	raw, ok := config["provenance"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	provenance := &Provenance{
+		SBOMURL:        getString(raw, "sbom_url", ""),
+		AttestationURL: getString(raw, "attestation_url", ""),
+	}
+	if level, ok := raw["slsa_level"].(float64); ok {
+		provenance.SLSALevel = int(level)
+	}
+	if sig, ok := raw["signature"].(map[string]interface{}); ok {
+		provenance.Signature = &Signature{
+			Type:     getString(sig, "type", ""),
+			URL:      getString(sig, "url", ""),
+			Identity: getString(sig, "identity", ""),
+		}
+	}
+	return provenance
+}
+
+// hasProvenance reports whether an entry ships a provenance attestation
+func hasProvenance(config map[string]interface{}) bool {
+	provenance := entryProvenance(config)
+	return provenance != nil && provenance.AttestationURL != ""
+}
+
+func parseProvenanceFilter(r *http.Request) (entryFilter, error) {
+	want, present, err := parseBoolParam(r, "has_provenance")
+	if err != nil || !present {
+		return nil, err
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		return hasProvenance(config) == want
+	}, nil
+}
+
+// provenanceURLs lists every supply-chain URL an entry declares
+func provenanceURLs(provenance *Provenance) map[string]string {
+	urls := make(map[string]string)
+	if provenance.SBOMURL != "" {
+		urls["sbom_url"] = provenance.SBOMURL
+	}
+	if provenance.AttestationURL != "" {
+		urls["attestation_url"] = provenance.AttestationURL
+	}
+	if provenance.Signature != nil && provenance.Signature.URL != "" {
+		urls["signature.url"] = provenance.Signature.URL
+	}
+	return urls
+}
+
+// checkURL reports whether a URL resolves to a successful response
+func checkURL(ctx context.Context, client *http.Client, url string) error {
+	for _, method := range []string{"HEAD", "GET"} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := callDependency(client, req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 400 {
+			return nil
+		}
+		// Some hosts reject HEAD; retry with GET before giving up
+		if method == "GET" || resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// provenanceCommand checks that every declared SBOM, attestation and
+// signature URL resolves:
+//
+//	provenance [-timeout 10s]
+func provenanceCommand(args []string) error {
+	flags := flag.NewFlagSet("provenance", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout per URL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(servers))
+	for serverID := range servers {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+
+	client := outboundClient(*timeout)
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "SERVER\tFIELD\tSTATUS")
+
+	failures := 0
+	for _, serverID := range ids {
+		config, _ := getEntry(serverID)
+		provenance := entryProvenance(config)
+		if provenance == nil {
+			continue
+		}
+		urls := provenanceURLs(provenance)
+		fields := make([]string, 0, len(urls))
+		for field := range urls {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		for _, field := range fields {
+			status := "ok"
+			if err := checkURL(context.Background(), client, urls[field]); err != nil {
+				status = "FAIL: " + err.Error()
+				failures++
+			}
+			fmt.Fprintf(out, "%s\t%s\t%s\n", serverID, field, status)
+		}
+	}
+	out.Flush()
+
+	if failures > 0 {
+		return fmt.Errorf("%d provenance URLs did not resolve", failures)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// siteTemplates render the static catalog site. Every page defines a
+// "content" block inside the shared "layout".
+const siteTemplates = `
+{{define "layout"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · MCP Server Catalog</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 960px; margin: 0 auto; padding: 1rem 2rem; color: #222; }
+a { color: #0b5cad; text-decoration: none; }
+a:hover { text-decoration: underline; }
+nav { margin-bottom: 1.5rem; }
+.server { border-bottom: 1px solid #eee; padding: .75rem 0; }
+.server p { margin: .25rem 0; color: #555; }
+.badge { font-size: .75rem; background: #fde2e1; color: #a12622; padding: .1rem .4rem; border-radius: .25rem; }
+pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; }
+</style>
+</head>
+<body>
+<nav><a href="{{.Root}}index.html">MCP Server Catalog</a></nav>
+{{template "content" .}}
+</body>
+</html>{{end}}
+
+{{define "index-content"}}
+<h1>MCP Server Catalog</h1>
+<p>{{len .Servers}} servers in {{len .Categories}} categories.</p>
+<h2>Categories</h2>
+<ul>{{range .Categories}}
+<li><a href="categories/{{pageName .Name}}.html">{{.Name}}</a> ({{.Count}}){{if .Description}} — {{.Description}}{{end}}</li>{{end}}
+</ul>
+<h2>All servers</h2>
+{{range .Servers}}
+<div class="server">
+<a href="servers/{{pageName .ID}}.html"><strong>{{.Name}}</strong></a>
+{{if .UnderReview}}<span class="badge">under review</span>{{end}}
+<p>{{.Description}}</p>
+</div>{{end}}
+{{end}}
+
+{{define "category-content"}}
+<h1>{{.Category.Name}}</h1>
+{{if .Category.LongDescription}}<p>{{.Category.LongDescription}}</p>{{else if .Category.Description}}<p>{{.Category.Description}}</p>{{end}}
+{{range .Servers}}
+<div class="server">
+<a href="../servers/{{pageName .ID}}.html"><strong>{{.Name}}</strong></a>
+{{if .UnderReview}}<span class="badge">under review</span>{{end}}
+<p>{{.Description}}</p>
+</div>{{end}}
+{{end}}
+
+{{define "server-content"}}
+<h1>{{.Server.Name}} {{if .Server.UnderReview}}<span class="badge">under review</span>{{end}}</h1>
+<p>{{.Server.Description}}</p>
+<p>Category: <a href="../categories/{{pageName .Server.Category}}.html">{{.Server.Category}}</a>
+{{if .Repository}} · <a href="{{.Repository}}">Repository</a>{{end}}
+{{if .Server.Homepage}} · <a href="{{.Server.Homepage}}">Homepage</a>{{end}}</p>
+{{if .Env}}<h2>Environment</h2>
+<ul>{{range .Env}}
+<li><code>{{.Key}}</code>{{if .Required}} (required){{end}}{{if .Description}} — {{.Description}}{{end}}</li>{{end}}
+</ul>{{end}}
+{{if .Snippet}}<h2>Install</h2>
+<p>Add to your <code>claude_desktop_config.json</code>:</p>
+<pre><code>{{.Snippet}}</code></pre>{{end}}
+{{end}}
+`
+
+// sitePage is the data passed to every page template
+type sitePage struct {
+	Title string
+	// Root is the relative path from the page back to the site root
+	Root       string
+	Servers    []Server
+	Categories []CategoryInfo
+	Category   CategoryInfo
+	Server     Server
+	Repository string
+	Env        []WizardQuestion
+	Snippet    string
+}
+
+// pageName turns a server or category ID into a safe file name; federated
+// IDs contain "/"
+func pageName(id string) string {
+	return strings.ReplaceAll(id, "/", "--")
+}
+
+// installSnippet renders the claude_desktop config block for one server
+func installSnippet(serverID string, config map[string]interface{}) string {
+	mcpConfig, bridge, err := launchConfig(serverID, config, defaultConfigOptions, clientProfile("claude_desktop"), 0)
+	if err != nil || bridge != nil {
+		return ""
+	}
+	if env := secretEnv(serverID, config, defaultConfigOptions); len(env) > 0 {
+		if _, local := mcpConfig["command"]; local {
+			mcpConfig["env"] = env
+		}
+	}
+	data, _ := json.MarshalIndent(map[string]interface{}{
+		"mcpServers": map[string]interface{}{serverID: mcpConfig},
+	}, "", "  ")
+	return string(data)
+}
+
+// renderPage executes a page's content template inside the layout
+func renderPage(base *template.Template, content, path string, page sitePage) error {
+	tmpl, err := base.Clone()
+	if err != nil {
+		return err
+	}
+	if _, err := tmpl.New("content").Parse(fmt.Sprintf(`{{template %q .}}`, content)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.ExecuteTemplate(file, "layout", page); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// publishSite renders the catalog as a static HTML site into dir
+func publishSite(dir string) (int, error) {
+	base, err := template.New("site").Funcs(template.FuncMap{"pageName": pageName}).Parse(siteTemplates)
+	if err != nil {
+		return 0, err
+	}
+
+	var all []Server
+	byCategory := make(map[string][]Server)
+	for serverID := range servers {
+		config, ok := getEntry(serverID)
+		if !ok {
+			continue
+		}
+		summary := serverSummary(serverID, config)
+		all = append(all, summary)
+		byCategory[summary.Category] = append(byCategory[summary.Category], summary)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	categories := categoryInfos()
+
+	pages := 0
+	if err := renderPage(base, "index-content", filepath.Join(dir, "index.html"), sitePage{
+		Title:      "All servers",
+		Servers:    all,
+		Categories: categories,
+	}); err != nil {
+		return pages, err
+	}
+	pages++
+
+	for _, category := range categories {
+		members := byCategory[category.Name]
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].ID < members[j].ID
+		})
+		path := filepath.Join(dir, "categories", pageName(category.Name)+".html")
+		if err := renderPage(base, "category-content", path, sitePage{
+			Title:    category.Name,
+			Root:     "../",
+			Servers:  members,
+			Category: category,
+		}); err != nil {
+			return pages, err
+		}
+		pages++
+	}
+
+	for _, summary := range all {
+		config, _ := getEntry(summary.ID)
+		page := sitePage{
+			Title:   summary.Name,
+			Root:    "../",
+			Server:  summary,
+			Env:     envQuestions(config),
+			Snippet: installSnippet(summary.ID, config),
+		}
+		if repo, ok := config["repository"].(map[string]interface{}); ok {
+			page.Repository = getString(repo, "url", "")
+		}
+		path := filepath.Join(dir, "servers", pageName(summary.ID)+".html")
+		if err := renderPage(base, "server-content", path, page); err != nil {
+			return pages, err
+		}
+		pages++
+	}
+
+	// GitHub Pages would otherwise run the output through Jekyll
+	if err := ioutil.WriteFile(filepath.Join(dir, ".nojekyll"), nil, 0644); err != nil {
+		return pages, err
+	}
+	return pages, nil
+}
+
+func publishCommand(args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	output := flags.String("o", "site", "output directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pages, err := publishSite(*output)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d pages for %d servers to %s\n", pages, len(servers), *output)
+	return nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QuotaLimits are the requests an API key user may make per UTC day and
+// per calendar month; 0 means unlimited
+type QuotaLimits struct {
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
+
+// QuotaPeriod is the usage of one quota period
+type QuotaPeriod struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+	// Remaining is omitted for unlimited periods
+	Remaining *int      `json:"remaining,omitempty"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// quotaUsageDays and quotaUsageMonths bound the history kept per user
+const (
+	quotaUsageDays   = 35
+	quotaUsageMonths = 13
+)
+
+// quotas holds the per-user limit overrides and request counts. Counts are
+// keyed by period, "2006-01-02" for days and "2006-01" for months, and
+// written to disk by startQuotaFlush rather than on every request.
+var quotas = struct {
+	sync.Mutex
+	limits map[string]QuotaLimits
+	usage  map[string]map[string]int
+	dirty  bool
+}{limits: make(map[string]QuotaLimits), usage: make(map[string]map[string]int)}
+
+func loadQuotas() {
+	var limits map[string]QuotaLimits
+	if err := readJSONFile(dataPath("quotas.json"), &limits); err != nil {
+		log.Printf("❌ Cannot load quota limits: %v", err)
+	}
+	var usage map[string]map[string]int
+	if err := readJSONFile(dataPath("usage.json"), &usage); err != nil {
+		log.Printf("❌ Cannot load API usage: %v", err)
+	}
+	quotas.Lock()
+	defer quotas.Unlock()
+	if limits != nil {
+		quotas.limits = limits
+	}
+	if usage != nil {
+		quotas.usage = usage
+	}
+}
+
+// userQuota returns the limits of a user: its override, else the
+// configured defaults. Callers must hold quotas.
+func userQuota(user string) QuotaLimits {
+	if limits, ok := quotas.limits[user]; ok {
+		return limits
+	}
+	return QuotaLimits{Daily: cfg.QuotaDaily, Monthly: cfg.QuotaMonthly}
+}
+
+// quotaPeriods returns the day and month keys of a time and when each
+// period ends
+func quotaPeriods(now time.Time) (day, month string, dayEnd, monthEnd time.Time) {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return now.Format("2006-01-02"), now.Format("2006-01"), dayStart.AddDate(0, 0, 1), monthStart.AddDate(0, 1, 0)
+}
+
+func quotaPeriod(used, limit int, resetsAt time.Time) QuotaPeriod {
+	period := QuotaPeriod{Used: used, Limit: limit, ResetsAt: resetsAt}
+	if limit > 0 {
+		remaining := max(limit-used, 0)
+		period.Remaining = &remaining
+	}
+	return period
+}
+
+// userUsage reports a user's usage of both periods. Callers must hold
+// quotas.
+func userUsage(user string, now time.Time) (daily, monthly QuotaPeriod) {
+	day, month, dayEnd, monthEnd := quotaPeriods(now)
+	limits := userQuota(user)
+	counts := quotas.usage[user]
+	return quotaPeriod(counts[day], limits.Daily, dayEnd), quotaPeriod(counts[month], limits.Monthly, monthEnd)
+}
+
+// chargeQuota counts one request of a user. When a period is used up it
+// names it, daily or monthly, and does not count the request.
+func chargeQuota(user string, now time.Time) (daily, monthly QuotaPeriod, exhausted string) {
+	quotas.Lock()
+	defer quotas.Unlock()
+	daily, monthly = userUsage(user, now)
+	switch {
+	case daily.Remaining != nil && *daily.Remaining == 0:
+		return daily, monthly, "daily"
+	case monthly.Remaining != nil && *monthly.Remaining == 0:
+		return daily, monthly, "monthly"
+	}
+
+	day, month, _, _ := quotaPeriods(now)
+	counts := quotas.usage[user]
+	if counts == nil {
+		counts = make(map[string]int)
+		quotas.usage[user] = counts
+	}
+	counts[day]++
+	counts[month]++
+	quotas.dirty = true
+	daily, monthly = userUsage(user, now)
+	return daily, monthly, ""
+}
+
+// setQuotaHeaders reports the limits and what is left of them
+func setQuotaHeaders(w http.ResponseWriter, daily, monthly QuotaPeriod) {
+	for name, period := range map[string]QuotaPeriod{"Daily": daily, "Monthly": monthly} {
+		if period.Remaining == nil {
+			continue
+		}
+		w.Header().Set("X-Quota-"+name+"-Limit", strconv.Itoa(period.Limit))
+		w.Header().Set("X-Quota-"+name+"-Remaining", strconv.Itoa(*period.Remaining))
+		w.Header().Set("X-Quota-"+name+"-Reset", period.ResetsAt.Format(time.RFC3339))
+	}
+}
+
+// quotaMiddleware charges every request made with an API key against the
+// key user's quotas and refuses it with 429 once a quota is used up.
+// Anonymous requests are not metered; checking one's own usage is free.
+func quotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := apiKeyUser(r)
+		if !ok || r.URL.Path == "/api/v1/me/usage" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now()
+		daily, monthly, exhausted := chargeQuota(user, now)
+		setQuotaHeaders(w, daily, monthly)
+		if exhausted != "" {
+			period := daily
+			if exhausted == "monthly" {
+				period = monthly
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(period.ResetsAt.Sub(now).Seconds())+1))
+			writeError(w, http.StatusTooManyRequests, fmt.Sprintf("The %s quota of %d requests is used up; it resets at %s", exhausted, period.Limit, period.ResetsAt.Format(time.RFC3339)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// quotaFlushInterval is how often request counts are written to disk
+const quotaFlushInterval = 30 * time.Second
+
+// startQuotaFlush periodically persists request counts, dropping periods
+// older than the kept history
+func startQuotaFlush() {
+	go func() {
+		for range time.Tick(quotaFlushInterval) {
+			flushQuotaUsage(time.Now())
+		}
+	}()
+}
+
+func flushQuotaUsage(now time.Time) {
+	quotas.Lock()
+	defer quotas.Unlock()
+	if !quotas.dirty {
+		return
+	}
+	oldestDay := now.UTC().AddDate(0, 0, -quotaUsageDays).Format("2006-01-02")
+	oldestMonth := now.UTC().AddDate(0, -quotaUsageMonths, 0).Format("2006-01")
+	for _, counts := range quotas.usage {
+		for period := range counts {
+			if len(period) == len(oldestDay) && period < oldestDay || len(period) == len(oldestMonth) && period < oldestMonth {
+				delete(counts, period)
+			}
+		}
+	}
+	if err := writeJSONFile(dataPath("usage.json"), quotas.usage); err != nil {
+		log.Printf("❌ Failed to save API usage: %v", err)
+		return
+	}
+	quotas.dirty = false
+}
+
+// DailyUsage is one day of a user's request history
+type DailyUsage struct {
+	Date     string `json:"date"`
+	Requests int    `json:"requests"`
+}
+
+// meUsageHandler reports the calling key user's quotas, what is left of
+// them and the daily history
+func meUsageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	quotas.Lock()
+	daily, monthly := userUsage(user, now)
+	history := []DailyUsage{}
+	for period, count := range quotas.usage[user] {
+		if len(period) == len("2006-01-02") {
+			history = append(history, DailyUsage{Date: period, Requests: count})
+		}
+	}
+	quotas.Unlock()
+	sort.Slice(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+
+	setQuotaHeaders(w, daily, monthly)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":    user,
+		"daily":   daily,
+		"monthly": monthly,
+		"history": history,
+	})
+}
+
+// keyUsers lists the users API keys are configured for
+func keyUsers() []string {
+	var users []string
+	for _, spec := range cfg.APIKeys {
+		if eq := strings.LastIndex(spec, "="); eq > 0 && !slices.Contains(users, spec[eq+1:]) {
+			users = append(users, spec[eq+1:])
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// UserQuota is one row of the admin quotas listing
+type UserQuota struct {
+	User     string      `json:"user"`
+	Limits   QuotaLimits `json:"limits"`
+	Override bool        `json:"override"`
+	Daily    QuotaPeriod `json:"daily"`
+	Monthly  QuotaPeriod `json:"monthly"`
+}
+
+// adminQuotasHandler lists the limits and current usage of every key user
+func adminQuotasHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	now := time.Now()
+	quotas.Lock()
+	result := []UserQuota{}
+	for _, user := range keyUsers() {
+		_, override := quotas.limits[user]
+		daily, monthly := userUsage(user, now)
+		result = append(result, UserQuota{User: user, Limits: userQuota(user), Override: override, Daily: daily, Monthly: monthly})
+	}
+	quotas.Unlock()
+	json.NewEncoder(w).Encode(result)
+}
+
+// adminQuotaHandler sets (PUT {"daily": N, "monthly": N}) or removes
+// (DELETE) the limit override of a key user
+func adminQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	user := r.PathValue("user")
+	if !slices.Contains(keyUsers(), user) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No API key is configured for user '%s'", user))
+		return
+	}
+
+	quotas.Lock()
+	defer quotas.Unlock()
+	switch r.Method {
+	case "PUT":
+		var limits QuotaLimits
+		if err := decodeJSONBody(w, r, &limits); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		if limits.Daily < 0 || limits.Monthly < 0 {
+			writeError(w, http.StatusBadRequest, "Fields 'daily' and 'monthly' must not be negative; 0 means unlimited")
+			return
+		}
+		quotas.limits[user] = limits
+		log.Printf("🎫 Set quota of '%s' to %d/day, %d/month", user, limits.Daily, limits.Monthly)
+	case "DELETE":
+		delete(quotas.limits, user)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := writeJSONFile(dataPath("quotas.json"), quotas.limits); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	_, override := quotas.limits[user]
+	daily, monthly := userUsage(user, time.Now())
+	json.NewEncoder(w).Encode(UserQuota{User: user, Limits: userQuota(user), Override: override, Daily: daily, Monthly: monthly})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Ranking weights. A field hit scores its field weight times its match
+// kind weight; boosts are added on top and the sum is scaled by the
+// popularity factor.
+var (
+	fieldWeights = map[string]float64{"id": 4, "name": 4, "alias": 2, "description": 1, "examples": 0.5}
+	matchWeights = map[string]float64{"exact": 1, "prefix": 0.6, "contains": 0.3}
+)
+
+const (
+	// accentInsensitiveWeight discounts hits that needed transliteration
+	accentInsensitiveWeight = 0.8
+	featuredBoost           = 1.0
+	verifiedBoost           = 0.5
+	underReviewBoost        = -2.0
+	// popularityWeight scales ln(1 + installs) into the popularity factor
+	popularityWeight = 0.1
+)
+
+// FieldMatch is one field hit contributing to a search score
+type FieldMatch struct {
+	// Field is id, name, description, examples, alias or semantic
+	Field string `json:"field"`
+	// Value is the alias that matched
+	Value string `json:"value,omitempty"`
+	// Kind is exact, prefix or contains, or similar for semantic matches
+	Kind              string  `json:"kind"`
+	AccentInsensitive bool    `json:"accent_insensitive,omitempty"`
+	Score             float64 `json:"score"`
+}
+
+// ScoreBoost is a ranking adjustment independent of the query
+type ScoreBoost struct {
+	Reason string  `json:"reason"`
+	Score  float64 `json:"score"`
+}
+
+// SearchExplanation breaks a search result's score into its parts
+type SearchExplanation struct {
+	Score            float64      `json:"score"`
+	FieldMatches     []FieldMatch `json:"field_matches"`
+	Boosts           []ScoreBoost `json:"boosts"`
+	Installs         int          `json:"installs"`
+	PopularityFactor float64      `json:"popularity_factor"`
+}
+
+func newFieldMatch(field, value, kind string, accentInsensitive bool) FieldMatch {
+	score := fieldWeights[field] * matchWeights[kind]
+	if accentInsensitive {
+		score *= accentInsensitiveWeight
+	}
+	return FieldMatch{Field: field, Value: value, Kind: kind, AccentInsensitive: accentInsensitive, Score: roundScore(score)}
+}
+
+// roundScore keeps explanations readable
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
+
+// isFeatured reports whether a server is featured in its category
+func isFeatured(serverID, category string) bool {
+	categoryMetaMu.RLock()
+	defer categoryMetaMu.RUnlock()
+	for _, featured := range categoryMeta[category].Featured {
+		if canonicalID(featured) == serverID {
+			return true
+		}
+	}
+	return false
+}
+
+// explainScore scores a search hit from its field matches, curation
+// boosts and install popularity
+func explainScore(serverID string, config map[string]interface{}, matches []FieldMatch) SearchExplanation {
+	explanation := SearchExplanation{FieldMatches: matches, Boosts: []ScoreBoost{}}
+	if explanation.FieldMatches == nil {
+		explanation.FieldMatches = []FieldMatch{}
+	}
+
+	if isFeatured(serverID, getString(config, "category", "other")) {
+		explanation.Boosts = append(explanation.Boosts, ScoreBoost{Reason: "featured", Score: featuredBoost})
+	}
+	if result := entryVerification(serverID); result != nil && result.Status == "verified" {
+		explanation.Boosts = append(explanation.Boosts, ScoreBoost{Reason: "verified_package", Score: verifiedBoost})
+	}
+	if underReview(serverID) {
+		explanation.Boosts = append(explanation.Boosts, ScoreBoost{Reason: "under_review", Score: underReviewBoost})
+	}
+
+	installStats.Lock()
+	explanation.Installs = installStats.installs[serverID]
+	installStats.Unlock()
+	explanation.PopularityFactor = roundScore(1 + popularityWeight*math.Log1p(float64(explanation.Installs)))
+
+	var base float64
+	for _, match := range matches {
+		base += match.Score
+	}
+	for _, boost := range explanation.Boosts {
+		base += boost.Score
+	}
+	explanation.Score = roundScore(base * explanation.PopularityFactor)
+	return explanation
+}
+
+// rankedResult pairs a search result with its score
+type rankedResult struct {
+	server      Server
+	explanation SearchExplanation
+}
+
+// rankResults orders results by score, then by ID
+func rankResults(results []rankedResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].explanation.Score != results[j].explanation.Score {
+			return results[i].explanation.Score > results[j].explanation.Score
+		}
+		return results[i].server.ID < results[j].server.ID
+	})
+}
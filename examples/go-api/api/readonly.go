@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// readOnlySafeRoutes are the POST endpoints that only compute a response
+// from the catalog, so read-only replicas keep serving them
+var readOnlySafeRoutes = map[string]bool{
+	"/api/v1/servers/generate-config":      true,
+	"/api/v1/servers/generate-config/bulk": true,
+	"/api/v1/validate-config":              true,
+	"/api/v1/identify-config":              true,
+	"/api/v1/upgrade-plan":                 true,
+	"/api/v1/wizard/next":                  true,
+	"/api/v1/preflight":                    true,
+}
+
+// readOnlyAllows reports whether a read-only instance serves a request.
+// Previews of hosted servers are allowed too: they call the server, not
+// the catalog.
+func readOnlyAllows(r *http.Request) bool {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	case "POST":
+		if readOnlySafeRoutes[r.URL.Path] {
+			return true
+		}
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/v1/servers/")
+		return ok && strings.HasSuffix(rest, "/try")
+	}
+	return false
+}
+
+// readOnlyMiddleware refuses every mutating request with 403 when the
+// instance runs with --read-only. It sits in front of the router, so
+// nothing is written even if admin tokens or API keys are misconfigured.
+func readOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.ReadOnly && !readOnlyAllows(r) {
+			w.Header().Set("Content-Type", "application/json")
+			writeError(w, http.StatusForbidden, "This catalog instance is read-only")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
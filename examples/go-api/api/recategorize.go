@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// renameCategory moves one entry from category from to category to,
+// in both its primary "category" and its "categories" list. It reports
+// whether the entry changed.
+func renameCategory(entry map[string]interface{}, from, to string) bool {
+	changed := false
+	if getString(entry, "category", "") == from {
+		entry["category"] = to
+		changed = true
+	}
+	if list, ok := entry["categories"].([]interface{}); ok {
+		var renamed []interface{}
+		present := false
+		for _, item := range list {
+			if item == to {
+				present = true
+			}
+		}
+		for _, item := range list {
+			if item != from {
+				renamed = append(renamed, item)
+				continue
+			}
+			changed = true
+			if !present {
+				renamed = append(renamed, to)
+				present = true
+			}
+		}
+		if changed {
+			entry["categories"] = renamed
+		}
+	}
+	return changed
+}
+
+// hasCategory reports whether an entry is in a category, as its primary
+// "category" or in its "categories" list
+func hasCategory(entry map[string]interface{}, name string) bool {
+	if getString(entry, "category", "") == name {
+		return true
+	}
+	list, _ := entry["categories"].([]interface{})
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recategorizeCommand moves entries from one category to another in bulk
+// and records the old name as an alias so existing clients keep working.
+// The alias is only recorded once no entry is left in the old category: a
+// split is several runs with -ids, one per new category, and its last run
+// makes the old name an alias of every new one:
+//
+//	recategorize -from OLD -to NEW [-ids a,b] [-file known_servers.json] [-alias=false] [-write]
+func recategorizeCommand(args []string) error {
+	flags := flag.NewFlagSet("recategorize", flag.ContinueOnError)
+	path := flags.String("file", loadedCatalogPath, "catalog file to rewrite")
+	from := flags.String("from", "", "category to migrate away from")
+	to := flags.String("to", "", "category to migrate entries to")
+	ids := flags.String("ids", "", "comma-separated server IDs to move (default: every entry in -from)")
+	alias := flags.Bool("alias", true, "keep -from as an alias of -to in categories.json")
+	write := flags.Bool("write", false, "rewrite the files instead of only previewing the diff")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" || *from == *to {
+		return fmt.Errorf("-from and -to must name two different categories")
+	}
+	if *path == "" {
+		return fmt.Errorf("no catalog file found; pass -file")
+	}
+
+	data, err := ioutil.ReadFile(*path)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if _, err := migrateCatalog(doc); err != nil {
+		return err
+	}
+	entries := catalogEntries(doc)
+
+	var selected []string
+	if *ids != "" {
+		for _, serverID := range strings.Split(*ids, ",") {
+			serverID = strings.TrimSpace(serverID)
+			if _, ok := entries[serverID].(map[string]interface{}); !ok {
+				return fmt.Errorf("server '%s' not found in %s", serverID, *path)
+			}
+			selected = append(selected, serverID)
+		}
+	} else {
+		for serverID := range entries {
+			selected = append(selected, serverID)
+		}
+	}
+	sort.Strings(selected)
+
+	fmt.Printf("--- %s\n+++ %s\n", *path, *path)
+	moved := 0
+	previous := make(map[string]map[string]interface{})
+	for _, serverID := range selected {
+		entry, _ := entries[serverID].(map[string]interface{})
+		before := indentedLines(entry)
+		original := make(map[string]interface{}, len(entry))
+		for key, value := range entry {
+			original[key] = value
+		}
+		if !renameCategory(entry, *from, *to) {
+			continue
+		}
+		moved++
+		previous[serverID] = original
+		fmt.Printf("@@ %s @@\n", serverID)
+		for _, line := range diffLines(before, indentedLines(entry)) {
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("\n%d entries move from '%s' to '%s'\n", moved, *from, *to)
+	remaining := 0
+	for _, raw := range entries {
+		if entry, ok := raw.(map[string]interface{}); ok && hasCategory(entry, *from) {
+			remaining++
+		}
+	}
+	if *alias && remaining > 0 {
+		fmt.Printf("'%s' keeps %d entries; it becomes an alias once none is left\n", *from, remaining)
+	} else if *alias {
+		fmt.Printf("'%s' becomes an alias of '%s' in %s\n", *from, *to, categoryMetaPath)
+	}
+
+	if !*write {
+		fmt.Println("\nDry run; pass -write to rewrite the files")
+		return nil
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(*path)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*path, append(out, '\n'), info.Mode()); err != nil {
+		return err
+	}
+	fmt.Printf("Rewrote %s\n", *path)
+	updated := make(map[string]map[string]interface{}, len(previous))
+	for serverID := range previous {
+		updated[serverID] = entries[serverID].(map[string]interface{})
+	}
+	recordFieldOrigins(previous, updated, newFieldOrigin("recategorize", "cli"))
+
+	if !*alias {
+		return nil
+	}
+	categoryMetaMu.Lock()
+	defer categoryMetaMu.Unlock()
+	old := categoryMeta[*from]
+	// The new category inherits curated metadata it does not have yet
+	if _, exists := categoryMeta[*to]; !exists && (old.Description != "" || old.LongDescription != "") {
+		categoryMeta[*to] = CategoryMeta{Description: old.Description, LongDescription: old.LongDescription, Order: old.Order}
+	}
+	if remaining > 0 {
+		if !slices.Contains(old.MovingTo, *to) {
+			old.MovingTo = append(old.MovingTo, *to)
+			categoryMeta[*from] = old
+		}
+		return saveCategoryMeta()
+	}
+	successors := old.ReplacedBy
+	for _, successor := range append(old.MovingTo, *to) {
+		if !slices.Contains(successors, successor) {
+			successors = append(successors, successor)
+		}
+	}
+	if slices.Equal(successors, old.ReplacedBy) {
+		return saveCategoryMeta()
+	}
+	categoryMeta[*from] = CategoryMeta{ReplacedBy: successors}
+	if err := saveCategoryMeta(); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded '%s' as an alias of '%s' in %s\n", *from, strings.Join(successors, "', '"), categoryMetaPath)
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxRecommendations bounds every recommendation list
+const maxRecommendations = 5
+
+// Recommendation is a server suggested alongside others
+type Recommendation struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Score  int    `json:"score"`
+}
+
+// installStats aggregates which servers are installed together, as
+// observed through config generation
+var installStats = struct {
+	sync.Mutex
+	installs  map[string]int
+	coInstall map[string]map[string]int
+}{
+	installs:  make(map[string]int),
+	coInstall: make(map[string]map[string]int),
+}
+
+// recordInstall counts one generated config containing the given servers
+func recordInstall(ids []string) {
+	recordDailyInstalls(ids, time.Now())
+
+	installStats.Lock()
+	defer installStats.Unlock()
+
+	for _, a := range ids {
+		installStats.installs[a]++
+		for _, b := range ids {
+			if a == b {
+				continue
+			}
+			if installStats.coInstall[a] == nil {
+				installStats.coInstall[a] = make(map[string]int)
+			}
+			installStats.coInstall[a][b]++
+		}
+	}
+}
+
+func sortRecommendations(recs []Recommendation) []Recommendation {
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].ID < recs[j].ID
+	})
+	if len(recs) > maxRecommendations {
+		recs = recs[:maxRecommendations]
+	}
+	return recs
+}
+
+// coInstalled returns servers most often installed together with any of
+// the given ones, excluding the given servers themselves
+func coInstalled(ids []string) []Recommendation {
+	selected := make(map[string]bool)
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	scores := make(map[string]int)
+	installStats.Lock()
+	for _, id := range ids {
+		for other, count := range installStats.coInstall[id] {
+			if !selected[other] {
+				scores[other] += count
+			}
+		}
+	}
+	installStats.Unlock()
+
+	var recs []Recommendation
+	for serverID, score := range scores {
+		config, exists := getEntry(serverID)
+		if !exists {
+			continue
+		}
+		recs = append(recs, Recommendation{
+			ID:     serverID,
+			Name:   getString(config, "name", serverID),
+			Reason: "co_installed",
+			Score:  score,
+		})
+	}
+	return sortRecommendations(recs)
+}
+
+// sameCategory returns popular servers sharing a category with any of the
+// given ones, excluding the given servers themselves
+func sameCategory(ids []string) []Recommendation {
+	selected := make(map[string]bool)
+	categories := make(map[string]bool)
+	for _, id := range ids {
+		selected[id] = true
+		if config, exists := getEntry(id); exists {
+			categories[getString(config, "category", "other")] = true
+		}
+	}
+
+	installStats.Lock()
+	defer installStats.Unlock()
+
+	var recs []Recommendation
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		if selected[serverID] || !categories[getString(config, "category", "other")] {
+			continue
+		}
+		recs = append(recs, Recommendation{
+			ID:     serverID,
+			Name:   getString(config, "name", serverID),
+			Reason: "same_category",
+			Score:  installStats.installs[serverID],
+		})
+	}
+	return sortRecommendations(recs)
+}
+
+// recommendFor suggests complementary servers for a selection, preferring
+// co-installation signals and filling up with same-category servers
+func recommendFor(ids []string) []Recommendation {
+	recs := coInstalled(ids)
+	seen := make(map[string]bool)
+	for _, rec := range recs {
+		seen[rec.ID] = true
+	}
+	for _, rec := range sameCategory(ids) {
+		if len(recs) >= maxRecommendations {
+			break
+		}
+		if !seen[rec.ID] {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+func relatedServersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serverID := r.PathValue("id")
+	if _, exists := getEntry(serverID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":            serverID,
+		"co_installed":  coInstalled([]string{serverID}),
+		"same_category": sameCategory([]string{serverID}),
+	})
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxReportLength bounds the reason given in an abuse report
+const maxReportLength = 2000
+
+// maxReports bounds the stored reports. When it is reached the oldest
+// dismissed report makes room; with none left new reports are refused.
+const maxReports = 10000
+
+// maxOpenReportsPerServer bounds the open reports against one server
+const maxOpenReportsPerServer = 100
+
+// Report flags a server as possibly malicious. Open reports wait in the
+// maintainer triage queue until they are confirmed or dismissed.
+type Report struct {
+	ID          string    `json:"id"`
+	ServerID    string    `json:"server_id"`
+	Reporter    string    `json:"reporter"`
+	Reason      string    `json:"reason"`
+	EvidenceURL string    `json:"evidence_url,omitempty"`
+	Status      string    `json:"status"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var (
+	reportsMu sync.RWMutex
+	reports   = make(map[string]*Report)
+	// serverReports indexes report IDs by server, so checks of an entry
+	// scan its own reports only
+	serverReports = make(map[string][]string)
+	// reportTimes holds each reporter's recent report times for rate
+	// limiting; reporters with none in the last hour are swept once there
+	// are maxReports of them
+	reportTimes = make(map[string][]time.Time)
+)
+
+func loadReports() {
+	var stored map[string]*Report
+	if err := readJSONFile(dataPath("reports.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load reports: %v", err)
+		return
+	}
+	for _, report := range stored {
+		report.ServerID = canonicalID(report.ServerID)
+	}
+	if stored != nil {
+		reportsMu.Lock()
+		reports = stored
+		indexReports()
+		reportsMu.Unlock()
+	}
+}
+
+// indexReports rebuilds serverReports. Callers must hold reportsMu.
+func indexReports() {
+	serverReports = make(map[string][]string)
+	for id, report := range reports {
+		serverReports[report.ServerID] = append(serverReports[report.ServerID], id)
+	}
+}
+
+// evictDismissedReport deletes the oldest dismissed report and reports
+// whether there was one. Callers must hold reportsMu.
+func evictDismissedReport() bool {
+	var oldest *Report
+	for _, report := range reports {
+		if report.Status == "dismissed" && (oldest == nil || report.UpdatedAt.Before(oldest.UpdatedAt)) {
+			oldest = report
+		}
+	}
+	if oldest == nil {
+		return false
+	}
+	delete(reports, oldest.ID)
+	indexReports()
+	return true
+}
+
+// saveReports persists all reports. Callers must hold reportsMu.
+func saveReports() {
+	if err := writeJSONFile(dataPath("reports.json"), reports); err != nil {
+		log.Printf("❌ Failed to save reports: %v", err)
+	}
+}
+
+// underReview reports whether an entry has a confirmed report or open
+// ones from at least the configured number of reporters
+func underReview(serverID string) bool {
+	reportsMu.RLock()
+	defer reportsMu.RUnlock()
+
+	reporters := make(map[string]bool)
+	for _, id := range serverReports[serverID] {
+		switch report := reports[id]; report.Status {
+		case "confirmed":
+			return true
+		case "open":
+			reporters[report.Reporter] = true
+		}
+	}
+	return cfg.ReportThreshold > 0 && len(reporters) >= cfg.ReportThreshold
+}
+
+// reporterID identifies who filed a report: the API key user when present,
+// otherwise the client address
+func reporterID(r *http.Request) string {
+	if user, ok := apiKeyUser(r); ok {
+		return "user:" + user
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// allowReport records a report attempt and reports whether the reporter is
+// still within its hourly limit. Callers must hold reportsMu.
+func allowReport(reporter string, now time.Time) bool {
+	cutoff := now.Add(-time.Hour)
+	if len(reportTimes) >= maxReports {
+		for other, times := range reportTimes {
+			if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+				delete(reportTimes, other)
+			}
+		}
+	}
+	var recent []time.Time
+	for _, at := range reportTimes[reporter] {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= cfg.ReportsPerHour {
+		reportTimes[reporter] = recent
+		return false
+	}
+	reportTimes[reporter] = append(recent, now)
+	return true
+}
+
+// ReportRequest is the body of POST /api/v1/servers/{id}/report
+type ReportRequest struct {
+	Reason      string `json:"reason"`
+	EvidenceURL string `json:"evidence_url"`
+}
+
+// reportServerHandler files an abuse report against a server
+func reportServerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serverID := r.PathValue("id")
+	if _, exists := getEntry(serverID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+
+	var req ReportRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		writeError(w, http.StatusBadRequest, "Field 'reason' is required")
+		return
+	}
+	if len(req.Reason) > maxReportLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Field 'reason' must be at most %d characters", maxReportLength))
+		return
+	}
+	if req.EvidenceURL != "" {
+		evidence, err := url.Parse(req.EvidenceURL)
+		if err != nil || (evidence.Scheme != "http" && evidence.Scheme != "https") || evidence.Host == "" {
+			writeError(w, http.StatusBadRequest, "Field 'evidence_url' must be an http(s) URL")
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	reporter := reporterID(r)
+
+	reportsMu.Lock()
+	open := 0
+	for _, id := range serverReports[serverID] {
+		if existing := reports[id]; existing.Status == "open" {
+			if existing.Reporter == reporter {
+				reportsMu.Unlock()
+				writeError(w, http.StatusConflict, fmt.Sprintf("You already reported '%s'; report %s awaits triage", serverID, existing.ID))
+				return
+			}
+			open++
+		}
+	}
+	if open >= maxOpenReportsPerServer {
+		reportsMu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("'%s' already has %d open reports awaiting triage", serverID, open))
+		return
+	}
+	if !allowReport(reporter, now) {
+		reportsMu.Unlock()
+		w.Header().Set("Retry-After", "3600")
+		writeError(w, http.StatusTooManyRequests, "Too many reports, try again later")
+		return
+	}
+	if len(reports) >= maxReports && !evictDismissedReport() {
+		reportsMu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "The report queue is full, try again later")
+		return
+	}
+	report := &Report{
+		ID:          newID(),
+		ServerID:    serverID,
+		Reporter:    reporter,
+		Reason:      req.Reason,
+		EvidenceURL: req.EvidenceURL,
+		Status:      "open",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	reports[report.ID] = report
+	serverReports[serverID] = append(serverReports[serverID], report.ID)
+	saveReports()
+	reportsMu.Unlock()
+
+	log.Printf("🚩 Abuse report %s filed against %s", report.ID, serverID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":           report.ID,
+		"server_id":    serverID,
+		"status":       report.Status,
+		"under_review": underReview(serverID),
+	})
+}
+
+// adminReportsHandler is the maintainer triage queue, by default the open
+// reports, oldest first
+func adminReportsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+	serverID := r.URL.Query().Get("server")
+
+	reportsMu.RLock()
+	var queue []Report
+	for _, report := range reports {
+		if report.Status == status && (serverID == "" || report.ServerID == serverID) {
+			queue = append(queue, *report)
+		}
+	}
+	reportsMu.RUnlock()
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i].CreatedAt.Before(queue[j].CreatedAt)
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"reports": queue,
+		"total":   len(queue),
+	})
+}
+
+// adminReportHandler triages a report: confirmed reports keep the entry
+// under review, dismissed ones no longer count toward the threshold
+func adminReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if req.Status != "open" && req.Status != "confirmed" && req.Status != "dismissed" {
+		writeError(w, http.StatusBadRequest, "Field 'status' must be open, confirmed or dismissed")
+		return
+	}
+
+	reportID := r.PathValue("report_id")
+	reportsMu.Lock()
+	report, exists := reports[reportID]
+	if !exists {
+		reportsMu.Unlock()
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Report '%s' not found", reportID))
+		return
+	}
+	report.Status = req.Status
+	report.Note = req.Note
+	report.UpdatedAt = time.Now().UTC()
+	updated := *report
+	saveReports()
+	reportsMu.Unlock()
+
+	json.NewEncoder(w).Encode(updated)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// maxServersPerRequest caps how many servers one request may reference
+const maxServersPerRequest = 100
+
+// requestError is a client error with the HTTP status to report
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// decodeStrict decodes exactly one JSON value into dst, rejecting unknown
+// fields and trailing data
+func decodeStrict(body io.Reader, dst interface{}) error {
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			return &requestError{
+				status:  http.StatusRequestEntityTooLarge,
+				message: fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit),
+			}
+		case errors.As(err, &syntaxErr):
+			return badRequest("Invalid JSON at offset %d", syntaxErr.Offset)
+		case errors.As(err, &typeErr):
+			return badRequest("Field '%s' has the wrong type", typeErr.Field)
+		case errors.Is(err, io.EOF):
+			return badRequest("Request body is empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return badRequest("Unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		default:
+			return badRequest("Invalid JSON")
+		}
+	}
+
+	if decoder.More() {
+		return badRequest("Request body must contain a single JSON object")
+	}
+	return nil
+}
+
+// limitBody caps how much of the request body handlers may read
+func limitBody(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxBodyBytes)
+}
+
+// decodeJSONBody limits the request body and strictly decodes it into dst
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	limitBody(w, r)
+	return decodeStrict(r.Body, dst)
+}
+
+// writeRequestError reports a decode or validation error to the client
+func writeRequestError(w http.ResponseWriter, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		writeError(w, reqErr.status, reqErr.message)
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+// validateServerIDs checks the server list shared by config-producing
+// requests and replaces legacy IDs with the current ones
+func validateServerIDs(ids []string) error {
+	if len(ids) == 0 {
+		return badRequest("Missing 'servers' in request body")
+	}
+	if len(ids) > maxServersPerRequest {
+		return badRequest("At most %d servers may be requested at once", maxServersPerRequest)
+	}
+	for _, id := range ids {
+		if strings.TrimSpace(id) == "" {
+			return badRequest("Server IDs must not be empty")
+		}
+	}
+	for i, id := range ids {
+		ids[i] = canonicalID(id)
+	}
+	return nil
+}
+
+// GenerateConfigRequest is the body of POST /api/v1/servers/generate-config
+type GenerateConfigRequest struct {
+	Servers []string `json:"servers"`
+	Format  string   `json:"format"`
+	// ClientVersion selects the config shape of an older client release
+	ClientVersion  string `json:"client_version"`
+	SecretsBackend string `json:"secrets_backend"`
+	SecretsPrefix  string `json:"secrets_prefix"`
+	// SuggestProfiles splits an oversized selection into client-sized profiles
+	SuggestProfiles bool `json:"suggest_profiles"`
+	// AllowExperimental lets experimental servers into the config
+	AllowExperimental bool `json:"allow_experimental"`
+	// WrapperDir is the absolute directory wrapper scripts are installed in
+	WrapperDir string `json:"wrapper_dir"`
+	// InlineWrappers embeds the wrapper scripts in the install script
+	InlineWrappers bool `json:"inline_wrappers"`
+	// BinaryDir is the absolute directory prebuilt binaries are installed in
+	BinaryDir string `json:"binary_dir"`
+	// OS and Lang select the installation notes; both are guessed from
+	// the request headers when empty
+	OS   string `json:"os"`
+	Lang string `json:"lang"`
+	// Channels orders the distribution channels to install servers from;
+	// servers not distributed over any of them fall back to their own order
+	Channels []string `json:"channels"`
+}
+
+func parseGenerateConfigRequest(body io.Reader) (GenerateConfigRequest, error) {
+	var req GenerateConfigRequest
+	if err := decodeStrict(body, &req); err != nil {
+		return req, err
+	}
+	if err := validateServerIDs(req.Servers); err != nil {
+		return req, err
+	}
+	if req.Format == "" {
+		req.Format = "claude_desktop"
+	}
+	if req.ClientVersion != "" && !clientVersionPattern.MatchString(req.ClientVersion) {
+		return req, badRequest("Field 'client_version' must be a version such as 1.2.0")
+	}
+	if _, ok := secretsBackends[req.SecretsBackend]; req.SecretsBackend != "" && !ok {
+		return req, badRequest("Field 'secrets_backend' must be one of %s", secretsBackendNames())
+	}
+	if req.OS != "" && !slices.Contains(installOSes, req.OS) {
+		return req, badRequest("Field 'os' must be one of %s", strings.Join(installOSes, ", "))
+	}
+	for _, channel := range req.Channels {
+		if !slices.Contains(distributionChannels, channel) {
+			return req, badRequest("Field 'channels' must only contain %s", strings.Join(distributionChannels, ", "))
+		}
+	}
+	if req.WrapperDir == "" {
+		req.WrapperDir = defaultWrapperDir
+	} else if !path.IsAbs(req.WrapperDir) {
+		return req, badRequest("Field 'wrapper_dir' must be an absolute path")
+	}
+	if req.BinaryDir == "" {
+		req.BinaryDir = defaultBinaryDir
+	} else if !path.IsAbs(req.BinaryDir) {
+		return req, badRequest("Field 'binary_dir' must be an absolute path")
+	}
+	return req, nil
+}
+
+// ValidateConfigRequest is the body of POST /api/v1/validate-config
+type ValidateConfigRequest struct {
+	Format        string                 `json:"format"`
+	ClientVersion string                 `json:"client_version"`
+	Config        map[string]interface{} `json:"config"`
+}
+
+func parseValidateConfigRequest(body io.Reader) (ValidateConfigRequest, error) {
+	var req ValidateConfigRequest
+	if err := decodeStrict(body, &req); err != nil {
+		return req, err
+	}
+	if req.Config == nil {
+		return req, badRequest("Missing 'config' in request body")
+	}
+	if req.Format == "" {
+		req.Format = "claude_desktop"
+	}
+	if req.ClientVersion != "" && !clientVersionPattern.MatchString(req.ClientVersion) {
+		return req, badRequest("Field 'client_version' must be a version such as 1.2.0")
+	}
+	return req, nil
+}
+
+// IdentifyConfigRequest is the body of POST /api/v1/identify-config: an
+// existing client config file as it is on disk
+type IdentifyConfigRequest struct {
+	Config map[string]interface{} `json:"config"`
+}
+
+func parseIdentifyConfigRequest(body io.Reader) (IdentifyConfigRequest, error) {
+	var req IdentifyConfigRequest
+	if err := decodeStrict(body, &req); err != nil {
+		return req, err
+	}
+	if req.Config == nil {
+		return req, badRequest("Missing 'config' in request body")
+	}
+	return req, nil
+}
+
+func parseWizardRequest(body io.Reader) (WizardRequest, error) {
+	var req WizardRequest
+	if err := decodeStrict(body, &req); err != nil {
+		return req, err
+	}
+	if err := validateServerIDs(req.Servers); err != nil {
+		return req, err
+	}
+	req.Answers = canonicalKeys(req.Answers)
+	return req, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var requestSeeds = []string{
+	`{"servers":["github"]}`,
+	`{"servers":["github","filesystem"],"format":"claude_desktop"}`,
+	`{"servers":[1,2,3]}`,
+	`{"servers":"github"}`,
+	`{"servers":[]}`,
+	`{"servers":[""]}`,
+	`{"servers":["github"],"unknown":true}`,
+	`{"servers":["github"]}{"servers":["x"]}`,
+	`{"servers":["github"],"answers":{"github":{"GITHUB_TOKEN":"t"}}}`,
+	`{"servers":["github"],"answers":{"github":"t"}}`,
+	`[]`,
+	`null`,
+	``,
+	`{`,
+}
+
+func FuzzParseGenerateConfigRequest(f *testing.F) {
+	for _, seed := range requestSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		req, err := parseGenerateConfigRequest(strings.NewReader(body))
+		if err != nil {
+			var reqErr *requestError
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("error %v is not a requestError", err)
+			}
+			return
+		}
+		if len(req.Servers) == 0 || len(req.Servers) > maxServersPerRequest {
+			t.Fatalf("accepted %d servers", len(req.Servers))
+		}
+		if req.Format == "" {
+			t.Fatal("format was not defaulted")
+		}
+	})
+}
+
+func FuzzParseWizardRequest(f *testing.F) {
+	for _, seed := range requestSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, body string) {
+		req, err := parseWizardRequest(strings.NewReader(body))
+		if err != nil {
+			var reqErr *requestError
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("error %v is not a requestError", err)
+			}
+			return
+		}
+		for _, id := range req.Servers {
+			if strings.TrimSpace(id) == "" {
+				t.Fatal("accepted an empty server ID")
+			}
+		}
+	})
+}
+
+func TestGenerateConfigRejectsMalformedBodies(t *testing.T) {
+	servers = map[string]interface{}{
+		"github": map[string]interface{}{"name": "github"},
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"non-string server", `{"servers":[1]}`, http.StatusBadRequest},
+		{"unknown field", `{"servers":["github"],"extra":1}`, http.StatusBadRequest},
+		{"trailing data", `{"servers":["github"]} []`, http.StatusBadRequest},
+		{"oversized", `{"servers":["` + strings.Repeat("a", 2<<20) + `"]}`, http.StatusRequestEntityTooLarge},
+		{"valid", `{"servers":["github"]}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/servers/generate-config", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			generateConfigHandler(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestToolsHandlerPages(t *testing.T) {
+	servers = map[string]interface{}{
+		"github": map[string]interface{}{"name": "github"},
+	}
+	capabilityIndex = []indexedTool{
+		{serverID: "github", tool: Tool{Name: "create_issue"}},
+		{serverID: "github", tool: Tool{Name: "search_code"}},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		tools  int
+	}{
+		{"first page", "?limit=1", http.StatusOK, 1},
+		{"last page", "?page=2&limit=1", http.StatusOK, 1},
+		{"past the last page", "?page=3&limit=1", http.StatusOK, 0},
+		{"oversized page", "?page=368934881474191033&limit=50", http.StatusOK, 0},
+		{"zero page", "?page=0", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/tools"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			toolsHandler(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Tools []ToolRecord `json:"tools"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Tools) != tt.tools {
+				t.Fatalf("got %d tools, want %d", len(body.Tools), tt.tools)
+			}
+		})
+	}
+}
+
+func TestSyncCodecsRoundTrip(t *testing.T) {
+	var catalog bytes.Buffer
+	for i := 0; catalog.Len() < 300<<10; i++ {
+		fmt.Fprintf(&catalog, `{"id":"community/server-%d","name":"Server %d","tags":["git","db"]},`, i, i%97)
+	}
+	noise := make([]byte, 200<<10)
+	rand.New(rand.NewSource(1)).Read(noise)
+
+	payloads := map[string][]byte{
+		"empty":   {},
+		"byte":    {'x'},
+		"run":     bytes.Repeat([]byte{'a'}, 150<<10),
+		"catalog": catalog.Bytes(),
+		"noise":   noise,
+	}
+	for _, name := range syncCodecOrder {
+		codec := syncCodecs[name]
+		for payloadName, payload := range payloads {
+			t.Run(name+"/"+payloadName, func(t *testing.T) {
+				compressed, err := codec.compress(payload)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := codec.decompress(compressed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, payload) {
+					t.Fatalf("round trip changed %d bytes into %d", len(payload), len(got))
+				}
+			})
+		}
+	}
+}
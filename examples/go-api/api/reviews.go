@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxReviewLength bounds the text of a review
+const maxReviewLength = 1000
+
+// Review is one user's rating of a server. Reviews are hidden until a
+// maintainer approves them.
+type Review struct {
+	ID        string    `json:"id"`
+	ServerID  string    `json:"server_id"`
+	User      string    `json:"user"`
+	Rating    int       `json:"rating"`
+	Text      string    `json:"text,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RatingSummary aggregates the approved ratings of a server
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+var (
+	reviewsMu sync.RWMutex
+	reviews   = make(map[string]*Review)
+	// ratings holds each server's approved rating summary, rebuilt when
+	// reviews change so listings do not scan every review per entry
+	ratings = make(map[string]*RatingSummary)
+)
+
+func loadReviews() {
+	var stored map[string]*Review
+	if err := readJSONFile(dataPath("reviews.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load reviews: %v", err)
+		return
+	}
+	for _, review := range stored {
+		review.ServerID = canonicalID(review.ServerID)
+	}
+	if stored != nil {
+		reviewsMu.Lock()
+		reviews = stored
+		aggregateRatings()
+		reviewsMu.Unlock()
+	}
+}
+
+// aggregateRatings rebuilds ratings from the approved reviews. Callers
+// must hold reviewsMu.
+func aggregateRatings() {
+	totals := make(map[string]int)
+	counts := make(map[string]int)
+	for _, review := range reviews {
+		if review.Status == "approved" {
+			totals[review.ServerID] += review.Rating
+			counts[review.ServerID]++
+		}
+	}
+	ratings = make(map[string]*RatingSummary, len(counts))
+	for serverID, count := range counts {
+		ratings[serverID] = &RatingSummary{
+			Average: math.Round(float64(totals[serverID])/float64(count)*10) / 10,
+			Count:   count,
+		}
+	}
+}
+
+// saveReviews persists all reviews. Callers must hold reviewsMu.
+func saveReviews() {
+	if err := writeJSONFile(dataPath("reviews.json"), reviews); err != nil {
+		log.Printf("❌ Failed to save reviews: %v", err)
+	}
+}
+
+// serverRating returns the approved rating summary of a server, or nil
+// when it has none
+func serverRating(serverID string) *RatingSummary {
+	reviewsMu.RLock()
+	defer reviewsMu.RUnlock()
+	return ratings[serverID]
+}
+
+func parseMinRatingFilter(r *http.Request) (entryFilter, error) {
+	raw := r.URL.Query().Get("min_rating")
+	if raw == "" {
+		return nil, nil
+	}
+	minRating, err := strconv.ParseFloat(raw, 64)
+	if err != nil || minRating < 1 || minRating > 5 {
+		return nil, fmt.Errorf("Query parameter 'min_rating' must be between 1 and 5")
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		rating := serverRating(serverID)
+		return rating != nil && rating.Average >= minRating
+	}, nil
+}
+
+// ReviewRequest is the body of POST /api/v1/servers/{id}/reviews
+type ReviewRequest struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+// serverReviewsHandler lists approved reviews (GET) or records the caller's
+// review, replacing any earlier one (POST)
+func serverReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	serverID := r.PathValue("id")
+	if _, exists := getEntry(serverID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		reviewsMu.RLock()
+		var approved []Review
+		for _, review := range reviews {
+			if review.ServerID == serverID && review.Status == "approved" {
+				approved = append(approved, *review)
+			}
+		}
+		reviewsMu.RUnlock()
+		sort.Slice(approved, func(i, j int) bool {
+			return approved[i].CreatedAt.After(approved[j].CreatedAt)
+		})
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"server_id": serverID,
+			"rating":    serverRating(serverID),
+			"reviews":   approved,
+		})
+	case "POST":
+		user, ok := requireUser(w, r)
+		if !ok {
+			return
+		}
+		var req ReviewRequest
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		req.Text = strings.TrimSpace(req.Text)
+		if req.Rating < 1 || req.Rating > 5 {
+			writeError(w, http.StatusBadRequest, "Field 'rating' must be between 1 and 5")
+			return
+		}
+		if len(req.Text) > maxReviewLength {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Field 'text' must be at most %d characters", maxReviewLength))
+			return
+		}
+
+		now := time.Now().UTC()
+		reviewsMu.Lock()
+		var review *Review
+		for _, existing := range reviews {
+			if existing.ServerID == serverID && existing.User == user {
+				review = existing
+				break
+			}
+		}
+		if review == nil {
+			review = &Review{ID: newID(), ServerID: serverID, User: user, CreatedAt: now}
+			reviews[review.ID] = review
+		}
+		review.Rating = req.Rating
+		review.Text = req.Text
+		review.Status = "pending"
+		review.UpdatedAt = now
+		saved := *review
+		aggregateRatings()
+		saveReviews()
+		reviewsMu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(saved)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// adminReviewsHandler lists reviews for moderation, by default the pending ones
+func adminReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	reviewsMu.RLock()
+	var queue []Review
+	for _, review := range reviews {
+		if review.Status == status {
+			queue = append(queue, *review)
+		}
+	}
+	reviewsMu.RUnlock()
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i].UpdatedAt.Before(queue[j].UpdatedAt)
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"reviews": queue,
+		"total":   len(queue),
+	})
+}
+
+// adminReviewHandler approves or rejects (POST) or deletes (DELETE) a review
+func adminReviewHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	reviewID := r.PathValue("review_id")
+	reviewsMu.Lock()
+	defer reviewsMu.Unlock()
+
+	review, exists := reviews[reviewID]
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Review '%s' not found", reviewID))
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		var req struct {
+			Status string `json:"status"`
+		}
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		if req.Status != "approved" && req.Status != "rejected" {
+			writeError(w, http.StatusBadRequest, "Field 'status' must be approved or rejected")
+			return
+		}
+		review.Status = req.Status
+		review.UpdatedAt = time.Now().UTC()
+		aggregateRatings()
+		saveReviews()
+		json.NewEncoder(w).Encode(review)
+	case "DELETE":
+		delete(reviews, reviewID)
+		aggregateRatings()
+		saveReviews()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
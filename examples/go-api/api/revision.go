@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRevisionWait caps how long a long-poll may block
+const maxRevisionWait = 60 * time.Second
+
+// The catalog revision increases whenever served entries or category
+// metadata change. revisionChanged is closed and replaced on every bump so
+// any number of waiters can block on it.
+var (
+	revisionMu      sync.Mutex
+	revision        int64 = 1
+	revisionTime          = time.Now().UTC()
+	revisionChanged       = make(chan struct{})
+)
+
+// bumpRevision records a catalog change and wakes every long-poll
+func bumpRevision() {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+	revision++
+	revisionTime = time.Now().UTC()
+	close(revisionChanged)
+	revisionChanged = make(chan struct{})
+}
+
+// currentRevision returns the revision and a channel closed on the next change
+func currentRevision() (int64, <-chan struct{}) {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+	return revision, revisionChanged
+}
+
+// revisionInfo returns the revision and when it was reached
+func revisionInfo() (int64, time.Time) {
+	revisionMu.Lock()
+	defer revisionMu.Unlock()
+	return revision, revisionTime
+}
+
+// revisionHandler returns the catalog revision. With since and wait it
+// blocks until the revision moves past since or wait elapses; without them
+// it honours If-None-Match for cheap conditional polling.
+func revisionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var wait time.Duration
+	if raw := r.URL.Query().Get("wait"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, "Query parameter 'wait' must be a duration such as 30s")
+			return
+		}
+		wait = min(parsed, maxRevisionWait)
+	}
+	since := int64(-1)
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Query parameter 'since' must be a revision number")
+			return
+		}
+		since = parsed
+	}
+
+	rev, changed := currentRevision()
+	if rev == since && wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-changed:
+			rev, _ = currentRevision()
+		case <-timer.C:
+		case <-r.Context().Done():
+			// Running out of request time just ends the wait early
+			if !timedOut(r) {
+				return
+			}
+		}
+	}
+
+	etag := fmt.Sprintf(`"%d"`, rev)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if since < 0 && r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"revision": rev,
+		"changed":  rev != since,
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// riskFlagLevels maps each capability an entry may declare under "risk" to
+// the risk level it implies. Outbound network access is read_only because
+// it changes nothing on the user's machine.
+var riskFlagLevels = map[string]string{
+	"read_fs":     "read_only",
+	"network":     "read_only",
+	"write_fs":    "read_write",
+	"exec":        "privileged",
+	"credentials": "privileged",
+}
+
+// riskLevels orders risk levels from least to most dangerous
+var riskLevels = []string{"none", "read_only", "read_write", "privileged"}
+
+func riskRank(level string) int {
+	for i, known := range riskLevels {
+		if known == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// RiskLabels are the capabilities of a server and the level they add up to
+type RiskLabels struct {
+	Flags []string `json:"flags"`
+	Level string   `json:"level"`
+	// Declared is false when the entry has no curated "risk" list and the
+	// flags were only inferred from its secrets and endpoints
+	Declared bool `json:"declared"`
+}
+
+// entryRisk classifies an entry from its curated "risk" list plus what its
+// config reveals: secret env vars mean credentials, and declared egress or
+// a remote endpoint means network access. Entries with neither a curated
+// list nor anything to infer have no labels.
+func entryRisk(config map[string]interface{}) *RiskLabels {
+	flags := make(map[string]bool)
+	raw, declared := config["risk"].([]interface{})
+	for _, flag := range raw {
+		if str, ok := flag.(string); ok {
+			if _, known := riskFlagLevels[str]; known {
+				flags[str] = true
+			}
+		}
+	}
+	for _, question := range envQuestions(config) {
+		if question.Secret {
+			flags["credentials"] = true
+		}
+	}
+	if hosts, _ := entryEgress(config); len(hosts) > 0 || getString(config, "url", "") != "" {
+		flags["network"] = true
+	}
+	if !declared && len(flags) == 0 {
+		return nil
+	}
+
+	labels := &RiskLabels{Flags: []string{}, Level: "none", Declared: declared}
+	for flag := range flags {
+		labels.Flags = append(labels.Flags, flag)
+		if level := riskFlagLevels[flag]; riskRank(level) > riskRank(labels.Level) {
+			labels.Level = level
+		}
+	}
+	sort.Strings(labels.Flags)
+	return labels
+}
+
+func parseMaxRiskFilter(r *http.Request) (entryFilter, error) {
+	maxRisk := r.URL.Query().Get("max_risk")
+	if maxRisk == "" {
+		return nil, nil
+	}
+	limit := riskRank(maxRisk)
+	if limit < 0 {
+		return nil, fmt.Errorf("Query parameter 'max_risk' must be one of %s", strings.Join(riskLevels, ", "))
+	}
+	// Undeclared entries are left out: inferred flags cannot rule out
+	// filesystem or exec access
+	return func(serverID string, config map[string]interface{}) bool {
+		labels := entryRisk(config)
+		return labels != nil && labels.Declared && riskRank(labels.Level) <= limit
+	}, nil
+}
+
+// RiskSummary is the combined risk of the servers in a generated config
+type RiskSummary struct {
+	Level string `json:"level"`
+	// Flags lists the servers contributing each capability
+	Flags map[string][]string `json:"flags"`
+	// Unclassified servers declare no risk list
+	Unclassified []string `json:"unclassified,omitempty"`
+}
+
+// riskSummary combines the risk labels of the included servers
+func riskSummary(serverIDs []string) *RiskSummary {
+	summary := &RiskSummary{Level: "none", Flags: make(map[string][]string)}
+	for _, serverID := range serverIDs {
+		config, _ := getEntry(serverID)
+		labels := entryRisk(config)
+		if labels == nil || !labels.Declared {
+			summary.Unclassified = append(summary.Unclassified, serverID)
+		}
+		if labels == nil {
+			continue
+		}
+		for _, flag := range labels.Flags {
+			summary.Flags[flag] = append(summary.Flags[flag], serverID)
+		}
+		if riskRank(labels.Level) > riskRank(summary.Level) {
+			summary.Level = labels.Level
+		}
+	}
+	return summary
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Rule is one catalog consistency rule from rules.yaml. Rules are keyed by
+// name; conditions map an entry field to glob patterns, any of which may
+// match, and a leading "!" negates a pattern:
+//
+//	rules:
+//	  no-gpl-official:
+//	    severity: block
+//	    message: Official entries must not be GPL-3.0
+//	    when:
+//	      categories: official
+//	    forbid:
+//	      license: GPL-3.0*
+//	  database-connection-env:
+//	    when:
+//	      category: database
+//	    require:
+//	      env: ["*_URL", "*CONNECTION*", "*DSN"]
+//
+// An entry violates a rule when it matches every "when" condition and
+// fails a "require" condition or matches every "forbid" condition.
+type Rule struct {
+	Name string `json:"name"`
+	// Severity is warn (reported only) or block (refused at load and on writes)
+	Severity string              `json:"severity"`
+	Message  string              `json:"message,omitempty"`
+	When     map[string][]string `json:"when,omitempty"`
+	Require  map[string][]string `json:"require,omitempty"`
+	Forbid   map[string][]string `json:"forbid,omitempty"`
+}
+
+// RuleViolation is one entry breaking one rule
+type RuleViolation struct {
+	ServerID string `json:"server_id"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ruleFields are the entry fields conditions may test
+var ruleFields = map[string]func(config map[string]interface{}) []string{
+	"name":     func(config map[string]interface{}) []string { return []string{getString(config, "name", "")} },
+	"category": func(config map[string]interface{}) []string { return []string{getString(config, "category", "other")} },
+	"license":  func(config map[string]interface{}) []string { return []string{getString(config, "license", "")} },
+	"vendor":   func(config map[string]interface{}) []string { return []string{entryVendor(config)} },
+	"maturity": func(config map[string]interface{}) []string { return []string{entryMaturity(config)} },
+	"vendor_verified": func(config map[string]interface{}) []string {
+		vendor, _ := vendorRecord(entryVendor(config))
+		return []string{fmt.Sprint(vendor.Verification == "verified")}
+	},
+	"categories":      func(config map[string]interface{}) []string { return ruleList(config, "categories") },
+	"transports":      entryTransports,
+	"risk":            func(config map[string]interface{}) []string { return ruleList(config, "risk") },
+	"system_binaries": entrySystemBinaries,
+	"env": func(config map[string]interface{}) []string {
+		var keys []string
+		for _, question := range envQuestions(config) {
+			keys = append(keys, question.Key)
+		}
+		return keys
+	},
+}
+
+// catalogRules are the loaded rules, sorted by name
+var catalogRules []Rule
+
+func ruleList(config map[string]interface{}, key string) []string {
+	list, _ := stringList(config[key])
+	return list
+}
+
+// loadRules reads rules.yaml next to the catalog. A broken file is
+// ignored as a whole, so a typo cannot half-apply a rule set.
+func loadRules() {
+	paths := []string{
+		"../../mcp_catalog/rules.yaml",
+		"rules.yaml",
+	}
+	data, source, ok := readSourceFile("rules.yaml", paths)
+	if !ok {
+		return
+	}
+	rules, err := parseRules(string(data))
+	if err != nil {
+		log.Printf("❌ Ignoring %s: %v", source, err)
+		return
+	}
+	log.Printf("📏 Loaded %d consistency rules from %s", len(rules), source)
+	catalogRules = rules
+}
+
+// parseRules decodes and checks a rules file
+func parseRules(data string) ([]Rule, error) {
+	tree, err := parseSimpleYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := tree["rules"].(map[string]interface{})
+	var rules []Rule
+	for name, value := range raw {
+		spec, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("rule '%s' must be a mapping", name)
+		}
+		rule := Rule{Name: name, Severity: getString(spec, "severity", "warn"), Message: getString(spec, "message", "")}
+		if rule.Severity != "warn" && rule.Severity != "block" {
+			return nil, fmt.Errorf("rule '%s': severity must be warn or block", name)
+		}
+		for key, target := range map[string]*map[string][]string{"when": &rule.When, "require": &rule.Require, "forbid": &rule.Forbid} {
+			conditions, err := parseRuleConditions(spec[key])
+			if err != nil {
+				return nil, fmt.Errorf("rule '%s' %s: %v", name, key, err)
+			}
+			*target = conditions
+		}
+		if len(rule.Require) == 0 && len(rule.Forbid) == 0 {
+			return nil, fmt.Errorf("rule '%s' needs require or forbid conditions", name)
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules, nil
+}
+
+func parseRuleConditions(raw interface{}) (map[string][]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	spec, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a mapping of field to patterns")
+	}
+	conditions := make(map[string][]string)
+	for field, value := range spec {
+		if _, known := ruleFields[field]; !known {
+			return nil, fmt.Errorf("unknown field '%s'", field)
+		}
+		var patterns []string
+		switch v := value.(type) {
+		case string:
+			patterns = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				patterns = append(patterns, fmt.Sprint(item))
+			}
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+				return nil, fmt.Errorf("field '%s': bad pattern '%s'", field, pattern)
+			}
+		}
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("field '%s' has no patterns", field)
+		}
+		conditions[field] = patterns
+	}
+	return conditions, nil
+}
+
+// matchesCondition reports whether any pattern matches the field, ignoring
+// case. A negated pattern matches when no value of the field matches it.
+func matchesCondition(config map[string]interface{}, field string, patterns []string) bool {
+	values := ruleFields[field](config)
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.ToLower(strings.TrimPrefix(pattern, "!"))
+		found := false
+		for _, value := range values {
+			if ok, _ := path.Match(pattern, strings.ToLower(value)); ok {
+				found = true
+				break
+			}
+		}
+		if found != negated {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAll(config map[string]interface{}, conditions map[string][]string) bool {
+	for field, patterns := range conditions {
+		if !matchesCondition(config, field, patterns) {
+			return false
+		}
+	}
+	return true
+}
+
+// violates reports whether an entry breaks a rule
+func (rule Rule) violates(config map[string]interface{}) bool {
+	if !matchesAll(config, rule.When) {
+		return false
+	}
+	if !matchesAll(config, rule.Require) {
+		return true
+	}
+	return len(rule.Forbid) > 0 && matchesAll(config, rule.Forbid)
+}
+
+// entryViolations evaluates every rule against one entry
+func entryViolations(serverID string, config map[string]interface{}) []RuleViolation {
+	var violations []RuleViolation
+	for _, rule := range catalogRules {
+		if !rule.violates(config) {
+			continue
+		}
+		message := rule.Message
+		if message == "" {
+			message = "Entry breaks rule '" + rule.Name + "'"
+		}
+		violations = append(violations, RuleViolation{ServerID: serverID, Rule: rule.Name, Severity: rule.Severity, Message: message})
+	}
+	return violations
+}
+
+// blockingViolations returns the messages of the block-level rules an
+// entry breaks
+func blockingViolations(serverID string, config map[string]interface{}) []string {
+	var problems []string
+	for _, violation := range entryViolations(serverID, config) {
+		if violation.Severity == "block" {
+			problems = append(problems, fmt.Sprintf("rule '%s': %s", violation.Rule, violation.Message))
+		}
+	}
+	return problems
+}
+
+// catalogViolations evaluates the rules against every served entry
+func catalogViolations() []RuleViolation {
+	violations := []RuleViolation{}
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		violations = append(violations, entryViolations(serverID, config)...)
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].ServerID != violations[j].ServerID {
+			return violations[i].ServerID < violations[j].ServerID
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+	return violations
+}
+
+// rulesReportHandler lists the loaded rules and the served entries that
+// break them. Blocked entries never load, so they show up as load errors.
+func rulesReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rules := catalogRules
+	if rules == nil {
+		rules = []Rule{}
+	}
+	violations := catalogViolations()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rules":      rules,
+		"violations": violations,
+		"total":      len(violations),
+	})
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// runtimeLanguages are the implementation languages an entry's "runtime"
+// block may name, with the runtime each needs
+var runtimeLanguages = map[string]string{
+	"typescript": "node",
+	"javascript": "node",
+	"python":     "python",
+	"go":         "go",
+	"rust":       "native",
+	"java":       "jvm",
+}
+
+// maxRuntimeFileBytes bounds the part of a repository file runtime
+// detection reads
+const maxRuntimeFileBytes = 256 << 10
+
+// runtimeFiles are the repository files runtime detection reads, in the
+// order they decide the language: a package.json is often only tooling
+// next to a pyproject.toml or go.mod
+var runtimeFiles = []string{"pyproject.toml", "go.mod", "package.json", "Dockerfile"}
+
+// registryRuntimes are the runtimes the packages of each registry run on
+var registryRuntimes = map[string]string{
+	"npm":  "node",
+	"pypi": "python",
+}
+
+// RuntimeInfo is the implementation language and runtime of a server.
+// Entries may declare it in a "runtime" block; enrichment detects it from
+// the repository, filling what the block leaves out.
+type RuntimeInfo struct {
+	Language string `json:"language,omitempty"`
+	Runtime  string `json:"runtime,omitempty"`
+	// Version is the runtime version the server requires, e.g. ">=18"
+	Version string `json:"version,omitempty"`
+	// Docker is set when the repository ships a Dockerfile
+	Docker bool `json:"docker,omitempty"`
+	// DetectedFrom lists the repository files detection read
+	DetectedFrom []string `json:"detected_from,omitempty"`
+}
+
+// RuntimeMismatch is a field of an entry's runtime or package that
+// disagrees with what detection found in the repository
+type RuntimeMismatch struct {
+	Field    string `json:"field"`
+	Entry    string `json:"entry"`
+	Detected string `json:"detected"`
+}
+
+var (
+	pythonRequiresPattern = regexp.MustCompile(`(?m)^\s*requires-python\s*=\s*["']([^"']+)["']`)
+	goDirectivePattern    = regexp.MustCompile(`(?m)^go\s+(\S+)`)
+)
+
+// detectRuntime reads the language and runtime off repository files by
+// name; a missing file is absent from the map
+func detectRuntime(files map[string]string) (RuntimeInfo, bool) {
+	var info RuntimeInfo
+	for _, name := range runtimeFiles {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		info.DetectedFrom = append(info.DetectedFrom, name)
+		if name == "Dockerfile" {
+			info.Docker = true
+			continue
+		}
+		if info.Language != "" {
+			continue
+		}
+		switch name {
+		case "pyproject.toml":
+			info.Language, info.Runtime = "python", "python"
+			if match := pythonRequiresPattern.FindStringSubmatch(content); match != nil {
+				info.Version = match[1]
+			}
+		case "go.mod":
+			info.Language, info.Runtime = "go", "go"
+			if match := goDirectivePattern.FindStringSubmatch(content); match != nil {
+				info.Version = match[1]
+			}
+		case "package.json":
+			var manifest struct {
+				Types           string            `json:"types"`
+				Engines         map[string]string `json:"engines"`
+				Dependencies    map[string]string `json:"dependencies"`
+				DevDependencies map[string]string `json:"devDependencies"`
+			}
+			if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+				continue
+			}
+			info.Language, info.Runtime, info.Version = "javascript", "node", manifest.Engines["node"]
+			if _, ok := manifest.DevDependencies["typescript"]; ok || manifest.Types != "" {
+				info.Language = "typescript"
+			} else if _, ok := manifest.Dependencies["typescript"]; ok {
+				info.Language = "typescript"
+			}
+		}
+	}
+	return info, len(info.DetectedFrom) > 0
+}
+
+// repositoryRawBase is where the raw files of an entry's GitHub repository
+// are read from
+func repositoryRawBase(config map[string]interface{}) (string, bool) {
+	owner, name, ok := githubRepo(config)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/HEAD/", owner, name), true
+}
+
+// fetchRuntime fetches the runtime files under a raw repository base and
+// detects the runtime from those that exist. Missing files are not
+// errors: a repository with none of them has no runtime info.
+func fetchRuntime(ctx context.Context, client *http.Client, base string) (RuntimeInfo, error) {
+	files := make(map[string]string)
+	for _, name := range runtimeFiles {
+		req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(base, "/")+"/"+name, nil)
+		if err != nil {
+			return RuntimeInfo{}, err
+		}
+		resp, err := callDependency(client, req)
+		if err != nil {
+			return RuntimeInfo{}, err
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxRuntimeFileBytes))
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return RuntimeInfo{}, fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL)
+		case err != nil:
+			return RuntimeInfo{}, err
+		}
+		files[name] = string(data)
+	}
+	info, _ := detectRuntime(files)
+	return info, nil
+}
+
+// declaredRuntime decodes the "runtime" block of an entry
+func declaredRuntime(config map[string]interface{}) RuntimeInfo {
+	raw, _ := config["runtime"].(map[string]interface{})
+	docker, _ := raw["docker"].(bool)
+	return RuntimeInfo{
+		Language: getString(raw, "language", ""),
+		Runtime:  getString(raw, "runtime", ""),
+		Version:  getString(raw, "version", ""),
+		Docker:   docker,
+	}
+}
+
+// enrichedRuntime returns the runtime detected from an entry's repository,
+// if detection found any of its files
+func enrichedRuntime(config map[string]interface{}) (RuntimeInfo, bool) {
+	source := entryEnrichment(config)["runtime"]
+	if source == "" {
+		return RuntimeInfo{}, false
+	}
+	enrichment.Lock()
+	defer enrichment.Unlock()
+	info, ok := enrichment.runtimes[source]
+	return info, ok && len(info.DetectedFrom) > 0
+}
+
+// entryRuntime is an entry's declared runtime with what detection found
+// filling the fields it leaves out; nil when neither says anything
+func entryRuntime(config map[string]interface{}) *RuntimeInfo {
+	info := declaredRuntime(config)
+	if info.Runtime == "" {
+		info.Runtime = runtimeLanguages[info.Language]
+	}
+	if detected, ok := enrichedRuntime(config); ok {
+		if info.Language == "" {
+			info.Language = detected.Language
+		}
+		if info.Runtime == "" {
+			info.Runtime = detected.Runtime
+		}
+		if info.Version == "" {
+			info.Version = detected.Version
+		}
+		info.Docker = info.Docker || detected.Docker
+		info.DetectedFrom = detected.DetectedFrom
+	}
+	if info.Language == "" && info.Runtime == "" && !info.Docker {
+		return nil
+	}
+	return &info
+}
+
+// runtimeMismatches compares an entry's runtime block and package with
+// what detection found in its repository
+func runtimeMismatches(config map[string]interface{}) []RuntimeMismatch {
+	detected, ok := enrichedRuntime(config)
+	if !ok {
+		return nil
+	}
+	declared := declaredRuntime(config)
+	var mismatches []RuntimeMismatch
+	compare := func(field, entry, found string) {
+		if entry != "" && found != "" && entry != found {
+			mismatches = append(mismatches, RuntimeMismatch{Field: field, Entry: entry, Detected: found})
+		}
+	}
+	compare("runtime.language", declared.Language, detected.Language)
+	compare("runtime.runtime", declared.Runtime, detected.Runtime)
+	compare("runtime.version", declared.Version, detected.Version)
+	if pkg, ok := entryPackage(config); ok {
+		if pkg.Registry == "docker" && !detected.Docker {
+			mismatches = append(mismatches, RuntimeMismatch{Field: "package.registry", Entry: "docker", Detected: "no Dockerfile"})
+		}
+		if runtime, ok := registryRuntimes[pkg.Registry]; ok && detected.Runtime != "" && runtime != detected.Runtime {
+			mismatches = append(mismatches, RuntimeMismatch{Field: "package.registry", Entry: pkg.Registry, Detected: detected.Runtime})
+		}
+	}
+	return mismatches
+}
+
+// validateRuntime checks the "runtime" block of an entry
+func validateRuntime(config map[string]interface{}) []string {
+	raw, present := config["runtime"]
+	if !present {
+		return nil
+	}
+	block, ok := raw.(map[string]interface{})
+	if !ok {
+		return []string{"'runtime' must be an object"}
+	}
+	languages := make([]string, 0, len(runtimeLanguages))
+	runtimes := make(map[string]bool)
+	for language, runtime := range runtimeLanguages {
+		languages = append(languages, language)
+		runtimes[runtime] = true
+	}
+	sort.Strings(languages)
+	var problems []string
+	for _, key := range []string{"language", "runtime", "version"} {
+		if value, present := block[key]; present {
+			if _, ok := value.(string); !ok {
+				problems = append(problems, fmt.Sprintf("'runtime.%s' must be a string", key))
+			}
+		}
+	}
+	if language := getString(block, "language", ""); language != "" && runtimeLanguages[language] == "" {
+		problems = append(problems, fmt.Sprintf("'runtime.language' must be one of %s", strings.Join(languages, ", ")))
+	}
+	if runtime := getString(block, "runtime", ""); runtime != "" && !runtimes[runtime] {
+		problems = append(problems, "'runtime.runtime' must be one of node, python, go, jvm, native")
+	}
+	if value, present := block["docker"]; present {
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, "'runtime.docker' must be a boolean")
+		}
+	}
+	return problems
+}
+
+// RuntimeReport is an entry's runtime in /api/v1/reports/runtime
+type RuntimeReport struct {
+	Runtime    *RuntimeInfo      `json:"runtime,omitempty"`
+	Detected   *RuntimeInfo      `json:"detected,omitempty"`
+	Mismatches []RuntimeMismatch `json:"mismatches,omitempty"`
+}
+
+// runtimeReportHandler lists the runtime of every entry detection has run
+// for; ?mismatched=true keeps those disagreeing with their entry
+func runtimeReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	mismatchedOnly, _, err := parseBoolParam(r, "mismatched")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results := make(map[string]RuntimeReport)
+	mismatched := 0
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		detected, ok := enrichedRuntime(config)
+		if !ok {
+			continue
+		}
+		report := RuntimeReport{Runtime: entryRuntime(config), Detected: &detected, Mismatches: runtimeMismatches(config)}
+		if len(report.Mismatches) > 0 {
+			mismatched++
+		} else if mismatchedOnly {
+			continue
+		}
+		results[serverID] = report
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"mismatched": mismatched,
+		"results":    results,
+	})
+}
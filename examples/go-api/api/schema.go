@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configSchemaVersion versions the generate-config output schemas. Entry
+// schemas follow the catalog file's currentSchemaVersion.
+const configSchemaVersion = 1
+
+// jsonSchemaDialect is the JSON Schema draft every published schema uses
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaBaseURL is the absolute URL schemas are published under, so their
+// $id stays valid when a schema is copied elsewhere
+func schemaBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/api/v1/schema"
+}
+
+func stringSchema(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func stringListSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": description,
+		"items":       map[string]interface{}{"type": "string"},
+	}
+}
+
+// entrySchema describes a catalog entry as validateEntry accepts it
+func entrySchema(id string) map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDialect,
+		"$id":         id,
+		"title":       "MCP catalog entry",
+		"description": fmt.Sprintf("One server entry of a schema v%d catalog file, keyed by server ID", currentSchemaVersion),
+		"type":        "object",
+		"required":    []string{"name"},
+		"patternProperties": map[string]interface{}{
+			"^" + extensionPrefix + strings.TrimPrefix(extensionNamePattern.String(), "^"): map[string]interface{}{
+				"description": "Extension: any JSON value, kept through exports and syncs and filtered with ?x.NAME=VALUE",
+			},
+		},
+		"properties": map[string]interface{}{
+			"name":        map[string]interface{}{"type": "string", "minLength": 1},
+			"description": stringSchema("One-line summary shown in listings"),
+			"category":    stringSchema("Primary category"),
+			"categories":  stringListSchema("Every category and tier marker, e.g. \"official\""),
+			"vendor":      map[string]interface{}{"type": "string", "pattern": vendorIDPattern.String(), "description": "Publisher's vendor ID, a key of vendors.json; defaults to \"community\""},
+			"homepage":    map[string]interface{}{"type": "string", "format": "uri"},
+			"license":     stringSchema("SPDX license identifier"),
+			"maturity":    map[string]interface{}{"type": "string", "enum": maturityLevels, "default": "stable", "description": "Experimental entries are hidden from list/search and need allow_experimental in generate-config"},
+			"url":         map[string]interface{}{"type": "string", "format": "uri", "description": "Endpoint of a remote server"},
+			"transport":   stringSchema("Single supported transport; prefer transports"),
+			"transports":  stringListSchema("Supported transports: stdio, sse or streamable-http"),
+			"aliases":     stringListSchema("Extra search terms"),
+			"tags": map[string]interface{}{
+				"type":        "array",
+				"description": "Free-form labels such as \"rag\"; managed in bulk with /api/v1/tags/apply",
+				"items":       map[string]interface{}{"type": "string", "pattern": tagPattern.String()},
+			},
+			"requires":    stringListSchema("Server IDs that must be installed alongside"),
+			"recommends":  stringListSchema("Server IDs that work well alongside"),
+			"maintainers": stringListSchema("Maintainers owning the entry; notifications.routes of the form MAINTAINER:EVENT_PATTERN=CHANNEL tell them about its advisories, broken links, smoke test failures and sync conflicts"),
+			"legacy_ids":  stringListSchema("Flat IDs the server had before IDs became vendor/name; they redirect to the current ID"),
+			"examples": map[string]interface{}{
+				"type":        "array",
+				"description": "Sample prompts showing what an agent can do with the server, shown on the detail endpoint and matched by search; curated with /api/v1/servers/ID/examples",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"title", "prompt"},
+					"properties": map[string]interface{}{
+						"title":             map[string]interface{}{"type": "string", "minLength": 1},
+						"prompt":            map[string]interface{}{"type": "string", "minLength": 1},
+						"expected_behavior": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+			"deprecated": map[string]interface{}{
+				"type":        "object",
+				"description": "Marks an entry that should no longer be installed; /api/v1/upgrade-plan swaps it for replaced_by",
+				"properties": map[string]interface{}{
+					"reason":      map[string]interface{}{"type": "string"},
+					"replaced_by": map[string]interface{}{"type": "string", "description": "ID of the entry to install instead"},
+				},
+			},
+			"distributions": map[string]interface{}{
+				"type":        "array",
+				"description": "Ways to install the server, in order of preference; generate-config picks one per the caller's channels and os. Entries without it are installed from package or url",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"channel"},
+					"properties": map[string]interface{}{
+						"channel":   map[string]interface{}{"type": "string", "enum": distributionChannels},
+						"package":   map[string]interface{}{"type": "string", "description": "npm or PyPI package"},
+						"image":     map[string]interface{}{"type": "string", "description": "Docker image"},
+						"url":       map[string]interface{}{"type": "string", "description": "Hosted endpoint"},
+						"transport": map[string]interface{}{"type": "string", "enum": []string{"sse", "streamable-http"}},
+						"binary":    map[string]interface{}{"type": "string", "description": "Program a binary distribution installs"},
+						"assets": map[string]interface{}{
+							"type":        "array",
+							"description": "Prebuilt binaries by platform; install scripts refuse a download whose checksum does not match",
+							"items": map[string]interface{}{
+								"type":     "object",
+								"required": []string{"platform", "url", "sha256"},
+								"properties": map[string]interface{}{
+									"platform": map[string]interface{}{"type": "string", "description": "OS and architecture, e.g. linux-amd64"},
+									"url":      map[string]interface{}{"type": "string", "format": "uri"},
+									"sha256":   map[string]interface{}{"type": "string", "pattern": sha256Pattern.String()},
+								},
+							},
+						},
+						"platforms":          stringListSchema("Platforms the distribution runs on; all when empty"),
+						"version":            map[string]interface{}{"type": "string"},
+						"known_good_version": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+			"runtime": map[string]interface{}{
+				"type":        "object",
+				"description": "Implementation language and runtime; enrichment detects them from the repository, fills what is left out and reports disagreements at /api/v1/reports/runtime",
+				"properties": map[string]interface{}{
+					"language": map[string]interface{}{"type": "string", "enum": []string{"go", "java", "javascript", "python", "rust", "typescript"}},
+					"runtime":  map[string]interface{}{"type": "string", "enum": []string{"node", "python", "go", "jvm", "native"}},
+					"version":  map[string]interface{}{"type": "string", "description": "Runtime version required, e.g. \">=18\""},
+					"docker":   map[string]interface{}{"type": "boolean"},
+				},
+				"additionalProperties": false,
+			},
+			"risk": map[string]interface{}{
+				"type":        "array",
+				"description": "What the server can do; an empty list declares no risky capabilities",
+				"items":       map[string]interface{}{"type": "string", "enum": []string{"read_fs", "write_fs", "exec", "network", "credentials"}},
+			},
+			"system_binaries": map[string]interface{}{
+				"type":        "array",
+				"description": "Programs the server shells out to, e.g. \"git\" or \"ffmpeg\"; checked by preflight and install scripts",
+				"items":       map[string]interface{}{"type": "string", "pattern": binaryNamePattern.String()},
+			},
+			"merge_policy": map[string]interface{}{
+				"type":                 "object",
+				"description":          "How upstream sync treats fields where an upstream copy of this entry disagrees, by field with \"*\" as the default; overrides sync.merge_policies",
+				"additionalProperties": map[string]interface{}{"type": "string", "enum": mergePolicies},
+			},
+			"platforms": map[string]interface{}{
+				"type":        "array",
+				"description": "Platforms the server runs on, e.g. \"macos\" or \"linux-amd64\"; none means every platform. Clients naming their platform in X-MCP-Client only see matching entries",
+				"items":       map[string]interface{}{"type": "string", "pattern": `^(macos|windows|linux)(-(amd64|arm64))?$`},
+			},
+			"egress": stringListSchema("External hosts the server connects to, e.g. \"*.slack.com\"; empty means none"),
+			"launch": map[string]interface{}{
+				"type":        "object",
+				"description": "Setup a stdio server needs before its command runs; generated configs launch it through a wrapper script",
+				"properties": map[string]interface{}{
+					"cwd":  stringSchema("Working directory; a leading ~/ is the user's home"),
+					"venv": stringSchema("Python virtualenv to activate, relative to cwd unless absolute"),
+				},
+				"additionalProperties": false,
+			},
+			"enrichment": map[string]interface{}{
+				"type":        "object",
+				"description": "Remote data fetched in the background after startup, bounded by enrichment.concurrency; /readyz?verbose=true reports progress",
+				"properties": map[string]interface{}{
+					"icon":         map[string]interface{}{"type": "string", "format": "uri", "description": "Served as /api/v1/assets/icons/ID.EXT unless a local icon exists"},
+					"readme":       map[string]interface{}{"type": "string", "format": "uri", "description": "Served as /api/v1/assets/readmes/ID.md unless a local README exists"},
+					"capabilities": map[string]interface{}{"type": "string", "format": "uri", "description": "JSON tools list, or an object with one, used when the entry declares no tools"},
+					"runtime":      map[string]interface{}{"type": "string", "format": "uri", "description": "Base URL of the raw repository files (package.json, pyproject.toml, go.mod, Dockerfile) the runtime is detected from; defaults to the GitHub repository with enrichment.detect_runtimes"},
+				},
+				"additionalProperties": false,
+			},
+			"verify": map[string]interface{}{
+				"type":        "object",
+				"description": "How to check an installed server works; replaces the generic MCP Inspector tools/list check in generate-config's post_install",
+				"required":    []string{"command"},
+				"properties": map[string]interface{}{
+					"command": stringSchema("Shell command that exercises the server"),
+					"expect":  stringSchema("What the command prints when the server works"),
+				},
+				"additionalProperties": false,
+			},
+			"troubleshooting": map[string]interface{}{"type": "string", "format": "uri", "description": "Troubleshooting docs linked from generate-config's post_install; defaults to the repository's issues"},
+			"try_it": map[string]interface{}{
+				"type":        "object",
+				"description": "Opts a hosted streamable-http server into anonymous \"Try it\" previews",
+				"properties": map[string]interface{}{
+					"sample_tool":      stringSchema("The only tool a preview may call"),
+					"sample_arguments": map[string]interface{}{"type": "object", "description": "Arguments of the sample tool call"},
+				},
+			},
+			"package": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"name"},
+				"properties": map[string]interface{}{
+					"name":               map[string]interface{}{"type": "string", "minLength": 1},
+					"registry":           map[string]interface{}{"type": "string", "enum": []string{"npm", "pypi", "docker"}, "default": "npm"},
+					"version":            map[string]interface{}{"type": "string", "default": "latest"},
+					"known_good_version": stringSchema("Version generated configs pin to"),
+				},
+			},
+			"config": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"args": stringListSchema("Extra launch arguments"),
+					"env": map[string]interface{}{
+						"type": "object",
+						"additionalProperties": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"description": map[string]interface{}{"type": "string"},
+								"required":    map[string]interface{}{"type": "boolean"},
+							},
+						},
+					},
+				},
+			},
+			"tools": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"name"},
+					"properties": map[string]interface{}{
+						"name":         map[string]interface{}{"type": "string", "minLength": 1},
+						"description":  map[string]interface{}{"type": "string"},
+						"inputSchema":  map[string]interface{}{"type": "object"},
+						"outputSchema": map[string]interface{}{"type": "object"},
+					},
+				},
+			},
+			"provenance": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"sbom_url":        map[string]interface{}{"type": "string", "format": "uri"},
+					"attestation_url": map[string]interface{}{"type": "string", "format": "uri"},
+					"slsa_level":      map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 4},
+					"signature": map[string]interface{}{
+						"type":     "object",
+						"required": []string{"type"},
+						"properties": map[string]interface{}{
+							"type":     map[string]interface{}{"type": "string"},
+							"url":      map[string]interface{}{"type": "string", "format": "uri"},
+							"identity": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// configSchema describes the "config" a generate-config format produces,
+// limited to what the client profile can express and in its shape
+func configSchema(id, format string, client ClientProfile) map[string]interface{} {
+	shape := client.Shape
+
+	var remoteTransports []string
+	for _, transport := range client.Transports {
+		if transport != "stdio" {
+			remoteTransports = append(remoteTransports, shape.transportName(transport))
+		}
+	}
+	var launches []interface{}
+	if client.supportsTransport("stdio") {
+		local := map[string]interface{}{
+			"command": map[string]interface{}{"type": "string"},
+			"args":    stringListSchema("Command arguments"),
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		}
+		if shape.TypedStdio {
+			local[shape.TransportKey] = map[string]interface{}{"const": "stdio"}
+		}
+		launches = append(launches, map[string]interface{}{
+			"title":      "Local process",
+			"required":   []string{"command"},
+			"properties": local,
+		})
+	}
+	if len(remoteTransports) > 0 {
+		required := []string{shape.URLKey}
+		remote := map[string]interface{}{
+			shape.URLKey: map[string]interface{}{"type": "string", "format": "uri"},
+		}
+		if shape.TransportKey != "" {
+			required = append(required, shape.TransportKey)
+			remote[shape.TransportKey] = map[string]interface{}{"type": "string", "enum": remoteTransports}
+		}
+		launches = append(launches, map[string]interface{}{
+			"title":      "Remote endpoint",
+			"required":   required,
+			"properties": remote,
+		})
+	}
+	server := map[string]interface{}{
+		"type":  "object",
+		"oneOf": launches,
+	}
+
+	mcpServers := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": server,
+	}
+	if client.RecommendedServers > 0 {
+		mcpServers["description"] = fmt.Sprintf("%s works best with at most %d servers", client.Name, client.RecommendedServers)
+	}
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDialect,
+		"$id":         id,
+		"title":       client.Name + " MCP configuration",
+		"description": fmt.Sprintf("The 'config' returned by generate-config with format '%s'", format),
+		"type":        "object",
+		"required":    []string{shape.ServersKey},
+		"properties": map[string]interface{}{
+			shape.ServersKey: mcpServers,
+		},
+	}
+}
+
+// schemaHandler publishes the entry and generate-config schemas:
+//
+//	/api/v1/schema                       index of every schema
+//	/api/v1/schema/entry[/vN]            catalog entry
+//	/api/v1/schema/config/{format}[/vN]  generate-config output
+//
+// Unversioned URLs serve the current version; versioned URLs are stable.
+// Config schemas take ?client_version for the shape an older client reads.
+func schemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	base := schemaBaseURL(r)
+	entryURL := fmt.Sprintf("%s/entry/v%d", base, currentSchemaVersion)
+	configURL := func(format string) string {
+		return fmt.Sprintf("%s/config/%s/v%d", base, format, configSchemaVersion)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/schema"), "/"), "/")
+	if parts[0] == "" {
+		formats := make([]string, 0, len(clientProfiles))
+		for format := range clientProfiles {
+			formats = append(formats, format)
+		}
+		sort.Strings(formats)
+		configs := make(map[string]string, len(formats))
+		for _, format := range formats {
+			configs[format] = configURL(format)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entry": map[string]interface{}{
+				"version": currentSchemaVersion,
+				"url":     entryURL,
+			},
+			"config": map[string]interface{}{
+				"version": configSchemaVersion,
+				"formats": configs,
+			},
+		})
+		return
+	}
+
+	// checkVersion accepts a missing version or the current one. Earlier
+	// versions are gone for good (410), malformed ones are bad requests
+	// and later ones do not exist yet.
+	checkVersion := func(rest []string, current int) bool {
+		if len(rest) == 0 {
+			return true
+		}
+		if len(rest) != 1 || !strings.HasPrefix(rest[0], "v") {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Schema version '%s' must be v followed by a number, such as v%d", strings.Join(rest, "/"), current))
+			return false
+		}
+		version, err := strconv.Atoi(rest[0][1:])
+		switch {
+		case err != nil || version < 1:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Schema version '%s' must be v followed by a number, such as v%d", rest[0], current))
+		case version < current:
+			writeError(w, http.StatusGone, fmt.Sprintf("Schema version v%d is no longer supported; the current version is v%d", version, current))
+		case version > current:
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Schema version v%d is not available; the current version is v%d", version, current))
+		default:
+			return true
+		}
+		return false
+	}
+
+	switch parts[0] {
+	case "entry":
+		if !checkVersion(parts[1:], currentSchemaVersion) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		json.NewEncoder(w).Encode(entrySchema(entryURL))
+	case "config":
+		if len(parts) < 2 {
+			writeError(w, http.StatusNotFound, "Schema path must name a format, e.g. /api/v1/schema/config/claude_desktop")
+			return
+		}
+		format := parts[1]
+		if _, known := clientProfiles[format]; !known {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown format '%s'", format))
+			return
+		}
+		if !checkVersion(parts[2:], configSchemaVersion) {
+			return
+		}
+		id := configURL(format)
+		version := r.URL.Query().Get("client_version")
+		if version != "" && !clientVersionPattern.MatchString(version) {
+			writeError(w, http.StatusBadRequest, "Query parameter 'client_version' must be a version such as 1.2.0")
+			return
+		}
+		client, err := clientProfileVersion(format, version)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if version != "" {
+			id += "?client_version=" + url.QueryEscape(version)
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		json.NewEncoder(w).Encode(configSchema(id, format, client))
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown schema '%s'", parts[0]))
+	}
+}
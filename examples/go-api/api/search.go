@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultSynonyms groups terms that should find each other in search
+var defaultSynonyms = [][]string{
+	{"postgres", "postgresql", "pg"},
+	{"github", "gh"},
+	{"gitlab", "gl"},
+	{"kubernetes", "k8s"},
+	{"filesystem", "fs", "files"},
+	{"javascript", "js"},
+	{"typescript", "ts"},
+	{"mysql", "mariadb"},
+	{"mongodb", "mongo"},
+	{"slack", "chat"},
+}
+
+// synonyms is the active synonym dictionary, optionally loaded from synonyms.json
+var synonyms = defaultSynonyms
+
+// indexedEntry holds the precomputed, case-folded search fields of an
+// entry, plus their transliterated forms for accent-insensitive fallback
+type indexedEntry struct {
+	id           string
+	fields       []string
+	aliases      []string
+	plainFields  []string
+	plainAliases []string
+}
+
+var searchIndex []indexedEntry
+
+func loadSynonyms() {
+	paths := []string{
+		"../../mcp_catalog/synonyms.json",
+		"synonyms.json",
+	}
+
+	data, path, ok := readSourceFile("synonyms.json", paths)
+	if !ok {
+		return
+	}
+	var groups [][]string
+	if err := json.Unmarshal(data, &groups); err != nil {
+		log.Printf("❌ Ignoring %s: %v", path, err)
+		return
+	}
+	log.Printf("🔤 Loaded %d synonym groups from %s", len(groups), path)
+	synonyms = groups
+}
+
+// tokenize splits text into case-folded alphanumeric words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(foldText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// entryAliases returns the curated "aliases" list of an entry
+func entryAliases(config map[string]interface{}) []string {
+	raw, _ := config["aliases"].([]interface{})
+	var aliases []string
+	for _, alias := range raw {
+		if str, ok := alias.(string); ok && str != "" {
+			aliases = append(aliases, str)
+		}
+	}
+	return aliases
+}
+
+// buildSearchIndex indexes the served entries for every search backend
+func buildSearchIndex() {
+	index := indexEntries(servers)
+	searchIndex = index
+	buildCapabilityIndex()
+	searchBackend.Reindex(index)
+	refreshEmbeddings()
+	refreshEnrichment()
+}
+
+// indexEntries precomputes search fields and expands each entry's curated
+// aliases with every synonym group its ID, name or aliases touch
+func indexEntries(entries map[string]interface{}) []indexedEntry {
+	index := make([]indexedEntry, 0, len(entries))
+	for serverID, entryInterface := range entries {
+		config, ok := entryInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		aliasSet := make(map[string]bool)
+		for _, alias := range entryAliases(config) {
+			aliasSet[foldText(alias)] = true
+		}
+
+		tokens := make(map[string]bool)
+		for _, text := range append([]string{serverID, getString(config, "name", "")}, entryAliases(config)...) {
+			for _, token := range tokenize(text) {
+				tokens[token] = true
+			}
+		}
+		for _, group := range synonyms {
+			touched := false
+			for _, term := range group {
+				if tokens[foldText(term)] {
+					touched = true
+					break
+				}
+			}
+			if !touched {
+				continue
+			}
+			for _, term := range group {
+				if !tokens[foldText(term)] {
+					aliasSet[foldText(term)] = true
+				}
+			}
+		}
+
+		aliases := make([]string, 0, len(aliasSet))
+		for alias := range aliasSet {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+
+		fields := []string{
+			foldText(serverID),
+			foldText(getString(config, "name", "")),
+			foldText(getString(config, "description", "")),
+			foldText(examplesText(config)),
+		}
+		index = append(index, indexedEntry{
+			id:           serverID,
+			fields:       fields,
+			aliases:      aliases,
+			plainFields:  transliterateAll(fields),
+			plainAliases: transliterateAll(aliases),
+		})
+	}
+	sort.Slice(index, func(i, j int) bool {
+		return index[i].id < index[j].id
+	})
+	return index
+}
+
+// transliterateAll transliterates every folded string
+func transliterateAll(folded []string) []string {
+	plain := make([]string, len(folded))
+	for i, text := range folded {
+		plain[i] = transliterate(text)
+	}
+	return plain
+}
+
+// searchQuery is a query in the normalized forms the index is matched against
+type searchQuery struct {
+	folded string
+	plain  string
+}
+
+func newSearchQuery(query string) searchQuery {
+	folded := foldText(query)
+	return searchQuery{folded: folded, plain: transliterate(folded)}
+}
+
+// searchFieldNames label indexedEntry.fields in score explanations
+var searchFieldNames = []string{"id", "name", "description", "examples"}
+
+// matchKind grades how a normalized query hits a field: "exact",
+// "prefix", "contains", or "" when it does not
+func matchKind(field, query string) string {
+	switch {
+	case field == query:
+		return "exact"
+	case strings.HasPrefix(field, query):
+		return "prefix"
+	case strings.Contains(field, query):
+		return "contains"
+	}
+	return ""
+}
+
+// match returns how the query hits each field of the entry, keeping only
+// the best alias hit. Exact (folded) matches win; accent-insensitive
+// matches are only tried when nothing matched exactly.
+func (e indexedEntry) match(query searchQuery) []FieldMatch {
+	if matches := matchFields(e.fields, e.aliases, e.aliases, query.folded, false); len(matches) > 0 {
+		return matches
+	}
+	return matchFields(e.plainFields, e.plainAliases, e.aliases, query.plain, true)
+}
+
+// matchFields matches one normalized form of the query; display holds the
+// aliases as reported back to clients
+func matchFields(fields, aliases, display []string, query string, accentInsensitive bool) []FieldMatch {
+	var matches []FieldMatch
+	for i, field := range fields {
+		if kind := matchKind(field, query); kind != "" {
+			matches = append(matches, newFieldMatch(searchFieldNames[i], "", kind, accentInsensitive))
+		}
+	}
+	var best *FieldMatch
+	for i, alias := range aliases {
+		if kind := matchKind(alias, query); kind != "" {
+			candidate := newFieldMatch("alias", display[i], kind, accentInsensitive)
+			if best == nil || candidate.Score > best.Score {
+				best = &candidate
+			}
+		}
+	}
+	if best != nil {
+		matches = append(matches, *best)
+	}
+	return matches
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// SearchHit is an entry a search backend matched, with how each field hit
+type SearchHit struct {
+	ID      string
+	Matches []FieldMatch
+}
+
+// IndexHealth is the state of the search backend reported by /readyz
+type IndexHealth struct {
+	Backend string `json:"backend"`
+	// Status is ok, reindexing, degraded (searches fall back to the
+	// in-memory index) or unavailable
+	Status    string     `json:"status"`
+	Documents int        `json:"documents"`
+	IndexedAt *time.Time `json:"indexed_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// SearchIndex is a search backend. Reindex is called with the normalized
+// entries of the in-memory index whenever the served catalog is replaced;
+// Search matches a non-empty query. An embedded engine such as bleve would
+// plug in here too; none ships because the server has no third-party
+// dependencies.
+type SearchIndex interface {
+	Reindex(entries []indexedEntry)
+	Search(ctx context.Context, query string) ([]SearchHit, error)
+	Health(ctx context.Context) IndexHealth
+}
+
+// memoryIndex searches the in-memory index buildSearchIndex maintains. That
+// index is kept for every backend, since it is also the fallback.
+type memoryIndex struct{}
+
+func (memoryIndex) Reindex(entries []indexedEntry) {}
+
+func (memoryIndex) Search(ctx context.Context, query string) ([]SearchHit, error) {
+	return matchIndex(ctx, searchIndex, query)
+}
+
+// matchIndex matches a query against every entry of an in-memory index. It
+// stops early when ctx ends, returning the hits found so far.
+func matchIndex(ctx context.Context, index []indexedEntry, query string) ([]SearchHit, error) {
+	normalized := newSearchQuery(query)
+	var hits []SearchHit
+	for _, entry := range index {
+		if err := ctx.Err(); err != nil {
+			return hits, err
+		}
+		if matches := entry.match(normalized); len(matches) > 0 {
+			hits = append(hits, SearchHit{ID: entry.id, Matches: matches})
+		}
+	}
+	return hits, nil
+}
+
+func (memoryIndex) Health(ctx context.Context) IndexHealth {
+	return IndexHealth{Backend: "memory", Status: "ok", Documents: len(searchIndex)}
+}
+
+// searchBackend is the configured backend; the in-memory index until
+// startSearchBackend runs
+var searchBackend SearchIndex = memoryIndex{}
+
+// startSearchBackend selects the configured backend and indexes the
+// catalog. Subcommands never reach it, so they do not touch a shared index.
+func startSearchBackend() {
+	switch cfg.SearchBackend {
+	case "opensearch":
+		searchBackend = newOpenSearchIndex(cfg.SearchURL, cfg.SearchIndex)
+		log.Printf("🔎 Searching with OpenSearch index '%s' at %s", cfg.SearchIndex, redactURL(cfg.SearchURL))
+	default:
+		return
+	}
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	searchBackend.Reindex(searchIndex)
+}
+
+// searchEntries runs a query on the configured backend. When the backend
+// fails the in-memory index answers instead, so search degrades rather
+// than breaks.
+func searchEntries(ctx context.Context, query string) ([]SearchHit, error) {
+	hits, err := searchBackend.Search(ctx, query)
+	if err == nil || ctx.Err() != nil {
+		return hits, err
+	}
+	if _, isMemory := searchBackend.(memoryIndex); isMemory {
+		return hits, err
+	}
+	log.Printf("⚠️  Search backend failed, using the in-memory index: %v", err)
+	return memoryIndex{}.Search(ctx, query)
+}
+
+func validateSearchBackend(c *Config) error {
+	switch c.SearchBackend {
+	case "memory":
+		return nil
+	case "opensearch":
+		if c.SearchURL == "" {
+			return fmt.Errorf("search.url is required with the opensearch backend")
+		}
+		return nil
+	}
+	return fmt.Errorf("search.backend must be memory or opensearch, not '%s'", c.SearchBackend)
+}
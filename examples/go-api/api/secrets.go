@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// secretsBackend renders a reference to a secret instead of its value
+type secretsBackend struct {
+	defaultPrefix string
+	reference     func(prefix, serverID, key string) string
+}
+
+// secretsBackends are the supported values of the secrets_backend parameter
+var secretsBackends = map[string]secretsBackend{
+	"placeholder": {
+		reference: func(prefix, serverID, key string) string {
+			return "${" + key + "}"
+		},
+	},
+	"env": {
+		reference: func(prefix, serverID, key string) string {
+			return "${env:" + key + "}"
+		},
+	},
+	"1password": {
+		defaultPrefix: "MCP",
+		reference: func(prefix, serverID, key string) string {
+			return fmt.Sprintf("op://%s/%s/%s", prefix, serverID, key)
+		},
+	},
+	"vault": {
+		defaultPrefix: "secret/data/mcp",
+		reference: func(prefix, serverID, key string) string {
+			return fmt.Sprintf("vault:%s/%s#%s", strings.TrimSuffix(prefix, "/"), serverID, key)
+		},
+	},
+	"aws-secrets-manager": {
+		defaultPrefix: "mcp",
+		reference: func(prefix, serverID, key string) string {
+			return fmt.Sprintf("aws-sm:%s/%s#%s", strings.TrimSuffix(prefix, "/"), serverID, key)
+		},
+	},
+}
+
+// secretsBackendNames lists the backends for error messages
+func secretsBackendNames() string {
+	names := make([]string, 0, len(secretsBackends))
+	for name := range secretsBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// secretEnv builds the env block of a server with every required variable
+// pointing at the configured secrets backend
+func secretEnv(serverID string, config map[string]interface{}, opts ConfigOptions) map[string]string {
+	backend, ok := secretsBackends[opts.SecretsBackend]
+	if !ok {
+		backend = secretsBackends["placeholder"]
+	}
+	prefix := opts.SecretsPrefix
+	if prefix == "" {
+		prefix = backend.defaultPrefix
+	}
+
+	env := make(map[string]string)
+	for _, question := range envQuestions(config) {
+		if question.Required {
+			env[question.Key] = backend.reference(prefix, serverID, question.Key)
+		}
+	}
+	return env
+}
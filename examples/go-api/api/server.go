@@ -3,105 +3,197 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
-	"path/filepath"
-	"strconv"
+	"os"
 	"strings"
+	"time"
 )
 
 // Server represents an MCP server
 type Server struct {
-	ID          string      `json:"id"`
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Category    string      `json:"category"`
-	Vendor      string      `json:"vendor"`
-	Homepage    string      `json:"homepage"`
-	License     string      `json:"license,omitempty"`
-	Features    []string    `json:"features,omitempty"`
-	Config      interface{} `json:"config,omitempty"`
+	ID                   string               `json:"id"`
+	Name                 string               `json:"name"`
+	Description          string               `json:"description"`
+	GeneratedDescription string               `json:"generated_description,omitempty"`
+	Category             string               `json:"category"`
+	Vendor               string               `json:"vendor"`
+	Maturity             string               `json:"maturity"`
+	VendorName           string               `json:"vendor_name"`
+	VendorVerified       bool                 `json:"vendor_verified,omitempty"`
+	Homepage             string               `json:"homepage"`
+	License              string               `json:"license,omitempty"`
+	Features             []string             `json:"features,omitempty"`
+	Config               interface{}          `json:"config,omitempty"`
+	Aliases              []string             `json:"aliases,omitempty"`
+	Tags                 []string             `json:"tags,omitempty"`
+	Examples             []Example            `json:"examples,omitempty"`
+	Platforms            []string             `json:"platforms,omitempty"`
+	// Extensions are the entry's "x-" fields
+	Extensions           map[string]interface{} `json:"extensions,omitempty"`
+	MatchedAlias         string               `json:"matched_alias,omitempty"`
+	// MissingRuntimes are launchers an X-MCP-Client did not list as installed
+	MissingRuntimes      []string             `json:"missing_runtimes,omitempty"`
+	Runtime              *RuntimeInfo         `json:"runtime,omitempty"`
+	// RuntimeMismatches are where the entry disagrees with its repository
+	RuntimeMismatches    []RuntimeMismatch    `json:"runtime_mismatches,omitempty"`
+	Provenance           *Provenance          `json:"provenance,omitempty"`
+	// FieldProvenance says where each field came from, on request
+	FieldProvenance      *FieldProvenance     `json:"field_provenance,omitempty"`
+	// OverlaidFields are the fields the requesting tenant's overlay changes
+	OverlaidFields       []string             `json:"overlaid_fields,omitempty"`
+	Egress               []string             `json:"egress,omitempty"`
+	Risk                 *RiskLabels          `json:"risk,omitempty"`
+	Rating               *RatingSummary       `json:"rating,omitempty"`
+	UnderReview          bool                 `json:"under_review,omitempty"`
+	Verification         *PackageVerification `json:"verification,omitempty"`
+	LastSmokeTest        *SmokeResult         `json:"last_smoke_test,omitempty"`
+	Explanation          *SearchExplanation   `json:"explanation,omitempty"`
+	Trend                *Trend               `json:"trend,omitempty"`
 }
 
 // Global server registry
 var servers map[string]interface{}
 
+// loadedCatalogPath is the file the registry was loaded from
+var loadedCatalogPath string
+
 func loadServers() {
+	if cfg.BundlePath != "" {
+		bundlePath := cfg.BundlePath
+		if strings.HasPrefix(bundlePath, "oci://") {
+			pulled, err := pullBundle(bundlePath)
+			if err != nil {
+				log.Fatalf("❌ Cannot pull bundle: %v", err)
+			}
+			bundlePath = pulled
+		}
+		files, err := loadBundle(bundlePath)
+		if err != nil {
+			log.Fatalf("❌ Cannot load bundle: %v", err)
+		}
+		bundleFiles = files
+	}
+	
+	loadSynonyms()
+	loadCategoryMeta()
+	loadVendors()
+	loadRules()
+	defer buildSearchIndex()
+	defer indexLegacyIDs()
+	
 	// Try to load known_servers.json
 	paths := []string{
 		"../../mcp_catalog/known_servers.json",
 		"known_servers.json",
 	}
+	if cfg.CatalogPath != "" {
+		paths = []string{cfg.CatalogPath}
+	}
 	
-	for _, path := range paths {
-		if data, err := ioutil.ReadFile(path); err == nil {
-			if err := json.Unmarshal(data, &servers); err == nil {
+	if data, path, ok := readSourceFile("catalog.json", paths); ok {
+		var doc map[string]interface{}
+		err := json.Unmarshal(data, &doc)
+		if err == nil {
+			var from int
+			from, err = migrateCatalog(doc)
+			if err == nil {
+				entries, rejected := validEntries(path, catalogEntries(doc))
+				for _, loadErr := range rejected {
+					log.Printf("❌ Invalid entry '%s' in %s: %s", loadErr.ServerID, path, strings.Join(loadErr.Errors, "; "))
+				}
+				if len(rejected) > 0 && cfg.LoadMode == "strict" {
+					log.Fatalf("❌ Refusing to start: %d invalid entries in %s (load mode strict)", len(rejected), path)
+				}
+				setLoadErrors(path, rejected)
+				servers = entries
+				loadedCatalogPath = path
 				log.Printf("📚 Loaded %d servers from %s", len(servers), path)
+				if violations := catalogViolations(); len(violations) > 0 {
+					log.Printf("⚠️  %d rule violations in %s; see /api/v1/reports/rules", len(violations), path)
+				}
+				if from < currentSchemaVersion {
+					log.Printf("🔁 Upgraded %s from schema v%d to v%d in memory; run 'migrate -write' to persist", path, from, currentSchemaVersion)
+				}
+				recordComponent("catalog", nil)
 				return
 			}
 		}
+		if cfg.LoadMode == "strict" {
+			log.Fatalf("❌ Refusing to start: cannot load %s: %v (load mode strict)", path, err)
+		}
+		log.Printf("❌ Cannot load %s: %v", path, err)
+		recordComponent("catalog", fmt.Errorf("cannot load %s: %v", path, err))
+	} else {
+		// Federated deployments may serve nothing but upstreams
+		recordComponent("catalog", nil)
 	}
 	
 	log.Println("⚠️  No known_servers.json found, using empty registry")
 	servers = make(map[string]interface{})
 }
 
-func enableCORS(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-}
-
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
+func listServersHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	
-	response := map[string]interface{}{
-		"status":          "healthy",
-		"server_count":    len(servers),
-		"catalog_version": "2.0.0",
-		"api_version":     "v1",
+	filters, err := parseEntryFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	client, _ := parseClientContext(r)
+	entries, _, ok := catalogForRead(w, r)
+	if !ok {
+		return
 	}
 	
-	json.NewEncoder(w).Encode(response)
-}
-
-func listServersHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	w.Header().Set("Content-Type", "application/json")
-	
-	var result []Server
-	for serverID, configInterface := range servers {
-		config := configInterface.(map[string]interface{})
-		
-		server := Server{
-			ID:          serverID,
-			Name:        getString(config, "name", serverID),
-			Description: getString(config, "description", ""),
-			Category:    getString(config, "category", "other"),
-			Vendor:      getString(config, "vendor", "community"),
-			Homepage:    getString(config, "homepage", ""),
+	summaries := func(emit func(Server)) {
+		for serverID, configInterface := range entries {
+			config := configInterface.(map[string]interface{})
+			if !matchesFilters(filters, serverID, config) {
+				continue
+			}
+			server := serverSummary(serverID, config)
+			if client != nil {
+				server.MissingRuntimes = client.missingRuntimes(serverID, config)
+			}
+			emit(server)
 		}
-		result = append(result, server)
 	}
 	
-	json.NewEncoder(w).Encode(result)
+	if wantsJSONAPI(r) {
+		var result []Server
+		summaries(func(server Server) { result = append(result, server) })
+		writeJSONAPI(w, r, serverResources(result, apiBase(r)), map[string]interface{}{"total": len(result)}, nil)
+		return
+	}
+	// Summaries are encoded as they are built, so a large catalog is never
+	// held in memory as a whole
+	result := startArray(w)
+	summaries(func(server Server) { result.add(server) })
+	result.end()
+	io.WriteString(w, "\n")
 }
 
 func getServerHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
 	w.Header().Set("Content-Type", "application/json")
 	
-	// Extract server ID from path
-	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(pathParts) < 4 {
+	serverID := r.PathValue("id")
+	if serverID == "" {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
-	serverID := pathParts[3]
+	if r.Method == "PATCH" {
+		patchServerHandler(w, r, serverID)
+		return
+	}
 	
-	configInterface, exists := servers[serverID]
+	entries, past, ok := catalogForRead(w, r)
+	if !ok {
+		return
+	}
+	configInterface, exists := entries[serverID]
 	if !exists {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -111,24 +203,59 @@ func getServerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	config := configInterface.(map[string]interface{})
+	egress, _ := entryEgress(config)
+	vendor, _ := vendorRecord(entryVendor(config))
+	includeProvenance, _, err := parseBoolParam(r, "include_provenance")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	
 	server := Server{
-		ID:          serverID,
-		Name:        getString(config, "name", serverID),
-		Description: getString(config, "description", ""),
-		Category:    getString(config, "category", "other"),
-		Vendor:      getString(config, "vendor", "community"),
-		Homepage:    getString(config, "homepage", ""),
-		License:     getString(config, "license", "Unknown"),
-		Config:      config,
+		ID:                   serverID,
+		Name:                 getString(config, "name", serverID),
+		Description:          getString(config, "description", ""),
+		GeneratedDescription: generatedDescription(serverID),
+		Category:             getString(config, "category", "other"),
+		Vendor:               entryVendor(config),
+		Maturity:             entryMaturity(config),
+		VendorName:           vendor.Name,
+		VendorVerified:       vendor.Verification == "verified",
+		Homepage:             getString(config, "homepage", ""),
+		License:              getString(config, "license", "Unknown"),
+		Config:               config,
+		Aliases:              entryAliases(config),
+		Tags:                 entryTags(config),
+		Examples:             entryExamples(config),
+		Platforms:            entryPlatforms(config),
+		Extensions:           entryExtensions(config),
+		Runtime:              entryRuntime(config),
+		RuntimeMismatches:    runtimeMismatches(config),
+		Provenance:           entryProvenance(config),
+		Egress:               egress,
+		Risk:                 entryRisk(config),
+		Rating:               serverRating(serverID),
+		UnderReview:          underReview(serverID),
+		Verification:         entryVerification(serverID),
+		LastSmokeTest:        lastSmokeTest(serverID),
+	}
+	if includeProvenance {
+		server.FieldProvenance = entryFieldProvenance(serverID, config)
+	}
+	if past == nil {
+		server.OverlaidFields = overlaidFields(r, serverID)
 	}
 	
+	if wantsJSONAPI(r) {
+		writeJSONAPI(w, r, serverResource(server, apiBase(r)), nil, nil)
+		return
+	}
 	json.NewEncoder(w).Encode(server)
 }
 
 func searchServersHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
 	w.Header().Set("Content-Type", "application/json")
+	start := time.Now()
 	
 	query := r.URL.Query().Get("q")
 	category := r.URL.Query().Get("category")
@@ -141,122 +268,211 @@ func searchServersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	var results []Server
-	for serverID, configInterface := range servers {
-		config := configInterface.(map[string]interface{})
-		
-		// Check query match
-		matchesQuery := true
-		if query != "" {
-			queryLower := strings.ToLower(query)
-			matchesQuery = strings.Contains(strings.ToLower(serverID), queryLower) ||
-				strings.Contains(strings.ToLower(getString(config, "name", "")), queryLower) ||
-				strings.Contains(strings.ToLower(getString(config, "description", "")), queryLower)
-		}
-		
-		// Check category filter
-		matchesCategory := category == "" || getString(config, "category", "other") == category
-		
-		if matchesQuery && matchesCategory {
-			server := Server{
-				ID:          serverID,
-				Name:        getString(config, "name", serverID),
-				Description: getString(config, "description", ""),
-				Category:    getString(config, "category", "other"),
-				Vendor:      getString(config, "vendor", "community"),
-				Homepage:    getString(config, "homepage", ""),
-			}
-			results = append(results, server)
-		}
+	filters, err := parseEntryFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+	// Renamed and split categories map to their successors
+	categories := requestedCategories(w, r)
+	// Filters already dropped what the client cannot use; the context
+	// itself ranks what it can launch right away first
+	client, _ := parseClientContext(r)
 	
-	response := map[string]interface{}{
-		"results":  results,
-		"total":    len(results),
-		"query":    query,
-		"category": category,
+	explain, _, err := parseBoolParam(r, "explain")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	
-	json.NewEncoder(w).Encode(response)
-}
-
-func generateConfigHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
-	w.Header().Set("Content-Type", "application/json")
-	
-	if r.Method == "OPTIONS" {
+	sortBy := r.URL.Query().Get("sort")
+	if sortBy != "" && sortBy != "relevance" && sortBy != "trending" {
+		writeError(w, http.StatusBadRequest, "Query parameter 'sort' must be relevance or trending")
 		return
 	}
-	
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	window, err := parseWindow(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	
-	var requestData map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	mode, err := parseSearchMode(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	
-	serversInterface, ok := requestData["servers"]
+	entries, past, ok := catalogForRead(w, r)
 	if !ok {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Missing 'servers' in request body",
+		return
+	}
+	index := searchIndex
+	if past != nil {
+		if mode != "keyword" {
+			writeError(w, http.StatusBadRequest, "Semantic search only covers the served catalog; drop 'mode' to search a past one")
+			return
+		}
+		index = past.index
+	}
+	
+	// Match the query, falling back to aliases and synonyms; a category
+	// search without a query considers every entry. Past catalogs are
+	// matched in memory.
+	var hits []SearchHit
+	switch {
+	case query != "" && past != nil:
+		hits, err = matchIndex(r.Context(), index, query)
+	case query != "":
+		hits, err = searchWithMode(r.Context(), query, mode)
+		if err != nil && !timedOut(r) {
+			writeError(w, http.StatusServiceUnavailable, "Search is unavailable: "+err.Error())
+			return
+		}
+	default:
+		for _, entry := range index {
+			hits = append(hits, SearchHit{ID: entry.id})
+		}
+	}
+	
+	var ranked []rankedResult
+	for _, hit := range hits {
+		if timedOut(r) {
+			break
+		}
+		config, exists := entries[hit.ID].(map[string]interface{})
+		if !exists {
+			continue
+		}
+		if inCategories(categories, config) && matchesFilters(filters, hit.ID, config) {
+			server := serverSummary(hit.ID, config)
+			// Aliases are matched last, so an alias first means no primary field hit
+			if len(hit.Matches) > 0 && hit.Matches[0].Field == "alias" {
+				server.MatchedAlias = hit.Matches[0].Value
+			}
+			explanation := explainScore(hit.ID, config, hit.Matches)
+			if client != nil {
+				client.personalize(hit.ID, config, &explanation)
+				server.MissingRuntimes = client.missingRuntimes(hit.ID, config)
+			}
+			ranked = append(ranked, rankedResult{server: server, explanation: explanation})
+		}
+	}
+	rankResults(ranked)
+	if sortBy == "trending" {
+		sortTrending(ranked, window, time.Now())
+	}
+	if timedOut(r) {
+		partial := make([]Server, 0, len(ranked))
+		for _, result := range ranked {
+			partial = append(partial, result.server)
+		}
+		recordSearchTiming(r, query, categories, len(partial), time.Since(start))
+		writeTimeout(w, requestTimeoutFor(r.URL.Path), map[string]interface{}{
+			"partial_results": partial,
+			"hint":            "Results are incomplete; narrow the query or add a category or filters",
 		})
 		return
 	}
 	
-	serversArray := serversInterface.([]interface{})
-	formatType := getString(requestData, "format", "claude_desktop")
+	if explain {
+		for i := range ranked {
+			explanation := ranked[i].explanation
+			ranked[i].server.Explanation = &explanation
+		}
+	}
 	
-	config := map[string]interface{}{
-		"mcpServers": make(map[string]interface{}),
+	if len(ranked) == 0 && query != "" {
+		recordMissedSearch(query, category)
 	}
-	mcpServers := config["mcpServers"].(map[string]interface{})
+	recordSearchTiming(r, query, categories, len(ranked), time.Since(start))
 	
-	for _, serverInterface := range serversArray {
-		serverID := serverInterface.(string)
-		if serverConfig, exists := servers[serverID]; exists {
-			mcpConfig := map[string]interface{}{
-				"command": "npx",
-				"args":    []string{"-y", fmt.Sprintf("@modelcontextprotocol/server-%s", serverID)},
-			}
-			mcpServers[serverID] = mcpConfig
+	if wantsJSONAPI(r) {
+		results := make([]Server, 0, len(ranked))
+		for _, result := range ranked {
+			results = append(results, result.server)
 		}
+		writeJSONAPI(w, r, serverResources(results, apiBase(r)), map[string]interface{}{
+			"total":    len(ranked),
+			"query":    query,
+			"category": category,
+		}, nil)
+		return
 	}
 	
 	response := map[string]interface{}{
-		"format":             formatType,
-		"config":             config,
-		"servers_included":   serversArray,
-		"installation_notes": fmt.Sprintf("Add this to your %s configuration file", formatType),
+		"total":    len(ranked),
+		"query":    query,
+		"category": category,
+	}
+	if sortBy == "trending" {
+		response["sort"] = sortBy
+		response["window"] = fmt.Sprintf("%dd", window)
+	}
+	if mode != "keyword" {
+		response["mode"] = mode
+	}
+	if client != nil {
+		response["client"] = client
+	}
+	if past != nil {
+		response["snapshot"] = past.snapshot
 	}
 	
-	json.NewEncoder(w).Encode(response)
+	// The results are streamed ahead of the other fields
+	io.WriteString(w, `{"results":`)
+	results := startArray(w)
+	for _, result := range ranked {
+		results.add(result.server)
+	}
+	results.end()
+	endObject(w, response)
 }
 
 func categoriesHandler(w http.ResponseWriter, r *http.Request) {
-	enableCORS(w)
 	w.Header().Set("Content-Type", "application/json")
 	
-	categories := make(map[string]int)
-	for _, configInterface := range servers {
-		config := configInterface.(map[string]interface{})
-		category := getString(config, "category", "other")
-		categories[category]++
+	if wantsJSONAPI(r) {
+		writeJSONAPI(w, r, categoryResources(categoryInfos(), apiBase(r)), nil, nil)
+		return
 	}
-	
-	var result []map[string]interface{}
-	for category, count := range categories {
-		result = append(result, map[string]interface{}{
-			"name":  category,
-			"count": count,
-		})
+	json.NewEncoder(w).Encode(categoryInfos())
+}
+
+// serverSummary builds the list/search representation of a server
+func serverSummary(serverID string, config map[string]interface{}) Server {
+	vendor, _ := vendorRecord(entryVendor(config))
+	return Server{
+		ID:                   serverID,
+		Name:                 getString(config, "name", serverID),
+		Description:          getString(config, "description", ""),
+		GeneratedDescription: generatedDescription(serverID),
+		Category:             getString(config, "category", "other"),
+		Vendor:               entryVendor(config),
+		Maturity:             entryMaturity(config),
+		VendorName:           vendor.Name,
+		VendorVerified:       vendor.Verification == "verified",
+		Homepage:             getString(config, "homepage", ""),
+		Tags:                 entryTags(config),
+		Platforms:            entryPlatforms(config),
+		Extensions:           entryExtensions(config),
+		Risk:                 entryRisk(config),
+		Rating:               serverRating(serverID),
+		UnderReview:          underReview(serverID),
 	}
-	
-	json.NewEncoder(w).Encode(result)
+}
+
+// getEntry returns the raw catalog entry for a server ID
+func getEntry(serverID string) (map[string]interface{}, bool) {
+	configInterface, exists := servers[serverID]
+	if !exists {
+		return nil, false
+	}
+	config, ok := configInterface.(map[string]interface{})
+	return config, ok
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": message,
+	})
 }
 
 func getString(m map[string]interface{}, key, defaultValue string) string {
@@ -269,35 +485,229 @@ func getString(m map[string]interface{}, key, defaultValue string) string {
 }
 
 func main() {
+	loaded, args, err := loadConfig(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	cfg = loaded
+	
 	loadServers()
+	if cfg.Synthetic > 0 {
+		addSyntheticServers(cfg.Synthetic)
+	}
+	localServers = servers
+	loadReviews()
+	loadReports()
+	loadVerification()
+	loadSmokeReport()
+	loadAdvisories()
+	loadSnapshots()
+	loadSubmissions()
+	loadGeneratedDescriptions()
+	loadFieldOrigins()
+	loadSyncConflicts()
+	loadChangelogs()
+	loadTryAudit()
+	loadPopularity()
+	loadQuotas()
+	loadOverlays()
+	loadPolicies()
+
+	if runCommand(args) {
+		return
+	}
+	startSearchBackend()
+	startEmbeddings()
+	startEnrichment()
+	startConsistencyChecks()
+	startQuotaFlush()
+	startPopularityFlush()
+	startArtifacts()
+	startGeneratedConfigSweep()
+	startAccessLog()
 	
+	startSnapshots()
+	startFederation()
+	startWeeklyReports()
+	
+	http.HandleFunc("/{$}", uiHandler)
 	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/api/v1/servers", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/api/v1/servers" {
-			listServersHandler(w, r)
-		} else {
-			getServerHandler(w, r)
-		}
-	})
-	http.HandleFunc("/api/v1/servers/", getServerHandler)
+	http.HandleFunc("/readyz", readyHandler)
+	http.HandleFunc("/api/v1/servers", listServersHandler)
+	// Server IDs contain slashes, so one handler routes the detail URL and
+	// every per-server endpoint
+	http.HandleFunc("/api/v1/servers/", serverPathHandler)
 	http.HandleFunc("/api/v1/servers/search", searchServersHandler)
 	http.HandleFunc("/api/v1/servers/generate-config", generateConfigHandler)
+	http.HandleFunc("/api/v1/validate-config", validateConfigHandler)
+	http.HandleFunc("/api/v1/identify-config", identifyConfigHandler)
+	http.HandleFunc("/api/v1/upgrade-plan", upgradePlanHandler)
+	http.HandleFunc("/api/v1/servers/generate-config/bulk", bulkConfigHandler)
+	http.HandleFunc("/api/v1/generated-configs/{id}", generatedConfigHandler)
+	http.HandleFunc("/api/v1/compare", compareHandler)
 	http.HandleFunc("/api/v1/categories", categoriesHandler)
+	http.HandleFunc("/api/v1/tags", tagsHandler)
+	http.HandleFunc("/api/v1/tags/apply", tagsApplyHandler)
+	http.HandleFunc("/api/v1/vendors", vendorsHandler)
+	http.HandleFunc("/api/v1/vendors/{id}", vendorHandler)
+	http.HandleFunc("/api/v1/vendors/{id}/servers", vendorHandler)
+	http.HandleFunc("/api/v1/capabilities/search", capabilitySearchHandler)
+	http.HandleFunc("/api/v1/tools", toolsHandler)
+	http.HandleFunc("/api/v1/llm/context", llmContextHandler)
+	http.HandleFunc("/api/v1/reports/verification", verificationReportHandler)
+	http.HandleFunc("/api/v1/reports/smoke", smokeReportHandler)
+	http.HandleFunc("/api/v1/reports/load-errors", loadErrorsHandler)
+	http.HandleFunc("/api/v1/reports/rules", rulesReportHandler)
+	http.HandleFunc("/api/v1/reports/runtime", runtimeReportHandler)
+	http.HandleFunc("/api/v1/reports/weekly", weeklyReportsHandler)
+	http.HandleFunc("/api/v1/reports/weekly/{week}", weeklyReportHandler)
+	http.HandleFunc("/api/v1/advisories", advisoriesHandler)
+	http.HandleFunc("/api/v1/advisories/feed.atom", advisoryFeedHandler)
+	http.HandleFunc("/api/v1/revision", revisionHandler)
+	http.HandleFunc("/api/v1/snapshots", snapshotsHandler)
+	http.HandleFunc("/api/v1/snapshots/{hash}", snapshotHandler)
+	http.HandleFunc("/api/v1/snapshots/{hash}/activate", activateSnapshotHandler)
+	http.HandleFunc("/api/v1/snapshots/{hash}/promote", promoteSnapshotHandler)
+	http.HandleFunc("/api/v1/snapshots/{hash}/discard", discardSnapshotHandler)
+	http.HandleFunc("/api/v1/wizard/next", wizardNextHandler)
+	http.HandleFunc("/api/v1/preflight", preflightHandler)
+	http.HandleFunc("/api/v1/stats/missed-searches", missedSearchesHandler)
+	http.HandleFunc("/api/v1/debug/slow-queries", slowQueriesHandler)
+	http.HandleFunc("/api/v1/debug/consistency", consistencyHandler)
+	http.HandleFunc("/api/v1/admin/categories/", adminCategoryHandler)
+	http.HandleFunc("/api/v1/admin/reviews", adminReviewsHandler)
+	http.HandleFunc("/api/v1/admin/reviews/{review_id}", adminReviewHandler)
+	http.HandleFunc("/api/v1/admin/reports", adminReportsHandler)
+	http.HandleFunc("/api/v1/admin/reports/{report_id}", adminReportHandler)
+	http.HandleFunc("/api/v1/admin/try-audit", adminTryAuditHandler)
+	http.HandleFunc("/api/v1/admin/submissions", adminSubmissionsHandler)
+	http.HandleFunc("/api/v1/admin/submissions/{submission_id}", adminSubmissionHandler)
+	http.HandleFunc("/api/v1/admin/notifications", adminNotificationsHandler)
+	http.HandleFunc("/api/v1/admin/sync-conflicts", adminSyncConflictsHandler)
+	http.HandleFunc("/api/v1/admin/quotas", adminQuotasHandler)
+	http.HandleFunc("/api/v1/admin/quotas/{user}", adminQuotaHandler)
+	http.HandleFunc("/api/v1/admin/overlays", adminOverlaysHandler)
+	http.HandleFunc("/api/v1/admin/debug/pprof/", profilesHandler)
+	http.HandleFunc("/api/v1/admin/debug/pprof/{profile}", profileHandler)
+	http.HandleFunc("/api/v1/admin/overlays/{tenant}", adminOverlayHandler)
+	http.HandleFunc("/api/v1/admin/policies", adminPoliciesHandler)
+	http.HandleFunc("/api/v1/admin/policies/{tenant}", adminPolicyHandler)
+	http.HandleFunc("/api/v1/me/usage", meUsageHandler)
+	http.HandleFunc("/api/v1/admin/sync-conflicts/{conflict_id}", adminSyncConflictHandler)
+	http.HandleFunc("/api/v1/config", configHandler)
+	http.HandleFunc("/api/v1/features", featuresHandler)
+	http.HandleFunc("/api/v1/schema", schemaHandler)
+	http.HandleFunc("/api/v1/schema/", schemaHandler)
+	http.HandleFunc("/api/v1/export", exportReportHandler)
+	http.HandleFunc("/api/v1/export/bundle", exportBundleHandler)
+	http.HandleFunc("/api/v1/export/catalog", exportCatalogHandler)
+	http.HandleFunc("/api/v1/export/sync", syncHandler)
+	http.HandleFunc("/api/v1/assets/{path...}", assetHandler)
 	
 	fmt.Printf("🚀 Starting Go REST API with %d servers\n", len(servers))
 	fmt.Println("📡 No OpenAPI generation built-in - use traffic capture!")
+	if cfg.ReadOnly {
+		fmt.Println("🔒 Read-only mode: mutating endpoints answer 403")
+	}
 	fmt.Println("")
 	fmt.Println("Available endpoints:")
+	fmt.Println("  GET  /  (browse UI)")
 	fmt.Println("  GET  /health")
 	fmt.Println("  GET  /api/v1/servers")
 	fmt.Println("  GET  /api/v1/servers/{id}")
-	fmt.Println("  GET  /api/v1/servers/search?q=...")
+	fmt.Println("  PATCH /api/v1/servers/{id}")
+	fmt.Println("  GET  /api/v1/servers/{id}/related")
+	fmt.Println("  GET  /api/v1/servers/{id}/graph")
+	fmt.Println("  GET  /api/v1/servers/{id}/reviews")
+	fmt.Println("  POST /api/v1/servers/{id}/reviews")
+	fmt.Println("  GET  /api/v1/servers/{id}/changelog")
+	fmt.Println("  GET  /api/v1/servers/{id}/stats?window=90d")
+	fmt.Println("  POST /api/v1/servers/{id}/try")
+	fmt.Println("  POST /api/v1/servers/{id}/report")
+	fmt.Println("  GET  /api/v1/servers/{id}/examples")
+	fmt.Println("  POST /api/v1/servers/{id}/examples")
+	fmt.Println("  PUT  /api/v1/servers/{id}/examples")
+	fmt.Println("  GET  /api/v1/servers/search?q=...&sort=relevance|trending")
 	fmt.Println("  POST /api/v1/servers/generate-config")
+	fmt.Println("  POST /api/v1/servers/generate-config/bulk")
+	fmt.Println("  GET  /api/v1/generated-configs/{id}")
+	fmt.Println("  POST /api/v1/validate-config")
+	fmt.Println("  POST /api/v1/identify-config")
+	fmt.Println("  POST /api/v1/upgrade-plan")
+	fmt.Println("  GET  /api/v1/compare?ids=a,b,c")
 	fmt.Println("  GET  /api/v1/categories")
+	fmt.Println("  GET  /api/v1/tags")
+	fmt.Println("  POST /api/v1/tags/apply")
+	fmt.Println("  GET  /api/v1/vendors")
+	fmt.Println("  GET  /api/v1/vendors/{id}")
+	fmt.Println("  GET  /api/v1/vendors/{id}/servers")
+	fmt.Println("  GET  /api/v1/capabilities/search")
+	fmt.Println("  GET  /api/v1/tools")
+	fmt.Println("  GET  /api/v1/llm/context")
+	fmt.Println("  GET  /api/v1/reports/verification")
+	fmt.Println("  GET  /api/v1/reports/smoke")
+	fmt.Println("  GET  /api/v1/reports/load-errors")
+	fmt.Println("  GET  /api/v1/reports/rules")
+	fmt.Println("  GET  /api/v1/reports/runtime?mismatched=true")
+	fmt.Println("  GET  /api/v1/reports/weekly")
+	fmt.Println("  GET  /api/v1/reports/weekly/{week|latest|current}")
+	fmt.Println("  GET  /api/v1/advisories")
+	fmt.Println("  POST /api/v1/advisories")
+	fmt.Println("  GET  /api/v1/advisories/feed.atom")
+	fmt.Println("  GET  /api/v1/revision")
+	fmt.Println("  GET  /api/v1/snapshots")
+	fmt.Println("  DELETE /api/v1/snapshots")
+	fmt.Println("  GET  /api/v1/snapshots/{hash}")
+	fmt.Println("  POST /api/v1/snapshots/{hash}/activate")
+	fmt.Println("  POST /api/v1/snapshots/{hash}/promote")
+	fmt.Println("  POST /api/v1/snapshots/{hash}/discard")
+	fmt.Println("  POST /api/v1/wizard/next")
+	fmt.Println("  POST /api/v1/preflight")
+	fmt.Println("  GET  /api/v1/stats/missed-searches")
+	fmt.Println("  GET  /api/v1/debug/slow-queries")
+	fmt.Println("  GET  /api/v1/debug/consistency")
+	fmt.Println("  POST /api/v1/debug/consistency")
+	fmt.Println("  PUT  /api/v1/admin/categories/{name}")
+	fmt.Println("  GET  /api/v1/admin/reviews")
+	fmt.Println("  POST /api/v1/admin/reviews/{review_id}")
+	fmt.Println("  GET  /api/v1/admin/reports")
+	fmt.Println("  POST /api/v1/admin/reports/{report_id}")
+	fmt.Println("  GET  /api/v1/admin/try-audit")
+	fmt.Println("  GET  /api/v1/admin/submissions")
+	fmt.Println("  POST /api/v1/admin/submissions/{submission_id}")
+	fmt.Println("  GET  /api/v1/admin/notifications")
+	fmt.Println("  POST /api/v1/admin/notifications")
+	fmt.Println("  GET  /api/v1/admin/sync-conflicts")
+	fmt.Println("  POST /api/v1/admin/sync-conflicts/{conflict_id}")
+	fmt.Println("  GET  /api/v1/admin/quotas")
+	fmt.Println("  PUT  /api/v1/admin/quotas/{user}")
+	fmt.Println("  GET  /api/v1/admin/overlays")
+	fmt.Println("  PUT  /api/v1/admin/overlays/{tenant}")
+	fmt.Println("  GET  /api/v1/admin/policies")
+	fmt.Println("  PUT  /api/v1/admin/policies/{tenant}")
+	fmt.Println("  GET  /api/v1/admin/debug/pprof/")
+	fmt.Println("  GET  /api/v1/admin/debug/pprof/{profile}?seconds=N&debug=1")
+	fmt.Println("  GET  /api/v1/me/usage")
+	fmt.Println("  GET  /api/v1/config")
+	fmt.Println("  GET  /api/v1/features")
+	fmt.Println("  GET  /api/v1/schema")
+	fmt.Println("  GET  /api/v1/schema/entry/v{N}")
+	fmt.Println("  GET  /api/v1/schema/config/{format}/v{N}")
+	fmt.Println("  GET  /api/v1/export?format=csv|xlsx")
+	fmt.Println("  GET  /api/v1/export/bundle")
+	fmt.Println("  GET  /api/v1/export/catalog")
+	fmt.Println("  GET  /api/v1/export/sync?have=HASH")
+	fmt.Println("  *    /api/v2/...  (every /api/v1 route; errors as {\"error\": {\"status\", \"message\"}})")
 	fmt.Println("")
 	fmt.Println("Capture traffic with:")
 	fmt.Println("  cd ../../generative-openapi && ./quick-capture.sh")
 	fmt.Println("")
 	
-	log.Fatal(http.ListenAndServe(":8000", nil))
+	handler := accessLogMiddleware(corsMiddleware(apiVersionMiddleware(quotaMiddleware(featureMiddleware(readOnlyMiddleware(timeoutMiddleware(cacheMiddleware(http.DefaultServeMux))))))))
+	
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		log.Fatal(http.ListenAndServeTLS(cfg.Addr, cfg.TLSCertFile, cfg.TLSKeyFile, handler))
+	}
+	log.Fatal(http.ListenAndServe(cfg.Addr, handler))
 }
\ No newline at end of file
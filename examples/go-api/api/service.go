@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// ServiceSpec is everything a service definition needs to run the API
+type ServiceSpec struct {
+	Name        string
+	Description string
+	Executable  string
+	Args        []string
+	WorkingDir  string
+	EnvFile     string
+	User        string
+	// Env is the env file's content, for service managers that cannot
+	// read one themselves
+	Env [][2]string
+	// LogDir receives stdout/stderr where the manager does not capture them
+	LogDir string
+}
+
+// serviceFuncs quote values for the formats the templates produce
+var serviceFuncs = template.FuncMap{
+	"shellQuote": shellQuote,
+	"xml":        xmlEscape,
+	// winQuote quotes an argument for a Windows command line
+	"winQuote": func(arg string) string {
+		if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+			return arg
+		}
+		return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+	},
+}
+
+var serviceTemplates = map[string]*template.Template{
+	"systemd": template.Must(template.New("systemd").Funcs(serviceFuncs).Parse(`[Unit]
+Description={{.Description}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart={{shellQuote .Executable}}{{range .Args}} {{shellQuote .}}{{end}}
+WorkingDirectory={{.WorkingDir}}
+{{- if .EnvFile}}
+EnvironmentFile=-{{.EnvFile}}
+{{- end}}
+{{- if .User}}
+User={{.User}}
+{{- end}}
+Restart=on-failure
+RestartSec=5s
+NoNewPrivileges=true
+
+[Install]
+WantedBy=multi-user.target
+`)),
+	// launchd has no env file support, so the env file is sourced by sh
+	"launchd": template.Must(template.New("launchd").Funcs(serviceFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>{{xml .Name}}</string>
+  <key>ProgramArguments</key>
+  <array>
+{{- if .EnvFile}}
+    <string>/bin/sh</string>
+    <string>-c</string>
+    <string>set -a; [ -f {{shellQuote .EnvFile | xml}} ] &amp;&amp; . {{shellQuote .EnvFile | xml}}; set +a; exec {{shellQuote .Executable | xml}}{{range .Args}} {{shellQuote . | xml}}{{end}}</string>
+{{- else}}
+    <string>{{xml .Executable}}</string>
+{{- range .Args}}
+    <string>{{xml .}}</string>
+{{- end}}
+{{- end}}
+  </array>
+  <key>WorkingDirectory</key>
+  <string>{{xml .WorkingDir}}</string>
+{{- if .User}}
+  <key>UserName</key>
+  <string>{{xml .User}}</string>
+{{- end}}
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <dict>
+    <key>SuccessfulExit</key>
+    <false/>
+  </dict>
+  <key>ThrottleInterval</key>
+  <integer>5</integer>
+  <key>StandardOutPath</key>
+  <string>{{xml .LogDir}}/{{xml .Name}}.log</string>
+  <key>StandardErrorPath</key>
+  <string>{{xml .LogDir}}/{{xml .Name}}.log</string>
+</dict>
+</plist>
+`)),
+	// Windows runs the API through the WinSW service wrapper, configured
+	// by an XML file next to the renamed WinSW executable
+	"windows": template.Must(template.New("windows").Funcs(serviceFuncs).Parse(`<service>
+  <id>{{xml .Name}}</id>
+  <name>{{xml .Name}}</name>
+  <description>{{xml .Description}}</description>
+  <executable>{{xml .Executable}}</executable>
+  <arguments>{{range $i, $arg := .Args}}{{if $i}} {{end}}{{winQuote $arg | xml}}{{end}}</arguments>
+  <workingdirectory>{{xml .WorkingDir}}</workingdirectory>
+{{- range .Env}}
+  <env name="{{index . 0 | xml}}" value="{{index . 1 | xml}}"/>
+{{- end}}
+  <onfailure action="restart" delay="5 sec"/>
+  <resetfailure>1 hour</resetfailure>
+  <startmode>Automatic</startmode>
+  <logpath>{{xml .LogDir}}</logpath>
+  <log mode="roll-by-size"/>
+</service>
+`)),
+}
+
+// servicePlatform maps GOOS to the service manager used there
+func servicePlatform(goos string) string {
+	switch goos {
+	case "darwin":
+		return "launchd"
+	case "windows":
+		return "windows"
+	}
+	return "systemd"
+}
+
+// defaultServicePath is where each service manager looks for definitions
+func defaultServicePath(platform, name string) string {
+	switch platform {
+	case "launchd":
+		return filepath.Join("/Library/LaunchDaemons", name+".plist")
+	case "windows":
+		return filepath.Join(`C:\Program Files`, name, name+".xml")
+	}
+	return filepath.Join("/etc/systemd/system", name+".service")
+}
+
+// serviceActivation lists the commands that register and start a service
+func serviceActivation(platform, name, path string) [][]string {
+	switch platform {
+	case "launchd":
+		return [][]string{{"launchctl", "bootstrap", "system", path}}
+	case "windows":
+		winsw := strings.TrimSuffix(path, ".xml") + ".exe"
+		return [][]string{{winsw, "install"}, {winsw, "start"}}
+	}
+	return [][]string{{"systemctl", "daemon-reload"}, {"systemctl", "enable", "--now", name}}
+}
+
+// readEnvFile parses KEY=VALUE lines, skipping blanks and comments
+func readEnvFile(path string) ([][2]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var env [][2]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("%s: invalid line '%s', want KEY=VALUE", path, line)
+		}
+		env = append(env, [2]string{strings.TrimSpace(line[:eq]), strings.Trim(strings.TrimSpace(line[eq+1:]), `"'`)})
+	}
+	return env, scanner.Err()
+}
+
+// serviceCommand writes a service definition for the API and optionally
+// registers it with the platform's service manager:
+//
+//	service install [-platform systemd|launchd|windows] [-name mcp-catalog] [-env-file FILE] [-start] [-- API FLAGS...]
+//	service print   [same flags]
+func serviceCommand(args []string) error {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "print") {
+		return fmt.Errorf("usage: service install|print [flags] [-- API flags]")
+	}
+	action := args[0]
+
+	flags := flag.NewFlagSet("service "+action, flag.ContinueOnError)
+	platform := flags.String("platform", servicePlatform(runtime.GOOS), "service manager: systemd, launchd or windows")
+	name := flags.String("name", "mcp-catalog", "service name")
+	user := flags.String("user", "", "account the service runs as (systemd and launchd)")
+	workDir := flags.String("workdir", "", "working directory (default: current directory)")
+	envFile := flags.String("env-file", "", "file of KEY=VALUE settings such as CATALOG_ADMIN_TOKEN")
+	logDir := flags.String("log-dir", "", "log directory for launchd and windows (default: <workdir>/logs)")
+	output := flags.String("o", "", "where to write the definition (default: the platform's service directory)")
+	start := flags.Bool("start", false, "register and start the service after installing")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if _, ok := serviceTemplates[*platform]; !ok {
+		return fmt.Errorf("unknown platform '%s'; use systemd, launchd or windows", *platform)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	spec := ServiceSpec{
+		Name:        *name,
+		Description: "MCP Server Catalog API",
+		Executable:  executable,
+		Args:        flags.Args(),
+		WorkingDir:  *workDir,
+		User:        *user,
+		LogDir:      *logDir,
+	}
+	if spec.WorkingDir == "" {
+		if spec.WorkingDir, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+	if spec.LogDir == "" {
+		spec.LogDir = filepath.Join(spec.WorkingDir, "logs")
+	}
+	if *envFile != "" {
+		if spec.EnvFile, err = filepath.Abs(*envFile); err != nil {
+			return err
+		}
+		if *platform == "windows" {
+			if spec.Env, err = readEnvFile(spec.EnvFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	var rendered strings.Builder
+	if err := serviceTemplates[*platform].Execute(&rendered, spec); err != nil {
+		return err
+	}
+	if action == "print" {
+		fmt.Print(rendered.String())
+		return nil
+	}
+
+	path := *output
+	if path == "" {
+		path = defaultServicePath(*platform, *name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Embedded env values may be secrets
+	mode := os.FileMode(0644)
+	if len(spec.Env) > 0 {
+		mode = 0600
+	}
+	if err := ioutil.WriteFile(path, []byte(rendered.String()), mode); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s service definition to %s\n", *platform, path)
+	if *platform == "windows" {
+		fmt.Printf("Place the WinSW executable next to it as %s\n", strings.TrimSuffix(path, ".xml")+".exe")
+		if len(spec.Env) > 0 {
+			fmt.Println("Env values are copied into the definition; re-run install after editing the env file")
+		}
+	}
+
+	activation := serviceActivation(*platform, *name, path)
+	if !*start {
+		fmt.Println("Activate it with:")
+		for _, command := range activation {
+			fmt.Println("  " + shellCommand(command[0], command[1:]))
+		}
+		return nil
+	}
+	for _, command := range activation {
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %v", shellCommand(command[0], command[1:]), err)
+		}
+	}
+	fmt.Printf("Started service %s\n", *name)
+	return nil
+}
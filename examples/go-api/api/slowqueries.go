@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSlowQueries is how many of the slowest searches are kept
+	maxSlowQueries = 50
+	// searchTimingWindow is how many recent searches percentiles cover
+	searchTimingWindow = 1000
+)
+
+// SlowQuery is one search kept for being among the slowest
+type SlowQuery struct {
+	Query string `json:"query"`
+	// Filters are the query parameters besides q
+	Filters map[string]string `json:"filters,omitempty"`
+	// Categories are the categories ?category resolved to
+	Categories []string  `json:"categories,omitempty"`
+	Results    int       `json:"results"`
+	DurationMS float64   `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+var searchTiming = struct {
+	sync.Mutex
+	// recent is a ring buffer of the last searchTimingWindow durations
+	recent []time.Duration
+	next   int
+	count  int64
+	// slowest holds at most maxSlowQueries entries, in no particular order
+	slowest []SlowQuery
+}{}
+
+// recordSearchTiming adds a search to the latency window and keeps it if
+// it is among the slowest seen
+func recordSearchTiming(r *http.Request, query string, categories []string, results int, elapsed time.Duration) {
+	filters := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if key != "q" && len(values) > 0 {
+			filters[key] = values[0]
+		}
+	}
+	entry := SlowQuery{
+		Query:      query,
+		Filters:    filters,
+		Categories: categories,
+		Results:    results,
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+		At:         time.Now().UTC(),
+	}
+	if cfg.SlowQueryThreshold > 0 && elapsed >= cfg.SlowQueryThreshold {
+		log.Printf("🐢 Slow search (%s): q=%q filters=%v results=%d", elapsed, query, filters, results)
+	}
+
+	searchTiming.Lock()
+	defer searchTiming.Unlock()
+	if len(searchTiming.recent) < searchTimingWindow {
+		searchTiming.recent = append(searchTiming.recent, elapsed)
+	} else {
+		searchTiming.recent[searchTiming.next] = elapsed
+	}
+	searchTiming.next = (searchTiming.next + 1) % searchTimingWindow
+	searchTiming.count++
+
+	if len(searchTiming.slowest) < maxSlowQueries {
+		searchTiming.slowest = append(searchTiming.slowest, entry)
+		return
+	}
+	fastest := 0
+	for i, kept := range searchTiming.slowest {
+		if kept.DurationMS < searchTiming.slowest[fastest].DurationMS {
+			fastest = i
+		}
+	}
+	if entry.DurationMS > searchTiming.slowest[fastest].DurationMS {
+		searchTiming.slowest[fastest] = entry
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations in ms
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return float64(sorted[rank].Microseconds()) / 1000
+}
+
+// slowQueriesHandler reports search latency percentiles over the recent
+// window and the slowest searches, slowest first. Admin only.
+func slowQueriesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	searchTiming.Lock()
+	sorted := append([]time.Duration(nil), searchTiming.recent...)
+	slowest := append([]SlowQuery(nil), searchTiming.slowest...)
+	count := searchTiming.count
+	searchTiming.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	sort.Slice(slowest, func(i, j int) bool { return slowest[i].DurationMS > slowest[j].DurationMS })
+	if slowest == nil {
+		slowest = []SlowQuery{}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"searches": count,
+		"latency_ms": map[string]interface{}{
+			"window": len(sorted),
+			"p50":    percentile(sorted, 50),
+			"p90":    percentile(sorted, 90),
+			"p95":    percentile(sorted, 95),
+			"p99":    percentile(sorted, 99),
+			"max":    percentile(sorted, 100),
+		},
+		"slow_query_threshold": cfg.SlowQueryThreshold.String(),
+		"slowest":              slowest,
+	})
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// SmokeResult is the outcome of starting a server and listing its tools
+type SmokeResult struct {
+	// Status is pass, fail or skipped
+	Status    string    `json:"status"`
+	StartupMS int64     `json:"startup_ms,omitempty"`
+	ToolCount int       `json:"tool_count,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Sandbox   string    `json:"sandbox"`
+	TestedAt  time.Time `json:"tested_at"`
+}
+
+// SmokeReport is the output of the smoke command
+type SmokeReport struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	Summary     map[string]int         `json:"summary"`
+	Results     map[string]SmokeResult `json:"results"`
+}
+
+// SmokeLimits bound the container a server is smoke-tested in
+type SmokeLimits struct {
+	Memory  string
+	CPUs    string
+	Network string
+}
+
+// sandboxImages run the package managers the generated configs rely on
+var sandboxImages = map[string]string{
+	"npx": "node:22-slim",
+	"uvx": "ghcr.io/astral-sh/uv:python3.12-bookworm-slim",
+}
+
+var (
+	smokeMu     sync.RWMutex
+	smokeReport *SmokeReport
+)
+
+func loadSmokeReport() {
+	var report SmokeReport
+	if err := readJSONFile(dataPath("smoke.json"), &report); err != nil {
+		log.Printf("❌ Cannot load smoke test report: %v", err)
+		return
+	}
+	if report.Results != nil {
+		canonicalKeys(report.Results)
+		smokeMu.Lock()
+		smokeReport = &report
+		smokeMu.Unlock()
+	}
+}
+
+// lastSmokeTest returns the latest smoke test result of a server
+func lastSmokeTest(serverID string) *SmokeResult {
+	smokeMu.RLock()
+	defer smokeMu.RUnlock()
+	if smokeReport == nil {
+		return nil
+	}
+	result, ok := smokeReport.Results[serverID]
+	if !ok {
+		return nil
+	}
+	return &result
+}
+
+// sandboxCommand wraps a server's launch command in a resource-limited
+// container. Docker entries already are containers and only gain limits.
+func sandboxCommand(command string, args []string, env map[string]string, limits SmokeLimits) (string, []string, error) {
+	docker := []string{"run", "-i", "--rm", "--memory", limits.Memory, "--cpus", limits.CPUs,
+		"--pids-limit", "256", "--network", limits.Network}
+	for _, key := range sortedKeys(env) {
+		docker = append(docker, "-e", key+"="+env[key])
+	}
+
+	if command == "docker" {
+		// args are "run -i --rm IMAGE ARGS..."
+		if len(args) < 4 {
+			return "", nil, fmt.Errorf("unexpected docker launch args %v", args)
+		}
+		return "docker", append(docker, args[3:]...), nil
+	}
+	image, ok := sandboxImages[command]
+	if !ok {
+		return "", nil, fmt.Errorf("no sandbox image for '%s'", command)
+	}
+	return "docker", append(append(docker, image, command), args...), nil
+}
+
+// sortedKeys returns the keys of a string map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// smokeTest starts one stdio server, runs initialize and tools/list, and
+// measures how long the server took to answer initialize
+func smokeTest(serverID string, config map[string]interface{}, sandbox string, limits SmokeLimits, timeout time.Duration) SmokeResult {
+	result := SmokeResult{Sandbox: sandbox, TestedAt: time.Now().UTC()}
+	fail := func(err error) SmokeResult {
+		result.Status = "fail"
+		result.Error = err.Error()
+		return result
+	}
+	if !hasTransport(entryTransports(config), "stdio") {
+		result.Status = "skipped"
+		result.Error = "server has no stdio transport"
+		return result
+	}
+
+	launch := mcpServerConfig(serverID, defaultConfigOptions)
+	command := launch["command"].(string)
+	args := launch["args"].([]string)
+	// Required secrets get dummy values; servers must still start and list tools
+	env := make(map[string]string)
+	for _, question := range envQuestions(config) {
+		if question.Required {
+			env[question.Key] = "smoke-test"
+		}
+	}
+
+	if sandbox == "docker" {
+		var err error
+		if command, args, err = sandboxCommand(command, args, env, limits); err != nil {
+			return fail(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, args...)
+	if sandbox == "none" {
+		cmd.Env = os.Environ()
+		for _, key := range sortedKeys(env) {
+			cmd.Env = append(cmd.Env, key+"="+env[key])
+		}
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fail(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fail(err)
+	}
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return fail(err)
+	}
+	defer func() {
+		stdin.Close()
+		cancel()
+		cmd.Wait()
+	}()
+
+	lines := make(chan []byte)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	call := func(id int, method string, params interface{}) (json.RawMessage, error) {
+		if err := writeRPC(stdin, id, method, params); err != nil {
+			return nil, err
+		}
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					return nil, fmt.Errorf("server exited before answering %s", method)
+				}
+				var resp struct {
+					ID     json.RawMessage `json:"id"`
+					Result json.RawMessage `json:"result"`
+					Error  *rpcError       `json:"error"`
+				}
+				if json.Unmarshal(line, &resp) != nil || string(resp.ID) != fmt.Sprint(id) {
+					continue // logs, notifications or server-initiated requests
+				}
+				if resp.Error != nil {
+					return nil, fmt.Errorf("%s failed: %s", method, resp.Error.Message)
+				}
+				return resp.Result, nil
+			case <-ctx.Done():
+				return nil, fmt.Errorf("timed out waiting for %s", method)
+			}
+		}
+	}
+
+	if _, err := call(1, "initialize", map[string]interface{}{
+		"protocolVersion": "2025-06-18",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "mcp-catalog-smoke", "version": "1.0.0"},
+	}); err != nil {
+		return fail(err)
+	}
+	result.StartupMS = time.Since(started).Milliseconds()
+	if err := writeRPC(stdin, 0, "notifications/initialized", nil); err != nil {
+		return fail(err)
+	}
+
+	raw, err := call(2, "tools/list", map[string]interface{}{})
+	if err != nil {
+		return fail(err)
+	}
+	var listed struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(raw, &listed); err != nil {
+		return fail(fmt.Errorf("invalid tools/list result: %v", err))
+	}
+	result.Status = "pass"
+	result.ToolCount = len(listed.Tools)
+	return result
+}
+
+// writeRPC sends one JSON-RPC message; id 0 sends a notification
+func writeRPC(w io.Writer, id int, method string, params interface{}) error {
+	message := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if id != 0 {
+		message["id"] = id
+	}
+	if params != nil {
+		message["params"] = params
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// smokeReportHandler summarizes the last smoke test run
+func smokeReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	smokeMu.RLock()
+	defer smokeMu.RUnlock()
+	if smokeReport == nil {
+		writeError(w, http.StatusNotFound, "No smoke test report; run the smoke command")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	results := make(map[string]SmokeResult)
+	for serverID, result := range smokeReport.Results {
+		if status == "" || result.Status == status {
+			results[serverID] = result
+		}
+	}
+	json.NewEncoder(w).Encode(SmokeReport{
+		GeneratedAt: smokeReport.GeneratedAt,
+		Summary:     smokeReport.Summary,
+		Results:     results,
+	})
+}
+
+// smokeCommand smoke-tests every stdio server, by default inside a
+// resource-limited Docker container, and writes the report the API serves:
+//
+//	smoke [-server ID] [-sandbox docker|none] [-timeout 90s] [-memory 512m] [-cpus 1]
+func smokeCommand(args []string) error {
+	flags := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	only := flags.String("server", "", "test only this server")
+	sandbox := flags.String("sandbox", "docker", "docker, or none to run servers directly")
+	timeout := flags.Duration("timeout", 90*time.Second, "timeout per server, including image pulls")
+	limits := SmokeLimits{}
+	flags.StringVar(&limits.Memory, "memory", "512m", "container memory limit")
+	flags.StringVar(&limits.CPUs, "cpus", "1", "container CPU limit")
+	flags.StringVar(&limits.Network, "network", "bridge", "container network; servers download packages at startup")
+	output := flags.String("o", dataPath("smoke.json"), "report file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *sandbox != "docker" && *sandbox != "none" {
+		return fmt.Errorf("-sandbox must be docker or none")
+	}
+
+	ids := make([]string, 0, len(servers))
+	for serverID := range servers {
+		if *only == "" || serverID == *only {
+			ids = append(ids, serverID)
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("server '%s' not found", *only)
+	}
+	sort.Strings(ids)
+
+	report := SmokeReport{
+		GeneratedAt: time.Now().UTC(),
+		Summary:     make(map[string]int),
+		Results:     make(map[string]SmokeResult),
+	}
+	// A single-server run updates the previous report instead of replacing it
+	if *only != "" {
+		var previous SmokeReport
+		if err := readJSONFile(*output, &previous); err == nil && previous.Results != nil {
+			report.Results = previous.Results
+		}
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "SERVER\tSTATUS\tSTARTUP\tTOOLS\tERROR")
+	for _, serverID := range ids {
+		config, _ := getEntry(serverID)
+		result := smokeTest(serverID, config, *sandbox, limits, *timeout)
+		report.Results[serverID] = result
+		if previous := lastSmokeTest(serverID); result.Status == "fail" && (previous == nil || previous.Status != "fail") {
+			notify(Notification{
+				Event:   "smoke.failed",
+				Title:   fmt.Sprintf("%s failed its smoke test", serverID),
+				Message: result.Error,
+				Servers: []string{serverID},
+				Data:    result,
+			})
+		}
+		fmt.Fprintf(out, "%s\t%s\t%dms\t%d\t%s\n", serverID, result.Status, result.StartupMS, result.ToolCount, result.Error)
+	}
+	out.Flush()
+	for _, result := range report.Results {
+		report.Summary[result.Status]++
+	}
+
+	if err := writeJSONFile(*output, report); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", *output)
+
+	if report.Summary["fail"] > 0 {
+		return fmt.Errorf("%d servers failed their smoke test", report.Summary["fail"])
+	}
+	return nil
+}
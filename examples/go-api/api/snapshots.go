@@ -0,0 +1,547 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSnapshots bounds how many snapshots are kept on disk
+const maxSnapshots = 50
+
+// maxSnapshotHistory bounds how many activations are remembered
+const maxSnapshotHistory = 1000
+
+// Snapshot is an immutable copy of the served catalog, identified by the
+// SHA-256 of its canonical JSON
+type Snapshot struct {
+	Hash        string    `json:"hash"`
+	CreatedAt   time.Time `json:"created_at"`
+	Source      string    `json:"source"`
+	ServerCount int       `json:"server_count"`
+	// Canary is the evaluation of a synced catalog before activation
+	Canary *CanaryReport `json:"canary,omitempty"`
+}
+
+// SnapshotState is which snapshots are served, pinned and held, kept
+// across restarts so a rollback survives one
+type SnapshotState struct {
+	Active string `json:"active,omitempty"`
+	Pinned string `json:"pinned,omitempty"`
+	Held   string `json:"held,omitempty"`
+}
+
+// SnapshotActivation records when a snapshot started being served, which
+// ?at_time reads resolve against
+type SnapshotActivation struct {
+	Hash        string    `json:"hash"`
+	ActivatedAt time.Time `json:"activated_at"`
+}
+
+var (
+	snapshotsMu sync.Mutex
+	// snapshots are ordered oldest first
+	snapshots      []Snapshot
+	activeSnapshot string
+	// pinnedSnapshot stops syncs from replacing a rolled-back catalog
+	pinnedSnapshot string
+	// heldSnapshot is a synced catalog that failed its canary evaluation,
+	// waiting for an admin to promote or discard it
+	heldSnapshot string
+	// snapshotHistory lists activations oldest first
+	snapshotHistory []SnapshotActivation
+)
+
+func snapshotPath(hash string) string {
+	return dataPath("snapshots/" + hash + ".json")
+}
+
+func loadSnapshots() {
+	var stored []Snapshot
+	if err := readJSONFile(dataPath("snapshots/index.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load snapshot index: %v", err)
+		return
+	}
+	var history []SnapshotActivation
+	if err := readJSONFile(dataPath("snapshots/history.json"), &history); err != nil {
+		log.Printf("❌ Cannot load snapshot history: %v", err)
+	}
+	var state SnapshotState
+	if err := readJSONFile(dataPath("snapshots/state.json"), &state); err != nil {
+		log.Printf("❌ Cannot load snapshot state: %v", err)
+	}
+	snapshotsMu.Lock()
+	snapshots = stored
+	snapshotHistory = history
+	activeSnapshot, pinnedSnapshot, heldSnapshot = state.Active, state.Pinned, state.Held
+	snapshotsMu.Unlock()
+}
+
+// saveSnapshotState persists the active, pinned and held snapshots.
+// Callers must hold snapshotsMu.
+func saveSnapshotState() {
+	state := SnapshotState{Active: activeSnapshot, Pinned: pinnedSnapshot, Held: heldSnapshot}
+	if err := writeJSONFile(dataPath("snapshots/state.json"), state); err != nil {
+		log.Printf("❌ Failed to save snapshot state: %v", err)
+	}
+}
+
+// startSnapshots records the loaded catalog as the startup snapshot. When
+// a rollback was pinned before the restart it serves the pinned snapshot
+// instead, so the rolled-back sync does not come back.
+func startSnapshots() {
+	snapshotsMu.Lock()
+	pinned := pinnedSnapshot
+	snapshotsMu.Unlock()
+	if pinned == "" {
+		recordSnapshot("startup", servers)
+		return
+	}
+
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	entries, err := readSnapshotEntries(pinned)
+	if err != nil {
+		log.Printf("❌ Cannot serve pinned snapshot %s, releasing the pin: %v", pinned, err)
+		snapshotsMu.Lock()
+		pinnedSnapshot = ""
+		saveSnapshotState()
+		snapshotsMu.Unlock()
+		recordSnapshot("startup", servers)
+		return
+	}
+	setServers(entries)
+	snapshotsMu.Lock()
+	activeSnapshot = pinned
+	recordActivation(pinned)
+	saveSnapshotState()
+	snapshotsMu.Unlock()
+	log.Printf("📸 Serving pinned catalog snapshot %s; syncs stay paused", pinned[:12])
+}
+
+// recordActivation notes that a snapshot is now served. Callers must hold
+// snapshotsMu.
+func recordActivation(hash string) {
+	if n := len(snapshotHistory); n > 0 && snapshotHistory[n-1].Hash == hash {
+		return
+	}
+	snapshotHistory = append(snapshotHistory, SnapshotActivation{Hash: hash, ActivatedAt: time.Now().UTC()})
+	if len(snapshotHistory) > maxSnapshotHistory {
+		snapshotHistory = snapshotHistory[len(snapshotHistory)-maxSnapshotHistory:]
+	}
+	if err := writeJSONFile(dataPath("snapshots/history.json"), snapshotHistory); err != nil {
+		log.Printf("❌ Failed to save snapshot history: %v", err)
+	}
+}
+
+// snapshotContent is what a snapshot hash covers: the entries without the
+// sync timestamps and staleness markers federation stamps on every sync,
+// so an unchanged upstream, reachable or not, does not produce a new
+// snapshot each interval
+func snapshotContent(entries map[string]interface{}) map[string]interface{} {
+	content := make(map[string]interface{}, len(entries))
+	for serverID, entryInterface := range entries {
+		entry, ok := entryInterface.(map[string]interface{})
+		origin, hasOrigin := entry["origin"].(map[string]interface{})
+		if !ok || !hasOrigin {
+			content[serverID] = entryInterface
+			continue
+		}
+		stripped := make(map[string]interface{}, len(origin))
+		for key, value := range origin {
+			if key != "synced_at" && key != "stale" {
+				stripped[key] = value
+			}
+		}
+		copied := make(map[string]interface{}, len(entry))
+		for key, value := range entry {
+			copied[key] = value
+		}
+		copied["origin"] = stripped
+		content[serverID] = copied
+	}
+	return content
+}
+
+// catalogHash is the SHA-256 of a catalog's snapshot content, which
+// identifies snapshots and checks sync streams
+func catalogHash(entries map[string]interface{}) (string, error) {
+	// encoding/json sorts map keys, so equal catalogs hash equally
+	data, err := json.Marshal(snapshotContent(entries))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordSnapshot stores the catalog as a snapshot unless one with the same
+// content exists, marks it active unless a rollback is pinned, and prunes
+// the oldest snapshots. A held snapshot stays held until it is promoted or
+// discarded.
+func recordSnapshot(source string, entries map[string]interface{}) {
+	hash, err := catalogHash(entries)
+	if err != nil {
+		log.Printf("❌ Cannot snapshot catalog: %v", err)
+		return
+	}
+	defer scheduleArtifacts()
+
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	if pinnedSnapshot == "" {
+		activeSnapshot = hash
+		recordActivation(hash)
+		saveSnapshotState()
+	}
+	storeSnapshot(hash, source, entries, nil)
+}
+
+// holdSnapshot stores a synced catalog that failed its canary evaluation
+// without serving it
+func holdSnapshot(source string, entries map[string]interface{}, report CanaryReport) {
+	hash, err := catalogHash(entries)
+	if err != nil {
+		log.Printf("❌ Cannot snapshot catalog: %v", err)
+		return
+	}
+
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	if heldSnapshot == hash {
+		return
+	}
+	heldSnapshot = hash
+	saveSnapshotState()
+	storeSnapshot(hash, source, entries, &report)
+	failed := strings.Join(report.failed(), ", ")
+	log.Printf("🐤 Held catalog snapshot %s: %s failed; promote it with POST /api/v1/snapshots/%s/promote", hash[:12], failed, hash[:12])
+	notify(Notification{
+		Event:   "canary.held",
+		Title:   "Synced catalog held for approval",
+		Message: fmt.Sprintf("Snapshot %s (%d added, %d changed, %d deleted) failed %s", hash[:12], report.Added, report.Changed, report.Deleted, failed),
+		Data:    report,
+	})
+}
+
+// storeSnapshot writes a snapshot unless one with the same content exists.
+// Callers must hold snapshotsMu.
+func storeSnapshot(hash, source string, entries map[string]interface{}, canary *CanaryReport) {
+	for i, snapshot := range snapshots {
+		if snapshot.Hash == hash {
+			if canary != nil {
+				snapshot.Canary = canary
+			}
+			// Re-recording moves the snapshot to the newest position
+			if i != len(snapshots)-1 || canary != nil {
+				snapshots = append(append(snapshots[:i:i], snapshots[i+1:]...), snapshot)
+				saveSnapshotIndex()
+			}
+			return
+		}
+	}
+
+	if err := writeJSONFile(snapshotPath(hash), map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"servers":        entries,
+	}); err != nil {
+		log.Printf("❌ Cannot write snapshot %s: %v", hash[:12], err)
+		return
+	}
+	snapshots = append(snapshots, Snapshot{Hash: hash, CreatedAt: time.Now().UTC(), Source: source, ServerCount: len(entries), Canary: canary})
+	for len(snapshots) > maxSnapshots {
+		os.Remove(snapshotPath(snapshots[0].Hash))
+		if snapshots[0].Hash == heldSnapshot {
+			heldSnapshot = ""
+			saveSnapshotState()
+		}
+		snapshots = snapshots[1:]
+	}
+	saveSnapshotIndex()
+	log.Printf("📸 Recorded catalog snapshot %s (%s, %d servers)", hash[:12], source, len(entries))
+}
+
+// saveSnapshotIndex persists the snapshot list. Callers must hold snapshotsMu.
+func saveSnapshotIndex() {
+	if err := writeJSONFile(dataPath("snapshots/index.json"), snapshots); err != nil {
+		log.Printf("❌ Failed to save snapshot index: %v", err)
+	}
+}
+
+// findSnapshot resolves a full hash or unique prefix of at least 8 characters
+func findSnapshot(ref string) (Snapshot, error) {
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	if len(ref) < 8 {
+		return Snapshot{}, fmt.Errorf("Snapshot reference '%s' must be at least 8 characters", ref)
+	}
+	var found []Snapshot
+	for _, snapshot := range snapshots {
+		if strings.HasPrefix(snapshot.Hash, ref) {
+			found = append(found, snapshot)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Snapshot{}, fmt.Errorf("Snapshot '%s' not found", ref)
+	case 1:
+		return found[0], nil
+	}
+	return Snapshot{}, fmt.Errorf("Snapshot reference '%s' is ambiguous", ref)
+}
+
+// readSnapshotEntries reads the entries of a stored snapshot, migrated to
+// the current schema version
+func readSnapshotEntries(hash string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := readJSONFile(snapshotPath(hash), &doc); err != nil || doc == nil {
+		return nil, fmt.Errorf("Snapshot '%s' cannot be read", hash)
+	}
+	if _, err := migrateCatalog(doc); err != nil {
+		return nil, fmt.Errorf("Snapshot '%s' cannot be read: %v", hash, err)
+	}
+	return catalogEntries(doc), nil
+}
+
+// restoreLatestSnapshot serves the newest snapshot that is not held, which
+// has the syncs and edits recorded while the catalog was pinned. Callers
+// must hold syncMu.
+func restoreLatestSnapshot() error {
+	snapshotsMu.Lock()
+	latest := ""
+	for i := len(snapshots) - 1; i >= 0 && latest == ""; i-- {
+		if snapshots[i].Hash != heldSnapshot {
+			latest = snapshots[i].Hash
+		}
+	}
+	active := activeSnapshot
+	snapshotsMu.Unlock()
+	if latest == "" || latest == active {
+		return nil
+	}
+
+	entries, err := readSnapshotEntries(latest)
+	if err != nil {
+		return err
+	}
+	setServers(entries)
+	snapshotsMu.Lock()
+	activeSnapshot = latest
+	recordActivation(latest)
+	saveSnapshotState()
+	snapshotsMu.Unlock()
+	log.Printf("📸 Restored the latest catalog snapshot %s", latest[:12])
+	return nil
+}
+
+// syncsPaused reports whether a rollback pinned the served catalog
+func syncsPaused() bool {
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	return pinnedSnapshot != ""
+}
+
+// snapshotsHandler lists snapshots, newest first (GET), or releases a
+// rollback pin, serving the latest snapshot again, so syncs resume
+// (DELETE, admin)
+func snapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		snapshotsMu.Lock()
+		list := make([]Snapshot, 0, len(snapshots))
+		for i := len(snapshots) - 1; i >= 0; i-- {
+			list = append(list, snapshots[i])
+		}
+		response := map[string]interface{}{
+			"snapshots": list,
+			"active":    activeSnapshot,
+			"pinned":    pinnedSnapshot != "",
+			"total":     len(list),
+		}
+		if heldSnapshot != "" {
+			response["held"] = heldSnapshot
+		}
+		snapshotsMu.Unlock()
+		json.NewEncoder(w).Encode(response)
+	case "DELETE":
+		if !requireAdmin(w, r) {
+			return
+		}
+		syncMu.Lock()
+		defer syncMu.Unlock()
+		snapshotsMu.Lock()
+		pinnedSnapshot = ""
+		saveSnapshotState()
+		snapshotsMu.Unlock()
+		if err := restoreLatestSnapshot(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.Printf("📸 Snapshot pin released; syncs resume")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// snapshotHandler serves the catalog stored in a snapshot. Content behind a
+// full hash never changes, so those responses are immutable.
+func snapshotHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ref := r.PathValue("hash")
+	snapshot, err := findSnapshot(ref)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	var doc map[string]interface{}
+	if err := readJSONFile(snapshotPath(snapshot.Hash), &doc); err != nil || doc == nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Snapshot '%s' cannot be read", snapshot.Hash))
+		return
+	}
+	if ref == snapshot.Hash {
+		w.Header().Set("Cache-Control", cacheControl("immutable"))
+	}
+	json.NewEncoder(w).Encode(doc)
+}
+
+// activateSnapshotHandler serves a stored snapshot again, migrated to the
+// current schema. The catalog stays pinned to it, across restarts too,
+// ignoring upstream syncs and refusing admin edits until the pin is
+// released with DELETE /api/v1/snapshots.
+func activateSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	snapshot, err := findSnapshot(r.PathValue("hash"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	entries, err := readSnapshotEntries(snapshot.Hash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	setServers(entries)
+
+	snapshotsMu.Lock()
+	activeSnapshot = snapshot.Hash
+	pinnedSnapshot = snapshot.Hash
+	recordActivation(snapshot.Hash)
+	saveSnapshotState()
+	snapshotsMu.Unlock()
+	log.Printf("📸 Rolled back to catalog snapshot %s", snapshot.Hash[:12])
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active": snapshot,
+		"pinned": true,
+	})
+}
+
+// promoteSnapshotHandler serves the snapshot held by a failed canary
+// evaluation once an admin has reviewed it. Unlike a rollback it does not
+// pin the catalog; the next sync is evaluated as usual. It holds syncMu so
+// a running sync cannot interleave.
+func promoteSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	snapshot, err := findSnapshot(r.PathValue("hash"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	snapshotsMu.Lock()
+	held := heldSnapshot
+	snapshotsMu.Unlock()
+	if snapshot.Hash != held {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Snapshot '%s' is not held for approval", snapshot.Hash))
+		return
+	}
+
+	entries, err := readSnapshotEntries(snapshot.Hash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	setServers(entries)
+
+	snapshotsMu.Lock()
+	activeSnapshot = snapshot.Hash
+	heldSnapshot = ""
+	recordActivation(snapshot.Hash)
+	saveSnapshotState()
+	snapshotsMu.Unlock()
+	scheduleArtifacts()
+	log.Printf("🐤 Promoted held catalog snapshot %s", snapshot.Hash[:12])
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active": snapshot,
+		"pinned": false,
+	})
+}
+
+// discardSnapshotHandler releases the snapshot held by a failed canary
+// evaluation without serving it. The snapshot itself is kept.
+func discardSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	snapshot, err := findSnapshot(r.PathValue("hash"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	snapshotsMu.Lock()
+	if snapshot.Hash != heldSnapshot {
+		snapshotsMu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("Snapshot '%s' is not held for approval", snapshot.Hash))
+		return
+	}
+	heldSnapshot = ""
+	saveSnapshotState()
+	snapshotsMu.Unlock()
+	log.Printf("🐤 Discarded held catalog snapshot %s", snapshot.Hash[:12])
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"discarded": snapshot,
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMissedSearches bounds how many distinct queries are tracked
+const maxMissedSearches = 1000
+
+// MissedSearch aggregates a query that returned no results
+type MissedSearch struct {
+	Query    string    `json:"query"`
+	Category string    `json:"category,omitempty"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+var (
+	missedSearchesMu sync.Mutex
+	missedSearches   = make(map[string]*MissedSearch)
+)
+
+// recordMissedSearch counts a zero-result search. Only the normalized query
+// is kept, never anything identifying the caller.
+func recordMissedSearch(query, category string) {
+	query = foldText(strings.TrimSpace(query))
+	if query == "" {
+		return
+	}
+	key := query + "\x00" + category
+
+	missedSearchesMu.Lock()
+	defer missedSearchesMu.Unlock()
+
+	if missed, ok := missedSearches[key]; ok {
+		missed.Count++
+		missed.LastSeen = time.Now()
+		return
+	}
+
+	if len(missedSearches) >= maxMissedSearches {
+		// Evict the least frequent, oldest entry to make room
+		var evictKey string
+		var evict *MissedSearch
+		for k, missed := range missedSearches {
+			if evict == nil || missed.Count < evict.Count ||
+				(missed.Count == evict.Count && missed.LastSeen.Before(evict.LastSeen)) {
+				evictKey, evict = k, missed
+			}
+		}
+		delete(missedSearches, evictKey)
+	}
+
+	missedSearches[key] = &MissedSearch{
+		Query:    query,
+		Category: category,
+		Count:    1,
+		LastSeen: time.Now(),
+	}
+}
+
+func missedSearchesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			writeError(w, http.StatusBadRequest, "Query parameter 'limit' must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	missedSearchesMu.Lock()
+	results := make([]MissedSearch, 0, len(missedSearches))
+	total := 0
+	for _, missed := range missedSearches {
+		results = append(results, *missed)
+		total += missed.Count
+	}
+	distinct := len(missedSearches)
+	missedSearchesMu.Unlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Query < results[j].Query
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"missed_searches": results,
+		"distinct":        distinct,
+		"total":           total,
+	})
+}
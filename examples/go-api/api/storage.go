@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// dataPath returns the location of a persisted state file in the data dir
+func dataPath(name string) string {
+	return filepath.Join(cfg.DataDir, name)
+}
+
+// readJSONFile decodes a persisted state file; a missing file is not an error
+func readJSONFile(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSONFile atomically replaces a persisted state file. The outcome is
+// the health of the store.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = replaceFile(path, data)
+	recordComponent("store", err)
+	return err
+}
+
+// replaceFile writes a file through a temporary one renamed over it
+func replaceFile(path string, data []byte) error {
+	return replaceFileMode(path, data, 0644)
+}
+
+// replaceFileMode is replaceFile for a file that keeps its own mode
+func replaceFileMode(path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	// WriteFile applies the umask; the replacement gets the mode as is
+	if err := os.Chmod(tmp, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newID returns a random identifier for stored records
+func newID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// streamFlushEvery is how many elements a streamed array writes between
+// flushes. Flushing sends the response chunked as it is encoded instead of
+// holding the whole encoding, which for a large catalog is many megabytes.
+const streamFlushEvery = 256
+
+// arrayStream writes a JSON array one element at a time
+type arrayStream struct {
+	w     http.ResponseWriter
+	enc   *json.Encoder
+	count int
+}
+
+// startArray opens a streamed array
+func startArray(w http.ResponseWriter) *arrayStream {
+	io.WriteString(w, "[")
+	return &arrayStream{w: w, enc: json.NewEncoder(w)}
+}
+
+// add encodes the next element
+func (a *arrayStream) add(v interface{}) {
+	if a.count > 0 {
+		io.WriteString(a.w, ",")
+	}
+	a.enc.Encode(v)
+	a.count++
+	if a.count%streamFlushEvery == 0 {
+		if flusher, ok := a.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+}
+
+// end closes the array
+func (a *arrayStream) end() {
+	io.WriteString(a.w, "]")
+}
+
+// endObject writes the remaining fields of an object whose first field was
+// streamed, then closes it
+func endObject(w http.ResponseWriter, fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(fields[key])
+		if err != nil {
+			continue
+		}
+		io.WriteString(w, ","+string(name)+":")
+		w.Write(value)
+	}
+	io.WriteString(w, "}\n")
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Submission is a proposed catalog entry waiting for a maintainer
+type Submission struct {
+	ID       string                 `json:"id"`
+	ServerID string                 `json:"server_id"`
+	Entry    map[string]interface{} `json:"entry"`
+	// Source says where the submission came from, e.g. an import
+	Source string   `json:"source"`
+	Notes  []string `json:"notes,omitempty"`
+	// Flagged submissions resemble popular entries, as Lookalikes shows,
+	// and need the lookalikes acknowledged to be approved
+	Flagged     bool        `json:"flagged,omitempty"`
+	Lookalikes  []Lookalike `json:"lookalikes,omitempty"`
+	Status      string      `json:"status"`
+	SubmittedAt time.Time   `json:"submitted_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+var (
+	submissionsMu sync.Mutex
+	submissions   = make(map[string]*Submission)
+)
+
+func loadSubmissions() {
+	var stored map[string]*Submission
+	if err := readJSONFile(dataPath("submissions.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load submissions: %v", err)
+		return
+	}
+	if stored != nil {
+		submissionsMu.Lock()
+		submissions = stored
+		submissionsMu.Unlock()
+	}
+}
+
+// saveSubmissions persists the queue. Callers must hold submissionsMu.
+func saveSubmissions() error {
+	return writeJSONFile(dataPath("submissions.json"), submissions)
+}
+
+// queueSubmission files a draft entry for review unless a pending
+// submission already proposes the same server ID. Drafts resembling a
+// popular entry are flagged.
+func queueSubmission(serverID string, entry map[string]interface{}, source string, notes []string) (*Submission, bool) {
+	submissionsMu.Lock()
+	defer submissionsMu.Unlock()
+	for _, existing := range submissions {
+		if existing.ServerID == serverID && existing.Status == "pending" {
+			return existing, false
+		}
+	}
+	now := time.Now().UTC()
+	submission := &Submission{
+		ID:          newID(),
+		ServerID:    serverID,
+		Entry:       entry,
+		Source:      source,
+		Notes:       notes,
+		Status:      "pending",
+		SubmittedAt: now,
+		UpdatedAt:   now,
+	}
+	if lookalikes := findLookalikes(serverID, entry); len(lookalikes) > 0 {
+		submission.Flagged, submission.Lookalikes = true, lookalikes
+		log.Printf("🚩 Flagged submission of '%s': %s", serverID, describeLookalikes(lookalikes))
+	}
+	submissions[submission.ID] = submission
+
+	message := fmt.Sprintf("Submission %s proposes '%s', from %s.", submission.ID, serverID, source)
+	if submission.Flagged {
+		message += "\nIt resembles popular entries: " + describeLookalikes(submission.Lookalikes)
+	}
+	notify(Notification{Event: "submission.created", Title: "New submission: " + serverID, Message: message, Data: submission})
+	return submission, true
+}
+
+// addCatalogEntry writes a new entry into the loaded catalog file and
+// starts serving it
+func addCatalogEntry(serverID string, entry map[string]interface{}, origin FieldOrigin) error {
+	_, err := rewriteCatalogEntry(origin, serverID, func(_ map[string]interface{}, exists bool) (map[string]interface{}, error) {
+		if exists {
+			return nil, fmt.Errorf("server '%s' already exists in %s", serverID, loadedCatalogPath)
+		}
+		return entry, nil
+	})
+	return err
+}
+
+// rewriteCatalogEntry replaces an entry of the loaded catalog file with
+// what change makes of its current version, writes the file back and
+// starts serving the result. Nothing is written when change fails.
+func rewriteCatalogEntry(origin FieldOrigin, serverID string, change func(existing map[string]interface{}, exists bool) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	changed, err := rewriteCatalogEntries(origin, []string{serverID}, func(_ string, existing map[string]interface{}, exists bool) (map[string]interface{}, error) {
+		return change(existing, exists)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed[serverID], nil
+}
+
+// rewriteCatalogEntries is rewriteCatalogEntry for several entries at
+// once: the file is written and the registry swapped a single time, and
+// nothing is written when any change fails. A nil result from change
+// leaves that entry as it is. The fields a change writes are stamped with
+// origin. The whole rewrite holds syncMu, so concurrent edits and syncs
+// cannot lose each other's changes.
+func rewriteCatalogEntries(origin FieldOrigin, serverIDs []string, change func(serverID string, existing map[string]interface{}, exists bool) (map[string]interface{}, error)) (map[string]map[string]interface{}, error) {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	return rewriteCatalogEntriesLocked(origin, serverIDs, change)
+}
+
+// rewriteCatalogEntriesLocked is rewriteCatalogEntries for a caller that
+// holds syncMu
+func rewriteCatalogEntriesLocked(origin FieldOrigin, serverIDs []string, change func(serverID string, existing map[string]interface{}, exists bool) (map[string]interface{}, error)) (map[string]map[string]interface{}, error) {
+	if loadedCatalogPath == "" {
+		return nil, fmt.Errorf("the catalog was not loaded from a file")
+	}
+	// Edits apply to the catalog file, which a pinned rollback no longer
+	// serves
+	snapshotsMu.Lock()
+	pinned := pinnedSnapshot
+	snapshotsMu.Unlock()
+	if pinned != "" {
+		return nil, &requestError{status: http.StatusConflict, message: fmt.Sprintf("The catalog is pinned to snapshot %s; release the pin with DELETE /api/v1/snapshots before editing", pinned[:12])}
+	}
+	data, err := ioutil.ReadFile(loadedCatalogPath)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if _, err := migrateCatalog(doc); err != nil {
+		return nil, err
+	}
+	entries := catalogEntries(doc)
+	changed := make(map[string]map[string]interface{}, len(serverIDs))
+	previous := make(map[string]map[string]interface{}, len(serverIDs))
+	for _, serverID := range serverIDs {
+		existing, exists := entries[serverID].(map[string]interface{})
+		entry, err := change(serverID, existing, exists)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+		if problems := blockingViolations(serverID, entry); len(problems) > 0 {
+			return nil, &requestError{status: http.StatusUnprocessableEntity, message: fmt.Sprintf("Entry '%s' breaks catalog rules: %s", serverID, strings.Join(problems, "; "))}
+		}
+		for _, violation := range entryViolations(serverID, entry) {
+			log.Printf("⚠️  '%s' breaks rule '%s': %s", serverID, violation.Rule, violation.Message)
+		}
+		entries[serverID] = entry
+		changed[serverID] = entry
+		previous[serverID] = existing
+	}
+	if len(changed) == 0 {
+		return changed, nil
+	}
+	doc["servers"] = entries
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(loadedCatalogPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := replaceFileMode(loadedCatalogPath, append(out, '\n'), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]interface{}, len(localServers)+len(changed))
+	for id, config := range localServers {
+		local[id] = config
+	}
+	served := make(map[string]interface{}, len(servers)+len(changed))
+	for id, config := range servers {
+		served[id] = config
+	}
+	for id, entry := range changed {
+		local[id] = entry
+		served[id] = entry
+	}
+	localServers = local
+	setServers(served)
+	recordFieldOrigins(previous, changed, origin)
+	// Edits are snapshotted like syncs so past catalogs stay readable
+	recordSnapshot("edit", served)
+	return changed, nil
+}
+
+// adminSubmissionsHandler lists the submission queue, optionally by ?status
+// and ?flagged
+func adminSubmissionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	flagged, byFlag, err := parseBoolParam(r, "flagged")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	submissionsMu.Lock()
+	result := []Submission{}
+	for _, submission := range submissions {
+		if (status == "" || submission.Status == status) && (!byFlag || submission.Flagged == flagged) {
+			result = append(result, *submission)
+		}
+	}
+	submissionsMu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SubmittedAt.Before(result[j].SubmittedAt)
+	})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"submissions": result,
+		"total":       len(result),
+	})
+}
+
+// adminSubmissionHandler approves or rejects a submission (POST) or drops
+// it (DELETE). Approval may replace the server ID and entry with edited
+// versions; the entry must then pass load validation and is added to the
+// catalog file. An entry resembling a popular one is only approved with
+// "acknowledge_lookalikes".
+func adminSubmissionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	submissionID := r.PathValue("submission_id")
+	submissionsMu.Lock()
+	defer submissionsMu.Unlock()
+
+	submission, exists := submissions[submissionID]
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Submission '%s' not found", submissionID))
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		var req struct {
+			Status                string                 `json:"status"`
+			ServerID              string                 `json:"server_id"`
+			Entry                 map[string]interface{} `json:"entry"`
+			AcknowledgeLookalikes bool                   `json:"acknowledge_lookalikes"`
+		}
+		if err := decodeJSONBody(w, r, &req); err != nil {
+			writeRequestError(w, err)
+			return
+		}
+		if req.Status != "approved" && req.Status != "rejected" {
+			writeError(w, http.StatusBadRequest, "Field 'status' must be approved or rejected")
+			return
+		}
+		if submission.Status != "pending" {
+			writeError(w, http.StatusConflict, fmt.Sprintf("Submission '%s' is already %s", submissionID, submission.Status))
+			return
+		}
+		serverID, entry := submission.ServerID, submission.Entry
+		if req.ServerID != "" {
+			if err := validateServerIDs([]string{req.ServerID}); err != nil {
+				writeRequestError(w, err)
+				return
+			}
+			serverID = req.ServerID
+		}
+		if req.Entry != nil {
+			entry = req.Entry
+		}
+
+		if req.Status == "approved" {
+			if problems := validateEntry(entry); len(problems) > 0 {
+				writeError(w, http.StatusBadRequest, "Entry is invalid: "+strings.Join(problems, "; "))
+				return
+			}
+			if err := checkNewServerID(serverID, entry); err != nil {
+				writeRequestError(w, err)
+				return
+			}
+			// The catalog or the edits may have changed what the entry
+			// resembles since it was queued
+			if lookalikes := findLookalikes(serverID, entry); len(lookalikes) > 0 {
+				submission.Flagged, submission.Lookalikes = true, lookalikes
+				if !req.AcknowledgeLookalikes {
+					writeError(w, http.StatusConflict, "Entry resembles popular entries, approve with 'acknowledge_lookalikes' after review: "+describeLookalikes(lookalikes))
+					return
+				}
+			}
+			if err := addCatalogEntry(serverID, entry, newFieldOrigin("submission:"+submission.Source, requestEditor(r))); err != nil {
+				var reqErr *requestError
+				if errors.As(err, &reqErr) {
+					writeRequestError(w, err)
+					return
+				}
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			log.Printf("📥 Added '%s' to the catalog from submission %s", serverID, submissionID)
+		}
+		submission.ServerID, submission.Entry = serverID, entry
+		submission.Status = req.Status
+		submission.UpdatedAt = time.Now().UTC()
+		if err := saveSubmissions(); err != nil {
+			log.Printf("❌ Failed to save submissions: %v", err)
+		}
+		json.NewEncoder(w).Encode(submission)
+	case "DELETE":
+		delete(submissions, submissionID)
+		if err := saveSubmissions(); err != nil {
+			log.Printf("❌ Failed to save submissions: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSummaryLength bounds heuristic summaries, in bytes
+const maxSummaryLength = 200
+
+// Summarizer condenses a README into a one-line description
+type Summarizer interface {
+	Summarize(ctx context.Context, name, readme string) (string, error)
+}
+
+// GeneratedDescription is a summary the enrichment pipeline produced for an
+// entry. It never replaces the curated description.
+type GeneratedDescription struct {
+	Text        string    `json:"text"`
+	Backend     string    `json:"backend"`
+	Source      string    `json:"source"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+var (
+	generatedMu           sync.RWMutex
+	generatedDescriptions = make(map[string]GeneratedDescription)
+)
+
+func loadGeneratedDescriptions() {
+	var stored map[string]GeneratedDescription
+	if err := readJSONFile(dataPath("summaries.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load generated descriptions: %v", err)
+		return
+	}
+	if stored != nil {
+		generatedMu.Lock()
+		generatedDescriptions = canonicalKeys(stored)
+		generatedMu.Unlock()
+	}
+}
+
+// generatedDescription returns the generated summary of a server, if any
+func generatedDescription(serverID string) string {
+	generatedMu.RLock()
+	defer generatedMu.RUnlock()
+	return generatedDescriptions[serverID].Text
+}
+
+var (
+	markdownImage  = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	markdownLink   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownMarks  = regexp.MustCompile("[*_`]+")
+	sentenceEnding = regexp.MustCompile(`[.!?](\s|$)`)
+)
+
+// heuristicSummarizer takes the leading sentences of the first prose
+// paragraph, skipping headings, badges, code blocks, tables and lists
+type heuristicSummarizer struct{}
+
+func (heuristicSummarizer) Summarize(ctx context.Context, name, readme string) (string, error) {
+	var paragraph []string
+	inCode := false
+	for _, line := range strings.Split(readme, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		prose := trimmed != "" && !strings.ContainsAny(trimmed[:1], "#<|>-*+![") && !strings.HasPrefix(trimmed, "[!")
+		if !prose {
+			if len(paragraph) > 0 && len(strings.Join(paragraph, " ")) >= 40 {
+				break
+			}
+			paragraph = nil
+			continue
+		}
+		paragraph = append(paragraph, trimmed)
+	}
+
+	text := strings.Join(paragraph, " ")
+	text = markdownImage.ReplaceAllString(text, "")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = strings.Join(strings.Fields(markdownMarks.ReplaceAllString(text, "")), " ")
+	if len(text) < 20 {
+		return "", fmt.Errorf("no descriptive paragraph found")
+	}
+
+	// Keep whole sentences while they fit, else cut at a word boundary
+	summary := ""
+	for _, end := range sentenceEnding.FindAllStringIndex(text, -1) {
+		if end[0]+1 > maxSummaryLength {
+			break
+		}
+		summary = text[:end[0]+1]
+	}
+	if summary == "" {
+		summary = text
+		if len(summary) > maxSummaryLength {
+			cut := strings.LastIndex(summary[:maxSummaryLength], " ")
+			if cut < 0 {
+				// No word boundary fits, so cut the word at a rune boundary
+				cut = maxSummaryLength
+				for cut > 0 && !utf8.RuneStart(summary[cut]) {
+					cut--
+				}
+			}
+			summary = summary[:cut] + "…"
+		}
+	}
+	return summary, nil
+}
+
+// llmSummarizer asks an OpenAI-compatible chat completions endpoint
+type llmSummarizer struct {
+	client *http.Client
+	url    string
+	model  string
+	apiKey string
+}
+
+// maxPromptReadme bounds how much README text is sent to the model
+const maxPromptReadme = 12000
+
+func (s llmSummarizer) Summarize(ctx context.Context, name, readme string) (string, error) {
+	if len(readme) > maxPromptReadme {
+		readme = readme[:maxPromptReadme]
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"model": s.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": "You write one-sentence catalog descriptions of MCP servers. Say what the server lets an assistant do, in at most 30 words, without marketing language."},
+			{"role": "user", "content": fmt.Sprintf("Server: %s\n\nREADME:\n%s", name, readme)},
+		},
+	})
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	resp, err := callDependency(s.client, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d from %s", resp.StatusCode, s.url)
+	}
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("empty completion")
+	}
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
+
+// needsSummary reports whether an entry's curated description is missing
+// or too thin to be useful in listings
+func needsSummary(serverID string, config map[string]interface{}) bool {
+	description := strings.TrimSpace(getString(config, "description", ""))
+	return len(description) < 30 ||
+		strings.EqualFold(description, serverID) ||
+		strings.EqualFold(description, getString(config, "name", ""))
+}
+
+// githubRepo returns the owner and name of an entry's GitHub repository
+func githubRepo(config map[string]interface{}) (string, string, bool) {
+	repo, _ := config["repository"].(map[string]interface{})
+	parts := strings.Split(normalizeRepoURL(getString(repo, "url", "")), "/")
+	if len(parts) != 3 || parts[0] != "github.com" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// maxFetchedBytes bounds the body fetchText reads
+const maxFetchedBytes = 4 << 20
+
+// fetchText GETs a URL that must answer 200 with at most maxFetchedBytes
+func fetchText(ctx context.Context, client *http.Client, source string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := callDependency(client, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d from %s", resp.StatusCode, source)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchedBytes+1))
+	if err == nil && len(data) > maxFetchedBytes {
+		return "", fmt.Errorf("%s is larger than %d bytes", source, maxFetchedBytes)
+	}
+	return string(data), err
+}
+
+// fetchReadme returns an entry's README from the README assets, falling
+// back to the default branch of its GitHub repository, and where it came from
+func fetchReadme(ctx context.Context, client *http.Client, assets map[string][]byte, serverID string, config map[string]interface{}) (string, string, error) {
+	if data, ok := assets["readmes/"+serverID+".md"]; ok {
+		return string(data), "assets/readmes/" + serverID + ".md", nil
+	}
+	owner, name, ok := githubRepo(config)
+	if !ok {
+		return "", "", fmt.Errorf("no README asset or GitHub repository")
+	}
+	source := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/HEAD/README.md", owner, name)
+	readme, err := fetchText(ctx, client, source)
+	return readme, source, err
+}
+
+// summarizeCommand generates descriptions from READMEs and stores them in
+// the file the API serves as generated_description:
+//
+//	summarize [-backend heuristic|llm] [-all] [-ids a,b] [-o data/summaries.json]
+//
+// The llm backend posts to -llm-url with the key in CATALOG_LLM_API_KEY.
+func summarizeCommand(args []string) error {
+	flags := flag.NewFlagSet("summarize", flag.ContinueOnError)
+	backend := flags.String("backend", "heuristic", "summarizer: heuristic or llm")
+	all := flags.Bool("all", false, "summarize every entry, not only those with a missing or thin description")
+	ids := flags.String("ids", "", "comma-separated server IDs to summarize")
+	output := flags.String("o", dataPath("summaries.json"), "generated descriptions file")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout per README fetch or model call")
+	llmURL := flags.String("llm-url", "https://api.openai.com/v1/chat/completions", "OpenAI-compatible chat completions endpoint")
+	llmModel := flags.String("llm-model", "gpt-4o-mini", "model the llm backend asks")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	client := outboundClient(*timeout)
+	var summarizer Summarizer
+	switch *backend {
+	case "heuristic":
+		summarizer = heuristicSummarizer{}
+	case "llm":
+		summarizer = llmSummarizer{client: client, url: *llmURL, model: *llmModel, apiKey: os.Getenv("CATALOG_LLM_API_KEY")}
+	default:
+		return fmt.Errorf("unknown summarizer backend '%s'", *backend)
+	}
+
+	var selected []string
+	if *ids != "" {
+		for _, serverID := range strings.Split(*ids, ",") {
+			if _, exists := getEntry(strings.TrimSpace(serverID)); !exists {
+				return fmt.Errorf("server '%s' not found", serverID)
+			}
+			selected = append(selected, strings.TrimSpace(serverID))
+		}
+	} else {
+		for serverID := range servers {
+			config, _ := getEntry(serverID)
+			if *all || needsSummary(serverID, config) {
+				selected = append(selected, serverID)
+			}
+		}
+	}
+	sort.Strings(selected)
+
+	stored := make(map[string]GeneratedDescription)
+	if err := readJSONFile(*output, &stored); err != nil {
+		return err
+	}
+	if stored == nil {
+		stored = make(map[string]GeneratedDescription)
+	}
+
+	assets := assetFiles()
+	failed := 0
+	for _, serverID := range selected {
+		config, _ := getEntry(serverID)
+		ctx := context.Background()
+		readme, source, err := fetchReadme(ctx, client, assets, serverID, config)
+		if err == nil {
+			var text string
+			if text, err = summarizer.Summarize(ctx, getString(config, "name", serverID), readme); err == nil {
+				stored[serverID] = GeneratedDescription{Text: text, Backend: *backend, Source: source, GeneratedAt: time.Now().UTC()}
+				fmt.Printf("%s: %s\n", serverID, text)
+				continue
+			}
+		}
+		failed++
+		fmt.Printf("%s: skipped (%v)\n", serverID, err)
+	}
+
+	if err := writeJSONFile(*output, stored); err != nil {
+		return err
+	}
+	fmt.Printf("Summarized %d of %d servers into %s\n", len(selected)-failed, len(selected), *output)
+	return nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mergePolicies decide a field where a local entry and an upstream copy it
+// shadows disagree: local-wins serves the local value, upstream-wins the
+// upstream one, and manual serves the local value and queues a conflict
+var mergePolicies = []string{"local-wins", "upstream-wins", "manual"}
+
+// unmergedFields are rewritten by federation itself and never conflict
+var unmergedFields = []string{"origin", "legacy_ids"}
+
+// parseMergePolicies parses "FIELD=POLICY" specs; "*" sets the default
+func parseMergePolicies(specs []string) (map[string]string, error) {
+	policies := make(map[string]string)
+	for _, spec := range specs {
+		field, policy, ok := strings.Cut(spec, "=")
+		field, policy = strings.TrimSpace(field), strings.TrimSpace(policy)
+		if !ok || field == "" || !slices.Contains(mergePolicies, policy) {
+			return nil, fmt.Errorf("invalid merge policy '%s', want FIELD=%s", spec, strings.Join(mergePolicies, "|"))
+		}
+		policies[field] = policy
+	}
+	return policies, nil
+}
+
+// entryMergePolicies decodes the optional "merge_policy" block of an
+// entry, field to policy with "*" as the entry's default
+func entryMergePolicies(config map[string]interface{}) map[string]string {
+	raw, _ := config["merge_policy"].(map[string]interface{})
+	policies := make(map[string]string, len(raw))
+	for field, value := range raw {
+		if policy, ok := value.(string); ok {
+			policies[field] = policy
+		}
+	}
+	return policies
+}
+
+// fieldMergePolicy resolves the policy of a field: the entry's own policy
+// for the field, then the configured one, then the entry's and the
+// configured defaults, then local-wins
+func fieldMergePolicy(config map[string]interface{}, field string) string {
+	own := entryMergePolicies(config)
+	configured, _ := parseMergePolicies(cfg.MergePolicies)
+	for _, policies := range []map[string]string{own, configured} {
+		if policy, ok := policies[field]; ok {
+			return policy
+		}
+	}
+	for _, policies := range []map[string]string{own, configured} {
+		if policy, ok := policies["*"]; ok {
+			return policy
+		}
+	}
+	return "local-wins"
+}
+
+// SyncConflict is a field of a local entry that an upstream copy disagrees
+// with under the manual policy
+type SyncConflict struct {
+	ID            string      `json:"id"`
+	ServerID      string      `json:"server_id"`
+	Field         string      `json:"field"`
+	Upstream      string      `json:"upstream"`
+	LocalValue    interface{} `json:"local_value"`
+	UpstreamValue interface{} `json:"upstream_value"`
+	// Status is pending or resolved; Resolution is local or upstream
+	Status     string     `json:"status"`
+	Resolution string     `json:"resolution,omitempty"`
+	DetectedAt time.Time  `json:"detected_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// mergeUpstreamCopy applies the merge policies to a local entry that
+// shadows the upstream copy upstreamID. Upstream-wins fields are copied
+// into the entry and listed in its origin; the disagreements left to a
+// maintainer are returned.
+func mergeUpstreamCopy(serverID string, entry, upstream map[string]interface{}, upstreamID string) []SyncConflict {
+	origin := entry["origin"].(map[string]interface{})
+	taken, _ := origin["upstream_fields"].(map[string]string)
+	var conflicts []SyncConflict
+	for field, value := range upstream {
+		local, present := entry[field]
+		if slices.Contains(unmergedFields, field) || present && reflect.DeepEqual(local, value) {
+			continue
+		}
+		// An earlier upstream already decided the field
+		if _, decided := taken[field]; decided {
+			continue
+		}
+		switch fieldMergePolicy(entry, field) {
+		case "upstream-wins":
+			if taken == nil {
+				taken = make(map[string]string)
+				origin["upstream_fields"] = taken
+			}
+			entry[field] = value
+			taken[field] = upstreamID
+		case "manual":
+			conflicts = append(conflicts, SyncConflict{ServerID: serverID, Field: field, Upstream: upstreamID, LocalValue: local, UpstreamValue: value})
+		}
+	}
+	return conflicts
+}
+
+var (
+	syncConflictsMu sync.Mutex
+	syncConflicts   = make(map[string]*SyncConflict)
+)
+
+func loadSyncConflicts() {
+	var stored map[string]*SyncConflict
+	if err := readJSONFile(dataPath("sync_conflicts.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load sync conflicts: %v", err)
+		return
+	}
+	if stored != nil {
+		syncConflictsMu.Lock()
+		syncConflicts = stored
+		syncConflictsMu.Unlock()
+	}
+}
+
+// saveSyncConflicts persists the queue. Callers must hold syncConflictsMu.
+func saveSyncConflicts() error {
+	return writeJSONFile(dataPath("sync_conflicts.json"), syncConflicts)
+}
+
+// queueSyncConflicts files the conflicts a sync found. A pending conflict
+// on the same field and upstream is refreshed; one resolved in favor of
+// the local value stays resolved until the upstream value changes again.
+func queueSyncConflicts(found []SyncConflict) {
+	if len(found) == 0 {
+		return
+	}
+	syncConflictsMu.Lock()
+	defer syncConflictsMu.Unlock()
+	now := time.Now().UTC()
+	added := 0
+	fields := make(map[string][]string)
+	for _, conflict := range found {
+		var existing *SyncConflict
+		for _, queued := range syncConflicts {
+			if queued.ServerID == conflict.ServerID && queued.Field == conflict.Field && queued.Upstream == conflict.Upstream {
+				existing = queued
+				break
+			}
+		}
+		switch {
+		case existing != nil && existing.Status == "pending":
+			existing.LocalValue, existing.UpstreamValue = conflict.LocalValue, conflict.UpstreamValue
+			continue
+		case existing != nil && reflect.DeepEqual(existing.UpstreamValue, conflict.UpstreamValue):
+			continue
+		case existing != nil:
+			delete(syncConflicts, existing.ID)
+		}
+		conflict.ID = newID()
+		conflict.Status = "pending"
+		conflict.DetectedAt = now
+		syncConflicts[conflict.ID] = &conflict
+		added++
+		fields[conflict.ServerID] = append(fields[conflict.ServerID], conflict.Field+" from "+conflict.Upstream)
+	}
+	if added > 0 {
+		log.Printf("🔀 Queued %d sync conflicts for review", added)
+	}
+	for serverID, disagreements := range fields {
+		notify(Notification{
+			Event:   "sync.conflict",
+			Title:   fmt.Sprintf("Sync conflicts on %s", serverID),
+			Message: "Upstream copies disagree on: " + strings.Join(disagreements, ", "),
+			Servers: []string{serverID},
+		})
+	}
+	if err := saveSyncConflicts(); err != nil {
+		log.Printf("❌ Failed to save sync conflicts: %v", err)
+	}
+}
+
+// adminSyncConflictsHandler lists the sync conflict queue, optionally by
+// ?status
+func adminSyncConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	status := r.URL.Query().Get("status")
+
+	syncConflictsMu.Lock()
+	result := []SyncConflict{}
+	for _, conflict := range syncConflicts {
+		if status == "" || conflict.Status == status {
+			result = append(result, *conflict)
+		}
+	}
+	syncConflictsMu.Unlock()
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DetectedAt.Equal(result[j].DetectedAt) {
+			return result[i].DetectedAt.Before(result[j].DetectedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	json.NewEncoder(w).Encode(result)
+}
+
+// adminSyncConflictHandler resolves a conflict with {"resolution":
+// "local"|"upstream"}. Taking the upstream value writes it into the local
+// catalog, so the field stops conflicting.
+func adminSyncConflictHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req struct {
+		Resolution string `json:"resolution"`
+	}
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if req.Resolution != "local" && req.Resolution != "upstream" {
+		writeError(w, http.StatusBadRequest, "Field 'resolution' must be local or upstream")
+		return
+	}
+
+	conflictID := r.PathValue("conflict_id")
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	syncConflictsMu.Lock()
+	defer syncConflictsMu.Unlock()
+	conflict, exists := syncConflicts[conflictID]
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Conflict '%s' not found", conflictID))
+		return
+	}
+	if conflict.Status != "pending" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Conflict '%s' is already resolved", conflictID))
+		return
+	}
+
+	if req.Resolution == "upstream" {
+		namespace, _, _ := strings.Cut(conflict.Upstream, "/")
+		_, err := rewriteCatalogEntriesLocked(newFieldOrigin("sync:"+namespace, requestEditor(r)), []string{conflict.ServerID}, func(_ string, existing map[string]interface{}, exists bool) (map[string]interface{}, error) {
+			if !exists {
+				return nil, &requestError{status: http.StatusConflict, message: fmt.Sprintf("Server '%s' is not in %s", conflict.ServerID, loadedCatalogPath)}
+			}
+			updated := make(map[string]interface{}, len(existing)+1)
+			for key, value := range existing {
+				updated[key] = value
+			}
+			updated[conflict.Field] = conflict.UpstreamValue
+			return updated, nil
+		})
+		var reqErr *requestError
+		switch {
+		case errors.As(err, &reqErr):
+			writeRequestError(w, err)
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.Printf("🔀 Took %s of '%s' from %s", conflict.Field, conflict.ServerID, conflict.Upstream)
+	}
+	now := time.Now().UTC()
+	conflict.Status = "resolved"
+	conflict.Resolution = req.Resolution
+	conflict.ResolvedAt = &now
+	if err := saveSyncConflicts(); err != nil {
+		log.Printf("❌ Failed to save sync conflicts: %v", err)
+	}
+	json.NewEncoder(w).Encode(conflict)
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The sync wire format lets a mirror fetch only what changed since the
+// catalog it already holds. A stream is the magic and version followed by
+// frames of a type byte, a big-endian uint32 length and the payload:
+//
+//	H  header, uncompressed JSON (syncHeader)
+//	U  upserted entries, a compressed JSON object of ID to entry
+//	R  removed entries, a compressed JSON list of IDs
+//	E  end, uncompressed JSON holding the frame count
+//
+// Applying the frames to the base catalog must yield a catalog whose
+// content hash is the header's target, otherwise the result is discarded.
+const (
+	syncMagic      = "MCPS"
+	syncVersion    = 1
+	syncMediaType  = "application/vnd.mcp-catalog.sync"
+	syncFrameLimit = 64 << 20
+	// syncFrameEntries bounds the entries per upsert frame
+	syncFrameEntries = 200
+)
+
+// syncCodec compresses frame payloads
+type syncCodec struct {
+	compress   func([]byte) ([]byte, error)
+	decompress func([]byte) ([]byte, error)
+}
+
+// syncCodecs are the codecs this instance speaks, in preference order
+var (
+	syncCodecOrder = []string{"zstd", "gzip", "identity"}
+	syncCodecs     = map[string]syncCodec{
+		"zstd": {
+			compress: func(data []byte) ([]byte, error) { return zstdCompress(data), nil },
+			decompress: func(data []byte) ([]byte, error) {
+				return ioutil.ReadAll(io.LimitReader(newZstdReader(bytes.NewReader(data)), syncFrameLimit))
+			},
+		},
+		"gzip": {
+			compress: func(data []byte) ([]byte, error) {
+				var b bytes.Buffer
+				zw := gzip.NewWriter(&b)
+				if _, err := zw.Write(data); err != nil {
+					return nil, err
+				}
+				if err := zw.Close(); err != nil {
+					return nil, err
+				}
+				return b.Bytes(), nil
+			},
+			decompress: func(data []byte) ([]byte, error) {
+				zr, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					return nil, err
+				}
+				defer zr.Close()
+				return ioutil.ReadAll(io.LimitReader(zr, syncFrameLimit))
+			},
+		},
+		"identity": {
+			compress:   func(data []byte) ([]byte, error) { return data, nil },
+			decompress: func(data []byte) ([]byte, error) { return data, nil },
+		},
+	}
+)
+
+// negotiateCodec picks the first codec of the peer's preference list this
+// instance speaks, or identity
+func negotiateCodec(offered string) string {
+	for _, name := range strings.Split(offered, ",") {
+		if _, ok := syncCodecs[strings.TrimSpace(name)]; ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return "identity"
+}
+
+// syncHeader opens a sync stream. Base is empty when the mirror's catalog
+// was unknown and the stream carries the full catalog.
+type syncHeader struct {
+	Codec         string `json:"codec"`
+	SchemaVersion int    `json:"schema_version"`
+	Revision      int64  `json:"revision"`
+	Base          string `json:"base,omitempty"`
+	Target        string `json:"target"`
+	ServerCount   int    `json:"server_count"`
+}
+
+// catalogDelta lists the entries of target that are new or differ from
+// base, and the IDs base has but target does not
+func catalogDelta(base, target map[string]interface{}) (map[string]interface{}, []string) {
+	upserts := make(map[string]interface{})
+	for serverID, entry := range target {
+		old, exists := base[serverID]
+		if !exists {
+			upserts[serverID] = entry
+			continue
+		}
+		a, errA := json.Marshal(old)
+		b, errB := json.Marshal(entry)
+		if errA != nil || errB != nil || !bytes.Equal(a, b) {
+			upserts[serverID] = entry
+		}
+	}
+	var removals []string
+	for serverID := range base {
+		if _, exists := target[serverID]; !exists {
+			removals = append(removals, serverID)
+		}
+	}
+	sort.Strings(removals)
+	return upserts, removals
+}
+
+func writeSyncFrame(w io.Writer, kind byte, payload []byte) error {
+	var head [5]byte
+	head[0] = kind
+	binary.BigEndian.PutUint32(head[1:], uint32(len(payload)))
+	if _, err := w.Write(head[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readSyncFrame(r io.Reader) (byte, []byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(head[1:])
+	if length > syncFrameLimit {
+		return 0, nil, fmt.Errorf("sync frame of %d bytes exceeds the limit", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return head[0], payload, nil
+}
+
+// writeSyncStream encodes the delta from base to target. A nil base sends
+// the full catalog.
+func writeSyncStream(w io.Writer, header syncHeader, base, target map[string]interface{}) error {
+	codec := syncCodecs[header.Codec]
+	if _, err := io.WriteString(w, syncMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{syncVersion}); err != nil {
+		return err
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if err := writeSyncFrame(w, 'H', data); err != nil {
+		return err
+	}
+
+	upserts, removals := catalogDelta(base, target)
+	ids := make([]string, 0, len(upserts))
+	for serverID := range upserts {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+
+	frames := 0
+	writeCompressed := func(kind byte, value interface{}) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if data, err = codec.compress(data); err != nil {
+			return err
+		}
+		frames++
+		return writeSyncFrame(w, kind, data)
+	}
+	for start := 0; start < len(ids); start += syncFrameEntries {
+		chunk := make(map[string]interface{})
+		for _, serverID := range ids[start:min(start+syncFrameEntries, len(ids))] {
+			chunk[serverID] = upserts[serverID]
+		}
+		if err := writeCompressed('U', chunk); err != nil {
+			return err
+		}
+	}
+	if len(removals) > 0 {
+		if err := writeCompressed('R', removals); err != nil {
+			return err
+		}
+	}
+	data, err = json.Marshal(map[string]int{"frames": frames})
+	if err != nil {
+		return err
+	}
+	return writeSyncFrame(w, 'E', data)
+}
+
+var (
+	// errSyncBaseMismatch means a delta was computed against a catalog the
+	// mirror does not hold
+	errSyncBaseMismatch = errors.New("sync delta does not apply to the local base")
+	// errSyncChecksum means the applied frames do not hash to the target
+	errSyncChecksum = errors.New("sync checksum mismatch")
+)
+
+// readSyncStream applies a sync stream to the base catalog the mirror holds
+// under baseHash and verifies the result against the target checksum
+func readSyncStream(r io.Reader, baseHash string, base map[string]interface{}) (syncHeader, map[string]interface{}, error) {
+	var header syncHeader
+	prefix := make([]byte, len(syncMagic)+1)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return header, nil, err
+	}
+	if string(prefix[:len(syncMagic)]) != syncMagic || prefix[len(syncMagic)] != syncVersion {
+		return header, nil, fmt.Errorf("not a version %d sync stream", syncVersion)
+	}
+	kind, payload, err := readSyncFrame(r)
+	if err != nil {
+		return header, nil, err
+	}
+	if kind != 'H' {
+		return header, nil, fmt.Errorf("sync stream starts with frame '%c', want 'H'", kind)
+	}
+	if err := json.Unmarshal(payload, &header); err != nil {
+		return header, nil, err
+	}
+	codec, ok := syncCodecs[header.Codec]
+	if !ok {
+		return header, nil, fmt.Errorf("unsupported sync codec '%s'", header.Codec)
+	}
+
+	result := make(map[string]interface{})
+	if header.Base != "" {
+		if header.Base != baseHash {
+			return header, nil, errSyncBaseMismatch
+		}
+		for serverID, entry := range base {
+			result[serverID] = entry
+		}
+	}
+
+	frames := 0
+	for {
+		kind, payload, err := readSyncFrame(r)
+		if err != nil {
+			return header, nil, fmt.Errorf("truncated sync stream: %v", err)
+		}
+		if kind == 'E' {
+			var end struct {
+				Frames int `json:"frames"`
+			}
+			if err := json.Unmarshal(payload, &end); err != nil {
+				return header, nil, err
+			}
+			if end.Frames != frames {
+				return header, nil, fmt.Errorf("sync stream ended after %d of %d frames", frames, end.Frames)
+			}
+			break
+		}
+		if payload, err = codec.decompress(payload); err != nil {
+			return header, nil, err
+		}
+		frames++
+		switch kind {
+		case 'U':
+			var upserts map[string]interface{}
+			if err := json.Unmarshal(payload, &upserts); err != nil {
+				return header, nil, err
+			}
+			for serverID, entry := range upserts {
+				result[serverID] = entry
+			}
+		case 'R':
+			var removals []string
+			if err := json.Unmarshal(payload, &removals); err != nil {
+				return header, nil, err
+			}
+			for _, serverID := range removals {
+				delete(result, serverID)
+			}
+		default:
+			return header, nil, fmt.Errorf("unknown sync frame '%c'", kind)
+		}
+	}
+
+	hash, err := catalogHash(result)
+	if err != nil {
+		return header, nil, err
+	}
+	if hash != header.Target {
+		return header, nil, fmt.Errorf("%w: got %s, want %s", errSyncChecksum, hash, header.Target)
+	}
+	return header, result, nil
+}
+
+// snapshotEntries returns the catalog stored under an exact snapshot hash
+func snapshotEntries(hash string) (map[string]interface{}, bool) {
+	if hash == "" {
+		return nil, false
+	}
+	snapshot, err := findSnapshot(hash)
+	if err != nil || snapshot.Hash != hash {
+		return nil, false
+	}
+	var doc map[string]interface{}
+	if err := readJSONFile(snapshotPath(hash), &doc); err != nil || doc == nil {
+		return nil, false
+	}
+	return catalogEntries(doc), true
+}
+
+// syncHandler streams the catalog to a mirror in the sync wire format.
+// ?have names the content hash of the catalog the mirror holds; when this
+// instance still has that catalog as a snapshot only the delta is sent,
+// otherwise the full catalog. ?codecs lists the mirror's codecs in
+// preference order. Only local entries are sent unless
+// include_upstream=true, like the JSON export.
+func syncHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	includeUpstream, _, err := parseBoolParam(r, "include_upstream")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries := localCatalog()
+	if includeUpstream {
+		entries = servers
+	}
+	target, err := catalogHash(entries)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	have := r.URL.Query().Get("have")
+	w.Header().Set("ETag", `"`+target+`"`)
+	if have == target {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rev, _ := revisionInfo()
+	header := syncHeader{
+		Codec:         negotiateCodec(r.URL.Query().Get("codecs")),
+		SchemaVersion: currentSchemaVersion,
+		Revision:      rev,
+		Target:        target,
+		ServerCount:   len(entries),
+	}
+	base, ok := snapshotEntries(have)
+	if ok {
+		header.Base = have
+	}
+
+	w.Header().Set("Content-Type", syncMediaType)
+	bw := bufio.NewWriter(w)
+	if err := writeSyncStream(bw, header, base, entries); err != nil {
+		log.Printf("❌ Sync stream failed: %v", err)
+		return
+	}
+	bw.Flush()
+}
+
+// syncBase is the last catalog received from an upstream, the base its
+// next delta applies to
+type syncBase struct {
+	hash          string
+	schemaVersion int
+	entries       map[string]interface{}
+}
+
+// syncBases are kept per upstream namespace, guarded by syncMu
+var syncBases = make(map[string]syncBase)
+
+// errSyncUnsupported means the upstream predates the sync endpoint
+var errSyncUnsupported = errors.New("upstream does not serve the sync endpoint")
+
+// fetchSyncDocument fetches an upstream catalog over the sync wire format,
+// returning a catalog document like the JSON export's
+func fetchSyncDocument(ctx context.Context, client *http.Client, upstream Upstream) (map[string]interface{}, error) {
+	base := syncBases[upstream.Namespace]
+	header, entries, err := requestSync(ctx, client, upstream, base)
+	if base.hash != "" && (errors.Is(err, errSyncBaseMismatch) || errors.Is(err, errSyncChecksum)) {
+		log.Printf("⚠️  Sync delta from upstream %s did not apply (%v); fetching the full catalog", upstream.Namespace, err)
+		base = syncBase{}
+		header, entries, err = requestSync(ctx, client, upstream, base)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		// Not modified
+		header.SchemaVersion, entries = base.schemaVersion, base.entries
+	} else {
+		mode := "full catalog"
+		if header.Base != "" {
+			mode = "delta"
+		}
+		log.Printf("🔗 Upstream %s sent a %s (%s) at revision %d", upstream.Namespace, mode, header.Codec, header.Revision)
+		syncBases[upstream.Namespace] = syncBase{hash: header.Target, schemaVersion: header.SchemaVersion, entries: entries}
+	}
+
+	// Migration and validation modify the document, so they get a copy and
+	// the base stays as received
+	data, err := json.Marshal(map[string]interface{}{"schema_version": header.SchemaVersion, "servers": entries})
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	return doc, json.Unmarshal(data, &doc)
+}
+
+// requestSync asks an upstream for the delta from base. It returns nil
+// entries when the upstream's catalog still matches base.
+func requestSync(ctx context.Context, client *http.Client, upstream Upstream, base syncBase) (syncHeader, map[string]interface{}, error) {
+	query := url.Values{"codecs": {strings.Join(syncCodecOrder, ",")}}
+	if base.hash != "" {
+		query.Set("have", base.hash)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(upstream.Source, "/")+"/api/v1/export/sync?"+query.Encode(), nil)
+	if err != nil {
+		return syncHeader{}, nil, err
+	}
+	req.Header.Set("Accept", syncMediaType)
+	resp, err := callDependency(client, req)
+	if err != nil {
+		return syncHeader{}, nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && base.hash != "":
+		return syncHeader{Target: base.hash}, nil, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return syncHeader{}, nil, errSyncUnsupported
+	case resp.StatusCode != http.StatusOK:
+		return syncHeader{}, nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return readSyncStream(bufio.NewReader(resp.Body), base.hash, base.entries)
+}
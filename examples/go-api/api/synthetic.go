@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"strings"
+)
+
+// Word lists the synthetic generator draws from. They are small on purpose:
+// collisions between names exercise search ranking the way a real catalog does.
+var (
+	syntheticProducts = []string{
+		"postgres", "mysql", "redis", "mongodb", "sqlite", "elasticsearch", "github", "gitlab",
+		"jira", "linear", "notion", "slack", "discord", "gmail", "calendar", "drive",
+		"s3", "gcs", "azure-blob", "kubernetes", "docker", "terraform", "sentry", "datadog",
+		"stripe", "shopify", "salesforce", "hubspot", "figma", "confluence", "airtable", "snowflake",
+	}
+	syntheticQualifiers = []string{
+		"", "lite", "pro", "admin", "readonly", "sync", "bridge", "cloud", "local", "insights",
+	}
+	syntheticCategories = []string{
+		"database", "version-control", "project-management", "communication", "cloud-storage",
+		"devops", "monitoring", "payments", "crm", "design", "documentation", "search",
+	}
+	syntheticVerbs = []string{"list", "get", "create", "update", "delete", "search", "sync", "export"}
+	syntheticNouns = []string{"records", "issues", "files", "messages", "users", "events", "tables", "reports"}
+)
+
+// syntheticEntries fabricates n catalog entries in the current schema.
+// The same seed always yields the same catalog so load tests are repeatable.
+func syntheticEntries(n int, seed int64) map[string]interface{} {
+	rng := rand.New(rand.NewSource(seed))
+	entries := make(map[string]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		product := syntheticProducts[rng.Intn(len(syntheticProducts))]
+		qualifier := syntheticQualifiers[rng.Intn(len(syntheticQualifiers))]
+		name := product
+		if qualifier != "" {
+			name += "-" + qualifier
+		}
+		serverID := namespacedID("synthetic", fmt.Sprintf("%s-%d", name, i))
+		category := syntheticCategories[rng.Intn(len(syntheticCategories))]
+		envVar := strings.ToUpper(strings.ReplaceAll(product, "-", "_")) + "_API_KEY"
+
+		var tools []interface{}
+		for t, count := 0, 1+rng.Intn(6); t < count; t++ {
+			verb := syntheticVerbs[rng.Intn(len(syntheticVerbs))]
+			noun := syntheticNouns[rng.Intn(len(syntheticNouns))]
+			tools = append(tools, map[string]interface{}{
+				"name":        verb + "_" + noun,
+				"description": fmt.Sprintf("%s %s in %s", strings.ToUpper(verb[:1])+verb[1:], noun, product),
+				"inputSchema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{"type": "string"},
+						"limit": map[string]interface{}{"type": "integer"},
+					},
+				},
+			})
+		}
+
+		entries[serverID] = map[string]interface{}{
+			"id":          serverID,
+			"name":        name,
+			"vendor":      "synthetic",
+			"description": fmt.Sprintf("Synthetic %s server for %s workflows (%s)", name, category, product),
+			"category":    category,
+			"categories":  []interface{}{category},
+			"package": map[string]interface{}{
+				"name":     "@synthetic/" + name,
+				"registry": "npm",
+				"version":  fmt.Sprintf("%d.%d.%d", 1+rng.Intn(3), rng.Intn(20), rng.Intn(10)),
+			},
+			"config": map[string]interface{}{
+				"env": map[string]interface{}{
+					envVar: map[string]interface{}{
+						"required":    rng.Intn(3) > 0,
+						"description": fmt.Sprintf("API key for %s", product),
+					},
+				},
+				"args": []interface{}{},
+			},
+			"tools": tools,
+			"repository": map[string]interface{}{
+				"url":    fmt.Sprintf("https://github.com/synthetic/%s", name),
+				"source": "github",
+			},
+		}
+	}
+	return entries
+}
+
+// addSyntheticServers merges generated entries into the loaded catalog
+func addSyntheticServers(n int) {
+	for serverID, entry := range syntheticEntries(n, 1) {
+		servers[serverID] = entry
+	}
+	indexLegacyIDs()
+	buildSearchIndex()
+	log.Printf("🧪 Added %d synthetic servers for load testing", n)
+}
+
+func syntheticCommand(args []string) error {
+	flags := flag.NewFlagSet("synthetic", flag.ContinueOnError)
+	count := flags.Int("n", 1000, "number of entries to generate")
+	seed := flags.Int64("seed", 1, "random seed")
+	output := flags.String("o", "synthetic_servers.json", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"schema_version": currentSchemaVersion,
+		"servers":        syntheticEntries(*count, *seed),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*output, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d synthetic servers to %s\n", *count, *output)
+	return nil
+}
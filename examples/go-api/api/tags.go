@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// tagPattern matches a normalized tag
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// entryTags returns the free-form "tags" of an entry
+func entryTags(config map[string]interface{}) []string {
+	tags, _ := stringList(config["tags"])
+	return tags
+}
+
+// normalizeTags lowercases and deduplicates tags and rejects malformed ones
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, badRequest("Tag '%s' must be 1-32 lowercase letters, digits or dashes", tag)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// parseTagFilter keeps entries carrying every tag of ?tag=vector,rag
+func parseTagFilter(r *http.Request) (entryFilter, error) {
+	raw := r.URL.Query().Get("tag")
+	if raw == "" {
+		return nil, nil
+	}
+	wanted, err := normalizeTags(strings.Split(raw, ","))
+	if err != nil {
+		return nil, fmt.Errorf("Query parameter 'tag' must list tags such as vector,rag")
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		tags := entryTags(config)
+		for _, tag := range wanted {
+			if !slices.Contains(tags, tag) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// TagCount is one row of the tags listing
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// tagsHandler lists every tag in use with its number of entries, most
+// used first
+func tagsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	counts := make(map[string]int)
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		for _, tag := range entryTags(config) {
+			counts[tag]++
+		}
+	}
+	result := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	json.NewEncoder(w).Encode(result)
+}
+
+// tagFilterKeys are the keys a bulk tag filter accepts: explicit IDs, a
+// category, and the list/search filter parameters
+var tagFilterKeys = []string{"ids", "category", "vendor", "tag", "maturity", "include_experimental", "max_risk", "min_rating", "egress_within", "has_provenance"}
+
+// TagApplyRequest is the body of POST /api/v1/tags/apply
+type TagApplyRequest struct {
+	// Filter selects entries by list/search parameters, e.g.
+	// {"category": "database", "vendor": "acme"}
+	Filter map[string]string `json:"filter"`
+	Add    []string          `json:"add"`
+	Remove []string          `json:"remove"`
+	DryRun bool              `json:"dry_run"`
+}
+
+func parseTagApplyRequest(w http.ResponseWriter, r *http.Request) (TagApplyRequest, []entryFilter, error) {
+	var req TagApplyRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		return req, nil, err
+	}
+	if len(req.Filter) == 0 {
+		return req, nil, badRequest("Field 'filter' must select entries; it must not be empty")
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		return req, nil, badRequest("Nothing to do: set 'add' or 'remove'")
+	}
+	var err error
+	if req.Add, err = normalizeTags(req.Add); err != nil {
+		return req, nil, err
+	}
+	if req.Remove, err = normalizeTags(req.Remove); err != nil {
+		return req, nil, err
+	}
+
+	query := url.Values{}
+	for key, value := range req.Filter {
+		if !slices.Contains(tagFilterKeys, key) {
+			return req, nil, badRequest("Unknown filter '%s'; use one of %s", key, strings.Join(tagFilterKeys, ", "))
+		}
+		// An empty value would drop the filter and select every entry
+		if strings.TrimSpace(value) == "" {
+			return req, nil, badRequest("Filter '%s' must not be empty", key)
+		}
+		query.Set(key, value)
+	}
+	// Bulk edits cover experimental entries unless the filter says otherwise
+	if !query.Has("maturity") && !query.Has("include_experimental") {
+		query.Set("include_experimental", "true")
+	}
+	filterRequest := &http.Request{URL: &url.URL{RawQuery: query.Encode()}}
+	filters, err := parseEntryFilters(filterRequest)
+	if err != nil {
+		return req, nil, badRequest("%s", strings.Replace(err.Error(), "Query parameter", "Filter", 1))
+	}
+	return req, filters, nil
+}
+
+// applyTags adds and removes tags on a copy of an entry; nil means the
+// entry already has the requested tags
+func applyTags(entry map[string]interface{}, add, remove []string) map[string]interface{} {
+	tags := entryTags(entry)
+	var updated []string
+	for _, tag := range tags {
+		if !slices.Contains(remove, tag) {
+			updated = append(updated, tag)
+		}
+	}
+	for _, tag := range add {
+		if !slices.Contains(updated, tag) && !slices.Contains(remove, tag) {
+			updated = append(updated, tag)
+		}
+	}
+	if slices.Equal(updated, tags) {
+		return nil
+	}
+
+	copied := make(map[string]interface{}, len(entry)+1)
+	for key, value := range entry {
+		copied[key] = value
+	}
+	delete(copied, "tags")
+	if len(updated) > 0 {
+		list := make([]interface{}, len(updated))
+		for i, tag := range updated {
+			list[i] = tag
+		}
+		copied["tags"] = list
+	}
+	return copied
+}
+
+// tagsApplyHandler adds and removes tags on every local entry matching a
+// filter in one catalog write. Upstream entries are reported, not changed.
+func tagsApplyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	req, filters, err := parseTagApplyRequest(w, r)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	var ids []string
+	if raw := req.Filter["ids"]; raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			ids = append(ids, canonicalID(strings.TrimSpace(id)))
+		}
+	}
+	var categories []string
+	if raw := req.Filter["category"]; raw != "" {
+		categories = strings.Split(raw, ",")
+	}
+	var matched, upstream []string
+	local := localCatalog()
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		if ids != nil && !slices.Contains(ids, serverID) || !inCategories(categories, config) || !matchesFilters(filters, serverID, config) {
+			continue
+		}
+		if _, isLocal := local[serverID]; !isLocal {
+			upstream = append(upstream, serverID)
+			continue
+		}
+		matched = append(matched, serverID)
+	}
+	sort.Strings(matched)
+	sort.Strings(upstream)
+
+	updated := []string{}
+	if req.DryRun {
+		for _, serverID := range matched {
+			if config, _ := getEntry(serverID); applyTags(config, req.Add, req.Remove) != nil {
+				updated = append(updated, serverID)
+			}
+		}
+	} else if len(matched) > 0 {
+		changed, err := rewriteCatalogEntries(newFieldOrigin("tags", requestEditor(r)), matched, func(serverID string, existing map[string]interface{}, exists bool) (map[string]interface{}, error) {
+			if !exists {
+				return nil, nil
+			}
+			return applyTags(existing, req.Add, req.Remove), nil
+		})
+		var reqErr *requestError
+		switch {
+		case errors.As(err, &reqErr):
+			writeRequestError(w, err)
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for serverID := range changed {
+			updated = append(updated, serverID)
+		}
+		sort.Strings(updated)
+		log.Printf("🏷️  Retagged %d entries (+%v -%v)", len(updated), req.Add, req.Remove)
+	}
+
+	response := map[string]interface{}{
+		"matched": len(matched),
+		"updated": updated,
+		"dry_run": req.DryRun,
+	}
+	if len(upstream) > 0 {
+		response["skipped_upstream"] = upstream
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// terraformModuleSource is where the emitted module blocks expect the
+// ECS service module. Terraform does not allow a variable here, so users
+// edit it to point at their own module.
+const terraformModuleSource = "./modules/mcp-ecs-service"
+
+// terraformContainerPort is the port bridged servers listen on
+const terraformContainerPort = 8000
+
+// hclString quotes a value as an HCL string literal, escaping template
+// sequences so values are never interpolated
+func hclString(value string) string {
+	quoted := strconv.Quote(value)
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(quoted)
+}
+
+// hclList renders a list of strings on one line
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = hclString(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// writeHCLAttributes writes "name = value" lines with the equals signs
+// aligned the way terraform fmt aligns them
+func writeHCLAttributes(b *strings.Builder, indent string, attributes [][2]string) {
+	width := 0
+	for _, attribute := range attributes {
+		width = max(width, len(attribute[0]))
+	}
+	for _, attribute := range attributes {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, attribute[0], attribute[1])
+	}
+}
+
+// hclIdentifier turns a server ID or variable key into a Terraform name
+func hclIdentifier(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(value))
+}
+
+// fargateContainer picks the image and command that run a server as a
+// network service. stdio servers run inside the supergateway image, which
+// exposes them over streamable-http; it returns a warning for servers that
+// cannot be served that way.
+func fargateContainer(serverID string, config map[string]interface{}, opts ConfigOptions) (string, []string, string) {
+	transports := entryTransports(config)
+	pkg, hasPackage := entryPackage(config)
+	if !hasPackage && getString(config, "url", "") != "" {
+		return "", nil, fmt.Sprintf("'%s' is hosted at %s; there is nothing to deploy", serverID, getString(config, "url", ""))
+	}
+	if hasPackage && pkg.Registry == "docker" {
+		image := pkg.Name
+		if version := pinnedVersion(pkg, opts.Pin); version != "" {
+			image += ":" + version
+		}
+		if !hasTransport(transports, "streamable-http") && !hasTransport(transports, "sse") {
+			return image, nil, fmt.Sprintf("The '%s' image only speaks stdio; wrap it with supergateway before deploying", serverID)
+		}
+		return image, nil, ""
+	}
+
+	image := "supercorp/supergateway"
+	if pkg.Registry == "pypi" {
+		image += ":uvx"
+	}
+	stdio := mcpServerConfig(serverID, opts)
+	args, _ := stdio["args"].([]string)
+	return image, []string{
+		"--stdio", shellCommand(stdio["command"].(string), args),
+		"--outputTransport", "streamableHttp",
+		"--port", strconv.Itoa(terraformContainerPort),
+	}, ""
+}
+
+// terraformConfig renders one module instantiation per server, with a
+// variable per plain env var and required secrets read from AWS Secrets
+// Manager: one JSON secret per server, named PREFIX/SERVER_ID, holding a
+// key per variable
+func terraformConfig(serverIDs []string, opts ConfigOptions) (string, []string, []string) {
+	prefix := opts.SecretsPrefix
+	if prefix == "" {
+		prefix = secretsBackends["aws-secrets-manager"].defaultPrefix
+	}
+
+	var b strings.Builder
+	b.WriteString(`# Generated by the MCP catalog: one ECS Fargate service per MCP server.
+# Point each module's source at your ECS service module.
+
+variable "ecs_cluster_arn" {
+  type = string
+}
+
+variable "subnet_ids" {
+  type = list(string)
+}
+
+variable "security_group_ids" {
+  type = list(string)
+}
+`)
+
+	var included, warnings []string
+	for _, serverID := range serverIDs {
+		config, exists := getEntry(serverID)
+		if !exists {
+			continue
+		}
+		image, command, warning := fargateContainer(serverID, config, opts)
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		if image == "" {
+			continue
+		}
+		included = append(included, serverID)
+		name := hclIdentifier(serverID)
+
+		var environment [][2]string
+		var secrets []string
+		for _, question := range envQuestions(config) {
+			if question.Secret && question.Required {
+				secrets = append(secrets, question.Key)
+				continue
+			}
+			variable := name + "_" + hclIdentifier(question.Key)
+			attributes := [][2]string{{"type", "string"}}
+			if question.Description != "" {
+				attributes = append(attributes, [2]string{"description", hclString(question.Description)})
+			}
+			if !question.Required {
+				attributes = append(attributes, [2]string{"default", `""`})
+			}
+			if question.Secret {
+				attributes = append(attributes, [2]string{"sensitive", "true"})
+			}
+			fmt.Fprintf(&b, "\nvariable %q {\n", variable)
+			writeHCLAttributes(&b, "  ", attributes)
+			b.WriteString("}\n")
+			environment = append(environment, [2]string{hclString(question.Key), "var." + variable})
+		}
+
+		if len(secrets) > 0 {
+			fmt.Fprintf(&b, "\ndata \"aws_secretsmanager_secret\" %q {\n  name = %s\n}\n", name, hclString(strings.TrimSuffix(prefix, "/")+"/"+serverID))
+		}
+
+		fmt.Fprintf(&b, "\nmodule \"mcp_%s\" {\n", name)
+		fmt.Fprintf(&b, "  source = %s\n\n", hclString(terraformModuleSource))
+		fmt.Fprintf(&b, "  name           = %s\n", hclString("mcp-"+serverID))
+		fmt.Fprintf(&b, "  image          = %s\n", hclString(image))
+		if len(command) > 0 {
+			fmt.Fprintf(&b, "  command        = %s\n", hclList(command))
+		}
+		fmt.Fprintf(&b, "  container_port = %d\n\n", terraformContainerPort)
+		b.WriteString("  cluster_arn        = var.ecs_cluster_arn\n")
+		b.WriteString("  subnet_ids         = var.subnet_ids\n")
+		b.WriteString("  security_group_ids = var.security_group_ids\n")
+		if len(environment) > 0 {
+			// Optional variables left empty are not set in the container
+			b.WriteString("\n  environment = { for key, value in {\n")
+			writeHCLAttributes(&b, "    ", environment)
+			b.WriteString("  } : key => value if value != \"\" }\n")
+		}
+		if len(secrets) > 0 {
+			references := make([][2]string, len(secrets))
+			for i, key := range secrets {
+				references[i] = [2]string{hclString(key), fmt.Sprintf("\"${data.aws_secretsmanager_secret.%s.arn}:%s::\"", name, key)}
+			}
+			b.WriteString("\n  secrets = {\n")
+			writeHCLAttributes(&b, "    ", references)
+			b.WriteString("  }\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String(), included, warnings
+}
+
+// writeTerraformConfig answers generate-config for format=terraform
+func writeTerraformConfig(w http.ResponseWriter, r *http.Request, req GenerateConfigRequest, opts ConfigOptions) {
+	selected, dependencyNotes := withRequiredDependencies(req.Servers)
+	hcl, included, warnings := terraformConfig(selected, opts)
+	if opts.SecretsBackend != defaultConfigOptions.SecretsBackend && opts.SecretsBackend != "aws-secrets-manager" {
+		warnings = append(warnings, "Terraform configs always read secrets from AWS Secrets Manager; secrets_backend was ignored")
+	}
+
+	recordInstall(included)
+
+	pinned := make(map[string]string)
+	for _, serverID := range included {
+		config, _ := getEntry(serverID)
+		if pkg, ok := entryPackage(config); ok {
+			if version := pinnedVersion(pkg, opts.Pin); version != "" {
+				pinned[serverID] = version
+			} else if opts.Pin != "latest" {
+				warnings = append(warnings, fmt.Sprintf("No known-good version for '%s'; it will float to latest", serverID))
+			}
+		}
+	}
+
+	response := map[string]interface{}{
+		"format":             req.Format,
+		"config":             hcl,
+		"servers_included":   included,
+		"pin":                opts.Pin,
+		"pinned_versions":    pinned,
+		"secrets_backend":    "aws-secrets-manager",
+		"firewall_notes":     firewallNotes(included),
+		"risk_summary":       riskSummary(included),
+		"installation_notes": "Save this as mcp_servers.tf in your stack and point each module's source at your ECS service module (it expects name, image, command, container_port, cluster_arn, subnet_ids, security_group_ids, environment and secrets)",
+	}
+	if len(dependencyNotes) > 0 {
+		response["dependency_notes"] = dependencyNotes
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	recordGeneratedConfig(r, req, response)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// latinCompositions lists, per combining mark, the precomposed Latin
+// letters it forms as "COMPOSED BASE" rune pairs. The standard library has
+// no normalization tables, so this covers Latin-1 Supplement, Latin
+// Extended-A/B and Latin Extended Additional, which is where accented
+// catalog text comes from in practice.
+var latinCompositions = map[rune]string{
+	// grave accent
+	'\u0300': "ÀAÈEÌIÒOÙUàaèeìiòoùuǛÜǜüǸNǹnḔĒḕēṐŌṑōẀWẁwẦÂầâẰĂằă" +
+		"ỀÊềêỒÔồôỜƠờơỪƯừưỲYỳy",
+	// acute accent
+	'\u0301': "ÁAÉEÍIÓOÚUÝYáaéeíióoúuýyĆCćcĹLĺlŃNńnŔRŕrŚSśsŹZźz" +
+		"ǗÜǘüǴGǵgǺÅǻåǼÆǽæǾØǿøḈÇḉçḖĒḗēḮÏḯïḰKḱkḾMḿmṌÕṍõṒŌṓō" +
+		"ṔPṕpṸŨṹũẂWẃwẤÂấâẮĂắăẾÊếêỐÔốôỚƠớơỨƯứư",
+	// circumflex accent
+	'\u0302': "ÂAÊEÎIÔOÛUâaêeîiôoûuĈCĉcĜGĝgĤHĥhĴJĵjŜSŝsŴWŵwŶYŷy" +
+		"ẐZẑzẬẠậạỆẸệẹỘỌộọ",
+	// tilde
+	'\u0303': "ÃAÑNÕOãañnõoĨIĩiŨUũuṼVṽvẪÂẫâẴĂẵăẼEẽeỄÊễêỖÔỗôỠƠỡơ" +
+		"ỮƯữưỸYỹy",
+	// macron
+	'\u0304': "ĀAāaĒEēeĪIīiŌOōoŪUūuǕÜǖüǞÄǟäǠȦǡȧǢÆǣæǬǪǭǫȪÖȫöȬÕȭõ" +
+		"ȰȮȱȯȲYȳyḠGḡgḸḶḹḷṜṚṝṛ",
+	// breve
+	'\u0306': "ĂAăaĔEĕeĞGğgĬIĭiŎOŏoŬUŭuḜȨḝȩẶẠặạ",
+	// dot above
+	'\u0307': "ĊCċcĖEėeĠGġgİIŻZżzȦAȧaȮOȯoḂBḃbḊDḋdḞFḟfḢHḣhṀMṁmṄN" +
+		"ṅnṖPṗpṘRṙrṠSṡsṤŚṥśṦŠṧšṨṢṩṣṪTṫtẆWẇwẊXẋxẎYẏyẛſ",
+	// diaeresis
+	'\u0308': "ÄAËEÏIÖOÜUäaëeïiöoüuÿyŸYḦHḧhṎÕṏõṺŪṻūẄWẅwẌXẍxẗt",
+	// hook above
+	'\u0309': "ẢAảaẨÂẩâẲĂẳăẺEẻeỂÊểêỈIỉiỎOỏoỔÔổôỞƠởơỦUủuỬƯửưỶYỷy",
+	// ring above
+	'\u030a': "ÅAåaŮUůuẘwẙy",
+	// double acute accent
+	'\u030b': "ŐOőoŰUűu",
+	// caron
+	'\u030c': "ČCčcĎDďdĚEěeĽLľlŇNňnŘRřrŠSšsŤTťtŽZžzǍAǎaǏIǐiǑOǒo" +
+		"ǓUǔuǙÜǚüǦGǧgǨKǩkǮƷǯʒǰjȞHȟh",
+	// double grave accent
+	'\u030f': "ȀAȁaȄEȅeȈIȉiȌOȍoȐRȑrȔUȕu",
+	// inverted breve
+	'\u0311': "ȂAȃaȆEȇeȊIȋiȎOȏoȒRȓrȖUȗu",
+	// horn
+	'\u031b': "ƠOơoƯUưu",
+	// dot below
+	'\u0323': "ḄBḅbḌDḍdḤHḥhḲKḳkḶLḷlṂMṃmṆNṇnṚRṛrṢSṣsṬTṭtṾVṿvẈWẉw" +
+		"ẒZẓzẠAạaẸEẹeỊIịiỌOọoỢƠợơỤUụuỰƯựưỴYỵy",
+	// diaeresis below
+	'\u0324': "ṲUṳu",
+	// ring below
+	'\u0325': "ḀAḁa",
+	// comma below
+	'\u0326': "ȘSșsȚTțt",
+	// cedilla
+	'\u0327': "ÇCçcĢGģgĶKķkĻLļlŅNņnŖRŗrŞSşsŢTţtȨEȩeḐDḑdḨHḩh",
+	// ogonek
+	'\u0328': "ĄAąaĘEęeĮIįiŲUųuǪOǫo",
+	// circumflex accent below
+	'\u032d': "ḒDḓdḘEḙeḼLḽlṊNṋnṰTṱtṶUṷu",
+	// breve below
+	'\u032e': "ḪHḫh",
+	// tilde below
+	'\u0330': "ḚEḛeḬIḭiṴUṵu",
+	// macron below
+	'\u0331': "ḆBḇbḎDḏdḴKḵkḺLḻlṈNṉnṞRṟrṮTṯtẔZẕzẖh",
+}
+
+// letterTransliterations spell letters that have no decomposition in ASCII
+var letterTransliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d",
+	'ł': "l", 'þ': "th", 'ħ': "h", 'ŧ': "t", 'ŋ': "n",
+}
+
+var (
+	composeTable   = make(map[[2]rune]rune)
+	decomposeTable = make(map[rune][2]rune)
+)
+
+func init() {
+	for mark, pairs := range latinCompositions {
+		runes := []rune(pairs)
+		for i := 0; i+1 < len(runes); i += 2 {
+			composeTable[[2]rune{runes[i+1], mark}] = runes[i]
+			decomposeTable[runes[i]] = [2]rune{runes[i+1], mark}
+		}
+	}
+}
+
+// composeNFC composes base letters followed by combining marks into their
+// precomposed form, so decomposed input matches precomposed catalog text
+func composeNFC(text string) string {
+	out := make([]rune, 0, len(text))
+	for _, r := range text {
+		if n := len(out); n > 0 && unicode.Is(unicode.Mn, r) {
+			if composed, ok := composeTable[[2]rune{out[n-1], r}]; ok {
+				out[n-1] = composed
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// foldText normalizes text for case-insensitive search: NFC composition
+// followed by Unicode simple case folding. Folding through upper case maps
+// Turkish dotless ı and dotted İ to i and final ς to σ, which ToLower alone
+// does not; ß folds to ss.
+func foldText(text string) string {
+	var b strings.Builder
+	for _, r := range composeNFC(text) {
+		if r == 'ß' || r == 'ẞ' {
+			b.WriteString("ss")
+			continue
+		}
+		b.WriteRune(unicode.ToLower(unicode.ToUpper(r)))
+	}
+	return b.String()
+}
+
+// transliterate strips accents from folded text and spells special
+// letters in ASCII, so "café" and "cafe" find each other
+func transliterate(folded string) string {
+	var b strings.Builder
+	var write func(r rune)
+	write = func(r rune) {
+		if parts, ok := decomposeTable[r]; ok {
+			write(parts[0])
+			return
+		}
+		if spelled, ok := letterTransliterations[r]; ok {
+			b.WriteString(spelled)
+			return
+		}
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	for _, r := range folded {
+		write(r)
+	}
+	return b.String()
+}
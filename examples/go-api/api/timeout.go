@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultRouteTimeouts lets long polls, bundle downloads and CPU profiles
+// outlast the global request timeout
+var defaultRouteTimeouts = []string{"/api/v1/revision=75s", "/api/v1/export/=5m", "/api/v1/admin/debug/pprof/=90s"}
+
+// routeTimeout overrides the request timeout for paths under a prefix
+type routeTimeout struct {
+	prefix  string
+	timeout time.Duration
+}
+
+// parseRouteTimeouts parses "PREFIX=DURATION" overrides; 0 disables the
+// timeout for the prefix
+func parseRouteTimeouts(specs []string) ([]routeTimeout, error) {
+	var routes []routeTimeout
+	for _, spec := range specs {
+		eq := strings.Index(spec, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid route timeout '%s', want PREFIX=DURATION", spec)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(spec[eq+1:]))
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid route timeout '%s': duration must be like 30s", spec)
+		}
+		routes = append(routes, routeTimeout{prefix: strings.TrimSpace(spec[:eq]), timeout: timeout})
+	}
+	return routes, nil
+}
+
+// requestTimeoutFor resolves the timeout for a path; the longest matching
+// route override wins over the global request timeout
+func requestTimeoutFor(path string) time.Duration {
+	timeout := cfg.RequestTimeout
+	routes, _ := parseRouteTimeouts(cfg.RouteTimeouts)
+	matched := ""
+	for _, route := range routes {
+		if strings.HasPrefix(path, route.prefix) && len(route.prefix) > len(matched) {
+			matched = route.prefix
+			timeout = route.timeout
+		}
+	}
+	return timeout
+}
+
+// timeoutWriter remembers whether a handler started its response
+type timeoutWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.wroteHeader = true
+	return tw.ResponseWriter.Write(p)
+}
+
+func (tw *timeoutWriter) Flush() {
+	if flusher, ok := tw.ResponseWriter.(http.Flusher); ok {
+		tw.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// timeoutMiddleware gives every request a deadline on its context.
+// Handlers that watch the context answer with writeTimeout themselves; a
+// handler that gives up without responding gets a plain 503.
+func timeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := requestTimeoutFor(r.URL.Path)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r.WithContext(ctx))
+		if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			w.Header().Set("Content-Type", "application/json")
+			writeTimeout(w, timeout, nil)
+		}
+	})
+}
+
+// writeTimeout answers 503 for a request that ran out of time. partial, when
+// set, carries what was computed so far plus hints for a cheaper retry.
+func writeTimeout(w http.ResponseWriter, timeout time.Duration, partial map[string]interface{}) {
+	response := map[string]interface{}{
+		"error":   fmt.Sprintf("Request timed out after %s", timeout),
+		"timeout": timeout.String(),
+	}
+	for key, value := range partial {
+		response[key] = value
+	}
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(response)
+}
+
+// timedOut reports whether a request ran past its deadline
+func timedOut(r *http.Request) bool {
+	return errors.Is(r.Context().Err(), context.DeadlineExceeded)
+}
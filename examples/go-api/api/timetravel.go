@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Listing, fetching and searching servers can read the catalog as it was
+// served in the past: ?at_revision=HASH names a snapshot by hash or a
+// prefix of at least 8 characters, ?at_time=RFC3339 the snapshot served at
+// that moment. Every read reports the snapshot it saw in the
+// X-Catalog-Snapshot header, so clients can record it and read the same
+// catalog again later. Only the entries travel; ratings, verification and
+// other annotations are today's.
+
+// catalogSnapshotHeader names the snapshot a read saw
+const catalogSnapshotHeader = "X-Catalog-Snapshot"
+
+// maxHistoricalCatalogs bounds how many past catalogs are kept decoded
+const maxHistoricalCatalogs = 4
+
+// historicalCatalog is a snapshot decoded and indexed for reads
+type historicalCatalog struct {
+	snapshot Snapshot
+	entries  map[string]interface{}
+	index    []indexedEntry
+}
+
+// historicalCatalogs caches recently read snapshots, most recent last
+var historicalCatalogs = struct {
+	sync.Mutex
+	loaded []*historicalCatalog
+}{}
+
+// snapshotAt returns the snapshot served at t: the last activation at or
+// before t or, for times before the recorded history, the newest snapshot
+// created by then
+func snapshotAt(t time.Time) (Snapshot, error) {
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	hash := ""
+	for i := len(snapshotHistory) - 1; i >= 0; i-- {
+		if !snapshotHistory[i].ActivatedAt.After(t) {
+			hash = snapshotHistory[i].Hash
+			break
+		}
+	}
+	var found *Snapshot
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		switch {
+		case hash != "" && snapshot.Hash == hash:
+			return *snapshot, nil
+		case hash == "" && !snapshot.CreatedAt.After(t) && (found == nil || snapshot.CreatedAt.After(found.CreatedAt)):
+			found = snapshot
+		}
+	}
+	if hash != "" {
+		return Snapshot{}, fmt.Errorf("Snapshot '%s' served at %s has been pruned", hash[:12], t.Format(time.RFC3339))
+	}
+	if found == nil {
+		return Snapshot{}, fmt.Errorf("No snapshot was served at %s", t.Format(time.RFC3339))
+	}
+	return *found, nil
+}
+
+// loadHistoricalCatalog decodes and indexes a snapshot, reusing the copy
+// of a recent read
+func loadHistoricalCatalog(snapshot Snapshot) (*historicalCatalog, error) {
+	historicalCatalogs.Lock()
+	defer historicalCatalogs.Unlock()
+	if i := slices.IndexFunc(historicalCatalogs.loaded, func(c *historicalCatalog) bool { return c.snapshot.Hash == snapshot.Hash }); i >= 0 {
+		past := historicalCatalogs.loaded[i]
+		historicalCatalogs.loaded = append(slices.Delete(historicalCatalogs.loaded, i, i+1), past)
+		return past, nil
+	}
+
+	var doc map[string]interface{}
+	if err := readJSONFile(snapshotPath(snapshot.Hash), &doc); err != nil || doc == nil {
+		return nil, fmt.Errorf("Snapshot '%s' cannot be read", snapshot.Hash)
+	}
+	if _, err := migrateCatalog(doc); err != nil {
+		return nil, fmt.Errorf("Snapshot '%s' cannot be read: %v", snapshot.Hash, err)
+	}
+	entries := catalogEntries(doc)
+	past := &historicalCatalog{snapshot: snapshot, entries: entries, index: indexEntries(entries)}
+	historicalCatalogs.loaded = append(historicalCatalogs.loaded, past)
+	if len(historicalCatalogs.loaded) > maxHistoricalCatalogs {
+		historicalCatalogs.loaded = historicalCatalogs.loaded[1:]
+	}
+	return past, nil
+}
+
+// catalogForRead returns the entries a read sees: the served ones with the
+// tenant's overlays, or with ?at_revision or ?at_time a past catalog, which
+// is then also returned. It sets the X-Catalog-Snapshot header and reports
+// false after writing an error.
+func catalogForRead(w http.ResponseWriter, r *http.Request) (map[string]interface{}, *historicalCatalog, bool) {
+	query := r.URL.Query()
+	ref, at := query.Get("at_revision"), query.Get("at_time")
+	if ref == "" && at == "" {
+		snapshotsMu.Lock()
+		active := activeSnapshot
+		snapshotsMu.Unlock()
+		if active != "" {
+			w.Header().Set(catalogSnapshotHeader, active)
+		}
+		return overlaidCatalog(w, r, servers), nil, true
+	}
+	if ref != "" && at != "" {
+		writeError(w, http.StatusBadRequest, "Query parameters 'at_revision' and 'at_time' cannot be combined")
+		return nil, nil, false
+	}
+
+	var snapshot Snapshot
+	var err error
+	if ref != "" {
+		snapshot, err = findSnapshot(ref)
+	} else {
+		t, parseErr := time.Parse(time.RFC3339, at)
+		if parseErr != nil {
+			writeError(w, http.StatusBadRequest, "Query parameter 'at_time' must be an RFC 3339 time such as 2025-06-01T12:00:00Z")
+			return nil, nil, false
+		}
+		snapshot, err = snapshotAt(t)
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return nil, nil, false
+	}
+	past, err := loadHistoricalCatalog(snapshot)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil, nil, false
+	}
+	w.Header().Set(catalogSnapshotHeader, snapshot.Hash)
+	return past.entries, past, true
+}
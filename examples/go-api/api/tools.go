@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// toolsPageLimit is the default and maxToolsPageLimit the largest page
+// of /api/v1/tools
+const (
+	toolsPageLimit    = 50
+	maxToolsPageLimit = 500
+)
+
+// ToolRecord is one tool of the flattened tool registry
+type ToolRecord struct {
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description,omitempty"`
+	ServerID     string                 `json:"server_id"`
+	ServerName   string                 `json:"server_name"`
+	Category     string                 `json:"category"`
+	InputSchema  map[string]interface{} `json:"input_schema,omitempty"`
+	OutputSchema map[string]interface{} `json:"output_schema,omitempty"`
+}
+
+// toolRelevance ranks a tool for a lowercase query: an exact name, a name
+// containing it, then a description containing every word; 0 is no match
+func toolRelevance(tool Tool, query string) int {
+	name := strings.ToLower(tool.Name)
+	switch {
+	case query == "":
+		return 1
+	case name == query:
+		return 4
+	case strings.Contains(name, query):
+		return 3
+	}
+	description := strings.ToLower(tool.Description)
+	for _, word := range strings.Fields(query) {
+		if !strings.Contains(name, word) && !strings.Contains(description, word) {
+			return 0
+		}
+	}
+	return 2
+}
+
+// toolsHandler serves the tools of every catalogued server as one list,
+// so capabilities can be found before the servers offering them. ?q
+// matches names and descriptions, best matches first; ?name is an exact
+// tool name and ?server one server. The entry filters of the server
+// listing apply to the owning servers. Pages are ?page and ?limit.
+func toolsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	page, limit := 1, toolsPageLimit
+	if raw := query.Get("page"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			writeError(w, http.StatusBadRequest, "Query parameter 'page' must be a positive integer")
+			return
+		}
+		page = parsed
+	}
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxToolsPageLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Query parameter 'limit' must be between 1 and %d", maxToolsPageLimit))
+			return
+		}
+		limit = parsed
+	}
+	filters, err := parseEntryFilters(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries, _, ok := catalogForRead(w, r)
+	if !ok {
+		return
+	}
+	search := strings.ToLower(strings.TrimSpace(query.Get("q")))
+	name := query.Get("name")
+	server := query.Get("server")
+	if server != "" {
+		server = canonicalID(server)
+	}
+
+	type rankedTool struct {
+		record    ToolRecord
+		relevance int
+	}
+	var matches []rankedTool
+	for _, indexed := range capabilityIndex {
+		if server != "" && indexed.serverID != server {
+			continue
+		}
+		if name != "" && indexed.tool.Name != name {
+			continue
+		}
+		config, ok := entries[indexed.serverID].(map[string]interface{})
+		if !ok || !matchesFilters(filters, indexed.serverID, config) {
+			continue
+		}
+		relevance := toolRelevance(indexed.tool, search)
+		if relevance == 0 {
+			continue
+		}
+		matches = append(matches, rankedTool{relevance: relevance, record: ToolRecord{
+			Name:         indexed.tool.Name,
+			Description:  indexed.tool.Description,
+			ServerID:     indexed.serverID,
+			ServerName:   getString(config, "name", indexed.serverID),
+			Category:     getString(config, "category", "other"),
+			InputSchema:  indexed.tool.InputSchema,
+			OutputSchema: indexed.tool.OutputSchema,
+		}})
+	}
+	// The index is sorted by server and tool, so a stable sort keeps that
+	// order among equally relevant tools
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].relevance > matches[j].relevance
+	})
+
+	// A page past the last is empty; the offset is only computed within
+	// range, as a huge ?page would overflow it
+	start := len(matches)
+	if page-1 <= len(matches)/limit {
+		start = min((page-1)*limit, len(matches))
+	}
+	end := min(start+limit, len(matches))
+	tools := []ToolRecord{}
+	for _, match := range matches[start:end] {
+		tools = append(tools, match.record)
+	}
+	pageLink := func(page int) string {
+		values := url.Values{}
+		for key, list := range query {
+			values[key] = list
+		}
+		values.Set("page", strconv.Itoa(page))
+		values.Set("limit", strconv.Itoa(limit))
+		return apiBase(r) + "/tools?" + values.Encode()
+	}
+
+	if wantsJSONAPI(r) {
+		links := map[string]string{"first": pageLink(1)}
+		if page > 1 {
+			links["prev"] = pageLink(min(page-1, max(1, (len(matches)+limit-1)/limit)))
+		}
+		if end < len(matches) {
+			links["next"] = pageLink(page + 1)
+		}
+		writeJSONAPI(w, r, toolResources(tools, apiBase(r)), map[string]interface{}{
+			"total": len(matches),
+			"page":  page,
+			"limit": limit,
+		}, links)
+		return
+	}
+
+	response := map[string]interface{}{
+		"tools": tools,
+		"total": len(matches),
+		"page":  page,
+		"limit": limit,
+	}
+	if end < len(matches) {
+		response["next"] = pageLink(page + 1)
+	}
+	json.NewEncoder(w).Encode(response)
+}
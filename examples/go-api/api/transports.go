@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bridgeBasePort is the first local port handed to stdio-to-SSE bridges
+const bridgeBasePort = 8100
+
+// Bridge is a helper process translating between a server's transport
+// and one the target client supports
+type Bridge struct {
+	Server string `json:"server"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Tool   string `json:"tool"`
+	// Command must be started separately when the bridge runs as a daemon
+	Command []string          `json:"command,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Notes   string            `json:"notes"`
+}
+
+// entryTransports lists the transports a catalog entry supports,
+// defaulting to stdio for entries that declare none
+func entryTransports(config map[string]interface{}) []string {
+	var transports []string
+	if raw, ok := config["transports"].([]interface{}); ok {
+		for _, t := range raw {
+			if str, ok := t.(string); ok {
+				transports = append(transports, str)
+			}
+		}
+	} else if transport := getString(config, "transport", ""); transport != "" {
+		transports = []string{transport}
+	}
+	if len(transports) == 0 {
+		transports = []string{"stdio"}
+	}
+	return transports
+}
+
+func hasTransport(transports []string, transport string) bool {
+	for _, t := range transports {
+		if t == transport {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes an argument for a POSIX shell command line
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func shellCommand(command string, args []string) string {
+	parts := []string{shellQuote(command)}
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// launchConfig connects a server to the client natively when they share a
+// transport, and through a bridge otherwise. bridgeIndex spreads bridge
+// daemons over distinct local ports.
+func launchConfig(serverID string, config map[string]interface{}, opts ConfigOptions, client ClientProfile, bridgeIndex int) (map[string]interface{}, *Bridge, error) {
+	transports := entryTransports(config)
+	url := getString(config, "url", "")
+
+	if hasTransport(transports, "stdio") && client.supportsTransport("stdio") {
+		return packageLaunchConfig(serverID, config, opts), nil, nil
+	}
+	for _, transport := range transports {
+		if transport != "stdio" && url != "" && client.supportsTransport(transport) {
+			return map[string]interface{}{"url": url, "transport": transport}, nil, nil
+		}
+	}
+
+	// Remote server, stdio-only client: proxy the remote endpoint over stdio
+	if url != "" && client.supportsTransport("stdio") {
+		bridge := &Bridge{
+			Server: serverID,
+			From:   transports[0],
+			To:     "stdio",
+			Tool:   "mcp-remote",
+			Notes:  "The client launches mcp-remote, which relays stdio to the remote server",
+		}
+		return map[string]interface{}{
+			"command": "npx",
+			"args":    []string{"-y", "mcp-remote", url},
+		}, bridge, nil
+	}
+
+	// Local stdio server, remote-only client: expose it over SSE locally
+	if hasTransport(transports, "stdio") && client.supportsTransport("sse") {
+		stdio := packageLaunchConfig(serverID, config, opts)
+		args, _ := stdio["args"].([]string)
+		port := bridgeBasePort + bridgeIndex
+		bridge := &Bridge{
+			Server: serverID,
+			From:   "stdio",
+			To:     "sse",
+			Tool:   "supergateway",
+			Command: []string{
+				"npx", "-y", "supergateway",
+				"--stdio", shellCommand(stdio["command"].(string), args),
+				"--port", fmt.Sprint(port),
+			},
+			Notes: "Start this bridge before connecting the client; it must keep running",
+		}
+		return map[string]interface{}{
+			"url":       fmt.Sprintf("http://localhost:%d/sse", port),
+			"transport": "sse",
+		}, bridge, nil
+	}
+
+	return nil, nil, fmt.Errorf("%s cannot reach '%s' over %s", client.Name, serverID, strings.Join(transports, "/"))
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTryResponseBytes bounds what a preview reads from the upstream server
+const maxTryResponseBytes = 1 << 20
+
+// maxTryAudit is how many preview records the audit log keeps
+const maxTryAudit = 1000
+
+// TrySpec is the "try_it" block of an entry whose hosted endpoint accepts
+// anonymous calls. Its presence opts the entry into previews; SampleTool is
+// the only tool a preview may call, always with SampleArguments.
+type TrySpec struct {
+	SampleTool      string                 `json:"sample_tool,omitempty"`
+	SampleArguments map[string]interface{} `json:"sample_arguments,omitempty"`
+}
+
+// entryTrySpec decodes the "try_it" block of a catalog entry
+func entryTrySpec(config map[string]interface{}) (TrySpec, bool) {
+	raw, ok := config["try_it"].(map[string]interface{})
+	if !ok {
+		return TrySpec{}, false
+	}
+	spec := TrySpec{SampleTool: getString(raw, "sample_tool", "")}
+	spec.SampleArguments, _ = raw["sample_arguments"].(map[string]interface{})
+	return spec, true
+}
+
+// tryable reports why an entry cannot be previewed, or "" when it can
+func tryable(config map[string]interface{}) string {
+	if _, ok := entryTrySpec(config); !ok {
+		return "the server does not offer anonymous previews"
+	}
+	if getString(config, "url", "") == "" {
+		return "the server has no hosted endpoint"
+	}
+	if !hasTransport(entryTransports(config), "streamable-http") {
+		return "previews need a streamable-http endpoint"
+	}
+	return ""
+}
+
+// TryAuditRecord is one preview attempt, including refused ones
+type TryAuditRecord struct {
+	ID       string    `json:"id"`
+	At       time.Time `json:"at"`
+	ServerID string    `json:"server_id"`
+	Client   string    `json:"client"`
+	Method   string    `json:"method"`
+	Tool     string    `json:"tool,omitempty"`
+	// Outcome is ok, error, timeout or rate_limited
+	Outcome    string `json:"outcome"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+var (
+	tryMu    sync.Mutex
+	tryAudit []TryAuditRecord
+	// tryTimes holds recent preview times per "client:" and "server:" key
+	tryTimes = make(map[string][]time.Time)
+)
+
+func loadTryAudit() {
+	var stored []TryAuditRecord
+	if err := readJSONFile(dataPath("try_audit.json"), &stored); err != nil {
+		log.Printf("❌ Cannot load the preview audit log: %v", err)
+		return
+	}
+	tryMu.Lock()
+	tryAudit = stored
+	tryMu.Unlock()
+}
+
+// auditTry appends a record to the audit log, dropping the oldest beyond
+// maxTryAudit
+func auditTry(record TryAuditRecord) {
+	record.ID = newID()
+	log.Printf("🔬 Preview %s %s of %s by %s: %s", record.Method, record.Tool, record.ServerID, record.Client, record.Outcome)
+
+	tryMu.Lock()
+	defer tryMu.Unlock()
+	tryAudit = append(tryAudit, record)
+	if len(tryAudit) > maxTryAudit {
+		tryAudit = tryAudit[len(tryAudit)-maxTryAudit:]
+	}
+	if err := writeJSONFile(dataPath("try_audit.json"), tryAudit); err != nil {
+		log.Printf("❌ Failed to save the preview audit log: %v", err)
+	}
+}
+
+// allowTry records a preview attempt and reports whether both the client
+// and the server are still within their hourly limits
+func allowTry(client, serverID string, now time.Time) bool {
+	tryMu.Lock()
+	defer tryMu.Unlock()
+
+	cutoff := now.Add(-time.Hour)
+	limits := map[string]int{"client:" + client: cfg.TryPerClient, "server:" + serverID: cfg.TryPerServer}
+	allowed := true
+	for key, limit := range limits {
+		var recent []time.Time
+		for _, at := range tryTimes[key] {
+			if at.After(cutoff) {
+				recent = append(recent, at)
+			}
+		}
+		tryTimes[key] = recent
+		if len(recent) >= limit {
+			allowed = false
+		}
+	}
+	if allowed {
+		for key := range limits {
+			tryTimes[key] = append(tryTimes[key], now)
+		}
+	}
+	return allowed
+}
+
+// mcpHTTPSession is a minimal streamable-http MCP client
+type mcpHTTPSession struct {
+	client    *http.Client
+	url       string
+	sessionID string
+	nextID    int
+}
+
+// post sends one JSON-RPC message. For requests it returns the matching
+// response, whether the server answers with JSON or an event stream.
+func (s *mcpHTTPSession) post(ctx context.Context, method string, params interface{}, notification bool) (json.RawMessage, error) {
+	message := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	id := ""
+	if !notification {
+		s.nextID++
+		id = strconv.Itoa(s.nextID)
+		message["id"] = s.nextID
+	}
+	body, _ := json.Marshal(message)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if s.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", s.sessionID)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if sessionID := resp.Header.Get("Mcp-Session-Id"); sessionID != "" {
+		s.sessionID = sessionID
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d from the server", resp.StatusCode)
+	}
+	if notification {
+		return nil, nil
+	}
+
+	var response struct {
+		ID     json.RawMessage `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	body = nil
+	reader := io.LimitReader(resp.Body, maxTryResponseBytes)
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// Skip events until the one answering this request
+		var data []string
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), maxTryResponseBytes)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(value, " "))
+				continue
+			}
+			if line != "" || len(data) == 0 {
+				continue
+			}
+			event := []byte(strings.Join(data, "\n"))
+			data = nil
+			if json.Unmarshal(event, &response) == nil && string(response.ID) == id {
+				body = event
+				break
+			}
+		}
+		if body == nil {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("the event stream ended without a response")
+		}
+	} else {
+		if body, err = ioutil.ReadAll(reader); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("invalid JSON-RPC response: %v", err)
+		}
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s (code %d)", response.Error.Message, response.Error.Code)
+	}
+	return response.Result, nil
+}
+
+// close ends the session on the server, if it issued one
+func (s *mcpHTTPSession) close() {
+	if s.sessionID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "DELETE", s.url, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Mcp-Session-Id", s.sessionID)
+	if resp, err := s.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// tryServer opens a session on a hosted server and relays one call
+func tryServer(ctx context.Context, endpoint, method string, params interface{}) (json.RawMessage, error) {
+	// The preview's context bounds the calls, so the client sets no timeout
+	session := &mcpHTTPSession{client: outboundClient(0), url: endpoint}
+	defer session.close()
+	_, err := session.post(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]string{"name": "mcp-catalog-preview", "version": "1.0.0"},
+	}, false)
+	if err != nil {
+		return nil, fmt.Errorf("initialize: %v", err)
+	}
+	if _, err := session.post(ctx, "notifications/initialized", nil, true); err != nil {
+		return nil, fmt.Errorf("initialized: %v", err)
+	}
+	return session.post(ctx, method, params, false)
+}
+
+// TryRequest is the body of POST /api/v1/servers/{id}/try
+type TryRequest struct {
+	// Method is tools/list or tools/call; tools/call runs the entry's
+	// sample tool with its sample arguments
+	Method string `json:"method"`
+}
+
+// tryServerHandler relays a preview call to a hosted server for the web
+// UI's "Try it" panel. Only tools/list and the entry's sample tool are
+// relayed, each preview is bounded by the preview timeout, and every
+// attempt is rate limited and audited.
+func tryServerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serverID := r.PathValue("id")
+	config, exists := getEntry(serverID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Server '%s' not found", serverID))
+		return
+	}
+	if reason := tryable(config); reason != "" {
+		writeError(w, http.StatusUnprocessableEntity, "Cannot preview this server: "+reason)
+		return
+	}
+
+	var req TryRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	spec, _ := entryTrySpec(config)
+	var params interface{}
+	switch req.Method {
+	case "tools/list":
+	case "tools/call":
+		if spec.SampleTool == "" {
+			writeError(w, http.StatusUnprocessableEntity, "The server declares no sample tool to preview")
+			return
+		}
+		arguments := spec.SampleArguments
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		params = map[string]interface{}{"name": spec.SampleTool, "arguments": arguments}
+	default:
+		writeError(w, http.StatusBadRequest, "Field 'method' must be tools/list or tools/call")
+		return
+	}
+
+	record := TryAuditRecord{At: time.Now().UTC(), ServerID: serverID, Client: reporterID(r), Method: req.Method}
+	if req.Method == "tools/call" {
+		record.Tool = spec.SampleTool
+	}
+	if !allowTry(record.Client, serverID, record.At) {
+		record.Outcome = "rate_limited"
+		auditTry(record)
+		w.Header().Set("Retry-After", "3600")
+		writeError(w, http.StatusTooManyRequests, "Too many previews, try again later")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), cfg.TryTimeout)
+	defer cancel()
+	result, err := tryServer(ctx, getString(config, "url", ""), req.Method, params)
+	record.DurationMS = time.Since(record.At).Milliseconds()
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		record.Outcome, record.Error = "timeout", err.Error()
+		auditTry(record)
+		writeError(w, http.StatusGatewayTimeout, fmt.Sprintf("The server did not answer within %s", cfg.TryTimeout))
+		return
+	case err != nil:
+		record.Outcome, record.Error = "error", err.Error()
+		auditTry(record)
+		writeError(w, http.StatusBadGateway, "The server failed the preview: "+err.Error())
+		return
+	}
+	record.Outcome = "ok"
+	auditTry(record)
+
+	response := map[string]interface{}{
+		"server_id":   serverID,
+		"method":      req.Method,
+		"result":      result,
+		"duration_ms": record.DurationMS,
+	}
+	if record.Tool != "" {
+		response["tool"] = record.Tool
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// adminTryAuditHandler lists preview attempts, newest first, optionally
+// for one server. Admin only.
+func adminTryAuditHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !requireAdmin(w, r) {
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serverID := r.URL.Query().Get("server")
+	tryMu.Lock()
+	records := []TryAuditRecord{}
+	for i := len(tryAudit) - 1; i >= 0; i-- {
+		if serverID == "" || tryAudit[i].ServerID == serverID {
+			records = append(records, tryAudit[i])
+		}
+	}
+	tryMu.Unlock()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"records": records,
+		"total":   len(records),
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Submissions whose server ID, package name or repository resembles a
+// popular entry are flagged: a maintainer must acknowledge the lookalikes
+// before approving them. Values resemble each other when they are one or
+// two edits apart or spell the same thing with lookalike characters.
+
+// maxLookalikeDistance is the largest edit distance that counts as a
+// lookalike
+const maxLookalikeDistance = 2
+
+// minLookalikeLength keeps short names, where a couple of edits make any
+// word, out of the comparison
+const minLookalikeLength = 4
+
+// Lookalike is the evidence that a submission resembles a popular entry
+type Lookalike struct {
+	// Field is what resembles: id, package or repository
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	ServerID string `json:"server_id"`
+	Existing string `json:"existing"`
+	// Reason is homoglyph when the values only differ in lookalike
+	// characters, else edit_distance
+	Reason   string `json:"reason"`
+	Distance int    `json:"distance"`
+	// Popularity says why the existing entry is protected
+	Popularity string `json:"popularity"`
+}
+
+// homoglyphs map characters to the Latin letter they pass for. Upper case
+// I is mapped before folding, where it would become i.
+var homoglyphs = map[rune]rune{
+	'I': 'l', '0': 'o', '1': 'l', '|': 'l',
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// homoglyphSequences are letter pairs that read as one letter
+var homoglyphSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// lookalikeSkeleton reduces a value to the letters a reader sees, so
+// values that differ only in lookalike characters share a skeleton
+func lookalikeSkeleton(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r == 'I' {
+			r = 'l'
+		}
+		b.WriteRune(r)
+	}
+	folded := transliterate(foldText(b.String()))
+	b.Reset()
+	for _, r := range folded {
+		if latin, ok := homoglyphs[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return homoglyphSequences.Replace(b.String())
+}
+
+// editDistance is the Levenshtein distance of two strings in runes
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
+
+// compareLookalike reports whether value passes for existing, and why
+func compareLookalike(value, existing string) (reason string, distance int, ok bool) {
+	if value == "" || existing == "" || foldText(value) == foldText(existing) {
+		return "", 0, false
+	}
+	skeleton, existingSkeleton := lookalikeSkeleton(value), lookalikeSkeleton(existing)
+	if len([]rune(skeleton)) < minLookalikeLength || len([]rune(existingSkeleton)) < minLookalikeLength {
+		return "", 0, false
+	}
+	distance = editDistance(skeleton, existingSkeleton)
+	switch {
+	case distance == 0:
+		return "homoglyph", editDistance(foldText(value), foldText(existing)), true
+	case distance <= maxLookalikeDistance:
+		return "edit_distance", distance, true
+	}
+	return "", 0, false
+}
+
+// popularEntries returns the served entries worth protecting, with the
+// reason: featured, a verified package or at least cfg.PopularInstalls
+// installs
+func popularEntries() map[string]string {
+	installStats.Lock()
+	installs := make(map[string]int, len(installStats.installs))
+	for serverID, count := range installStats.installs {
+		installs[serverID] = count
+	}
+	installStats.Unlock()
+
+	popular := make(map[string]string)
+	for serverID := range servers {
+		config, ok := getEntry(serverID)
+		if !ok {
+			continue
+		}
+		switch result := entryVerification(serverID); {
+		case isFeatured(serverID, getString(config, "category", "other")):
+			popular[serverID] = "featured"
+		case result != nil && result.Status == "verified":
+			popular[serverID] = "verified"
+		case cfg.PopularInstalls > 0 && installs[serverID] >= cfg.PopularInstalls:
+			popular[serverID] = fmt.Sprintf("%d installs", installs[serverID])
+		}
+	}
+	return popular
+}
+
+// lookalikeFields returns the values of an entry that are compared: its
+// server ID, its package name scoped by registry and its repository
+func lookalikeFields(serverID string, config map[string]interface{}) map[string]string {
+	fields := map[string]string{"id": serverID}
+	if pkg, ok := entryPackage(config); ok {
+		fields["package"] = pkg.Registry + ":" + pkg.Name
+	}
+	if repo, ok := config["repository"].(map[string]interface{}); ok {
+		if normalized := normalizeRepoURL(getString(repo, "url", "")); normalized != "" {
+			fields["repository"] = normalized
+		}
+	}
+	return fields
+}
+
+// findLookalikes compares a proposed entry with every popular entry
+func findLookalikes(serverID string, entry map[string]interface{}) []Lookalike {
+	proposed := lookalikeFields(serverID, entry)
+	var found []Lookalike
+	for existingID, popularity := range popularEntries() {
+		if existingID == serverID {
+			continue
+		}
+		config, _ := getEntry(existingID)
+		existing := lookalikeFields(existingID, config)
+		for field, value := range proposed {
+			other := existing[field]
+			if field == "package" {
+				// Packages only collide within a registry
+				registry, _, _ := strings.Cut(value, ":")
+				if !strings.HasPrefix(other, registry+":") {
+					continue
+				}
+			}
+			if reason, distance, ok := compareLookalike(value, other); ok {
+				found = append(found, Lookalike{Field: field, Value: value, ServerID: existingID, Existing: other, Reason: reason, Distance: distance, Popularity: popularity})
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].ServerID != found[j].ServerID {
+			return found[i].ServerID < found[j].ServerID
+		}
+		return found[i].Field < found[j].Field
+	})
+	return found
+}
+
+// describeLookalikes summarizes evidence for an error message
+func describeLookalikes(lookalikes []Lookalike) string {
+	parts := make([]string, 0, len(lookalikes))
+	for _, lookalike := range lookalikes {
+		parts = append(parts, fmt.Sprintf("%s '%s' resembles '%s' of %s (%s)", lookalike.Field, lookalike.Value, lookalike.Existing, lookalike.ServerID, strings.ReplaceAll(lookalike.Reason, "_", " ")))
+	}
+	return strings.Join(parts, "; ")
+}
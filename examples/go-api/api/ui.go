@@ -0,0 +1,34 @@
+package main
+
+import (
+	"embed"
+	"net/http"
+)
+
+// uiFiles is the browse UI compiled into the binary, so the server alone
+// is a usable catalog browser, offline included
+//
+//go:embed ui/index.html
+var uiFiles embed.FS
+
+// uiHandler serves the browse UI at /. It only talks to this instance's
+// public API.
+func uiHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.UI {
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, http.StatusNotFound, "The browse UI is disabled")
+		return
+	}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	page, err := uiFiles.ReadFile("ui/index.html")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; img-src 'self' data:")
+	w.Write(page)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Deprecation is the "deprecated" block of an entry that should no longer
+// be installed, naming the entry that replaces it if any
+type Deprecation struct {
+	Reason     string `json:"reason,omitempty"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+// entryDeprecation decodes the "deprecated" block of an entry
+func entryDeprecation(config map[string]interface{}) (Deprecation, bool) {
+	raw, ok := config["deprecated"].(map[string]interface{})
+	if !ok {
+		return Deprecation{}, false
+	}
+	return Deprecation{Reason: getString(raw, "reason", ""), ReplacedBy: getString(raw, "replaced_by", "")}, true
+}
+
+// UpgradeAction is one change an upgrade plan recommends for an
+// mcpServers entry. Action is bump (a newer known-good version), replace
+// (a deprecated entry's successor) or deprecated (no successor; left for
+// the user to remove).
+type UpgradeAction struct {
+	Key         string `json:"key"`
+	ServerID    string `json:"server_id"`
+	Action      string `json:"action"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+// launchSpecs are the package operands a launcher accepts for a version,
+// the one generate-config emits first
+func launchSpecs(launched launchedPackage, version string) []string {
+	switch launched.Registry {
+	case "pypi":
+		return []string{launched.Name + "==" + version, launched.Name + "==" + version + ".*"}
+	case "docker":
+		return []string{launched.Name + ":" + version}
+	}
+	return []string{launched.Name + "@" + version}
+}
+
+// replacementPolicyViolations checks a successor against the config
+// policy of the request's tenant, as generate-config would, returning the
+// tenant and what the policy forbids
+func replacementPolicyViolations(r *http.Request, replacement string) (string, []string) {
+	tenant, policy, ok := requestPolicy(r)
+	if !ok {
+		return "", nil
+	}
+	var messages []string
+	for _, violation := range entryPolicyViolations(r, policy, replacement) {
+		messages = append(messages, violation.Message)
+	}
+	return tenant, messages
+}
+
+// upgradePlanHandler reads an existing client config like identify-config
+// and plans its upgrade: pins behind the known-good version are bumped and
+// deprecated entries swapped for their successors, unless the tenant's
+// config policy forbids the successor. The response carries the upgraded
+// config and the JSON Patch that turns the given config into it; servers
+// the catalog does not know are left alone.
+func upgradePlanHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limitBody(w, r)
+	req, err := parseValidateConfigRequest(r.Body)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	client, err := clientProfileVersion(req.Format, req.ClientVersion)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	shape := client.Shape
+	mcpServers, ok := req.Config[shape.ServersKey].(map[string]interface{})
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("The config needs a '%s' object", shape.ServersKey))
+		return
+	}
+
+	upgraded := deepCopyJSON(req.Config).(map[string]interface{})
+	upgradedServers := upgraded[shape.ServersKey].(map[string]interface{})
+	actions, patch := []UpgradeAction{}, []PatchOperation{}
+	var warnings []string
+	// installedAs maps the servers the config runs to their keys, so a
+	// successor already installed under another key is not added twice
+	installedAs := make(map[string]string)
+	for _, key := range sortedServerKeys(mcpServers) {
+		if configured := readConfiguredServer(key, shape.readServer(mcpServers[key])); configured.serverID != "" {
+			if _, seen := installedAs[configured.serverID]; !seen {
+				installedAs[configured.serverID] = key
+			}
+		}
+	}
+	for _, key := range sortedServerKeys(mcpServers) {
+		configured := readConfiguredServer(key, shape.readServer(mcpServers[key]))
+		if configured.serverID == "" {
+			continue
+		}
+		serverID := configured.serverID
+		entry, _ := getEntry(serverID)
+		serverPath := "/" + escapePointerToken(shape.ServersKey) + "/" + escapePointerToken(key)
+
+		if deprecation, deprecated := entryDeprecation(entry); deprecated {
+			action := UpgradeAction{Key: key, ServerID: serverID, Action: "deprecated", Reason: fmt.Sprintf("'%s' is deprecated", serverID)}
+			if deprecation.Reason != "" {
+				action.Reason += ": " + deprecation.Reason
+			}
+			replacement := canonicalID(deprecation.ReplacedBy)
+			successor, exists := getEntry(replacement)
+			if deprecation.ReplacedBy == "" || !exists {
+				actions = append(actions, action)
+				continue
+			}
+			if tenant, violations := replacementPolicyViolations(r, replacement); len(violations) > 0 {
+				warnings = append(warnings, fmt.Sprintf("'%s' replaces '%s', but the config policy of '%s' forbids it: %s", replacement, serverID, tenant, strings.Join(violations, "; ")))
+				actions = append(actions, action)
+				continue
+			}
+			launch, bridge, err := launchConfig(replacement, successor, defaultConfigOptions, client, 0)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("'%s' replaces '%s', but %v", replacement, serverID, err))
+				actions = append(actions, action)
+				continue
+			}
+			action.Action, action.Replacement = "replace", replacement
+			delete(upgradedServers, key)
+			patch = append(patch, PatchOperation{Op: "remove", Path: serverPath})
+			if existing, installed := installedAs[replacement]; installed {
+				action.Reason += fmt.Sprintf("; '%s' already runs '%s'", existing, replacement)
+			} else if _, added := upgradedServers[replacement]; !added {
+				if env := secretEnv(replacement, successor, defaultConfigOptions); len(env) > 0 {
+					if _, local := launch["command"]; local && (bridge == nil || len(bridge.Command) == 0) {
+						launch["env"] = env
+					}
+				}
+				if bridge != nil && len(bridge.Command) > 0 {
+					warnings = append(warnings, fmt.Sprintf("'%s' needs a %s bridge: %s", replacement, bridge.Tool, bridge.Notes))
+				}
+				upgradedServers[replacement] = shape.writeServer(launch)
+				patch = append(patch, PatchOperation{Op: "add", Path: "/" + escapePointerToken(shape.ServersKey) + "/" + escapePointerToken(replacement), Value: upgradedServers[replacement]})
+			}
+			actions = append(actions, action)
+			continue
+		}
+
+		available, yanked, behind := configured.outdatedPin()
+		if !behind {
+			continue
+		}
+		action := UpgradeAction{Key: key, ServerID: serverID, Action: "bump", From: configured.launched.Version, To: available,
+			Reason: fmt.Sprintf("%s %s is behind the known-good %s", configured.launched.Name, configured.launched.Version, available)}
+		if yanked {
+			action.Reason = fmt.Sprintf("%s %s was yanked upstream; %s is known to work", configured.launched.Name, configured.launched.Version, available)
+		}
+		actions = append(actions, action)
+
+		args, _ := upgradedServers[key].(map[string]interface{})["args"].([]interface{})
+		bumped := false
+		for i, arg := range args {
+			for n, spec := range launchSpecs(configured.launched, configured.launched.Version) {
+				if arg != spec || bumped {
+					continue
+				}
+				args[i] = launchSpecs(configured.launched, available)[n]
+				patch = append(patch, PatchOperation{Op: "replace", Path: serverPath + "/args/" + strconv.Itoa(i), Value: args[i]})
+				bumped = true
+			}
+		}
+		if !bumped {
+			warnings = append(warnings, fmt.Sprintf("Cannot find the version of %s in the args of '%s'; bump it by hand", configured.launched.Name, key))
+		}
+	}
+
+	response := map[string]interface{}{
+		"format":  req.Format,
+		"actions": actions,
+		"patch":   patch,
+		"config":  upgraded,
+	}
+	if req.ClientVersion != "" {
+		response["client_version"] = req.ClientVersion
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoadError lists why an entry was rejected while loading a catalog source
+type LoadError struct {
+	Source   string   `json:"source"`
+	ServerID string   `json:"server_id"`
+	Errors   []string `json:"errors"`
+}
+
+var (
+	loadErrorsMu sync.RWMutex
+	// loadErrors are keyed by source so a re-synced upstream replaces its own
+	loadErrors = make(map[string][]LoadError)
+)
+
+// stringListField checks that an optional field is a list of strings;
+// label names the field in the error
+func stringListField(config map[string]interface{}, key, label string) error {
+	raw, present := config[key]
+	if !present {
+		return nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return fmt.Errorf("'%s' must be a list", label)
+	}
+	for _, item := range list {
+		if _, ok := item.(string); !ok {
+			return fmt.Errorf("'%s' must only contain strings", label)
+		}
+	}
+	return nil
+}
+
+// validateEntry checks the shape every handler assumes of a catalog entry
+func validateEntry(raw interface{}) []string {
+	config, ok := raw.(map[string]interface{})
+	if !ok {
+		return []string{"entry must be an object"}
+	}
+
+	var problems []string
+	for _, key := range []string{"name", "description", "category", "vendor", "homepage", "license", "url", "transport", "maturity", "troubleshooting"} {
+		if value, present := config[key]; present {
+			if _, ok := value.(string); !ok {
+				problems = append(problems, fmt.Sprintf("'%s' must be a string", key))
+			}
+		}
+	}
+	if name, _ := config["name"].(string); name == "" {
+		problems = append(problems, "'name' is required")
+	}
+	if vendor, ok := config["vendor"].(string); ok && !vendorIDPattern.MatchString(vendor) {
+		problems = append(problems, fmt.Sprintf("'vendor' must be a vendor ID such as \"%s\"", vendorSlug(vendor)))
+	}
+	if maturity, ok := config["maturity"].(string); ok && !isMaturityLevel(maturity) {
+		problems = append(problems, fmt.Sprintf("'maturity' must be one of %s", strings.Join(maturityLevels, ", ")))
+	}
+	for _, key := range []string{"categories", "aliases", "transports", "requires", "recommends", "legacy_ids", "egress", "system_binaries", "tags", "platforms", "maintainers"} {
+		if err := stringListField(config, key, key); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, tag := range entryTags(config) {
+		if !tagPattern.MatchString(tag) {
+			problems = append(problems, fmt.Sprintf("tag '%s' must be 1-32 lowercase letters, digits or dashes", tag))
+		}
+	}
+	if raw, present := config["merge_policy"]; present {
+		policies, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'merge_policy' must map fields to policies")
+		}
+		for field, policy := range policies {
+			if name, _ := policy.(string); !slices.Contains(mergePolicies, name) {
+				problems = append(problems, fmt.Sprintf("'merge_policy.%s' must be one of %s", field, strings.Join(mergePolicies, ", ")))
+			}
+		}
+	}
+	if raw, present := config["enrichment"]; present {
+		sources, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'enrichment' must map icon, readme, capabilities and runtime to URLs")
+		}
+		for kind, source := range sources {
+			link, _ := source.(string)
+			if parsed, err := url.Parse(link); !slices.Contains(enrichmentKinds, kind) || err != nil || parsed.Scheme != "https" && parsed.Scheme != "http" {
+				problems = append(problems, fmt.Sprintf("'enrichment.%s' must be the http(s) URL of an icon, readme, capabilities or repository files", kind))
+			}
+		}
+	}
+	if raw, present := config["deprecated"]; present {
+		deprecated, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'deprecated' must be an object")
+		}
+		for _, key := range []string{"reason", "replaced_by"} {
+			if value, present := deprecated[key]; present {
+				if _, ok := value.(string); !ok {
+					problems = append(problems, fmt.Sprintf("'deprecated.%s' must be a string", key))
+				}
+			}
+		}
+	}
+	if raw, present := config["verify"]; present {
+		verify, ok := raw.(map[string]interface{})
+		if command, _ := verify["command"].(string); !ok || command == "" {
+			problems = append(problems, "'verify' must be an object with a 'command'")
+		}
+		if expect, present := verify["expect"]; present {
+			if _, ok := expect.(string); !ok {
+				problems = append(problems, "'verify.expect' must be a string")
+			}
+		}
+	}
+	problems = append(problems, validateExamples(config)...)
+	problems = append(problems, validateDistributions(config)...)
+	problems = append(problems, validateRuntime(config)...)
+	problems = append(problems, validateExtensions(config)...)
+	for _, platform := range entryPlatforms(config) {
+		if !isPlatform(platform) {
+			problems = append(problems, fmt.Sprintf("platform '%s' must be macos, windows or linux, optionally with -amd64 or -arm64", platform))
+		}
+	}
+	for _, binary := range entrySystemBinaries(config) {
+		if !binaryNamePattern.MatchString(binary) {
+			problems = append(problems, fmt.Sprintf("'system_binaries' entry '%s' must be a program name such as \"git\"", binary))
+		}
+	}
+
+	if err := stringListField(config, "risk", "risk"); err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		risk, _ := config["risk"].([]interface{})
+		for _, flag := range risk {
+			if _, known := riskFlagLevels[flag.(string)]; !known {
+				problems = append(problems, fmt.Sprintf("unknown risk flag '%s'", flag))
+			}
+		}
+	}
+
+	if raw, present := config["package"]; present {
+		pkg, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'package' must be an object")
+		} else if name, _ := pkg["name"].(string); name == "" {
+			problems = append(problems, "'package.name' is required")
+		} else if _, ok := pkg["registry"].(string); !ok {
+			problems = append(problems, "'package.registry' must be a string")
+		}
+	}
+
+	if raw, present := config["config"]; present {
+		serverConfig, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'config' must be an object")
+		} else {
+			if env, present := serverConfig["env"]; present {
+				vars, ok := env.(map[string]interface{})
+				if !ok {
+					problems = append(problems, "'config.env' must be an object")
+				}
+				for name, spec := range vars {
+					if _, ok := spec.(map[string]interface{}); !ok {
+						problems = append(problems, fmt.Sprintf("'config.env.%s' must be an object", name))
+					}
+				}
+			}
+			if err := stringListField(serverConfig, "args", "config.args"); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+
+	if raw, present := config["launch"]; present {
+		launch, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'launch' must be an object")
+		}
+		for _, key := range []string{"cwd", "venv"} {
+			if value, present := launch[key]; present {
+				if _, ok := value.(string); !ok {
+					problems = append(problems, fmt.Sprintf("'launch.%s' must be a string", key))
+				}
+			}
+		}
+	}
+
+	if raw, present := config["try_it"]; present {
+		spec, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, "'try_it' must be an object")
+		} else {
+			if tool, present := spec["sample_tool"]; present {
+				if _, ok := tool.(string); !ok {
+					problems = append(problems, "'try_it.sample_tool' must be a string")
+				}
+			}
+			if arguments, present := spec["sample_arguments"]; present {
+				if _, ok := arguments.(map[string]interface{}); !ok {
+					problems = append(problems, "'try_it.sample_arguments' must be an object")
+				}
+			}
+		}
+	}
+
+	if raw, present := config["tools"]; present {
+		tools, ok := raw.([]interface{})
+		if !ok {
+			problems = append(problems, "'tools' must be a list")
+		}
+		for i, tool := range tools {
+			spec, ok := tool.(map[string]interface{})
+			if name, _ := spec["name"].(string); !ok || name == "" {
+				problems = append(problems, fmt.Sprintf("'tools[%d]' must be an object with a name", i))
+			}
+		}
+	}
+	return problems
+}
+
+// validEntries drops invalid entries, and entries breaking a blocking
+// consistency rule, from a loaded source and reports why
+func validEntries(source string, entries map[string]interface{}) (map[string]interface{}, []LoadError) {
+	valid := make(map[string]interface{}, len(entries))
+	var rejected []LoadError
+	for serverID, entry := range entries {
+		problems := validateEntry(entry)
+		if len(problems) == 0 {
+			problems = blockingViolations(serverID, entry.(map[string]interface{}))
+		}
+		if len(problems) > 0 {
+			rejected = append(rejected, LoadError{Source: source, ServerID: serverID, Errors: problems})
+			continue
+		}
+		valid[serverID] = entry
+	}
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].ServerID < rejected[j].ServerID
+	})
+	return valid, rejected
+}
+
+// setLoadErrors replaces the recorded load errors of one source
+func setLoadErrors(source string, rejected []LoadError) {
+	loadErrorsMu.Lock()
+	defer loadErrorsMu.Unlock()
+	if len(rejected) == 0 {
+		delete(loadErrors, source)
+		return
+	}
+	loadErrors[source] = rejected
+}
+
+// allLoadErrors returns the recorded load errors ordered by source and ID
+func allLoadErrors() []LoadError {
+	loadErrorsMu.RLock()
+	defer loadErrorsMu.RUnlock()
+	all := []LoadError{}
+	for _, rejected := range loadErrors {
+		all = append(all, rejected...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Source != all[j].Source {
+			return all[i].Source < all[j].Source
+		}
+		return all[i].ServerID < all[j].ServerID
+	})
+	return all
+}
+
+// readyHandler reports readiness. Skipped entries leave the service ready
+// but degraded; ?verbose=true lists them and failed enrichment fetches.
+// The service is ready while enrichment is still loading. Open circuit
+// breakers and upstreams or enrichment served from their last good copy
+// degrade it too: reads keep answering from what was fetched before.
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	verbose, _, err := parseBoolParam(r, "verbose")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rejected := allLoadErrors()
+	response := map[string]interface{}{
+		"status":          "ready",
+		"server_count":    len(servers),
+		"load_mode":       cfg.LoadMode,
+		"skipped_entries": len(rejected),
+	}
+	if len(rejected) > 0 {
+		response["status"] = "degraded"
+	}
+	index := searchBackend.Health(r.Context())
+	response["search_index"] = index
+	enriched := enrichmentProgress()
+	if !verbose {
+		enriched.Errors = nil
+	}
+	response["enrichment"] = enriched
+	if cfg.ArtifactsBucket != "" && verbose {
+		response["artifacts"] = artifactsReport()
+	}
+	if verbose {
+		response["outbound"] = outboundReport()
+	}
+	if index.Status == "degraded" || index.Status == "unavailable" {
+		response["status"] = "degraded"
+	}
+	dependencies := breakerReport(time.Now())
+	response["dependencies"] = dependencies
+	if len(openDependencies(dependencies)) > 0 || enriched.Stale > 0 {
+		response["status"] = "degraded"
+	}
+	if upstreams := upstreamStatuses(); len(upstreams) > 0 {
+		response["upstreams"] = upstreams
+		for _, upstream := range upstreams {
+			if upstream.Stale {
+				response["status"] = "degraded"
+			}
+		}
+	}
+	if verbose {
+		response["load_errors"] = rejected
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func loadErrorsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rejected := allLoadErrors()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"load_mode": cfg.LoadMode,
+		"errors":    rejected,
+		"total":     len(rejected),
+	})
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is one finding about a user-edited client config
+type Diagnostic struct {
+	// Severity is error, warning or info
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	// Path locates the finding in the config, e.g. "mcpServers.github.env"
+	Path     string `json:"path"`
+	ServerID string `json:"server_id,omitempty"`
+	Message  string `json:"message"`
+}
+
+// configLinter collects diagnostics for one config
+type configLinter struct {
+	diagnostics []Diagnostic
+}
+
+func (l *configLinter) add(severity, code, path, serverID, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Path:     path,
+		ServerID: serverID,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// launchedPackage is the package and version a launch command runs
+type launchedPackage struct {
+	Registry string
+	Name     string
+	Version  string
+}
+
+// dockerValueFlags are the docker run flags that take the next argument
+// as their value, unless it is given as --flag=value
+var dockerValueFlags = []string{
+	"-e", "--env", "--env-file", "--name", "-v", "--volume", "--mount",
+	"-p", "--publish", "--network", "--net", "-w", "--workdir", "-u",
+	"--user", "-l", "--label", "--entrypoint", "--platform", "--pull",
+	"--restart", "-h", "--hostname", "--add-host", "--cpus", "-m", "--memory",
+}
+
+// parseLaunchedPackage recognizes the npx, uvx and docker launches
+// generate-config emits
+func parseLaunchedPackage(command string, args []string) (launchedPackage, bool) {
+	var operands []string
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			operands = append(operands, args[i])
+		} else if command == "docker" && slices.Contains(dockerValueFlags, args[i]) {
+			i++
+		}
+	}
+	switch command {
+	case "npx":
+		if len(operands) == 0 {
+			break
+		}
+		// "@scope/name@1.2.0": the version follows the last @ that is not
+		// the scope's
+		spec := operands[0]
+		if at := strings.LastIndex(spec, "@"); at > 0 {
+			return launchedPackage{Registry: "npm", Name: spec[:at], Version: spec[at+1:]}, true
+		}
+		return launchedPackage{Registry: "npm", Name: spec}, true
+	case "uvx":
+		if len(operands) == 0 {
+			break
+		}
+		name, version, _ := strings.Cut(operands[0], "==")
+		return launchedPackage{Registry: "pypi", Name: name, Version: strings.TrimSuffix(version, ".*")}, true
+	case "docker":
+		if len(operands) < 2 || operands[0] != "run" {
+			break
+		}
+		image := operands[1]
+		if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+			return launchedPackage{Registry: "docker", Name: image[:colon], Version: image[colon+1:]}, true
+		}
+		return launchedPackage{Registry: "docker", Name: image}, true
+	}
+	return launchedPackage{}, false
+}
+
+// catalogMatch finds the catalog entry a configured server runs: the entry
+// named like the config key, else the one whose package or URL it launches
+func catalogMatch(key string, launched launchedPackage, endpoint string) (string, bool) {
+	if _, exists := getEntry(canonicalID(key)); exists {
+		return canonicalID(key), true
+	}
+	var ids []string
+	for serverID := range servers {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+	for _, serverID := range ids {
+		config, _ := getEntry(serverID)
+		if endpoint != "" && getString(config, "url", "") == endpoint {
+			return serverID, true
+		}
+		if pkg, ok := entryPackage(config); ok && launched.Name != "" && pkg.Name == launched.Name && pkg.Registry == launched.Registry {
+			return serverID, true
+		}
+	}
+	return "", false
+}
+
+// stringList reports whether a JSON value is a list of strings
+func stringList(value interface{}) ([]string, bool) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+	return strs, true
+}
+
+// lintServer checks one server entry, in the client's config shape, and
+// returns the catalog entry it runs, if any
+func (l *configLinter) lintServer(key string, raw interface{}, client ClientProfile, advisories map[string][]Advisory) string {
+	shape := client.Shape
+	path := shape.ServersKey + "." + key
+	server, ok := raw.(map[string]interface{})
+	if !ok {
+		l.add("error", "invalid_server", path, "", "Server '%s' must be an object", key)
+		return ""
+	}
+
+	allowed := map[string]bool{"command": true, "args": true, "env": true, shape.URLKey: true}
+	if shape.TransportKey != "" {
+		allowed[shape.TransportKey] = true
+	}
+	for field := range server {
+		if !allowed[field] {
+			l.add("warning", "unknown_field", path+"."+field, "", "%s ignores '%s'", client.Name, field)
+		}
+	}
+	transportPath := path + "." + shape.TransportKey
+	declared, _ := server[shape.TransportKey].(string)
+	if shape.TransportKey == "" {
+		declared = ""
+	}
+	transport := shape.readTransport(declared)
+
+	command, hasCommand := server["command"]
+	endpoint, hasURL := server[shape.URLKey]
+	var launched launchedPackage
+	var env map[string]interface{}
+	relayed := false
+	switch {
+	case hasCommand && hasURL:
+		l.add("error", "ambiguous_launch", path, "", "Server '%s' sets both 'command' and '%s'; keep one", key, shape.URLKey)
+		return ""
+	case hasCommand:
+		commandStr, ok := command.(string)
+		if !ok || commandStr == "" {
+			l.add("error", "invalid_field", path+".command", "", "'command' must be a non-empty string")
+			return ""
+		}
+		if !client.supportsTransport("stdio") {
+			l.add("error", "transport_unsupported", path, "", "%s cannot launch local processes; use a remote endpoint", client.Name)
+		}
+		switch {
+		case declared == "" && shape.TypedStdio:
+			l.add("error", "missing_field", transportPath, "", "Local servers need '%s': \"stdio\"", shape.TransportKey)
+		case declared != "" && declared != "stdio":
+			l.add("error", "invalid_field", transportPath, "", "Local servers have '%s' \"stdio\", not \"%s\"", shape.TransportKey, declared)
+		}
+		var args []string
+		if rawArgs, present := server["args"]; present {
+			if args, ok = stringList(rawArgs); !ok {
+				l.add("error", "invalid_field", path+".args", "", "'args' must be a list of strings")
+			}
+		}
+		if rawEnv, present := server["env"]; present {
+			if env, ok = rawEnv.(map[string]interface{}); !ok {
+				l.add("error", "invalid_field", path+".env", "", "'env' must be an object")
+			}
+			for name, value := range env {
+				if _, ok := value.(string); !ok {
+					l.add("error", "invalid_field", path+".env."+name, "", "Environment values must be strings")
+				}
+			}
+		}
+		launched, _ = parseLaunchedPackage(commandStr, args)
+		// mcp-remote relays stdio to a remote server; check that server
+		if launched.Name == "mcp-remote" {
+			launched, relayed = launchedPackage{}, true
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+					endpoint = arg
+				}
+			}
+		}
+	case hasURL:
+		endpointStr, _ := endpoint.(string)
+		parsed, err := url.Parse(endpointStr)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			l.add("error", "invalid_field", path+"."+shape.URLKey, "", "'%s' must be an http(s) URL", shape.URLKey)
+		}
+		// A client without a transport field tells it from the endpoint
+		switch {
+		case shape.TransportKey == "":
+		case transport == "":
+			l.add("error", "missing_field", transportPath, "", "Remote servers need a '%s'", shape.TransportKey)
+		case transport == "stdio" || !client.supportsTransport(transport):
+			l.add("error", "transport_unsupported", transportPath, "", "%s cannot connect over '%s'", client.Name, declared)
+		}
+		endpoint = endpointStr
+	default:
+		l.add("error", "missing_launch", path, "", "Server '%s' needs a 'command' or a '%s'", key, shape.URLKey)
+		return ""
+	}
+
+	endpointStr, _ := endpoint.(string)
+	serverID, matched := catalogMatch(key, launched, endpointStr)
+	if !matched {
+		l.add("info", "not_in_catalog", path, "", "'%s' is not in the catalog, so its package and settings were not checked", key)
+		return ""
+	}
+	config, _ := getEntry(serverID)
+
+	if hasURL && transport != "" && !hasTransport(entryTransports(config), transport) {
+		l.add("warning", "transport_mismatch", transportPath, serverID, "'%s' does not declare the '%s' transport", serverID, transport)
+	}
+
+	if pkg, ok := entryPackage(config); ok && launched.Name != "" {
+		if launched.Name != pkg.Name || launched.Registry != pkg.Registry {
+			l.add("warning", "package_differs", path+".args", serverID, "Runs %s:%s, but '%s' is published as %s:%s; check for a typosquat",
+				launched.Registry, launched.Name, serverID, pkg.Registry, pkg.Name)
+		}
+		if result := entryVerification(serverID); result != nil {
+			switch result.Status {
+			case "missing":
+				l.add("error", "package_missing", path+".args", serverID, "%s is not published on %s", pkg.Name, pkg.Registry)
+			case "mismatch":
+				l.add("warning", "package_mismatch", path+".args", serverID, "%s failed verification: %s", pkg.Name, strings.Join(result.Issues, "; "))
+			}
+			for _, yanked := range result.YankedVersions {
+				if launched.Version != "" && launched.Version == yanked {
+					l.add("error", "version_yanked", path+".args", serverID, "%s %s was yanked upstream; upgrade", pkg.Name, yanked)
+				}
+			}
+		}
+		if launched.Version == "" || launched.Version == "latest" {
+			if version := pinnedVersion(pkg, "exact"); version != "" {
+				l.add("info", "version_unpinned", path+".args", serverID, "%s floats to latest; pin the known-good version %s", pkg.Name, version)
+			}
+		}
+	}
+
+	if hasCommand && !relayed {
+		for _, question := range envQuestions(config) {
+			value, present := env[question.Key]
+			if str, _ := value.(string); question.Required && (!present || strings.TrimSpace(str) == "") {
+				l.add("error", "env_missing", path+".env."+question.Key, serverID, "'%s' requires %s: %s", serverID, question.Key, question.Description)
+			}
+		}
+	}
+
+	for _, advisory := range advisories[serverID] {
+		l.add("warning", "advisory", path, serverID, "%s advisory %s: %s", advisory.Severity, advisory.ID, advisory.Title)
+	}
+	if underReview(serverID) {
+		l.add("warning", "under_review", path, serverID, "'%s' is under review after abuse reports", serverID)
+	}
+	return serverID
+}
+
+// validateConfigHandler lints a user-edited client config against the
+// target client's schema and the catalog, for editors to show inline
+func validateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limitBody(w, r)
+	req, err := parseValidateConfigRequest(r.Body)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	client, err := clientProfileVersion(req.Format, req.ClientVersion)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	serversKey := client.Shape.ServersKey
+	linter := &configLinter{}
+	for field := range req.Config {
+		if field != serversKey {
+			linter.add("info", "unchecked_field", field, "", "Only '%s' is checked; '%s' was left alone", serversKey, field)
+		}
+	}
+	matches := make(map[string]interface{})
+	var matched []string
+	switch mcpServers := req.Config[serversKey].(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(mcpServers))
+		for key := range mcpServers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		advisories := serverAdvisories()
+		for _, key := range keys {
+			matches[key] = nil
+			if serverID := linter.lintServer(key, mcpServers[key], client, advisories); serverID != "" {
+				matches[key] = serverID
+				matched = append(matched, serverID)
+			}
+		}
+	case nil:
+		linter.add("error", "missing_field", serversKey, "", "The config needs a '%s' object", serversKey)
+	default:
+		linter.add("error", "invalid_field", serversKey, "", "'%s' must be an object", serversKey)
+	}
+
+	for _, warning := range conflictWarnings(detectToolConflicts(matched), client) {
+		linter.add("warning", "tool_conflict", serversKey, "", "%s", warning)
+	}
+	for _, warning := range clientLimitWarnings(client, matched) {
+		linter.add("warning", "client_limits", serversKey, "", "%s", warning)
+	}
+
+	summary := map[string]int{"error": 0, "warning": 0, "info": 0}
+	for _, diagnostic := range linter.diagnostics {
+		summary[diagnostic.Severity]++
+	}
+	diagnostics := linter.diagnostics
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	response := map[string]interface{}{
+		"valid":       summary["error"] == 0,
+		"format":      req.Format,
+		"servers":     matches,
+		"summary":     summary,
+		"diagnostics": diagnostics,
+	}
+	if req.ClientVersion != "" {
+		response["client_version"] = req.ClientVersion
+	}
+	json.NewEncoder(w).Encode(response)
+}
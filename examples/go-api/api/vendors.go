@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultVendorID is the vendor of entries that name none
+const defaultVendorID = "community"
+
+// vendorIDPattern matches the vendor IDs entries reference
+var vendorIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Vendor is the canonical record of a publisher, kept in vendors.json
+// keyed by vendor ID
+type Vendor struct {
+	Name    string `json:"name"`
+	Website string `json:"website,omitempty"`
+	// Verification is verified once maintainers confirmed who publishes
+	// under the name, otherwise unverified
+	Verification   string `json:"verification"`
+	SupportContact string `json:"support_contact,omitempty"`
+}
+
+// VendorInfo is a vendor as returned by the vendors endpoints
+type VendorInfo struct {
+	ID string `json:"id"`
+	Vendor
+	ServerCount int `json:"server_count"`
+}
+
+var (
+	vendorsMu sync.RWMutex
+	vendors   = make(map[string]Vendor)
+	// vendorsPath is the vendors.json loaded, empty when there was none or
+	// it came from a bundle
+	vendorsPath string
+)
+
+func loadVendors() {
+	paths := []string{
+		"../../mcp_catalog/vendors.json",
+		"vendors.json",
+	}
+
+	data, path, ok := readSourceFile("vendors.json", paths)
+	if !ok {
+		return
+	}
+	records := make(map[string]Vendor)
+	if err := json.Unmarshal(data, &records); err != nil {
+		log.Printf("❌ Ignoring %s: %v", path, err)
+		return
+	}
+	for id, record := range records {
+		if !vendorIDPattern.MatchString(id) {
+			log.Printf("⚠️  Ignoring vendor '%s' in %s: IDs are lowercase words joined by hyphens", id, path)
+			delete(records, id)
+			continue
+		}
+		if record.Verification != "verified" {
+			record.Verification = "unverified"
+			records[id] = record
+		}
+	}
+	log.Printf("🏢 Loaded %d vendors from %s", len(records), path)
+	vendorsMu.Lock()
+	vendors = records
+	if bundleFiles == nil {
+		vendorsPath = path
+	}
+	vendorsMu.Unlock()
+}
+
+// adoptVendorName records the display name of a vendor without a record,
+// unverified, and reports whether it did
+func adoptVendorName(id, name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" || name == id {
+		return false
+	}
+	vendorsMu.Lock()
+	defer vendorsMu.Unlock()
+	if _, exists := vendors[id]; exists {
+		return false
+	}
+	vendors[id] = Vendor{Name: name, Verification: "unverified"}
+	return true
+}
+
+// vendorSlug turns a free-text vendor name into a vendor ID
+func vendorSlug(name string) string {
+	var b strings.Builder
+	for _, r := range transliterate(foldText(name)) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// entryVendor returns the vendor ID an entry references
+func entryVendor(config map[string]interface{}) string {
+	return getString(config, "vendor", defaultVendorID)
+}
+
+// vendorRecord returns a vendor's record. Vendors referenced by entries
+// but missing from vendors.json are unverified and named by their ID.
+func vendorRecord(id string) (Vendor, bool) {
+	vendorsMu.RLock()
+	defer vendorsMu.RUnlock()
+	if record, ok := vendors[id]; ok {
+		return record, true
+	}
+	if id == defaultVendorID {
+		return Vendor{Name: "Community", Verification: "unverified"}, false
+	}
+	return Vendor{Name: id, Verification: "unverified"}, false
+}
+
+// vendorInfos lists every vendor with a record or an entry, by name
+func vendorInfos() []VendorInfo {
+	counts := make(map[string]int)
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		counts[entryVendor(config)]++
+	}
+	vendorsMu.RLock()
+	for id := range vendors {
+		if _, ok := counts[id]; !ok {
+			counts[id] = 0
+		}
+	}
+	vendorsMu.RUnlock()
+
+	result := make([]VendorInfo, 0, len(counts))
+	for id, count := range counts {
+		record, _ := vendorRecord(id)
+		result = append(result, VendorInfo{ID: id, Vendor: record, ServerCount: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := strings.ToLower(result[i].Name), strings.ToLower(result[j].Name)
+		if a != b {
+			return a < b
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// parseVendorFilter handles ?vendor=ID
+func parseVendorFilter(r *http.Request) (entryFilter, error) {
+	vendor := r.URL.Query().Get("vendor")
+	if vendor == "" {
+		return nil, nil
+	}
+	return func(serverID string, config map[string]interface{}) bool {
+		return entryVendor(config) == vendor
+	}, nil
+}
+
+// vendorsHandler lists vendors, optionally only ?verified=true ones
+func vendorsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	verified, filtered, err := parseBoolParam(r, "verified")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := []VendorInfo{}
+	for _, info := range vendorInfos() {
+		if !filtered || (info.Verification == "verified") == verified {
+			result = append(result, info)
+		}
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// vendorServers returns the summaries of a vendor's servers by ID
+func vendorServers(id string) []Server {
+	result := []Server{}
+	for serverID := range servers {
+		config, _ := getEntry(serverID)
+		if entryVendor(config) == id {
+			result = append(result, serverSummary(serverID, config))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// vendorHandler serves one vendor record (/api/v1/vendors/{id}) or its
+// servers (/api/v1/vendors/{id}/servers)
+func vendorHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := r.PathValue("id")
+	record, known := vendorRecord(id)
+	owned := vendorServers(id)
+	if !known && len(owned) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Vendor '%s' not found", id))
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/servers") {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"vendor":  VendorInfo{ID: id, Vendor: record, ServerCount: len(owned)},
+			"servers": owned,
+			"total":   len(owned),
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(VendorInfo{ID: id, Vendor: record, ServerCount: len(owned)})
+}
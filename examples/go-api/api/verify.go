@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// PackageVerification is the result of checking an entry's package against
+// its registry
+type PackageVerification struct {
+	Registry string `json:"registry"`
+	Package  string `json:"package"`
+	// Status is verified, mismatch, missing, unverifiable or error
+	Status string `json:"status"`
+	// RegistryRepository is the repository the registry metadata declares
+	RegistryRepository string `json:"registry_repository,omitempty"`
+	// LatestVersion and LatestReleaseAt describe the newest published release
+	LatestVersion   string     `json:"latest_version,omitempty"`
+	LatestReleaseAt *time.Time `json:"latest_release_at,omitempty"`
+	// YankedVersions were yanked (PyPI) or deprecated (npm) upstream
+	YankedVersions []string  `json:"yanked_versions,omitempty"`
+	Issues         []string  `json:"issues,omitempty"`
+	CheckedAt      time.Time `json:"checked_at"`
+}
+
+// VerificationReport is the output of the verify command
+type VerificationReport struct {
+	GeneratedAt time.Time                      `json:"generated_at"`
+	Summary     map[string]int                 `json:"summary"`
+	Results     map[string]PackageVerification `json:"results"`
+}
+
+// registryEndpoints are the metadata URLs of each supported registry
+var registryEndpoints = map[string]string{
+	"npm":    "https://registry.npmjs.org/%s",
+	"pypi":   "https://pypi.org/pypi/%s/json",
+	"docker": "https://hub.docker.com/v2/repositories/%s/",
+}
+
+var (
+	verificationMu sync.RWMutex
+	verification   *VerificationReport
+)
+
+func loadVerification() {
+	var report VerificationReport
+	if err := readJSONFile(dataPath("verification.json"), &report); err != nil {
+		log.Printf("❌ Cannot load verification report: %v", err)
+		return
+	}
+	if report.Results != nil {
+		canonicalKeys(report.Results)
+		verificationMu.Lock()
+		verification = &report
+		verificationMu.Unlock()
+	}
+}
+
+// entryVerification returns the last verification result of a server
+func entryVerification(serverID string) *PackageVerification {
+	verificationMu.RLock()
+	defer verificationMu.RUnlock()
+	if verification == nil {
+		return nil
+	}
+	result, ok := verification.Results[serverID]
+	if !ok {
+		return nil
+	}
+	return &result
+}
+
+// normalizeRepoURL reduces a repository URL to host/owner/repo so registry
+// and catalog spellings ("git+https://...git", ".../tree/main/src/x") compare equal
+func normalizeRepoURL(raw string) string {
+	raw = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(raw)), "git+")
+	raw = strings.Replace(raw, "git@github.com:", "https://github.com/", 1)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	path := strings.TrimSuffix(strings.Join(segments, "/"), ".git")
+	return strings.TrimPrefix(parsed.Host, "www.") + "/" + path
+}
+
+// registryRepository extracts the declared source repository from registry
+// metadata
+func registryRepository(registry string, meta map[string]interface{}) string {
+	switch registry {
+	case "npm":
+		switch repo := meta["repository"].(type) {
+		case string:
+			return repo
+		case map[string]interface{}:
+			return getString(repo, "url", "")
+		}
+	case "pypi":
+		info, _ := meta["info"].(map[string]interface{})
+		if urls, ok := info["project_urls"].(map[string]interface{}); ok {
+			for _, key := range []string{"Source", "Repository", "Source Code", "Code", "Homepage"} {
+				if value := getString(urls, key, ""); value != "" {
+					return value
+				}
+			}
+		}
+		return getString(info, "home_page", "")
+	}
+	return ""
+}
+
+// registryLatestRelease extracts the newest version and its publish time
+// from registry metadata
+func registryLatestRelease(registry string, meta map[string]interface{}) (string, *time.Time) {
+	var version, published string
+	switch registry {
+	case "npm":
+		tags, _ := meta["dist-tags"].(map[string]interface{})
+		times, _ := meta["time"].(map[string]interface{})
+		version = getString(tags, "latest", "")
+		published = getString(times, version, "")
+	case "pypi":
+		info, _ := meta["info"].(map[string]interface{})
+		version = getString(info, "version", "")
+		releases, _ := meta["releases"].(map[string]interface{})
+		if files, ok := releases[version].([]interface{}); ok && len(files) > 0 {
+			file, _ := files[0].(map[string]interface{})
+			published = getString(file, "upload_time_iso_8601", "")
+		}
+	case "docker":
+		published = getString(meta, "last_updated", "")
+	}
+	at, err := time.Parse(time.RFC3339, published)
+	if err != nil {
+		return version, nil
+	}
+	at = at.UTC()
+	return version, &at
+}
+
+// registryYankedVersions lists the versions registry metadata marks as
+// yanked or deprecated, sorted
+func registryYankedVersions(registry string, meta map[string]interface{}) []string {
+	var yanked []string
+	switch registry {
+	case "npm":
+		versions, _ := meta["versions"].(map[string]interface{})
+		for version, raw := range versions {
+			manifest, _ := raw.(map[string]interface{})
+			if getString(manifest, "deprecated", "") != "" {
+				yanked = append(yanked, version)
+			}
+		}
+	case "pypi":
+		releases, _ := meta["releases"].(map[string]interface{})
+		for version, raw := range releases {
+			files, _ := raw.([]interface{})
+			allYanked := len(files) > 0
+			for _, file := range files {
+				if fileMeta, _ := file.(map[string]interface{}); fileMeta["yanked"] != true {
+					allYanked = false
+				}
+			}
+			if allYanked {
+				yanked = append(yanked, version)
+			}
+		}
+	}
+	sort.Slice(yanked, func(i, j int) bool { return compareVersions(yanked[i], yanked[j]) < 0 })
+	return yanked
+}
+
+// verifyPackage checks that an entry's package exists and points back to
+// the repository the catalog claims
+func verifyPackage(ctx context.Context, client *http.Client, config map[string]interface{}) PackageVerification {
+	result := PackageVerification{CheckedAt: time.Now().UTC()}
+	pkg, ok := entryPackage(config)
+	if !ok {
+		result.Status = "unverifiable"
+		result.Issues = []string{"entry declares no package"}
+		return result
+	}
+	result.Registry, result.Package = pkg.Registry, pkg.Name
+
+	endpoint, ok := registryEndpoints[pkg.Registry]
+	if !ok {
+		result.Status = "unverifiable"
+		result.Issues = []string{fmt.Sprintf("registry '%s' is not supported", pkg.Registry)}
+		return result
+	}
+	name := pkg.Name
+	if pkg.Registry == "docker" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if pkg.Registry != "docker" {
+		name = url.PathEscape(name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(endpoint, name), nil)
+	if err != nil {
+		result.Status = "error"
+		result.Issues = []string{err.Error()}
+		return result
+	}
+	resp, err := callDependency(client, req)
+	if err != nil {
+		result.Status = "error"
+		result.Issues = []string{err.Error()}
+		return result
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		result.Status = "missing"
+		result.Issues = []string{fmt.Sprintf("package not found on %s", pkg.Registry)}
+		return result
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.Status = "error"
+		result.Issues = []string{fmt.Sprintf("HTTP %d from %s", resp.StatusCode, pkg.Registry)}
+		return result
+	}
+
+	var meta map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		result.Status = "error"
+		result.Issues = []string{"cannot decode registry metadata: " + err.Error()}
+		return result
+	}
+
+	result.Status = "verified"
+	claimed := ""
+	if repo, ok := config["repository"].(map[string]interface{}); ok {
+		claimed = getString(repo, "url", "")
+	}
+	result.RegistryRepository = registryRepository(pkg.Registry, meta)
+	result.LatestVersion, result.LatestReleaseAt = registryLatestRelease(pkg.Registry, meta)
+	result.YankedVersions = registryYankedVersions(pkg.Registry, meta)
+	switch {
+	case pkg.Registry == "docker":
+		// Docker Hub does not expose a source repository to compare against
+	case claimed == "":
+		result.Issues = append(result.Issues, "catalog entry declares no repository")
+	case result.RegistryRepository == "":
+		result.Status = "mismatch"
+		result.Issues = append(result.Issues, "registry metadata declares no repository")
+	case normalizeRepoURL(claimed) != normalizeRepoURL(result.RegistryRepository):
+		result.Status = "mismatch"
+		result.Issues = append(result.Issues, fmt.Sprintf("registry points to %s, catalog claims %s (possible typosquat)",
+			result.RegistryRepository, claimed))
+	}
+	return result
+}
+
+// verificationReportHandler serves the last verification report
+func verificationReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	verificationMu.RLock()
+	defer verificationMu.RUnlock()
+	if verification == nil {
+		writeError(w, http.StatusNotFound, "No verification report; run the verify command")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	results := make(map[string]PackageVerification)
+	for serverID, result := range verification.Results {
+		if status == "" || result.Status == status {
+			results[serverID] = result
+		}
+	}
+	json.NewEncoder(w).Encode(VerificationReport{
+		GeneratedAt: verification.GeneratedAt,
+		Summary:     verification.Summary,
+		Results:     results,
+	})
+}
+
+// verifyCommand checks every entry's package on its registry and writes
+// the report the API serves:
+//
+//	verify [-timeout 10s] [-o data/verification.json]
+func verifyCommand(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout per registry request")
+	output := flags.String("o", dataPath("verification.json"), "report file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(servers))
+	for serverID := range servers {
+		ids = append(ids, serverID)
+	}
+	sort.Strings(ids)
+
+	client := outboundClient(*timeout)
+	report := VerificationReport{
+		GeneratedAt: time.Now().UTC(),
+		Summary:     make(map[string]int),
+		Results:     make(map[string]PackageVerification),
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "SERVER\tPACKAGE\tSTATUS\tISSUES")
+	for _, serverID := range ids {
+		config, _ := getEntry(serverID)
+		result := verifyPackage(context.Background(), client, config)
+		report.Results[serverID] = result
+		if previous := entryVerification(serverID); result.Status == "missing" && (previous == nil || previous.Status != "missing") {
+			notify(Notification{
+				Event:   "link.broken",
+				Title:   fmt.Sprintf("Package of %s not found", serverID),
+				Message: fmt.Sprintf("%s is no longer on %s.", result.Package, result.Registry),
+				Servers: []string{serverID},
+				Data:    result,
+			})
+		}
+		report.Summary[result.Status]++
+		fmt.Fprintf(out, "%s\t%s:%s\t%s\t%s\n", serverID, result.Registry, result.Package, result.Status, strings.Join(result.Issues, "; "))
+	}
+	out.Flush()
+
+	if err := writeJSONFile(*output, report); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", *output)
+
+	if failed := report.Summary["missing"] + report.Summary["mismatch"]; failed > 0 {
+		return fmt.Errorf("%d packages are missing or do not match their repository", failed)
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The weekly report is the state of the catalog over an ISO week, Monday
+// to Monday UTC: entries added and removed between the catalogs served at
+// its start and end, the servers whose installs grew most, the advisories
+// published and how much of the catalog carries key metadata. Once a week
+// is over its report is archived as data/reports/weekly/<week>.json with a
+// Markdown rendering beside it, for the newsletter and dashboards.
+
+// weeklyCheckInterval is how often the job looks for a finished week
+const weeklyCheckInterval = time.Hour
+
+// weeklyTopRisers is how many risers a report lists
+const weeklyTopRisers = 10
+
+// weekPattern matches ISO week names such as 2026-W07
+var weekPattern = regexp.MustCompile(`^\d{4}-W\d{2}$`)
+
+// WeeklyEntry is an entry added or removed during the week
+type WeeklyEntry struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// WeeklyRiser is a server whose installs grew over the week
+type WeeklyRiser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Trend
+}
+
+// CoverageMetric is the share of entries carrying one kind of metadata
+type CoverageMetric struct {
+	Name    string  `json:"name"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// WeeklyReport is the state of the catalog over one week
+type WeeklyReport struct {
+	Week        string    `json:"week"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Baseline and Snapshot are the catalogs compared, served at the start
+	// and end of the week
+	Baseline       string           `json:"baseline,omitempty"`
+	Snapshot       string           `json:"snapshot,omitempty"`
+	ServerCount    int              `json:"server_count"`
+	NewEntries     []WeeklyEntry    `json:"new_entries"`
+	RemovedEntries []WeeklyEntry    `json:"removed_entries"`
+	TopRisers      []WeeklyRiser    `json:"top_risers"`
+	NewAdvisories  []Advisory       `json:"new_advisories"`
+	Coverage       []CoverageMetric `json:"coverage"`
+	Notes          []string         `json:"notes,omitempty"`
+}
+
+// weekStart is the Monday 00:00 UTC starting the ISO week of t
+func weekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// weekName names the ISO week starting at from, such as 2026-W07
+func weekName(from time.Time) string {
+	year, week := from.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// weeklyReportPath is where the report of a week is archived
+func weeklyReportPath(week, extension string) string {
+	return dataPath("reports/weekly/" + week + extension)
+}
+
+// weeklyEntries lists entries of one catalog missing from another
+func weeklyEntries(entries, other map[string]interface{}) []WeeklyEntry {
+	result := []WeeklyEntry{}
+	for serverID, entry := range entries {
+		if _, ok := other[serverID]; ok {
+			continue
+		}
+		config, _ := entry.(map[string]interface{})
+		result = append(result, WeeklyEntry{ID: serverID, Name: getString(config, "name", serverID), Category: getString(config, "category", "other")})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// catalogCoverage measures how many entries carry tools, a package, a
+// license, a known runtime and a tier above community
+func catalogCoverage(entries map[string]interface{}) []CoverageMetric {
+	checks := []struct {
+		name string
+		has  func(map[string]interface{}) bool
+	}{
+		{"tools", func(config map[string]interface{}) bool { return len(entryTools(config)) > 0 }},
+		{"package", func(config map[string]interface{}) bool { _, ok := entryPackage(config); return ok }},
+		{"license", func(config map[string]interface{}) bool {
+			license := getString(config, "license", "Unknown")
+			return license != "" && license != "Unknown"
+		}},
+		{"runtime", func(config map[string]interface{}) bool { return entryRuntime(config) != nil }},
+		{"verified", func(config map[string]interface{}) bool { return entryTier(config) != "community" }},
+	}
+	coverage := make([]CoverageMetric, 0, len(checks))
+	for _, check := range checks {
+		metric := CoverageMetric{Name: check.name}
+		for _, entry := range entries {
+			if config, ok := entry.(map[string]interface{}); ok && check.has(config) {
+				metric.Count++
+			}
+		}
+		if len(entries) > 0 {
+			metric.Percent = math.Round(float64(metric.Count)*1000/float64(len(entries))) / 10
+		}
+		coverage = append(coverage, metric)
+	}
+	return coverage
+}
+
+// weeklyCatalog is the snapshot served at t and its entries
+func weeklyCatalog(t time.Time) (string, map[string]interface{}, error) {
+	snapshot, err := snapshotAt(t)
+	if err != nil {
+		return "", nil, err
+	}
+	past, err := loadHistoricalCatalog(snapshot)
+	if err != nil {
+		return "", nil, err
+	}
+	return snapshot.Hash, past.entries, nil
+}
+
+// buildWeeklyReport reports on the week starting at from. A week not yet
+// over is reported up to now.
+func buildWeeklyReport(from, now time.Time) WeeklyReport {
+	to := from.AddDate(0, 0, 7)
+	report := WeeklyReport{Week: weekName(from), From: from, To: to, GeneratedAt: now.UTC(),
+		TopRisers: []WeeklyRiser{}, NewAdvisories: []Advisory{}}
+	end := to
+	if now.Before(to) {
+		end = now
+		report.Notes = append(report.Notes, "The week is not over; figures run to "+now.UTC().Format(time.RFC3339))
+	}
+
+	current := servers
+	if hash, entries, err := weeklyCatalog(end); err == nil {
+		report.Snapshot, current = hash, entries
+	}
+	report.ServerCount = len(current)
+	if hash, baseline, err := weeklyCatalog(from); err == nil {
+		report.Baseline = hash
+		report.NewEntries = weeklyEntries(current, baseline)
+		report.RemovedEntries = weeklyEntries(baseline, current)
+	} else {
+		report.NewEntries, report.RemovedEntries = []WeeklyEntry{}, []WeeklyEntry{}
+		report.Notes = append(report.Notes, "No catalog was served at the start of the week, so additions and removals are unknown")
+	}
+
+	// The trend window is the seven days ending on the week's Sunday, or
+	// today for a week not over
+	lastDay := end.Add(-time.Nanosecond)
+	for serverID, entry := range current {
+		trend := serverTrend(serverID, 7, lastDay)
+		if trend.Growth <= 0 {
+			continue
+		}
+		config, _ := entry.(map[string]interface{})
+		report.TopRisers = append(report.TopRisers, WeeklyRiser{ID: serverID, Name: getString(config, "name", serverID), Trend: trend})
+	}
+	sort.Slice(report.TopRisers, func(i, j int) bool {
+		a, b := report.TopRisers[i], report.TopRisers[j]
+		if a.Growth != b.Growth {
+			return a.Growth > b.Growth
+		}
+		return a.ID < b.ID
+	})
+	if len(report.TopRisers) > weeklyTopRisers {
+		report.TopRisers = report.TopRisers[:weeklyTopRisers]
+	}
+
+	advisoriesMu.RLock()
+	for _, advisory := range advisories {
+		if !advisory.PublishedAt.Before(from) && advisory.PublishedAt.Before(end) {
+			report.NewAdvisories = append(report.NewAdvisories, advisory)
+		}
+	}
+	advisoriesMu.RUnlock()
+	sort.Slice(report.NewAdvisories, func(i, j int) bool {
+		return report.NewAdvisories[i].PublishedAt.Before(report.NewAdvisories[j].PublishedAt)
+	})
+
+	report.Coverage = catalogCoverage(current)
+	return report
+}
+
+// weeklyMarkdown renders a report for the newsletter
+func weeklyMarkdown(report WeeklyReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# State of the catalog, %s\n\n", report.Week)
+	fmt.Fprintf(&b, "%s to %s: %d servers, %d new, %d removed, %d advisories.\n\n", report.From.Format(dayLayout),
+		report.To.AddDate(0, 0, -1).Format(dayLayout), report.ServerCount, len(report.NewEntries), len(report.RemovedEntries), len(report.NewAdvisories))
+	for _, note := range report.Notes {
+		fmt.Fprintf(&b, "> %s\n\n", note)
+	}
+	entryList := func(title string, entries []WeeklyEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "## %s\n\n", title)
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "- **%s** (`%s`, %s)\n", entry.Name, entry.ID, entry.Category)
+		}
+		b.WriteString("\n")
+	}
+	entryList("New entries", report.NewEntries)
+	entryList("Removed entries", report.RemovedEntries)
+	if len(report.TopRisers) > 0 {
+		b.WriteString("## Top risers\n\n| Server | Installs | Previous week | Growth |\n|---|---:|---:|---:|\n")
+		for _, riser := range report.TopRisers {
+			fmt.Fprintf(&b, "| %s (`%s`) | %d | %d | +%d |\n", riser.Name, riser.ID, riser.Installs, riser.PreviousInstalls, riser.Growth)
+		}
+		b.WriteString("\n")
+	}
+	if len(report.NewAdvisories) > 0 {
+		b.WriteString("## New advisories\n\n")
+		for _, advisory := range report.NewAdvisories {
+			fmt.Fprintf(&b, "- **%s** [%s] %s: %s\n", advisory.ID, advisory.Severity, advisory.Title, strings.Join(advisory.Servers, ", "))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("## Coverage\n\n| Metadata | Entries | Share |\n|---|---:|---:|\n")
+	for _, metric := range report.Coverage {
+		fmt.Fprintf(&b, "| %s | %d | %.1f%% |\n", metric.Name, metric.Count, metric.Percent)
+	}
+	return b.String()
+}
+
+// archiveWeeklyReport stores a report as JSON and Markdown
+func archiveWeeklyReport(report WeeklyReport) error {
+	if err := writeJSONFile(weeklyReportPath(report.Week, ".json"), report); err != nil {
+		return err
+	}
+	return replaceFile(weeklyReportPath(report.Week, ".md"), []byte(weeklyMarkdown(report)))
+}
+
+// archivedWeeks lists the weeks with an archived report, newest first
+func archivedWeeks() []string {
+	files, _ := os.ReadDir(dataPath("reports/weekly"))
+	weeks := []string{}
+	for _, file := range files {
+		if week, ok := strings.CutSuffix(file.Name(), ".json"); ok && weekPattern.MatchString(week) {
+			weeks = append(weeks, week)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(weeks)))
+	return weeks
+}
+
+// archiveLastWeek archives the report of the last finished week unless it
+// already is
+func archiveLastWeek(now time.Time) {
+	from := weekStart(now).AddDate(0, 0, -7)
+	week := weekName(from)
+	if _, err := os.Stat(weeklyReportPath(week, ".json")); err == nil {
+		return
+	}
+	if err := archiveWeeklyReport(buildWeeklyReport(from, now)); err != nil {
+		log.Printf("❌ Failed to archive the weekly report for %s: %v", week, err)
+		return
+	}
+	log.Printf("📰 Archived the weekly report for %s", week)
+}
+
+// startWeeklyReports archives each week's report once it is over
+func startWeeklyReports() {
+	go func() {
+		archiveLastWeek(time.Now())
+		for range time.Tick(weeklyCheckInterval) {
+			archiveLastWeek(time.Now())
+		}
+	}()
+}
+
+// weeklyReportsHandler lists the archived weeks
+func weeklyReportsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	weeks := archivedWeeks()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"weeks":   weeks,
+		"current": weekName(weekStart(time.Now())),
+	})
+}
+
+// weeklyReportHandler serves the report of a week: "latest" is the last
+// archived one and "current" the week so far. JSON unless ?format=markdown
+// or an Accept of text/markdown asks for the newsletter rendering.
+func weeklyReportHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		if strings.Contains(r.Header.Get("Accept"), "text/markdown") {
+			format = "markdown"
+		}
+	case "json", "markdown":
+	default:
+		writeError(w, http.StatusBadRequest, "Query parameter 'format' must be json or markdown")
+		return
+	}
+
+	var report WeeklyReport
+	week := r.PathValue("week")
+	switch {
+	case week == "current":
+		now := time.Now()
+		report = buildWeeklyReport(weekStart(now), now)
+	case week == "latest" || weekPattern.MatchString(week):
+		if week == "latest" {
+			weeks := archivedWeeks()
+			if len(weeks) == 0 {
+				writeError(w, http.StatusNotFound, "No weekly report has been archived yet")
+				return
+			}
+			week = weeks[0]
+		}
+		if err := readJSONFile(weeklyReportPath(week, ".json"), &report); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if report.Week == "" {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No weekly report is archived for %s", week))
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "The week must be an ISO week such as 2026-W07, latest or current")
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		fmt.Fprint(w, weeklyMarkdown(report))
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WizardQuestion is a single parameter or secret the user must supply
+type WizardQuestion struct {
+	Key         string `json:"key"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+}
+
+// WizardRequest carries the full wizard state; the server keeps none
+type WizardRequest struct {
+	Servers []string                     `json:"servers"`
+	Answers map[string]map[string]string `json:"answers"`
+	// AllowExperimental lets experimental servers into the config
+	AllowExperimental bool `json:"allow_experimental"`
+}
+
+// WizardStep is either the next questions to ask or the finished config
+type WizardStep struct {
+	Step       int              `json:"step"`
+	TotalSteps int              `json:"total_steps"`
+	Done       bool             `json:"done"`
+	Server     string           `json:"server,omitempty"`
+	Questions  []WizardQuestion `json:"questions,omitempty"`
+	Config     interface{}      `json:"config,omitempty"`
+	Warnings   []string         `json:"warnings,omitempty"`
+}
+
+// envQuestions lists the environment variables a catalog entry declares
+func envQuestions(config map[string]interface{}) []WizardQuestion {
+	serverConfig, _ := config["config"].(map[string]interface{})
+	env, _ := serverConfig["env"].(map[string]interface{})
+
+	var questions []WizardQuestion
+	for key, specInterface := range env {
+		spec, _ := specInterface.(map[string]interface{})
+		required, _ := spec["required"].(bool)
+		questions = append(questions, WizardQuestion{
+			Key:         key,
+			Description: getString(spec, "description", ""),
+			Required:    required,
+			Secret:      isSecretName(key),
+		})
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Key < questions[j].Key
+	})
+	return questions
+}
+
+func isSecretName(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, marker := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// wizardNextHandler walks the selected servers in order and returns the
+// first one that still has unanswered required parameters. Once every
+// server is satisfied it returns the completed config, launching each
+// server as generate-config does for Claude Desktop. Questions and config
+// follow the requesting tenant's overlays.
+func wizardNextHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limitBody(w, r)
+	req, err := parseWizardRequest(r.Body)
+	if err != nil {
+		writeRequestError(w, err)
+		return
+	}
+
+	for _, serverID := range req.Servers {
+		if _, exists := getEntry(serverID); !exists {
+			writeError(w, http.StatusNotFound, "Server '"+serverID+"' not found")
+			return
+		}
+	}
+	check := GenerateConfigRequest{Servers: req.Servers, AllowExperimental: req.AllowExperimental}
+	if err := checkExperimental(check); err != nil {
+		writeRequestError(w, err)
+		return
+	}
+	if tenant, policy, ok := requestPolicy(r); ok {
+		if violations := evaluatePolicy(r, policy, req.Servers); len(violations) > 0 {
+			writePolicyViolations(w, tenant, violations)
+			return
+		}
+	}
+
+	for i, serverID := range req.Servers {
+		config, _ := tenantEntry(r, serverID)
+		answered := req.Answers[serverID]
+
+		var pending []WizardQuestion
+		missingRequired := false
+		for _, question := range envQuestions(config) {
+			if _, ok := answered[question.Key]; ok {
+				continue
+			}
+			pending = append(pending, question)
+			if question.Required {
+				missingRequired = true
+			}
+		}
+
+		if missingRequired {
+			json.NewEncoder(w).Encode(WizardStep{
+				Step:       i + 1,
+				TotalSteps: len(req.Servers),
+				Server:     serverID,
+				Questions:  pending,
+			})
+			return
+		}
+	}
+
+	client := clientProfile("claude_desktop")
+	mcpServers := make(map[string]interface{})
+	var warnings []string
+	bridges := 0
+	for _, serverID := range req.Servers {
+		config, _ := tenantEntry(r, serverID)
+		_, _, mcpConfig, bridge, err := launchDistribution(serverID, config, nil, "", defaultConfigOptions, client, bridges)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		if bridge != nil {
+			bridges++
+		}
+		if _, local := mcpConfig["command"]; !local {
+			mcpServers[serverID] = mcpConfig
+			continue
+		}
+		if answered := req.Answers[serverID]; len(answered) > 0 {
+			env := make(map[string]string)
+			for key, value := range answered {
+				env[key] = value
+			}
+			mcpConfig["env"] = env
+		}
+		mcpServers[serverID] = mcpConfig
+	}
+
+	json.NewEncoder(w).Encode(WizardStep{
+		Step:       len(req.Servers),
+		TotalSteps: len(req.Servers),
+		Done:       true,
+		Config:     client.Shape.writeConfig(mcpServers),
+		Warnings:   warnings,
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultWrapperDir is where generated wrapper scripts are expected unless
+// the request sets wrapper_dir. Client configs need absolute commands.
+const defaultWrapperDir = "/usr/local/lib/mcp/wrappers"
+
+// LaunchSetup is the optional "launch" block of an entry: what has to
+// happen before the server command runs
+type LaunchSetup struct {
+	// Cwd is the working directory; a leading "~/" is the user's home
+	Cwd string `json:"cwd,omitempty"`
+	// Venv is a Python virtualenv to activate, relative to Cwd unless absolute
+	Venv string `json:"venv,omitempty"`
+}
+
+// entryLaunch decodes the "launch" block of an entry
+func entryLaunch(config map[string]interface{}) (LaunchSetup, bool) {
+	raw, ok := config["launch"].(map[string]interface{})
+	if !ok {
+		return LaunchSetup{}, false
+	}
+	setup := LaunchSetup{Cwd: getString(raw, "cwd", ""), Venv: getString(raw, "venv", "")}
+	return setup, setup.Cwd != "" || setup.Venv != ""
+}
+
+// WrapperScript is a generated launcher that prepares the environment of
+// a stdio server and then execs its command
+type WrapperScript struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// shellPath quotes a path for sh, keeping a leading "~/" expandable
+func shellPath(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(p)
+}
+
+// wrapperScript renders the wrapper of a server. Arguments given to the
+// wrapper are passed on, so clients may still append their own.
+func wrapperScript(serverID string, setup LaunchSetup, command string, args []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/sh\n# Launches %s with its working directory and virtualenv set up\nset -e\n", serverID)
+	if setup.Cwd != "" {
+		fmt.Fprintf(&b, "cd %s\n", shellPath(setup.Cwd))
+	}
+	if setup.Venv != "" {
+		fmt.Fprintf(&b, ". %s\n", shellPath(strings.TrimSuffix(setup.Venv, "/")+"/bin/activate"))
+	}
+	fmt.Fprintf(&b, "exec %s \"$@\"\n", shellCommand(command, args))
+	return b.String()
+}
+
+// wrapLaunchConfig replaces the command of a local server config with a
+// wrapper script when the entry needs launch setup. It returns nil when
+// the config can be used as is.
+func wrapLaunchConfig(serverID string, entry, mcpConfig map[string]interface{}, wrapperDir string) *WrapperScript {
+	setup, ok := entryLaunch(entry)
+	command, local := mcpConfig["command"].(string)
+	if !ok || !local {
+		return nil
+	}
+	args, _ := mcpConfig["args"].([]string)
+	wrapper := &WrapperScript{
+		Path:    path.Join(wrapperDir, strings.ReplaceAll(serverID, "/", "-")+".sh"),
+		Content: wrapperScript(serverID, setup, command, args),
+	}
+	mcpConfig["command"] = wrapper.Path
+	delete(mcpConfig, "args")
+	return wrapper
+}
+
+// wrapperInstallSteps renders sh that writes every wrapper from its
+// base64-encoded content, so install scripts carry them without quoting
+// issues
+func wrapperInstallSteps(wrappers map[string]*WrapperScript, order []string) string {
+	var b strings.Builder
+	for _, serverID := range order {
+		wrapper, ok := wrappers[serverID]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(path.Dir(wrapper.Path)))
+		fmt.Fprintf(&b, "echo %s | base64 -d > %s\n", base64.StdEncoding.EncodeToString([]byte(wrapper.Content)), shellQuote(wrapper.Path))
+		fmt.Fprintf(&b, "chmod +x %s\n", shellQuote(wrapper.Path))
+	}
+	return b.String()
+}
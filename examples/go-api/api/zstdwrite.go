@@ -0,0 +1,721 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// zstdCompress writes data as one zstd frame (RFC 8878) that any zstd
+// decoder reads. It finds matches with one hash probe per position, like
+// the fastest zstd levels, then Huffman-codes the literals and FSE-codes
+// the sequences with tables fitted to each block.
+func zstdCompress(data []byte) []byte {
+	out := []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	// Frame header: a window descriptor, a content checksum and the
+	// content size in 4 or 8 bytes
+	if uint64(len(data)) < 1<<32 {
+		out = append(out, 2<<6|1<<2, (zstdWindowLog-10)<<3)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+	} else {
+		out = append(out, 3<<6|1<<2, (zstdWindowLog-10)<<3)
+		out = binary.LittleEndian.AppendUint64(out, uint64(len(data)))
+	}
+
+	e := zstdEncoder{
+		src:     data,
+		long:    make([]int32, 1<<zstdHashLog),
+		short:   make([]int32, 1<<zstdHashLog),
+		repeats: [3]uint32{1, 4, 8},
+	}
+	for start := 0; start == 0 || start < len(data); start += zstdBlockSize {
+		end := min(start+zstdBlockSize, len(data))
+		repeats := e.repeats
+		body := e.compressBlock(start, end)
+		blockType := 2
+		if len(body) >= end-start {
+			// The decoder never sees these sequences
+			body, blockType = data[start:end], 0
+			e.repeats = repeats
+		}
+		header := uint32(len(body))<<3 | uint32(blockType)<<1
+		if end == len(data) {
+			header |= 1
+		}
+		out = append(out, byte(header), byte(header>>8), byte(header>>16))
+		out = append(out, body...)
+	}
+
+	var checksum xxhash64
+	checksum.reset()
+	checksum.update(data)
+	return binary.LittleEndian.AppendUint32(out, uint32(checksum.digest()))
+}
+
+const (
+	// zstdWindowLog bounds how far back matches reach
+	zstdWindowLog = 20
+	zstdBlockSize = 128 << 10
+	zstdHashLog   = 17
+	// Matches are looked up by their first 8 bytes and, failing that,
+	// their first 5
+	zstdLongMatch  = 8
+	zstdShortMatch = 5
+)
+
+// zstdSequence copies Literals bytes from the literals section, then Match
+// bytes from Offset bytes back
+type zstdSequence struct {
+	Literals, Match, Offset uint32
+}
+
+// zstdEncoder finds matches over the whole input, so blocks reference
+// the ones before them, and tracks the repeat offsets the decoder keeps.
+// The hash tables hold the last position plus one of each hashed prefix.
+type zstdEncoder struct {
+	src         []byte
+	long, short []int32
+	repeats     [3]uint32
+}
+
+// hash hashes the first n bytes at i; callers leave 8 bytes
+func (e *zstdEncoder) hash(i, n int) uint32 {
+	return uint32(binary.LittleEndian.Uint64(e.src[i:]) << (64 - 8*n) * 0xcf1bbcdcb7a56463 >> (64 - zstdHashLog))
+}
+
+// insert records position i in both hash tables
+func (e *zstdEncoder) insert(i int) {
+	e.long[e.hash(i, zstdLongMatch)] = int32(i + 1)
+	e.short[e.hash(i, zstdShortMatch)] = int32(i + 1)
+}
+
+// lookup returns the longest match at i the hash tables know of
+func (e *zstdEncoder) lookup(i, end int) (candidate, length int) {
+	if c := int(e.long[e.hash(i, zstdLongMatch)]) - 1; c >= 0 && i-c < 1<<zstdWindowLog {
+		if l := e.matchLength(c, i, end); l >= zstdLongMatch {
+			return c, l
+		}
+	}
+	if c := int(e.short[e.hash(i, zstdShortMatch)]) - 1; c >= 0 && i-c < 1<<zstdWindowLog {
+		if l := e.matchLength(c, i, end); l >= zstdShortMatch {
+			return c, l
+		}
+	}
+	return 0, 0
+}
+
+// matchLength is how far src[i:] repeats src[candidate:] before end
+func (e *zstdEncoder) matchLength(candidate, i, end int) int {
+	length := 0
+	for i+length+8 <= end {
+		if diff := binary.LittleEndian.Uint64(e.src[candidate+length:]) ^ binary.LittleEndian.Uint64(e.src[i+length:]); diff != 0 {
+			return length + bits.TrailingZeros64(diff)/8
+		}
+		length += 8
+	}
+	for i+length < end && e.src[candidate+length] == e.src[i+length] {
+		length++
+	}
+	return length
+}
+
+// compressBlock returns the literals and sequences sections of the block
+// src[start:end]
+func (e *zstdEncoder) compressBlock(start, end int) []byte {
+	var literals []byte
+	var sequences []zstdSequence
+	anchor := start
+	last := int(e.repeats[0])
+	for i := start; i+8 <= end; {
+		// The last offset again is the cheapest to code, so it is tried
+		// before the hash tables
+		var candidate, length int
+		if i > anchor && last <= i {
+			candidate, length = i-last, e.matchLength(i-last, i, end)
+		}
+		if length < 4 {
+			candidate, length = e.lookup(i, end)
+			// A longer match one byte on is worth a literal
+			if length > 0 && i+9 <= end {
+				if c, l := e.lookup(i+1, end); l > length+1 {
+					e.insert(i)
+					i, candidate, length = i+1, c, l
+				}
+			}
+		}
+		e.insert(i)
+		if length < 4 {
+			// Searches speed up through data that does not repeat
+			i += 1 + (i-anchor)>>8
+			continue
+		}
+		for i > anchor && candidate > 0 && e.src[i-1] == e.src[candidate-1] {
+			i, candidate, length = i-1, candidate-1, length+1
+		}
+
+		literals = append(literals, e.src[anchor:i]...)
+		sequences = append(sequences, zstdSequence{
+			Literals: uint32(i - anchor),
+			Match:    uint32(length),
+			Offset:   uint32(i - candidate),
+		})
+		last = i - candidate
+		for _, j := range [2]int{i + 2, i + length - 2} {
+			if j+8 <= len(e.src) {
+				e.insert(j)
+			}
+		}
+		i += length
+		anchor = i
+	}
+	literals = append(literals, e.src[anchor:end]...)
+
+	return e.sequences(zstdLiterals(literals), sequences)
+}
+
+// offsetValue codes an offset as one of the repeat offsets when it is one,
+// updating them as the decoder will. RFC 3.1.1.5.
+func (e *zstdEncoder) offsetValue(offset, literals uint32) uint32 {
+	r := &e.repeats
+	switch {
+	case literals > 0 && offset == r[0]:
+		return 1
+	case offset == r[1]:
+		r[0], r[1] = offset, r[0]
+		if literals == 0 {
+			return 1
+		}
+		return 2
+	case offset == r[2]:
+		r[0], r[1], r[2] = offset, r[0], r[1]
+		if literals == 0 {
+			return 2
+		}
+		return 3
+	}
+	r[0], r[1], r[2] = offset, r[0], r[1]
+	return offset + 3
+}
+
+// zstdLiterals returns the literals section: Huffman-coded when that is
+// smaller, a single repeated byte, or the bytes as they are
+func zstdLiterals(literals []byte) []byte {
+	var counts [256]int
+	distinct := 0
+	for _, b := range literals {
+		if counts[b] == 0 {
+			distinct++
+		}
+		counts[b]++
+	}
+	if distinct == 1 && len(literals) > 1 {
+		return append(zstdLiteralsHeader(nil, 1, len(literals)), literals[0])
+	}
+	raw := append(zstdLiteralsHeader(nil, 0, len(literals)), literals...)
+	if distinct < 2 || len(literals) < 64 {
+		return raw
+	}
+	if compressed := zstdHuffmanLiterals(literals, counts); compressed != nil && len(compressed) < len(raw) {
+		return compressed
+	}
+	return raw
+}
+
+// zstdLiteralsHeader appends the header of a raw (0) or RLE (1) literals
+// section of size bytes
+func zstdLiteralsHeader(out []byte, kind byte, size int) []byte {
+	switch {
+	case size < 1<<5:
+		return append(out, kind|byte(size)<<3)
+	case size < 1<<12:
+		return append(out, kind|1<<2|byte(size)<<4, byte(size>>4))
+	default:
+		return append(out, kind|3<<2|byte(size)<<4, byte(size>>4), byte(size>>12))
+	}
+}
+
+// zstdHuffmanLiterals returns a compressed literals section, or nil when
+// the code does not fit the directly written weights
+func zstdHuffmanLiterals(literals []byte, counts [256]int) []byte {
+	lengths := huffmanLengths(counts, maxHuffmanBits)
+	maxSymbol, tableBits := 0, uint8(0)
+	for symbol, length := range lengths {
+		if length > 0 {
+			maxSymbol = symbol
+			tableBits = max(tableBits, length)
+		}
+	}
+	// Weights are written four bits each for every symbol but the last,
+	// which allows 128 of them
+	if maxSymbol > 128 {
+		return nil
+	}
+
+	var weights [256]uint8
+	for symbol, length := range lengths {
+		if length > 0 {
+			weights[symbol] = tableBits + 1 - length
+		}
+	}
+	// Codes follow the decoder's table: by weight, then by symbol
+	var next [13]uint32
+	position := uint32(0)
+	for w := 1; w <= int(tableBits); w++ {
+		next[w] = position
+		for _, weight := range weights[:maxSymbol+1] {
+			if int(weight) == w {
+				position += 1 << (w - 1)
+			}
+		}
+	}
+	var codes [256]uint32
+	for symbol, weight := range weights[:maxSymbol+1] {
+		if weight > 0 {
+			codes[symbol] = next[weight] >> (weight - 1)
+			next[weight] += 1 << (weight - 1)
+		}
+	}
+
+	// The last symbol's weight is implied
+	tree := []byte{byte(127 + maxSymbol)}
+	weights[maxSymbol] = 0
+	for i := 0; i < maxSymbol; i += 2 {
+		tree = append(tree, weights[i]<<4|weights[i+1])
+	}
+	stream := func(out, symbols []byte) []byte {
+		w := zstdBitWriter{out: out}
+		for i := len(symbols) - 1; i >= 0; i-- {
+			w.add(codes[symbols[i]], uint(lengths[symbols[i]]))
+		}
+		return w.close()
+	}
+
+	size := len(literals)
+	var body []byte
+	if size < 1<<10 {
+		body = stream(tree, literals)
+	} else {
+		quarter := (size + 3) / 4
+		var streams [4][]byte
+		for i := range streams {
+			streams[i] = stream(nil, literals[min(i*quarter, size):min((i+1)*quarter, size)])
+		}
+		body = tree
+		for _, s := range streams[:3] {
+			if len(s) > 0xffff {
+				return nil
+			}
+			body = binary.LittleEndian.AppendUint16(body, uint16(len(s)))
+		}
+		for _, s := range streams {
+			body = append(body, s...)
+		}
+	}
+
+	// One stream takes a 3-byte header; four streams a 4- or 5-byte one
+	compressed := len(body)
+	var out []byte
+	switch {
+	case size < 1<<10 && compressed < 1<<10:
+		out = append(out, 2|byte(size)<<4, byte(size>>4)&0x3f|byte(compressed)<<6, byte(compressed>>2))
+	case size < 1<<10:
+		return nil
+	case size < 1<<14 && compressed < 1<<14:
+		out = append(out, 2|2<<2|byte(size)<<4, byte(size>>4), byte(size>>12)&3|byte(compressed)<<2, byte(compressed>>6))
+	case size < 1<<18 && compressed < 1<<18:
+		out = append(out, 2|3<<2|byte(size)<<4, byte(size>>4), byte(size>>12)&0x3f|byte(compressed)<<6, byte(compressed>>2), byte(compressed>>10))
+	default:
+		return nil
+	}
+	return append(out, body...)
+}
+
+// huffmanLengths returns the Huffman code length of each counted symbol,
+// flattening the counts until no code is longer than limit
+func huffmanLengths(counts [256]int, limit uint8) [256]uint8 {
+	for {
+		type node struct {
+			count, parent int
+		}
+		var nodes []node
+		var leaves [256]int
+		var live []int
+		for symbol, count := range counts {
+			leaves[symbol] = -1
+			if count > 0 {
+				leaves[symbol] = len(nodes)
+				live = append(live, len(nodes))
+				nodes = append(nodes, node{count: count, parent: -1})
+			}
+		}
+		for len(live) > 1 {
+			sort.Slice(live, func(i, j int) bool { return nodes[live[i]].count < nodes[live[j]].count })
+			parent := len(nodes)
+			nodes = append(nodes, node{count: nodes[live[0]].count + nodes[live[1]].count, parent: -1})
+			nodes[live[0]].parent, nodes[live[1]].parent = parent, parent
+			live = append(live[2:], parent)
+		}
+
+		var lengths [256]uint8
+		fits := true
+		for symbol, leaf := range leaves {
+			if leaf < 0 {
+				continue
+			}
+			depth := 0
+			for n := leaf; nodes[n].parent >= 0; n = nodes[n].parent {
+				depth++
+			}
+			if depth > int(limit) {
+				fits = false
+				break
+			}
+			lengths[symbol] = uint8(depth)
+		}
+		if fits {
+			return lengths
+		}
+		for symbol, count := range counts {
+			if count > 0 {
+				counts[symbol] = (count + 1) / 2
+			}
+		}
+	}
+}
+
+// sequences appends the sequences section to the literals section
+func (e *zstdEncoder) sequences(out []byte, sequences []zstdSequence) []byte {
+	n := len(sequences)
+	switch {
+	case n < 128:
+		out = append(out, byte(n))
+	case n < 0x7f00:
+		out = append(out, byte(n>>8)+0x80, byte(n))
+	default:
+		out = append(out, 0xff)
+		out = binary.LittleEndian.AppendUint16(out, uint16(n-0x7f00))
+	}
+	if n == 0 {
+		return out
+	}
+
+	type coded struct {
+		literal, match, offset zstdCode
+	}
+	codes := make([]coded, n)
+	var literalCounts, matchCounts, offsetCounts [zstdMaxCodes]int
+	for i, s := range sequences {
+		c := coded{
+			literal: zstdLiteralCodes.code(s.Literals),
+			match:   zstdMatchCodes.code(s.Match),
+			offset:  zstdOffsetCode(e.offsetValue(s.Offset, s.Literals)),
+		}
+		codes[i] = c
+		literalCounts[c.literal.code]++
+		matchCounts[c.match.code]++
+		offsetCounts[c.offset.code]++
+	}
+
+	literalStates, literalMode, literalTable := zstdLiteralLengths.fit(literalCounts[:], n)
+	offsetStates, offsetMode, offsetTable := zstdOffsets.fit(offsetCounts[:], n)
+	matchStates, matchMode, matchTable := zstdMatchLengths.fit(matchCounts[:], n)
+	out = append(out, literalMode<<6|offsetMode<<4|matchMode<<2)
+	out = append(out, literalTable...)
+	out = append(out, offsetTable...)
+	out = append(out, matchTable...)
+
+	// The decoder reads the stream from its end, so it is written from the
+	// last sequence back to the first
+	w := zstdBitWriter{out: out}
+	extras := func(c coded) {
+		w.add(c.literal.extra, uint(c.literal.bits))
+		w.add(c.match.extra, uint(c.match.bits))
+		w.add(c.offset.extra, uint(c.offset.bits))
+	}
+	c := codes[n-1]
+	literalState := literalStates.start(c.literal.code)
+	matchState := matchStates.start(c.match.code)
+	offsetState := offsetStates.start(c.offset.code)
+	extras(c)
+	for i := n - 2; i >= 0; i-- {
+		c = codes[i]
+		offsetState = offsetStates.encode(&w, c.offset.code, offsetState)
+		matchState = matchStates.encode(&w, c.match.code, matchState)
+		literalState = literalStates.encode(&w, c.literal.code, literalState)
+		extras(c)
+	}
+	w.add(uint32(matchState), uint(matchStates.tableBits))
+	w.add(uint32(offsetState), uint(offsetStates.tableBits))
+	w.add(uint32(literalState), uint(literalStates.tableBits))
+	return w.close()
+}
+
+// zstdMaxCodes bounds the literal length, match length and offset codes
+const zstdMaxCodes = 53
+
+// zstdCode is a sequence value as its code and the extra bits added to
+// the code's baseline
+type zstdCode struct {
+	code  int
+	extra uint32
+	bits  uint8
+}
+
+// zstdCodeTable lists the baseline and extra bits of each code
+type zstdCodeTable []fseBaselineEntry
+
+func newZstdCodeTable(direct int, offset uint32, bases []uint32) zstdCodeTable {
+	var table zstdCodeTable
+	for i := 0; i < direct; i++ {
+		table = append(table, fseBaselineEntry{baseline: offset + uint32(i)})
+	}
+	for _, base := range bases {
+		table = append(table, fseBaselineEntry{baseline: base & 0xffffff, basebits: uint8(base >> 24)})
+	}
+	return table
+}
+
+func (t zstdCodeTable) code(value uint32) zstdCode {
+	// The first codes stand for one value each
+	if i := int(value - t[0].baseline); i >= 0 && i < len(t) && t[i].baseline == value && t[i].basebits == 0 {
+		return zstdCode{code: i}
+	}
+	i := sort.Search(len(t), func(i int) bool { return t[i].baseline > value }) - 1
+	return zstdCode{code: i, extra: value - t[i].baseline, bits: t[i].basebits}
+}
+
+// zstdOffsetCode codes an offset value, whose code is its number of extra
+// bits
+func zstdOffsetCode(value uint32) zstdCode {
+	code := uint8(bits.Len32(value) - 1)
+	return zstdCode{code: int(code), extra: value - 1<<code, bits: code}
+}
+
+var (
+	zstdLiteralCodes = newZstdCodeTable(literalLengthOffset, 0, literalLengthBase)
+	zstdMatchCodes   = newZstdCodeTable(matchLengthOffset, 3, matchLengthBase)
+)
+
+// zstdSequenceCode is how one of the sequence codes is coded: with the
+// predefined distribution of RFC 3.1.1.3.2.2 or a table of at most
+// maxBits a block carries
+type zstdSequenceCode struct {
+	predefined zstdStates
+	maxBits    int
+}
+
+var (
+	zstdLiteralLengths = zstdSequenceCode{newZstdStates([]int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2,
+		2, 3, 2, 1, 1, 1, 1, 1, -1, -1, -1, -1,
+	}, 6), 9}
+	zstdMatchLengths = zstdSequenceCode{newZstdStates([]int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}, 6), 9}
+	zstdOffsets = zstdSequenceCode{newZstdStates([]int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1, -1,
+	}, 5), 8}
+)
+
+// fit picks the cheapest way to code counts: as one repeated code, with
+// the predefined table or with a table fitted to them. It returns the
+// states, the mode for the section header and the table description.
+func (c zstdSequenceCode) fit(counts []int, n int) (zstdStates, byte, []byte) {
+	for code, count := range counts {
+		if count == n {
+			next := make([][]uint16, code+1)
+			next[code] = []uint16{0}
+			return zstdStates{next: next}, 1, []byte{byte(code)}
+		}
+	}
+
+	predefined := c.predefined.cost(counts)
+	if n < 32 {
+		return c.predefined, 0, nil
+	}
+	tableBits := min(c.maxBits, max(5, bits.Len(uint(n))))
+	norm := zstdNormalize(counts, n, tableBits)
+	if norm == nil {
+		return c.predefined, 0, nil
+	}
+	states := newZstdStates(norm, tableBits)
+	description := zstdWriteNorm(norm, tableBits)
+	if states.cost(counts)+float64(8*len(description)) >= predefined {
+		return c.predefined, 0, nil
+	}
+	return states, 2, description
+}
+
+// zstdNormalize scales counts to a distribution over 1<<tableBits
+// states in which every counted code keeps at least one, or returns nil
+// when there are too many codes for the table
+func zstdNormalize(counts []int, n, tableBits int) []int16 {
+	size := 1 << tableBits
+	norm := make([]int16, len(counts))
+	last, total := 0, 0
+	for code, count := range counts {
+		if count > 0 {
+			last = code
+			norm[code] = int16(max(1, count*size/n))
+			total += int(norm[code])
+		}
+	}
+	// Rounding is settled on the most probable codes
+	for total != size {
+		largest := 0
+		for code, v := range norm {
+			if v > norm[largest] {
+				largest = code
+			}
+		}
+		if total < size {
+			norm[largest] += int16(size - total)
+			break
+		}
+		if norm[largest] <= 1 {
+			return nil
+		}
+		norm[largest]--
+		total--
+	}
+	return norm[:last+1]
+}
+
+// zstdWriteNorm writes a distribution as the FSE table description the
+// decoder reads. RFC 4.1.1.
+func zstdWriteNorm(norm []int16, tableBits int) []byte {
+	var w zstdBitWriter
+	w.add(uint32(tableBits-5), 4)
+	remaining := 1<<tableBits + 1
+	threshold := 1 << tableBits
+	bitsNeeded := tableBits + 1
+	for code := 0; code < len(norm) && remaining > 1; code++ {
+		value := int(norm[code]) + 1
+		small := 2*threshold - 1 - remaining
+		switch {
+		case value < small:
+			w.add(uint32(value), uint(bitsNeeded-1))
+		case value >= threshold:
+			w.add(uint32(value+small), uint(bitsNeeded))
+		default:
+			w.add(uint32(value), uint(bitsNeeded))
+		}
+		if norm[code] < 0 {
+			remaining--
+		} else {
+			remaining -= int(norm[code])
+		}
+		for remaining < threshold {
+			bitsNeeded--
+			threshold >>= 1
+		}
+
+		if norm[code] == 0 {
+			// A zero is followed by how many more come, in 2-bit flags
+			// where 3 means three and another flag follows
+			zeros := 0
+			for norm[code+1+zeros] == 0 {
+				zeros++
+			}
+			code += zeros
+			for ; zeros >= 3; zeros -= 3 {
+				w.add(3, 2)
+			}
+			w.add(uint32(zeros), 2)
+		}
+	}
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.bits))
+	}
+	return w.out
+}
+
+// zstdStates inverts an FSE decoding table: next[code][state] is the state
+// that decodes code and moves to state with the bits it reads
+type zstdStates struct {
+	table     []fseEntry
+	tableBits int
+	next      [][]uint16
+}
+
+func newZstdStates(norm []int16, tableBits int) zstdStates {
+	s := zstdStates{table: make([]fseEntry, 1<<tableBits), tableBits: tableBits}
+	if err := new(zstdReader).buildFSE(0, norm, s.table, tableBits); err != nil {
+		panic("zstd: " + err.Error())
+	}
+	s.next = make([][]uint16, len(norm))
+	for state, e := range s.table {
+		if s.next[e.sym] == nil {
+			s.next[e.sym] = make([]uint16, len(s.table))
+		}
+		for t := int(e.base); t < int(e.base)+1<<e.bits; t++ {
+			s.next[e.sym][t] = uint16(state)
+		}
+	}
+	return s
+}
+
+// start is a state the stream may open with for code
+func (s zstdStates) start(code int) uint16 {
+	return s.next[code][0]
+}
+
+// encode writes the bits that take the decoder from the state that
+// decodes code to state, and returns that state
+func (s zstdStates) encode(w *zstdBitWriter, code int, state uint16) uint16 {
+	if s.table == nil {
+		return 0
+	}
+	previous := s.next[code][state]
+	e := s.table[previous]
+	w.add(uint32(state-e.base), uint(e.bits))
+	return previous
+}
+
+// cost estimates the bits coding counts takes
+func (s zstdStates) cost(counts []int) float64 {
+	var states [zstdMaxCodes]int
+	for _, e := range s.table {
+		states[e.sym]++
+	}
+	total := 0.0
+	for code, count := range counts {
+		if count > 0 {
+			total += float64(count) * (float64(s.tableBits) - math.Log2(float64(states[code])))
+		}
+	}
+	return total
+}
+
+// zstdBitWriter writes the bit streams zstd reads backwards from their
+// end
+type zstdBitWriter struct {
+	out  []byte
+	bits uint64
+	n    uint
+}
+
+func (w *zstdBitWriter) add(value uint32, n uint) {
+	w.bits |= uint64(value) & (1<<n - 1) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.bits))
+		w.bits >>= 8
+		w.n -= 8
+	}
+}
+
+// close marks the end of the stream, which the reader finds by the
+// highest set bit of the last byte
+func (w *zstdBitWriter) close() []byte {
+	w.add(1, 1)
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.bits))
+	}
+	return w.out
+}